@@ -2,11 +2,14 @@ package cli
 
 import (
 	"fmt"
-	"os"
+	"os/exec"
+	"strings"
 	"text/tabwriter"
 
 	"github.com/Lzww0608/ClixGo/pkg/alias"
+	"github.com/Lzww0608/ClixGo/pkg/commands"
 	"github.com/Lzww0608/ClixGo/pkg/logger"
+	"github.com/Lzww0608/ClixGo/pkg/shell"
 	"github.com/spf13/cobra"
 	"go.uber.org/zap"
 )
@@ -15,54 +18,199 @@ func NewAliasCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "alias",
 		Short: "管理命令别名",
-		Long:  `添加、删除、列出命令别名`,
+		Long:  `添加、删除、列出命令别名，查看别名的展开链，以及展开别名后执行命令`,
 	}
 
+	cmd.AddCommand(newAliasAddCmd())
+	cmd.AddCommand(newAliasRemoveCmd())
+
 	cmd.AddCommand(&cobra.Command{
-		Use:   "add",
-		Short: "添加别名",
-		Args:  cobra.ExactArgs(2),
+		Use:   "list",
+		Short: "列出所有别名",
+		Long: `列出所有作用域中的别名，以及别名的作用域和定义别名的文件。
+同名别名按 project > profile > global 的优先级生效，被覆盖的定义标记为"(被覆盖)"。`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			name := args[0]
-			command := args[1]
-			if err := alias.AddAlias(name, command); err != nil {
-				return err
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "别名\t参数\t作用域\t来源\t命令")
+			fmt.Fprintln(w, "----\t----\t------\t----\t----")
+
+			for _, entry := range alias.Entries() {
+				scope := string(entry.Scope)
+				if entry.Shadowed {
+					scope += "(被覆盖)"
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", entry.Name, aliasParams(entry.Command), scope, entry.File, entry.Command)
 			}
-			logger.Info("别名添加成功", zap.String("name", name), zap.String("command", command))
+			w.Flush()
 			return nil
 		},
 	})
 
 	cmd.AddCommand(&cobra.Command{
-		Use:   "remove",
-		Short: "删除别名",
-		Args:  cobra.ExactArgs(1),
+		Use:   "which <name>",
+		Short: "显示别名的展开链",
+		Long: `显示别名逐层展开的过程：别名的定义以另一个别名开头时继续展开，直到得到实际执行的程序。
+别名以自身开头（如 ls='ls --color'）时停止展开；出现循环引用或超过最大层数时报错并显示展开路径。`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeAliases,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			name := args[0]
-			if err := alias.RemoveAlias(name); err != nil {
+			steps, err := alias.Which(args[0])
+			if err != nil {
 				return err
 			}
-			logger.Info("别名删除成功", zap.String("name", name))
+			out := cmd.OutOrStdout()
+			for i, step := range steps {
+				fmt.Fprintf(out, "%s%s: %s  [%s]\n", strings.Repeat("  ", i), step.Name, step.Command, step.Scope)
+			}
+
+			final := steps[len(steps)-1].Command
+			words, _, ok, err := shell.SplitCall(final)
+			if err != nil || !ok || !words[0].Literal {
+				return nil
+			}
+			if path, err := exec.LookPath(words[0].Value); err == nil {
+				fmt.Fprintf(out, "程序: %s\n", path)
+			} else {
+				fmt.Fprintf(out, "程序: %s（未在 PATH 中找到）\n", words[0].Value)
+			}
 			return nil
 		},
 	})
 
-	cmd.AddCommand(&cobra.Command{
-		Use:   "list",
-		Short: "列出所有别名",
+	cmd.AddCommand(newAliasRunCmd())
+
+	return cmd
+}
+
+func newAliasAddCmd() *cobra.Command {
+	var scope string
+
+	cmd := &cobra.Command{
+		Use:   "add <name> <command>",
+		Short: "添加别名",
+		Long: `添加别名。命令中可以使用位置参数占位符，并且可以包含 ;、&&、|| 组成的多条命令:
+  $1 ~ $9、${N}    第 N 个参数，执行时未提供会报错
+  ${N:-默认值}      第 N 个参数，未提供或为空时使用默认值
+  $@               所有参数
+
+不含占位符的别名把参数追加到命令末尾。添加时会检查占位符和引号的语法。
+请用单引号包住命令，避免占位符被当前的shell展开，例如:
+  ClixGo alias add gco 'git checkout $1'
+  ClixGo alias add ship 'go test ./... && git push ${1:-origin} $2'
+
+使用 --scope 指定别名的作用域:
+  global   全局别名（默认）
+  profile  只在使用当前 --profile 时生效
+  project  只在项目目录下生效，写入从当前目录向上最近的 ` + alias.ProjectFileName + `，
+           没有时在当前目录创建`,
+		Args: cobra.ExactArgs(2),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			aliases := alias.ListAliases()
-			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-			fmt.Fprintln(w, "别名\t命令")
-			fmt.Fprintln(w, "----\t----")
+			s, err := alias.ParseScope(scope)
+			if err != nil {
+				return err
+			}
+			name := args[0]
+			command := args[1]
+			if err := alias.AddScopedAlias(s, name, command); err != nil {
+				return err
+			}
+			logger.Info("别名添加成功", zap.String("name", name), zap.String("command", command), zap.String("scope", scope))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&scope, "scope", string(alias.ScopeGlobal), "别名的作用域: global、profile、project")
+	return cmd
+}
 
-			for name, command := range aliases {
-				fmt.Fprintf(w, "%s\t%s\n", name, command)
+func newAliasRemoveCmd() *cobra.Command {
+	var scope string
+
+	cmd := &cobra.Command{
+		Use:               "remove <name>",
+		Short:             "删除别名",
+		Long:              `删除别名，默认删除当前生效的定义；使用 --scope 删除指定作用域中的定义`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeAliases,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+			var s alias.Scope
+			if scope == "" {
+				entry, exists := alias.Lookup(name)
+				if !exists {
+					return fmt.Errorf("别名不存在: %s", name)
+				}
+				s = entry.Scope
+			} else {
+				var err error
+				if s, err = alias.ParseScope(scope); err != nil {
+					return err
+				}
 			}
-			w.Flush()
+			if err := alias.RemoveScopedAlias(s, name); err != nil {
+				return err
+			}
+			logger.Info("别名删除成功", zap.String("name", name), zap.String("scope", string(s)))
 			return nil
 		},
-	})
+	}
 
+	cmd.Flags().StringVar(&scope, "scope", "", "别名的作用域: global、profile、project")
 	return cmd
 }
+
+func newAliasRunCmd() *cobra.Command {
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "run [flags] -- <alias> [args...]",
+		Short: "展开别名并执行",
+		Long: `展开命令中的别名后执行，命令只有一个参数时按shell语法解析，例如:
+  ClixGo alias run -- gs --short
+  ClixGo alias run --dry-run "gs --short"
+
+使用 --dry-run 只输出展开后的命令、解析到的程序和安全策略的检查结果，不执行命令。`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			command := args[0]
+			if len(args) > 1 {
+				command = shell.Join(args)
+			}
+			if dryRun {
+				printPlans(cmd.OutOrStdout(), "展开别名后执行", []*commands.Plan{commands.ExplainCommand(command, commands.Spec{})})
+				return nil
+			}
+			return commands.ExecuteCommand(command)
+		},
+	}
+
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "只输出执行计划，不执行命令")
+	return cmd
+}
+
+// completeAliases 补全别名名称，说明中显示生效的命令和作用域
+func completeAliases(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var completions []string
+	for _, entry := range alias.Entries() {
+		if !entry.Shadowed && strings.HasPrefix(entry.Name, toComplete) {
+			completions = append(completions, fmt.Sprintf("%s\t%s [%s]", entry.Name, entry.Command, entry.Scope))
+		}
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// aliasParams 描述别名需要的参数，如 "2+" 表示至少 2 个参数并接受更多参数
+func aliasParams(command string) string {
+	required, variadic := alias.Params(command)
+	switch {
+	case variadic:
+		return fmt.Sprintf("%d+", required)
+	case required == 0:
+		return "-"
+	default:
+		return fmt.Sprint(required)
+	}
+}
@@ -1,127 +1,499 @@
 package cli
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+	"text/tabwriter"
+	"time"
 
+	"github.com/Lzww0608/ClixGo/pkg/awk"
 	"github.com/Lzww0608/ClixGo/pkg/commands"
 	"github.com/Lzww0608/ClixGo/pkg/logger"
+	"github.com/Lzww0608/ClixGo/pkg/shell"
+	"github.com/Lzww0608/ClixGo/pkg/textproc"
 	"github.com/Lzww0608/ClixGo/pkg/utils"
+	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 	"go.uber.org/zap"
 )
 
+// execFlags 命令执行的超时、重试与资源限制选项
+type execFlags struct {
+	timeout       time.Duration
+	attempts      int
+	backoff       time.Duration
+	maxBackoff    time.Duration
+	jitter        float64
+	retryOnExit   []int
+	retryOnOutput []string
+	cpu           time.Duration
+	memory        string
+	noFile        uint64
+	nProc         uint64
+	processGroup  bool
+	noNetwork     bool
+	readOnly      []string
+}
+
+// register 注册超时与重试相关的标志
+func (f *execFlags) register(cmd *cobra.Command) {
+	cmd.Flags().DurationVar(&f.timeout, "timeout", 0, "单个命令的超时时间，如 90s、5m，默认使用配置项 commands.timeout")
+	cmd.Flags().IntVar(&f.attempts, "attempts", 1, "每个命令最多执行的次数（含第一次）")
+	cmd.Flags().DurationVar(&f.backoff, "backoff", time.Second, "第一次重试前的等待时间，之后按指数增长")
+	cmd.Flags().DurationVar(&f.maxBackoff, "max-backoff", 30*time.Second, "重试等待时间上限")
+	cmd.Flags().Float64Var(&f.jitter, "jitter", 0.2, "重试等待时间的随机抖动比例(0~1)")
+	cmd.Flags().IntSliceVar(&f.retryOnExit, "retry-on-exit", nil, "只在这些退出码时重试，多个用逗号分隔")
+	cmd.Flags().StringArrayVar(&f.retryOnOutput, "retry-on-output", nil, "只在输出匹配该正则时重试，可重复指定")
+	cmd.Flags().DurationVar(&f.cpu, "cpu", 0, "单个命令的CPU时间上限，如 10s（仅Linux）")
+	cmd.Flags().StringVar(&f.memory, "memory", "", "单个命令的虚拟内存上限，如 512M、2G（仅Linux）")
+	cmd.Flags().Uint64Var(&f.noFile, "nofile", 0, "单个命令可打开的文件数上限（仅Linux）")
+	cmd.Flags().Uint64Var(&f.nProc, "nproc", 0, "当前用户的进程数上限（仅Linux）")
+	cmd.Flags().BoolVar(&f.processGroup, "pgroup", false, "在独立进程组中运行，超时时杀死整个进程组（仅Linux）")
+	cmd.Flags().BoolVar(&f.noNetwork, "no-network", false, "在没有网络的命名空间中运行（仅Linux）")
+	cmd.Flags().StringSliceVar(&f.readOnly, "read-only", nil, "将这些路径以只读方式挂载后运行，多个用逗号分隔（仅Linux）")
+}
+
+// limits 根据标志构造资源限制和隔离设置
+func (f *execFlags) limits() (commands.Limits, commands.Sandbox, error) {
+	limits := commands.Limits{CPUTime: f.cpu, NoFile: f.noFile, NProc: f.nProc}
+	if f.memory != "" {
+		memory, err := commands.ParseMemory(f.memory)
+		if err != nil {
+			return limits, commands.Sandbox{}, err
+		}
+		limits.Memory = memory
+	}
+	sandbox := commands.Sandbox{
+		ProcessGroup:  f.processGroup,
+		NoNetwork:     f.noNetwork,
+		ReadOnlyPaths: f.readOnly,
+	}
+	return limits, sandbox, nil
+}
+
+// retryPolicy 根据标志构造重试策略
+func (f *execFlags) retryPolicy() (commands.RetryPolicy, error) {
+	if f.jitter < 0 || f.jitter > 1 {
+		return commands.RetryPolicy{}, fmt.Errorf("--jitter 必须在 0 到 1 之间")
+	}
+	patterns, err := commands.CompileRetryPatterns(f.retryOnOutput)
+	if err != nil {
+		return commands.RetryPolicy{}, err
+	}
+	return commands.RetryPolicy{
+		MaxAttempts:      f.attempts,
+		InitialDelay:     f.backoff,
+		MaxDelay:         f.maxBackoff,
+		Jitter:           f.jitter,
+		RetryOnExitCodes: f.retryOnExit,
+		RetryOnOutput:    patterns,
+	}, nil
+}
+
 func NewSequentialCmd() *cobra.Command {
-	return &cobra.Command{
+	var useShell bool
+	var dryRun bool
+	var flags execFlags
+
+	cmd := &cobra.Command{
 		Use:   "sequential",
 		Short: "串行执行多个命令",
-		Long:  `按顺序执行多个命令，用分号(;)分隔`,
-		Args:  cobra.ExactArgs(1),
+		Long: `按顺序执行多个命令，用分号(;)分隔。
+命令按shell语法解析，支持引号、转义、变量赋值以及 &&、||、重定向。
+使用 --shell 时整个字符串作为一段脚本交给进程内解释器执行，语句之间共享变量和工作目录。
+使用 --attempts 为失败的命令启用重试，重试间隔按指数退避并带随机抖动，
+可通过 --retry-on-exit / --retry-on-output 限定只在特定退出码或输出时重试。
+在 Linux 上可用 --cpu、--memory、--nofile、--nproc 限制资源，--pgroup 使超时时结束整个进程组，
+--no-network、--read-only 在新的命名空间中隔离运行不受信任的脚本。
+使用 --dry-run 只输出展开别名后的命令、解析到的程序、环境变量、工作目录、
+超时与资源限制以及安全策略的检查结果，不执行任何命令`,
+		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			retry, err := flags.retryPolicy()
+			if err != nil {
+				return err
+			}
+			limits, sandbox, err := flags.limits()
+			if err != nil {
+				return err
+			}
+			opts := commands.ExecOptions{
+				Shell:   useShell,
+				Timeout: flags.timeout,
+				Retry:   retry,
+				Limits:  limits,
+				Sandbox: sandbox,
+			}
+
+			if useShell {
+				if dryRun {
+					printPlans(cmd.OutOrStdout(), "串行执行", commands.ExplainCommandsWith(args[:1], opts))
+					return nil
+				}
+				logger.Info("开始解释执行脚本", zap.String("script", args[0]))
+				return commands.ExecuteCommandWith(args[0], opts)
+			}
 			commandList := utils.SplitCommands(args[0])
 			if err := utils.ValidateCommands(commandList); err != nil {
 				return err
 			}
+			if dryRun {
+				printPlans(cmd.OutOrStdout(), "串行执行，遇到失败立即停止", commands.ExplainCommandsWith(commandList, opts))
+				return nil
+			}
 			logger.Info("开始串行执行命令", zap.String("commands", args[0]))
-			return commands.ExecuteCommandsSequentially(commandList)
+			return commands.ExecuteCommandsSequentiallyWith(commandList, opts)
 		},
 	}
+
+	cmd.Flags().BoolVar(&useShell, "shell", false, "使用进程内解释器执行整个字符串")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "只输出执行计划，不执行命令")
+	flags.register(cmd)
+	return cmd
 }
 
 func NewParallelCmd() *cobra.Command {
-	return &cobra.Command{
+	var useShell bool
+	var jobs int
+	var keepGoing bool
+	var noColor bool
+	var dryRun bool
+	var flags execFlags
+
+	cmd := &cobra.Command{
 		Use:   "parallel",
 		Short: "并行执行多个命令",
-		Long:  `同时执行多个命令，用分号(;)分隔`,
-		Args:  cobra.ExactArgs(1),
+		Long: `同时执行多个命令，用分号(;)分隔。
+最多同时运行 --jobs 个命令（默认为CPU核数），各命令的输出按行加上 [序号:命令] 前缀实时显示，
+结束后输出各命令的退出码和耗时汇总。
+默认任一命令失败即取消其余命令，使用 --keep-going 继续执行全部命令。
+使用 --shell 时每个命令都交给进程内解释器执行，使用 --dry-run 只输出执行计划`,
+		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			commandList := utils.SplitCommands(args[0])
 			if err := utils.ValidateCommands(commandList); err != nil {
 				return err
 			}
-			logger.Info("开始并行执行命令", zap.String("commands", args[0]))
-			return commands.ExecuteCommandsParallel(commandList)
+			retry, err := flags.retryPolicy()
+			if err != nil {
+				return err
+			}
+			limits, sandbox, err := flags.limits()
+			if err != nil {
+				return err
+			}
+			opts := commands.ParallelOptions{
+				Jobs:      jobs,
+				KeepGoing: keepGoing,
+				Shell:     useShell,
+				Timeout:   flags.timeout,
+				Retry:     retry,
+				Limits:    limits,
+				Sandbox:   sandbox,
+				Output:    cmd.OutOrStdout(),
+				Color:     !noColor && !color.NoColor,
+			}
+			if dryRun {
+				if jobs <= 0 {
+					jobs = runtime.NumCPU()
+				}
+				mode := fmt.Sprintf("并行执行，最多同时运行 %d 个命令，按顺序启动", jobs)
+				if !keepGoing {
+					mode += "，任一命令失败即取消其余命令"
+				}
+				printPlans(cmd.OutOrStdout(), mode, commands.ExplainParallel(commandList, opts))
+				return nil
+			}
+			logger.Info("开始并行执行命令", zap.String("commands", args[0]), zap.Int("jobs", jobs))
+
+			results, err := commands.RunParallel(cmd.Context(), commandList, opts)
+			printParallelSummary(cmd.OutOrStdout(), results)
+			return err
 		},
 	}
+
+	cmd.Flags().BoolVar(&useShell, "shell", false, "使用进程内解释器执行每个命令")
+	cmd.Flags().IntVarP(&jobs, "jobs", "j", 0, "最大并发数，0 表示CPU核数")
+	cmd.Flags().BoolVarP(&keepGoing, "keep-going", "k", false, "某个命令失败后继续执行其余命令")
+	cmd.Flags().BoolVar(&noColor, "no-color", false, "输出前缀不使用颜色")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "只输出执行计划，不执行命令")
+	flags.register(cmd)
+	return cmd
+}
+
+// printParallelSummary 输出并行命令的汇总表
+func printParallelSummary(out io.Writer, results []commands.ParallelResult) {
+	if len(results) == 0 {
+		return
+	}
+
+	fmt.Fprintln(out)
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "序号\t命令\t状态\t退出码\t尝试次数\t耗时")
+	fmt.Fprintln(w, "----\t----\t----\t------\t--------\t----")
+	for _, r := range results {
+		exitCode, attempts, duration := "-", "-", "-"
+		if r.Status != commands.ParallelSkipped {
+			exitCode = fmt.Sprintf("%d", r.ExitCode)
+			attempts = fmt.Sprintf("%d", r.Attempts)
+			duration = r.Duration.Round(time.Millisecond).String()
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n", r.Index+1, r.Command, r.Status, exitCode, attempts, duration)
+	}
+	w.Flush()
+}
+
+// readTextInput 读取文本处理命令的输入文件，"-" 表示标准输入
+func readTextInput(cmd *cobra.Command, name string) (string, error) {
+	if name == "-" {
+		data, err := io.ReadAll(cmd.InOrStdin())
+		if err != nil {
+			return "", fmt.Errorf("读取标准输入失败: %v", err)
+		}
+		return string(data), nil
+	}
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return "", fmt.Errorf("读取输入文件失败: %v", err)
+	}
+	return string(data), nil
 }
 
 func NewAWKCmd() *cobra.Command {
-	return &cobra.Command{
-		Use:   "awk",
+	var fieldSeparator string
+	var assignments []string
+	var external bool
+
+	cmd := &cobra.Command{
+		Use:   "awk <file|-> <program>",
 		Short: "执行AWK命令",
-		Long:  `执行AWK命令处理输入文本`,
-		Args:  cobra.ExactArgs(2),
+		Long: `执行AWK程序处理输入文件，文件为 - 时读取标准输入。
+默认使用内置的 GoAWK 解释器，支持 POSIX AWK 的全部功能，包括 getline、管道和输出重定向；
+程序调用 gensub、strftime 等 gawk 扩展函数时自动改用系统中的 awk。
+使用 --external 总是调用系统中的 awk。`,
+		Args: cobra.ExactArgs(2),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			input := args[0]
 			pattern := args[1]
-			logger.Info("执行AWK命令", zap.String("pattern", pattern))
-			result, err := commands.AWKCommand(input, pattern)
+			input, err := readTextInput(cmd, args[0])
 			if err != nil {
 				return err
 			}
-			fmt.Println(result)
+			opts := awk.Options{FieldSeparator: fieldSeparator}
+			for _, assignment := range assignments {
+				name, value, ok := strings.Cut(assignment, "=")
+				if !ok {
+					return fmt.Errorf("无效的变量赋值 %q，格式应为 name=value", assignment)
+				}
+				if opts.Vars == nil {
+					opts.Vars = make(map[string]string)
+				}
+				opts.Vars[name] = value
+			}
+			logger.Info("执行AWK命令", zap.String("pattern", pattern), zap.Bool("external", external))
+			result, err := commands.AWKCommandWith(input, pattern, opts, external)
+			if err != nil {
+				return err
+			}
+			fmt.Print(result)
 			return nil
 		},
 	}
+
+	cmd.Flags().StringVarP(&fieldSeparator, "field-separator", "F", "", "字段分隔符")
+	cmd.Flags().StringArrayVarP(&assignments, "assign", "v", nil, "在执行前设置变量(name=value)，可重复")
+	cmd.Flags().BoolVar(&external, "external", false, "调用系统中的 awk 而不是内置实现")
+	return cmd
 }
 
 func NewGrepCmd() *cobra.Command {
-	return &cobra.Command{
-		Use:   "grep",
+	var opts textproc.GrepOptions
+	var contextLines int
+	var external bool
+
+	cmd := &cobra.Command{
+		Use:   "grep <file|-> <pattern>",
 		Short: "执行grep命令",
-		Long:  `执行grep命令搜索文本`,
-		Args:  cobra.ExactArgs(2),
+		Long: `在输入文件中搜索匹配正则表达式的行，文件为 - 时读取标准输入。
+默认使用内置的 RE2 正则引擎，输出格式与 GNU grep 一致；没有匹配时返回错误。
+RE2 无法表示的模式（如反向引用）自动改用系统中的 grep（按 grep -E 解析正则）。
+使用 --external 总是调用系统中的 grep。`,
+		Args: cobra.ExactArgs(2),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			input := args[0]
 			pattern := args[1]
-			logger.Info("执行grep命令", zap.String("pattern", pattern))
-			result, err := commands.GrepCommand(input, pattern)
+			input, err := readTextInput(cmd, args[0])
 			if err != nil {
 				return err
 			}
-			fmt.Println(result)
-			return nil
+			if contextLines > 0 {
+				if !cmd.Flags().Changed("before-context") {
+					opts.Before = contextLines
+				}
+				if !cmd.Flags().Changed("after-context") {
+					opts.After = contextLines
+				}
+			}
+			logger.Info("执行grep命令", zap.String("pattern", pattern), zap.Bool("external", external))
+			result, err := commands.GrepCommandWith(input, pattern, opts, external)
+			// 没有匹配时 -c 仍会输出计数
+			fmt.Print(result)
+			return err
 		},
 	}
+
+	flags := cmd.Flags()
+	flags.BoolVarP(&opts.IgnoreCase, "ignore-case", "i", false, "忽略大小写")
+	flags.BoolVarP(&opts.Invert, "invert-match", "v", false, "输出不匹配的行")
+	flags.BoolVarP(&opts.LineNumber, "line-number", "n", false, "输出行号")
+	flags.BoolVar(&opts.Count, "count", false, "只输出匹配的行数")
+	flags.BoolVarP(&opts.OnlyMatching, "only-matching", "o", false, "只输出匹配的部分")
+	flags.BoolVarP(&opts.Fixed, "fixed-strings", "F", false, "将模式作为普通字符串")
+	flags.IntVarP(&opts.After, "after-context", "A", 0, "输出匹配行之后的行数")
+	flags.IntVarP(&opts.Before, "before-context", "B", 0, "输出匹配行之前的行数")
+	flags.IntVarP(&contextLines, "context", "C", 0, "输出匹配行前后的行数")
+	flags.BoolVar(&external, "external", false, "调用系统中的 grep 而不是内置实现")
+	return cmd
 }
 
 func NewSedCmd() *cobra.Command {
-	return &cobra.Command{
-		Use:   "sed",
+	var opts textproc.SedOptions
+	var external bool
+
+	cmd := &cobra.Command{
+		Use:   "sed <file|-> <script>",
 		Short: "执行sed命令",
-		Long:  `执行sed命令处理文本`,
-		Args:  cobra.ExactArgs(2),
+		Long: `执行sed脚本处理输入文件，文件为 - 时读取标准输入。
+默认使用内置实现，支持 s（标志 g、p、N、i）、d、p、q、= 命令，
+行号、$、/regex/、first~step 地址，addr1,addr2、addr1,+N、addr1,~N、0,/regex/ 范围
+以及 ! 取反，多条命令用分号分隔。正则默认按基本正则(BRE)解析，-E 使用扩展正则。
+脚本使用其他命令（如 y、a、N、{}）或反向引用时自动改用系统中的 sed。
+使用 --external 总是调用系统中的 sed。`,
+		Args: cobra.ExactArgs(2),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			input := args[0]
 			pattern := args[1]
-			logger.Info("执行sed命令", zap.String("pattern", pattern))
-			result, err := commands.SedCommand(input, pattern)
+			input, err := readTextInput(cmd, args[0])
+			if err != nil {
+				return err
+			}
+			logger.Info("执行sed命令", zap.String("pattern", pattern), zap.Bool("external", external))
+			result, err := commands.SedCommandWith(input, pattern, opts, external)
 			if err != nil {
 				return err
 			}
-			fmt.Println(result)
+			fmt.Print(result)
 			return nil
 		},
 	}
+
+	cmd.Flags().BoolVarP(&opts.Quiet, "quiet", "n", false, "不自动输出模式空间")
+	cmd.Flags().BoolVarP(&opts.Extended, "regexp-extended", "E", false, "使用扩展正则表达式")
+	cmd.Flags().BoolVar(&external, "external", false, "调用系统中的 sed 而不是内置实现")
+	return cmd
 }
 
 func NewPipeCmd() *cobra.Command {
-	return &cobra.Command{
+	var useShell bool
+	var pipefail bool
+	var showStatus bool
+	var dryRun bool
+	var timeout time.Duration
+
+	cmd := &cobra.Command{
 		Use:   "pipe",
 		Short: "执行管道命令",
-		Long:  `执行多个命令的管道操作，用分号(;)分隔`,
-		Args:  cobra.ExactArgs(1),
+		Long: `执行多个命令的管道操作，用分号(;)或管道符(|)分隔。
+各阶段并发运行并通过管道流式传递数据，输出实时写到标准输出。
+默认启用 pipefail：任一阶段失败即整体失败。
+使用 --shell 时整个字符串交给进程内解释器执行，使用 --dry-run 只输出各阶段的执行计划`,
+		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			if timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, timeout)
+				defer cancel()
+			}
+
+			opts := commands.PipeOptions{
+				Stdin:    cmd.InOrStdin(),
+				Stdout:   cmd.OutOrStdout(),
+				Stderr:   cmd.ErrOrStderr(),
+				Pipefail: pipefail,
+			}
+
+			if useShell {
+				if dryRun {
+					printPlans(cmd.OutOrStdout(), pipeMode(pipefail, timeout, "由进程内解释器执行整个管道"),
+						[]*commands.Plan{commands.ExplainShellPipeline(args[0])})
+					return nil
+				}
+				logger.Info("开始解释执行管道", zap.String("script", args[0]))
+				_, err := commands.RunShellPipeline(ctx, args[0], opts)
+				return err
+			}
+
 			commandList := utils.SplitCommands(args[0])
+			if len(commandList) == 1 {
+				commandList = shell.SplitPipeline(commandList[0])
+			}
 			if err := utils.ValidateCommands(commandList); err != nil {
 				return err
 			}
+			if dryRun {
+				printPlans(cmd.OutOrStdout(), pipeMode(pipefail, timeout, "各阶段同时启动，数据按顺序流经各阶段"),
+					commands.ExplainPipeline(commandList))
+				return nil
+			}
 			logger.Info("开始执行管道命令", zap.String("commands", args[0]))
-			result, err := commands.PipeCommands(commandList)
-			if err != nil {
-				return err
+			result, err := commands.RunPipeline(ctx, commandList, opts)
+			if result != nil && (showStatus || err != nil) {
+				printStageStatus(cmd, result)
 			}
-			fmt.Println(result)
-			return nil
+			return err
 		},
 	}
+
+	cmd.Flags().BoolVar(&useShell, "shell", false, "使用进程内解释器执行整个管道")
+	cmd.Flags().BoolVar(&pipefail, "pipefail", true, "任一阶段失败时整个管道失败")
+	cmd.Flags().BoolVar(&showStatus, "status", false, "完成后显示每个阶段的退出码")
+	cmd.Flags().DurationVar(&timeout, "timeout", 0, "整个管道的超时时间，默认不限制")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "只输出执行计划，不执行命令")
+	return cmd
+}
+
+// pipeMode 描述管道的执行方式
+func pipeMode(pipefail bool, timeout time.Duration, mode string) string {
+	if pipefail {
+		mode += "，任一阶段失败即整体失败"
+	} else {
+		mode += "，只看最后一个阶段的退出码"
+	}
+	if timeout > 0 {
+		mode += fmt.Sprintf("，整个管道超时时间 %s", timeout)
+	}
+	return mode
+}
+
+// printPlans 按执行顺序输出命令的执行计划
+func printPlans(out io.Writer, mode string, plans []*commands.Plan) {
+	fmt.Fprintf(out, "执行计划（%s）:\n", mode)
+	for i, plan := range plans {
+		fmt.Fprintf(out, "[%d] %s\n", i+1, plan.Original)
+		plan.Describe(out, "    ")
+	}
+}
+
+// printStageStatus 输出管道各阶段的退出码
+func printStageStatus(cmd *cobra.Command, result *commands.PipelineResult) {
+	w := cmd.ErrOrStderr()
+	for i, stage := range result.Stages {
+		line := fmt.Sprintf("[%d] %-40s 退出码: %d", i+1, stage.Command, stage.ExitCode)
+		if stage.Signal != "" {
+			line += fmt.Sprintf(" (信号: %s)", stage.Signal)
+		}
+		fmt.Fprintln(w, line)
+	}
 }
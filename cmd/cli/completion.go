@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/Lzww0608/ClixGo/pkg/completion"
+	"github.com/spf13/cobra"
+)
+
+func NewCompletionCmd() *cobra.Command {
+	var install, uninstall bool
+
+	cmd := &cobra.Command{
+		Use:   "completion bash|zsh|fish|powershell",
+		Short: "生成命令补全脚本",
+		Long: `生成指定shell的命令补全脚本并输出到标准输出，例如:
+  source <(ClixGo completion bash)
+  ClixGo completion zsh > "${fpath[1]}/_ClixGo"
+  ClixGo completion fish | source
+  ClixGo completion powershell | Out-String | Invoke-Expression
+
+使用 --install 将脚本写入该shell的补全目录（不会修改 .bashrc 等启动文件）:
+  bash        ~/.local/share/bash-completion/completions/ClixGo，需要 bash-completion 2.x
+  zsh         ~/.zsh/completions/_ClixGo，需要将该目录加入 fpath
+  fish        ~/.config/fish/completions/ClixGo.fish
+  powershell  ~/.config/powershell/completions/ClixGo.ps1，需要在 $PROFILE 中加载
+使用 --uninstall 删除安装的脚本。`,
+		ValidArgs:             completion.Shells,
+		Args:                  cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+		DisableFlagsInUseLine: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			shell := args[0]
+			out := cmd.OutOrStdout()
+			switch {
+			case install:
+				path, hint, err := completion.Install(cmd.Root(), shell)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(out, "补全脚本已安装到 %s\n", path)
+				if hint != "" {
+					fmt.Fprintln(out, hint)
+				}
+				return nil
+			case uninstall:
+				path, err := completion.Uninstall(cmd.Root(), shell)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(out, "已删除补全脚本 %s\n", path)
+				return nil
+			default:
+				return completion.Generate(cmd.Root(), shell, out)
+			}
+		},
+	}
+
+	cmd.Flags().BoolVar(&install, "install", false, "将补全脚本安装到该shell的补全目录")
+	cmd.Flags().BoolVar(&uninstall, "uninstall", false, "删除安装的补全脚本")
+	cmd.MarkFlagsMutuallyExclusive("install", "uninstall")
+	return cmd
+}
@@ -0,0 +1,181 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/Lzww0608/ClixGo/pkg/config"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+func NewConfigCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "管理配置",
+		Long: `读取和修改配置文件中的配置项，支持加密保存 API 密钥等敏感配置。
+
+加密的配置项以 AES-256-GCM 密文保存在配置文件中，读取时自动解密。密钥按以下顺序查找:
+  环境变量 ` + config.KeyEnv + `         base64 编码的 32 字节密钥
+  密钥文件 ~/.clixgo/` + config.KeyFileName + `       与配置文件位于同一目录，首次加密时自动生成
+  环境变量 ` + config.PassphraseEnv + `  口令，使用 scrypt 派生密钥`,
+	}
+
+	cmd.AddCommand(newConfigGetCmd(), newConfigSetCmd(), newConfigRotateKeyCmd())
+	return cmd
+}
+
+func newConfigGetCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "get <key>",
+		Short: "输出配置项的值，加密的值输出解密后的内容",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			value, err := config.GetInstance().Get(strings.ToLower(args[0]))
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), value)
+			return nil
+		},
+	}
+}
+
+func newConfigSetCmd() *cobra.Command {
+	var secret bool
+
+	cmd := &cobra.Command{
+		Use:   "set <key> [value]",
+		Short: "设置配置项",
+		Long: `将配置项写入配置文件，key 为以 . 分隔的路径，文件中的其他内容和注释保持不变，例如:
+  ClixGo config set commands.timeout 60s
+  ClixGo config set --secret translate.api_key
+
+使用 --secret 时加密后保存；省略 value 时从终端输入（不回显）或从标准输入读取，
+避免密钥出现在shell历史中。没有可用的密钥时会生成密钥文件。`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key := args[0]
+			cm := config.GetInstance()
+			if !secret {
+				if len(args) < 2 {
+					return fmt.Errorf("缺少配置项 %s 的值", key)
+				}
+				if err := cm.SetValue(key, args[1]); err != nil {
+					return err
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "已设置 %s\n", key)
+				return nil
+			}
+
+			var value string
+			if len(args) == 2 {
+				value = args[1]
+			} else {
+				var err error
+				if value, err = readSecret(cmd.InOrStdin(), "请输入 "+key+" 的值:"); err != nil {
+					return err
+				}
+			}
+			generated, err := cm.SetSecret(key, value)
+			if err != nil {
+				return err
+			}
+			if generated != "" {
+				fmt.Fprintf(cmd.OutOrStdout(), "已生成密钥文件 %s，请妥善备份，丢失后无法解密\n", generated)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "已加密保存 %s\n", key)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&secret, "secret", false, "加密保存配置项")
+	return cmd
+}
+
+func newConfigRotateKeyCmd() *cobra.Command {
+	var usePassphrase bool
+
+	cmd := &cobra.Command{
+		Use:   "rotate-key",
+		Short: "更换加密配置项使用的密钥",
+		Long: `生成新的密钥文件，并用新的密钥重新加密配置文件中所有加密的配置项。
+使用 --passphrase 改为使用口令加密，完成后删除原密钥文件，之后需要通过环境变量 ` + config.PassphraseEnv + ` 提供口令。
+密钥来自环境变量 ` + config.KeyEnv + ` 时无法自动轮换。`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var newKey []byte
+			var passphrase string
+			var err error
+			if usePassphrase {
+				if passphrase, err = askNewPassphrase(); err != nil {
+					return err
+				}
+			} else if newKey, err = config.GenerateKey(); err != nil {
+				return err
+			}
+
+			cm := config.GetInstance()
+			keys, err := cm.RotateKey(newKey, passphrase)
+			if err != nil {
+				return err
+			}
+
+			out := cmd.OutOrStdout()
+			fmt.Fprintf(out, "已重新加密 %d 个配置项\n", len(keys))
+			for _, key := range keys {
+				fmt.Fprintf(out, "  %s\n", key)
+			}
+			if usePassphrase {
+				fmt.Fprintf(out, "请将环境变量 %s 设置为新的口令\n", config.PassphraseEnv)
+			} else {
+				fmt.Fprintf(out, "新的密钥已保存到 %s\n", cm.KeyFile())
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&usePassphrase, "passphrase", false, "改为使用口令加密")
+	return cmd
+}
+
+// readSecret 读取敏感的值：标准输入是终端时不回显地输入，否则读取标准输入的全部内容（去掉末尾的换行）
+func readSecret(in io.Reader, message string) (string, error) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		data, err := io.ReadAll(in)
+		if err != nil {
+			return "", fmt.Errorf("读取标准输入失败: %v", err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+
+	var value string
+	stdio := survey.WithStdio(os.Stdin, os.Stderr, os.Stderr)
+	if err := survey.AskOne(&survey.Password{Message: message}, &value, stdio, survey.WithValidator(survey.Required)); err != nil {
+		return "", err
+	}
+	return value, nil
+}
+
+// askNewPassphrase 在终端中输入两次新的口令
+func askNewPassphrase() (string, error) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return "", fmt.Errorf("需要在终端中输入口令")
+	}
+
+	stdio := survey.WithStdio(os.Stdin, os.Stderr, os.Stderr)
+	var passphrase, confirm string
+	if err := survey.AskOne(&survey.Password{Message: "新的口令:"}, &passphrase, stdio, survey.WithValidator(survey.Required)); err != nil {
+		return "", err
+	}
+	if err := survey.AskOne(&survey.Password{Message: "再次输入口令:"}, &confirm, stdio); err != nil {
+		return "", err
+	}
+	if passphrase != confirm {
+		return "", fmt.Errorf("两次输入的口令不一致")
+	}
+	return passphrase, nil
+}
@@ -1,20 +1,35 @@
 package cli
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
 	"text/tabwriter"
+	"time"
 
-	"github.com/spf13/cobra"
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/Lzww0608/ClixGo/pkg/commands"
 	"github.com/Lzww0608/ClixGo/pkg/history"
 	"github.com/Lzww0608/ClixGo/pkg/logger"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+	"golang.org/x/term"
 )
 
 func NewHistoryCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "history",
 		Short: "管理命令历史记录",
-		Long:  `查看、清除命令历史记录`,
+		Long: `查看、清除命令历史记录。
+
+历史记录以每行一条 JSON 的格式追加写入 ~/.clixgo/history.jsonl，多个终端可以同时写入。
+默认保留最近 10000 条，可通过配置项 history.max_entries 和 history.max_age（如 90d）调整。
+旧版本的 ~/.clixgo/history.json 会在首次使用时自动导入。`,
 	}
 
 	cmd.AddCommand(&cobra.Command{
@@ -27,14 +42,15 @@ func NewHistoryCmd() *cobra.Command {
 			}
 
 			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-			fmt.Fprintln(w, "时间\t命令\t状态\t耗时")
-			fmt.Fprintln(w, "----\t----\t----\t----")
+			fmt.Fprintln(w, "时间\t命令\t状态\t退出码\t耗时")
+			fmt.Fprintln(w, "----\t----\t----\t------\t----")
 
 			for _, h := range history {
-				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
+				fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\n",
 					h.StartTime.Format("2006-01-02 15:04:05"),
 					h.Command,
 					h.Status,
+					h.ExitCode,
 					h.Duration,
 				)
 			}
@@ -56,9 +72,10 @@ func NewHistoryCmd() *cobra.Command {
 	})
 
 	cmd.AddCommand(&cobra.Command{
-		Use:   "show",
-		Short: "显示命令详情",
-		Args:  cobra.ExactArgs(1),
+		Use:               "show <index>",
+		Short:             "显示命令详情",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeHistoryIndexes,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			history, err := history.GetHistory()
 			if err != nil {
@@ -74,6 +91,11 @@ func NewHistoryCmd() *cobra.Command {
 			h := history[index]
 			fmt.Printf("命令: %s\n", h.Command)
 			fmt.Printf("状态: %s\n", h.Status)
+			fmt.Printf("退出码: %d\n", h.ExitCode)
+			fmt.Printf("工作目录: %s\n", h.Dir)
+			fmt.Printf("主机: %s\n", h.Hostname)
+			fmt.Printf("用户: %s\n", h.User)
+			fmt.Printf("会话: %s\n", h.SessionID)
 			fmt.Printf("开始时间: %s\n", h.StartTime.Format("2006-01-02 15:04:05"))
 			fmt.Printf("结束时间: %s\n", h.EndTime.Format("2006-01-02 15:04:05"))
 			fmt.Printf("耗时: %s\n", h.Duration)
@@ -83,5 +105,562 @@ func NewHistoryCmd() *cobra.Command {
 		},
 	})
 
+	cmd.AddCommand(newHistorySearchCmd(), newHistoryGrepCmd(), newHistoryImportCmd(), newHistoryExportCmd(), newHistoryStatsCmd())
+
+	return cmd
+}
+
+// completeHistoryCount 不输入前缀时补全的最近记录条数
+const completeHistoryCount = 50
+
+// completeHistoryIndexes 补全历史记录索引，最新的记录在前，说明中显示命令的预览。
+// 没有输入时只补全最近的 completeHistoryCount 条
+func completeHistoryIndexes(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	entries, err := history.GetHistory()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var completions []string
+	for i := len(entries) - 1; i >= 0; i-- {
+		if toComplete == "" && len(completions) == completeHistoryCount {
+			break
+		}
+		index := strconv.Itoa(i)
+		if !strings.HasPrefix(index, toComplete) {
+			continue
+		}
+		preview, _, _ := strings.Cut(entries[i].Command, "\n")
+		if runes := []rune(preview); len(runes) > 60 {
+			preview = string(runes[:60]) + "…"
+		}
+		completions = append(completions, fmt.Sprintf("%s\t[%s] %s", index, entries[i].Status, preview))
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveKeepOrder
+}
+
+// historyFilterFlags search 和 grep 共用的筛选参数
+type historyFilterFlags struct {
+	status   string
+	exitCode int
+	dir      string
+	since    string
+	until    string
+}
+
+func (f *historyFilterFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.status, "status", "", "只包含该状态的记录，如 success、failed")
+	cmd.Flags().IntVar(&f.exitCode, "exit-code", 0, "只包含该退出码的记录")
+	cmd.Flags().StringVar(&f.dir, "dir", "", "只包含在该目录及其子目录中执行的记录，. 表示当前目录")
+	cmd.Flags().StringVar(&f.since, "since", "", "开始时间下限，如 2024-05-01、\"2024-05-01 09:00\"、7d、2h")
+	cmd.Flags().StringVar(&f.until, "until", "", "开始时间上限，格式同 --since")
+}
+
+// filter 将命令行参数转换为筛选条件
+func (f *historyFilterFlags) filter(cmd *cobra.Command) (history.Filter, error) {
+	filter := history.Filter{Status: f.status}
+	if cmd.Flags().Changed("exit-code") {
+		code := f.exitCode
+		filter.ExitCode = &code
+	}
+	if f.dir != "" {
+		dir, err := filepath.Abs(f.dir)
+		if err != nil {
+			return filter, err
+		}
+		filter.Dir = dir
+	}
+
+	now := time.Now()
+	var err error
+	if f.since != "" {
+		if filter.Since, err = history.ParseTime(f.since, now); err != nil {
+			return filter, err
+		}
+	}
+	if f.until != "" {
+		if filter.Until, err = history.ParseTime(f.until, now); err != nil {
+			return filter, err
+		}
+	}
+	return filter, nil
+}
+
+func newHistorySearchCmd() *cobra.Command {
+	var filterFlags historyFilterFlags
+	var printOnly bool
+	var pageSize int
+
+	cmd := &cobra.Command{
+		Use:   "search [query]",
+		Short: "交互式模糊搜索历史命令",
+		Long: `交互式模糊搜索历史命令，输入字符即可按 fzf 的方式筛选（字符按顺序出现即匹配，
+单词开头和连续匹配排在前面），列表中显示退出码和工作目录。
+选中后显示命令输出的预览，可以直接重新执行、编辑后执行或只输出命令。
+
+--print 只把选中的命令输出到标准输出（界面显示在标准错误上），便于绑定到shell快捷键，例如 bash:
+  bind -x '"\C-r": READLINE_LINE=$(ClixGo history search --print); READLINE_POINT=${#READLINE_LINE}'
+
+脚本中请使用非交互的 history grep。`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !term.IsTerminal(int(os.Stdin.Fd())) {
+				return fmt.Errorf("history search 需要在终端中运行，脚本中请使用 history grep")
+			}
+			filter, err := filterFlags.filter(cmd)
+			if err != nil {
+				return err
+			}
+			entries, err := history.GetHistory()
+			if err != nil {
+				return err
+			}
+			query := ""
+			if len(args) > 0 {
+				query = args[0]
+			}
+			matches := history.Search(entries, query, filter)
+			if len(matches) == 0 {
+				return fmt.Errorf("没有匹配的历史记录")
+			}
+
+			stdio := survey.WithStdio(os.Stdin, os.Stderr, os.Stderr)
+			selected, err := pickHistory(matches, pageSize, stdio)
+			if err != nil {
+				return err
+			}
+			if printOnly {
+				fmt.Println(selected.Command)
+				return nil
+			}
+
+			previewHistory(os.Stderr, selected)
+			return historyAction(selected, stdio)
+		},
+	}
+
+	filterFlags.register(cmd)
+	cmd.Flags().BoolVar(&printOnly, "print", false, "只输出选中的命令，不执行")
+	cmd.Flags().IntVar(&pageSize, "height", 15, "列表显示的行数")
+	return cmd
+}
+
+// pickHistory 显示可模糊筛选的历史命令列表并返回选中的记录
+func pickHistory(matches []history.Match, pageSize int, opts ...survey.AskOpt) (*history.Match, error) {
+	options := make([]string, len(matches))
+	for i, m := range matches {
+		options[i] = fmt.Sprintf("%s  %s", m.StartTime.Format("01-02 15:04"), m.Command)
+	}
+
+	prompt := &survey.Select{
+		Message:  "搜索历史命令:",
+		Options:  options,
+		PageSize: pageSize,
+		Description: func(value string, index int) string {
+			m := matches[index]
+			if m.Dir == "" {
+				return fmt.Sprintf("[%d]", m.ExitCode)
+			}
+			return fmt.Sprintf("[%d] %s", m.ExitCode, m.Dir)
+		},
+	}
+	filter := survey.WithFilter(func(filter, value string, index int) bool {
+		if filter == "" {
+			return true
+		}
+		for _, term := range strings.Fields(filter) {
+			if _, ok := history.FuzzyScore(term, matches[index].Command); !ok {
+				return false
+			}
+		}
+		return true
+	})
+
+	var index int
+	if err := survey.AskOne(prompt, &index, append(opts, filter)...); err != nil {
+		return nil, err
+	}
+	return &matches[index], nil
+}
+
+// previewLines 预览中最多显示的输出行数
+const previewLines = 20
+
+// previewHistory 输出选中记录的执行信息和输出预览
+func previewHistory(w io.Writer, m *history.Match) {
+	fmt.Fprintf(w, "命令: %s\n", m.Command)
+	fmt.Fprintf(w, "状态: %s (退出码 %d)  时间: %s  耗时: %s\n",
+		m.Status, m.ExitCode, m.StartTime.Format("2006-01-02 15:04:05"), m.Duration)
+	if m.Dir != "" {
+		fmt.Fprintf(w, "工作目录: %s\n", m.Dir)
+	}
+
+	output := strings.TrimRight(m.Output, "\n")
+	if output == "" {
+		fmt.Fprintln(w, "输出: (无)")
+		return
+	}
+	lines := strings.Split(output, "\n")
+	fmt.Fprintln(w, "输出:")
+	for i, line := range lines {
+		if i == previewLines {
+			fmt.Fprintf(w, "  ... 省略 %d 行，使用 history show %d 查看完整输出\n", len(lines)-previewLines, m.Index)
+			break
+		}
+		fmt.Fprintf(w, "  %s\n", line)
+	}
+}
+
+// historyAction 询问对选中命令的操作并执行
+func historyAction(m *history.Match, opts ...survey.AskOpt) error {
+	const (
+		actionRun   = "重新执行"
+		actionEdit  = "编辑后执行"
+		actionPrint = "输出命令"
+		actionNone  = "取消"
+	)
+
+	var action string
+	prompt := &survey.Select{
+		Message: "操作:",
+		Options: []string{actionRun, actionEdit, actionPrint, actionNone},
+	}
+	if err := survey.AskOne(prompt, &action, opts...); err != nil {
+		return err
+	}
+
+	command := m.Command
+	switch action {
+	case actionEdit:
+		if err := survey.AskOne(&survey.Input{Message: "$", Default: command}, &command, opts...); err != nil {
+			return err
+		}
+		if strings.TrimSpace(command) == "" {
+			return nil
+		}
+	case actionPrint:
+		fmt.Println(command)
+		return nil
+	case actionNone:
+		return nil
+	}
+	return commands.ExecuteCommand(command)
+}
+
+func newHistoryGrepCmd() *cobra.Command {
+	var filterFlags historyFilterFlags
+	var ignoreCase, inOutput, asJSON bool
+
+	cmd := &cobra.Command{
+		Use:   "grep <regex>",
+		Short: "按正则表达式搜索历史命令",
+		Long: `按正则表达式（Go 语法）搜索历史命令，按时间顺序输出，适合在脚本中使用。
+--json 输出 JSON 数组，每个元素包含记录在 history list 中的位置 index 和完整的记录字段，例如:
+  ClixGo history grep --status failed --since 7d --json '^go test' | jq -r '.[].dir'
+
+没有匹配的记录时以非零状态退出。`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			expr := args[0]
+			if ignoreCase {
+				expr = "(?i)" + expr
+			}
+			re, err := regexp.Compile(expr)
+			if err != nil {
+				return fmt.Errorf("无效的正则表达式: %v", err)
+			}
+			filter, err := filterFlags.filter(cmd)
+			if err != nil {
+				return err
+			}
+			entries, err := history.GetHistory()
+			if err != nil {
+				return err
+			}
+
+			matches := history.Grep(entries, re, filter, inOutput)
+			if asJSON {
+				if matches == nil {
+					matches = []history.Match{}
+				}
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				if err := enc.Encode(matches); err != nil {
+					return err
+				}
+			} else {
+				w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+				for _, m := range matches {
+					fmt.Fprintf(w, "%d\t%s\t%s\t%d\t%s\n",
+						m.Index, m.StartTime.Format("2006-01-02 15:04:05"), m.Status, m.ExitCode, m.Command)
+				}
+				w.Flush()
+			}
+			if len(matches) == 0 {
+				cmd.SilenceUsage = true
+				return fmt.Errorf("没有匹配的历史记录")
+			}
+			return nil
+		},
+	}
+
+	filterFlags.register(cmd)
+	cmd.Flags().BoolVarP(&ignoreCase, "ignore-case", "i", false, "忽略大小写")
+	cmd.Flags().BoolVar(&inOutput, "output", false, "同时搜索命令的输出")
+	cmd.Flags().BoolVar(&asJSON, "json", false, "以 JSON 格式输出")
+	return cmd
+}
+
+func newHistoryImportCmd() *cobra.Command {
+	var from string
+
+	cmd := &cobra.Command{
+		Use:   "import --from bash|zsh|fish [file]",
+		Short: "导入shell的历史记录",
+		Long: `将 bash、zsh 或 fish 的历史记录合并到 ClixGo 的历史记录中，合并后按时间排序，
+命令和时间都相同的记录只保留一条，因此可以重复导入同一个文件。
+未指定文件时使用shell默认的历史文件：bash 和 zsh 优先使用 $HISTFILE，
+否则为 ~/.bash_history、~/.zsh_history；fish 为 ~/.local/share/fish/fish_history。
+
+导入的记录状态为 imported，退出码为 -1。zsh 需要开启 EXTENDED_HISTORY、
+bash 需要设置 HISTTIMEFORMAT 才有每条命令的时间，否则使用文件的修改时间。`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := ""
+			if len(args) > 0 {
+				path = args[0]
+			} else {
+				var err error
+				if path, err = history.DefaultShellFile(from); err != nil {
+					return err
+				}
+			}
+
+			f, err := os.Open(path)
+			if err != nil {
+				return fmt.Errorf("打开历史文件失败: %v", err)
+			}
+			defer f.Close()
+			info, err := f.Stat()
+			if err != nil {
+				return fmt.Errorf("读取历史文件失败: %v", err)
+			}
+
+			records, err := history.ParseShell(f, from, info.ModTime())
+			if err != nil {
+				return err
+			}
+			added, err := history.Import(records)
+			if err != nil {
+				return err
+			}
+			logger.Info("导入历史记录", zap.String("file", path), zap.Int("records", len(records)), zap.Int("added", added))
+			fmt.Fprintf(cmd.OutOrStdout(), "从 %s 读取 %d 条记录，新增 %d 条，跳过 %d 条重复记录\n",
+				path, len(records), added, len(records)-added)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&from, "from", "", "历史文件的格式: bash、zsh 或 fish")
+	cmd.MarkFlagRequired("from")
+	return cmd
+}
+
+func newHistoryExportCmd() *cobra.Command {
+	var filterFlags historyFilterFlags
+	var format, output string
+	var dedup bool
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "导出历史记录",
+		Long: `按时间顺序导出历史记录：
+  bash  带 "#时间戳" 行的 .bash_history 格式（需要设置 HISTTIMEFORMAT 让 bash 读取时间）
+  zsh   EXTENDED_HISTORY 格式
+  fish  fish_history 格式
+  json  记录数组
+  csv   第一行为表头
+
+例如把 ClixGo 的历史追加到 zsh 的历史中:
+  ClixGo history export --format zsh --dedup >> ~/.zsh_history`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			filter, err := filterFlags.filter(cmd)
+			if err != nil {
+				return err
+			}
+			entries, err := history.GetHistory()
+			if err != nil {
+				return err
+			}
+
+			records := make([]history.CommandHistory, 0, len(entries))
+			for i := range entries {
+				if filter.Match(&entries[i]) {
+					records = append(records, entries[i])
+				}
+			}
+			if dedup {
+				records = history.Dedup(records)
+			}
+
+			w := cmd.OutOrStdout()
+			if output != "" {
+				f, err := os.Create(output)
+				if err != nil {
+					return fmt.Errorf("创建导出文件失败: %v", err)
+				}
+				defer f.Close()
+				w = f
+			}
+			if err := history.Export(w, records, format); err != nil {
+				return err
+			}
+			if output != "" {
+				logger.Info("导出历史记录", zap.String("file", output), zap.String("format", format), zap.Int("records", len(records)))
+			}
+			return nil
+		},
+	}
+
+	filterFlags.register(cmd)
+	cmd.Flags().StringVar(&format, "format", history.FormatJSON, "导出格式: bash、zsh、fish、json 或 csv")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "导出到文件，默认输出到标准输出")
+	cmd.Flags().BoolVar(&dedup, "dedup", false, "每个命令只保留最后一次执行的记录")
 	return cmd
-} 
\ No newline at end of file
+}
+
+func newHistoryStatsCmd() *cobra.Command {
+	var filterFlags historyFilterFlags
+	var opts history.StatsOptions
+	var asJSON bool
+
+	cmd := &cobra.Command{
+		Use:   "stats",
+		Short: "统计命令的使用情况",
+		Long: `根据历史记录统计最常用的命令、每个命令的失败率和耗时分位数（P50/P90/P99）、
+按小时和星期的使用分布，以及耗时最长的命令，用于决定哪些命令值得设置别名或自动化。
+--by-program 按程序名（如 git、go）而不是完整命令分组；--json 输出 JSON（耗时单位为毫秒）。
+从shell导入的记录没有执行状态，不参与失败率的计算。`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			filter, err := filterFlags.filter(cmd)
+			if err != nil {
+				return err
+			}
+			entries, err := history.GetHistory()
+			if err != nil {
+				return err
+			}
+			records := make([]history.CommandHistory, 0, len(entries))
+			for i := range entries {
+				if filter.Match(&entries[i]) {
+					records = append(records, entries[i])
+				}
+			}
+
+			report := history.Stats(records, opts)
+			// 筛选后的下标与 history show 使用的下标不同，转换回原始位置
+			positions := make([]int, 0, len(records))
+			for i := range entries {
+				if filter.Match(&entries[i]) {
+					positions = append(positions, i)
+				}
+			}
+			for i := range report.Slowest {
+				report.Slowest[i].Index = positions[report.Slowest[i].Index]
+			}
+
+			if asJSON {
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				return enc.Encode(report)
+			}
+			printStats(cmd.OutOrStdout(), report)
+			return nil
+		},
+	}
+
+	filterFlags.register(cmd)
+	cmd.Flags().BoolVar(&opts.ByProgram, "by-program", false, "按程序名而不是完整命令分组")
+	cmd.Flags().IntVar(&opts.Top, "top", 10, "最常用命令和最慢命令各显示的条数，0 表示全部")
+	cmd.Flags().BoolVar(&asJSON, "json", false, "以 JSON 格式输出")
+	return cmd
+}
+
+// printStats 以表格输出使用统计
+func printStats(out io.Writer, r *history.Report) {
+	if r.Total == 0 {
+		fmt.Fprintln(out, "没有历史记录")
+		return
+	}
+
+	fmt.Fprintf(out, "共 %d 条记录，失败 %d 条", r.Total, r.Failures)
+	if !r.Since.IsZero() {
+		fmt.Fprintf(out, "，时间范围 %s ~ %s", r.Since.Local().Format("2006-01-02 15:04"), r.Until.Local().Format("2006-01-02 15:04"))
+	}
+	fmt.Fprintf(out, "\n耗时: P50 %s  P90 %s  P99 %s  最长 %s\n",
+		formatStatsDuration(r.Duration.P50), formatStatsDuration(r.Duration.P90),
+		formatStatsDuration(r.Duration.P99), formatStatsDuration(r.Duration.Max))
+
+	fmt.Fprintln(out, "\n最常用的命令:")
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "次数\t失败率\tP50\tP90\tP99\t命令")
+	for _, c := range r.Commands {
+		fmt.Fprintf(w, "%d\t%.1f%%\t%s\t%s\t%s\t%s\n", c.Count, c.FailureRate*100,
+			formatStatsDuration(c.Duration.P50), formatStatsDuration(c.Duration.P90),
+			formatStatsDuration(c.Duration.P99), c.Command)
+	}
+	w.Flush()
+
+	if len(r.Slowest) > 0 {
+		fmt.Fprintln(out, "\n耗时最长的命令:")
+		w = tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "序号\t时间\t耗时\t状态\t命令")
+		for _, m := range r.Slowest {
+			d, _ := m.Elapsed()
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", m.Index, m.StartTime.Format("2006-01-02 15:04:05"), formatStatsDuration(d), m.Status, m.Command)
+		}
+		w.Flush()
+	}
+
+	fmt.Fprintln(out, "\n按小时:")
+	printHistogram(out, r.ByHour[:], func(i int) string { return fmt.Sprintf("%02d", i) })
+	fmt.Fprintln(out, "\n按星期:")
+	weekdays := []string{"周日", "周一", "周二", "周三", "周四", "周五", "周六"}
+	printHistogram(out, r.ByWeekday[:], func(i int) string { return weekdays[i] })
+}
+
+// histogramWidth 直方图最长条的宽度
+const histogramWidth = 40
+
+// printHistogram 输出横向直方图
+func printHistogram(out io.Writer, counts []int, label func(int) string) {
+	max := 0
+	for _, n := range counts {
+		if n > max {
+			max = n
+		}
+	}
+	for i, n := range counts {
+		bar := 0
+		if max > 0 {
+			bar = (n*histogramWidth + max - 1) / max
+		}
+		fmt.Fprintf(out, "  %s %s %d\n", label(i), strings.Repeat("█", bar), n)
+	}
+}
+
+// formatStatsDuration 格式化耗时，没有数据时显示 -
+func formatStatsDuration(d time.Duration) string {
+	if d == 0 {
+		return "-"
+	}
+	if d >= time.Second {
+		return d.Round(10 * time.Millisecond).String()
+	}
+	return d.Round(time.Microsecond * 100).String()
+}
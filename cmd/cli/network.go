@@ -2,7 +2,9 @@ package cli
 
 import (
 	"fmt"
+	"net"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/Lzww0608/ClixGo/pkg/network"
@@ -256,6 +258,7 @@ func NewNetworkCmd() *cobra.Command {
 		},
 	}
 	configCmd.Flags().StringP("interface", "i", "", "网络接口名称")
+	configCmd.RegisterFlagCompletionFunc("interface", completeInterfaces)
 	cmd.AddCommand(configCmd)
 
 	// 带宽测试命令
@@ -308,6 +311,7 @@ func NewNetworkCmd() *cobra.Command {
 		},
 	}
 	captureCmd.Flags().StringP("interface", "i", "", "网络接口名称")
+	captureCmd.RegisterFlagCompletionFunc("interface", completeInterfaces)
 	captureCmd.Flags().StringP("filter", "f", "", "过滤表达式")
 	captureCmd.Flags().IntP("count", "c", 0, "捕获数量(0表示无限)")
 	captureCmd.Flags().DurationP("timeout", "t", 0, "超时时间(0表示无限)")
@@ -431,6 +435,7 @@ func NewNetworkCmd() *cobra.Command {
 		},
 	}
 	trafficCmd.Flags().StringP("interface", "i", "", "网络接口名称")
+	trafficCmd.RegisterFlagCompletionFunc("interface", completeInterfaces)
 	trafficCmd.Flags().DurationP("interval", "I", 1*time.Second, "统计间隔")
 	cmd.AddCommand(trafficCmd)
 
@@ -479,6 +484,7 @@ func NewNetworkCmd() *cobra.Command {
 		},
 	}
 	optimizeCmd.Flags().StringP("interface", "i", "", "网络接口名称")
+	optimizeCmd.RegisterFlagCompletionFunc("interface", completeInterfaces)
 	optimizeCmd.Flags().BoolP("auto", "a", false, "自动应用优化建议")
 	cmd.AddCommand(optimizeCmd)
 
@@ -567,6 +573,7 @@ func NewNetworkCmd() *cobra.Command {
 		},
 	}
 	analyzeCmd.Flags().StringP("interface", "i", "", "网络接口名称")
+	analyzeCmd.RegisterFlagCompletionFunc("interface", completeInterfaces)
 	analyzeCmd.Flags().DurationP("duration", "d", 1*time.Minute, "分析持续时间")
 	cmd.AddCommand(analyzeCmd)
 
@@ -631,6 +638,7 @@ func NewNetworkCmd() *cobra.Command {
 		},
 	}
 	backupCmd.Flags().StringP("interface", "i", "", "网络接口名称")
+	backupCmd.RegisterFlagCompletionFunc("interface", completeInterfaces)
 	cmd.AddCommand(backupCmd)
 
 	// 网络配置恢复命令
@@ -651,3 +659,33 @@ func NewNetworkCmd() *cobra.Command {
 
 	return cmd
 }
+
+// completeInterfaces 补全网络接口名称，说明中显示接口的地址
+func completeInterfaces(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var completions []string
+	for _, iface := range ifaces {
+		if !strings.HasPrefix(iface.Name, toComplete) {
+			continue
+		}
+		var addrs []string
+		if list, err := iface.Addrs(); err == nil {
+			for _, addr := range list {
+				addrs = append(addrs, addr.String())
+			}
+		}
+		desc := "无地址"
+		if len(addrs) > 0 {
+			desc = strings.Join(addrs, ", ")
+		}
+		if iface.Flags&net.FlagUp == 0 {
+			desc += " (down)"
+		}
+		completions = append(completions, iface.Name+"\t"+desc)
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
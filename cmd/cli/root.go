@@ -3,13 +3,18 @@ package cli
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
 
 	"github.com/Lzww0608/ClixGo/cmd/task"
 	"github.com/Lzww0608/ClixGo/pkg/alias"
-	"github.com/Lzww0608/ClixGo/pkg/completion"
+	"github.com/Lzww0608/ClixGo/pkg/commands"
 	"github.com/Lzww0608/ClixGo/pkg/config"
+	"github.com/Lzww0608/ClixGo/pkg/history"
 	"github.com/Lzww0608/ClixGo/pkg/logger"
+	"github.com/Lzww0608/ClixGo/pkg/redact"
 	"github.com/spf13/cobra"
+	"go.uber.org/zap"
 )
 
 var rootCmd = &cobra.Command{
@@ -23,6 +28,8 @@ var rootCmd = &cobra.Command{
 - grep命令处理
 - sed命令处理
 - 管道命令处理
+- 工作流(DAG)执行
+- 定时执行命令
 - 命令历史记录
 - 命令别名
 - 命令补全
@@ -34,7 +41,14 @@ var rootCmd = &cobra.Command{
 			fmt.Printf("初始化配置失败: %v\n", err)
 			os.Exit(1)
 		}
+		if profile, _ := cmd.Flags().GetString("profile"); profile != "" {
+			config.GetInstance().SetProfile(profile)
+		}
 		logger.InitLogger()
+		applyCommandConfig()
+		applyHistoryConfig()
+		applyRedactConfig()
+		alias.SetProfile(config.GetInstance().GetProfiles()[0])
 		if err := alias.InitAliases(); err != nil {
 			fmt.Printf("初始化别名失败: %v\n", err)
 			os.Exit(1)
@@ -42,6 +56,79 @@ var rootCmd = &cobra.Command{
 	},
 }
 
+// applyCommandConfig 应用配置文件中的命令执行设置
+func applyCommandConfig() {
+	value, err := config.GetInstance().Get("commands.timeout")
+	if err != nil {
+		return
+	}
+	timeout, err := commands.ParseTimeout(value)
+	if err != nil {
+		logger.Warn("配置项 commands.timeout 无效", zap.Error(err))
+		return
+	}
+	commands.SetDefaultTimeout(timeout)
+}
+
+// applyHistoryConfig 应用配置文件中的历史记录保留策略
+func applyHistoryConfig() {
+	r := history.DefaultRetention
+	if value, err := config.GetInstance().Get("history.max_entries"); err == nil {
+		n, err := strconv.Atoi(strings.TrimSpace(fmt.Sprint(value)))
+		if err != nil || n < 0 {
+			logger.Warn("配置项 history.max_entries 无效", zap.Any("value", value))
+		} else {
+			r.MaxEntries = n
+		}
+	}
+	if value, err := config.GetInstance().Get("history.max_age"); err == nil {
+		age, err := history.ParseAge(fmt.Sprint(value))
+		if err != nil {
+			logger.Warn("配置项 history.max_age 无效", zap.Error(err))
+		} else {
+			r.MaxAge = age
+		}
+	}
+	history.SetRetention(r)
+}
+
+// applyRedactConfig 应用配置文件中的脱敏设置：redact.enabled 关闭脱敏，
+// redact.patterns 添加自定义的正则表达式
+func applyRedactConfig() {
+	if value, err := config.GetInstance().Get("redact.enabled"); err == nil {
+		enabled, err := strconv.ParseBool(strings.TrimSpace(fmt.Sprint(value)))
+		if err != nil {
+			logger.Warn("配置项 redact.enabled 无效", zap.Any("value", value))
+		} else {
+			redact.Default().SetEnabled(enabled)
+		}
+	}
+
+	value, err := config.GetInstance().Get("redact.patterns")
+	if err != nil {
+		return
+	}
+	var patterns []string
+	switch v := value.(type) {
+	case []interface{}:
+		for _, p := range v {
+			patterns = append(patterns, fmt.Sprint(p))
+		}
+	case []string:
+		patterns = v
+	case string:
+		if v != "" {
+			patterns = []string{v}
+		}
+	}
+	rules, err := redact.Compile(patterns)
+	if err != nil {
+		logger.Warn("配置项 redact.patterns 无效", zap.Error(err))
+		return
+	}
+	redact.Default().SetRules(rules)
+}
+
 func Execute() error {
 	return rootCmd.Execute()
 }
@@ -49,6 +136,7 @@ func Execute() error {
 func init() {
 	// 在这里添加全局标志
 	rootCmd.PersistentFlags().StringP("config", "c", "", "配置文件路径")
+	rootCmd.PersistentFlags().String("profile", os.Getenv("CLIXGO_PROFILE"), "配置环境，默认使用环境变量 CLIXGO_PROFILE")
 
 	// 添加子命令
 	rootCmd.AddCommand(NewSequentialCmd())
@@ -57,6 +145,9 @@ func init() {
 	rootCmd.AddCommand(NewGrepCmd())
 	rootCmd.AddCommand(NewSedCmd())
 	rootCmd.AddCommand(NewPipeCmd())
+	rootCmd.AddCommand(NewRunCmd())
+	rootCmd.AddCommand(NewWatchCmd())
+	rootCmd.AddCommand(NewScheduleCmd())
 	rootCmd.AddCommand(NewHistoryCmd())
 	rootCmd.AddCommand(NewAliasCmd())
 	rootCmd.AddCommand(NewNetworkCmd())
@@ -64,16 +155,9 @@ func init() {
 	rootCmd.AddCommand(NewFilesystemCmd())
 	rootCmd.AddCommand(NewSecurityCmd())
 	rootCmd.AddCommand(NewTerminalCmd())
+	rootCmd.AddCommand(NewCompletionCmd())
+	rootCmd.AddCommand(NewConfigCmd())
 
 	// 添加任务管理命令
 	rootCmd.AddCommand(task.Command())
-
-	// 添加补全命令
-	rootCmd.AddCommand(&cobra.Command{
-		Use:   "completion",
-		Short: "生成命令补全脚本",
-		RunE: func(cmd *cobra.Command, args []string) error {
-			return completion.GenerateCompletionScript(rootCmd)
-		},
-	})
 }
@@ -0,0 +1,141 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Lzww0608/ClixGo/pkg/logger"
+	"github.com/Lzww0608/ClixGo/pkg/workflow"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+func NewRunCmd() *cobra.Command {
+	var from string
+	var only []string
+	var maxParallel int
+	var noColor bool
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "run <workflow.yaml>",
+		Short: "按依赖关系执行工作流",
+		Long: `执行 YAML 工作流文件。步骤通过 needs 声明依赖，依赖全部成功后立即启动，
+无依赖关系的步骤并行执行。步骤失败时跳过其下游步骤，其他分支继续执行。
+
+工作流文件示例:
+  name: release
+  max_parallel: 4
+  env:
+    GOFLAGS: -mod=mod
+  steps:
+    - name: lint
+      run: go vet ./...
+    - name: test
+      run: go test ./...
+      retries: 2
+      retry_delay: 5s
+    - name: build
+      needs: [lint, test]
+      commands: ["go build -o bin/app .", "ls -l bin/app"]
+      timeout: 10m
+    - name: publish
+      needs: [build]
+      if: test -n "$PUBLISH"
+      run: ./scripts/publish.sh
+
+使用 --dry-run 按执行顺序输出每个步骤展开别名后的命令、解析到的程序、环境变量、
+工作目录、超时时间和安全策略的检查结果，不执行任何命令（包括 if 条件）。`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			wf, err := workflow.Load(args[0])
+			if err != nil {
+				return err
+			}
+			opts := workflow.Options{
+				From:        from,
+				Only:        only,
+				MaxParallel: maxParallel,
+				Output:      cmd.OutOrStdout(),
+				Color:       !noColor && !color.NoColor,
+			}
+			if dryRun {
+				levels, err := workflow.Explain(wf, opts)
+				if err != nil {
+					return err
+				}
+				if maxParallel == 0 {
+					maxParallel = wf.MaxParallel
+				}
+				printWorkflowPlan(cmd.OutOrStdout(), wf.Name, maxParallel, levels)
+				return nil
+			}
+			logger.Info("开始执行工作流", zap.String("file", args[0]), zap.String("name", wf.Name))
+
+			report, err := workflow.Run(cmd.Context(), wf, opts)
+			if report != nil {
+				printWorkflowReport(cmd.OutOrStdout(), report)
+			}
+			return err
+		},
+	}
+
+	cmd.Flags().StringVar(&from, "from", "", "从指定步骤开始执行（包含其所有下游步骤）")
+	cmd.Flags().StringSliceVar(&only, "only", nil, "只执行指定的步骤，多个步骤用逗号分隔")
+	cmd.Flags().IntVarP(&maxParallel, "max-parallel", "j", 0, "最大并发步骤数，覆盖工作流中的设置")
+	cmd.Flags().BoolVar(&noColor, "no-color", false, "输出前缀不使用颜色")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "只输出执行计划，不执行任何步骤")
+	return cmd
+}
+
+// printWorkflowPlan 按层输出工作流的执行计划，同一层的步骤在依赖完成后并行执行
+func printWorkflowPlan(out io.Writer, name string, maxParallel int, levels [][]*workflow.StepPlan) {
+	parallel := "不限制"
+	if maxParallel > 0 {
+		parallel = fmt.Sprintf("%d", maxParallel)
+	}
+	fmt.Fprintf(out, "工作流 %s 的执行计划（最大并发步骤数: %s）:\n", name, parallel)
+	for i, level := range levels {
+		fmt.Fprintf(out, "\n第 %d 层:\n", i+1)
+		for _, step := range level {
+			fmt.Fprintf(out, "  步骤 %s\n", step.Name)
+			if len(step.Needs) > 0 {
+				fmt.Fprintf(out, "    依赖: %s\n", strings.Join(step.Needs, ", "))
+			}
+			if step.If != "" {
+				fmt.Fprintf(out, "    条件: %s\n", step.If)
+			}
+			if step.Retries > 0 {
+				fmt.Fprintf(out, "    失败重试: %d 次\n", step.Retries)
+			}
+			for j, plan := range step.Commands {
+				fmt.Fprintf(out, "    [%d] %s\n", j+1, plan.Original)
+				plan.Describe(out, "        ")
+			}
+		}
+	}
+}
+
+// printWorkflowReport 输出工作流各步骤的执行汇总
+func printWorkflowReport(out io.Writer, report *workflow.Report) {
+	fmt.Fprintln(out)
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "步骤\t状态\t尝试次数\t退出码\t耗时\t说明")
+	fmt.Fprintln(w, "----\t----\t--------\t------\t----\t----")
+	for _, step := range report.Steps {
+		attempts, exitCode, duration := "-", "-", "-"
+		if step.Attempts > 0 {
+			attempts = fmt.Sprintf("%d", step.Attempts)
+			exitCode = fmt.Sprintf("%d", step.ExitCode)
+			duration = step.Duration.Round(time.Millisecond).String()
+		}
+		reason := strings.ReplaceAll(step.Reason, "\n", " ")
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", step.Name, step.Status, attempts, exitCode, duration, reason)
+	}
+	w.Flush()
+	fmt.Fprintf(out, "总耗时: %s\n", report.Duration.Round(time.Millisecond))
+}
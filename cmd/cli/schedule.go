@@ -0,0 +1,210 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Lzww0608/ClixGo/cmd/task"
+	"github.com/Lzww0608/ClixGo/pkg/logger"
+	"github.com/Lzww0608/ClixGo/pkg/schedule"
+	"github.com/Lzww0608/ClixGo/pkg/shell"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+func NewScheduleCmd() *cobra.Command {
+	var storePath string
+
+	cmd := &cobra.Command{
+		Use:   "schedule",
+		Short: "定时执行命令",
+		Long: `按 cron 表达式定时执行命令。任务保存在 ~/.clixgo/schedules.json，
+由前台运行的 schedule run 负责执行，每次执行记录为一个后台任务并写入命令历史。
+
+表达式支持标准的五字段格式（分 时 日 月 星期）、@hourly/@daily/@weekly/@monthly/@yearly
+以及 "@every 30s" 形式的固定间隔。`,
+	}
+	cmd.PersistentFlags().StringVar(&storePath, "store", "", "定时任务文件路径（默认 ~/.clixgo/schedules.json）")
+
+	store := func() *schedule.Store {
+		if storePath == "" {
+			return schedule.NewStore(schedule.DefaultStorePath())
+		}
+		return schedule.NewStore(storePath)
+	}
+
+	cmd.AddCommand(
+		newScheduleAddCmd(store),
+		newScheduleListCmd(store),
+		newScheduleRemoveCmd(store),
+		newSchedulePauseCmd(store, true),
+		newSchedulePauseCmd(store, false),
+		newScheduleRunCmd(store),
+	)
+	return cmd
+}
+
+func newScheduleAddCmd(store func() *schedule.Store) *cobra.Command {
+	var job schedule.Job
+	var missed string
+
+	cmd := &cobra.Command{
+		Use:   "add <expr> -- <command> [args...]",
+		Short: "添加定时任务",
+		Long: `添加定时任务，命令只有一个参数时按shell语法解析，例如:
+  ClixGo schedule add "*/5 * * * *" -- ./scripts/sync.sh
+  ClixGo schedule add "@every 90s" --name ping -- "curl -fsS https://example.com/health"
+  ClixGo schedule add "0 3 * * *" --missed once -- "make backup"
+
+--missed 指定调度器未运行期间错过执行时的处理：skip 跳过（默认），once 启动后补执行一次。
+默认上一次执行尚未结束时跳过本次执行，--allow-overlap 允许同时执行。`,
+		Args: cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			job.Expr = args[0]
+			job.Command = args[1]
+			if len(args) > 2 {
+				job.Command = shell.Join(args[1:])
+			}
+			job.Missed = schedule.MissedPolicy(missed)
+			if err := store().Add(&job); err != nil {
+				return err
+			}
+
+			next, _ := job.NextRun(time.Now())
+			logger.Info("添加定时任务", zap.String("id", job.ID), zap.String("expr", job.Expr), zap.String("command", job.Command))
+			fmt.Printf("定时任务已添加，ID: %s\n", job.ID)
+			fmt.Printf("下次执行: %s\n", formatScheduleTime(next))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&job.Name, "name", "", "任务名称（默认使用命令）")
+	cmd.Flags().StringVar(&missed, "missed", string(schedule.MissedSkip), "错过执行时的处理方式: skip 或 once")
+	cmd.Flags().BoolVar(&job.AllowOverlap, "allow-overlap", false, "允许上一次执行尚未结束时再次执行")
+	cmd.Flags().DurationVar(&job.Timeout, "timeout", 0, "单次执行的超时时间，默认使用配置项 commands.timeout")
+	cmd.Flags().BoolVar(&job.Shell, "shell", false, "使用进程内解释器执行命令")
+	return cmd
+}
+
+func newScheduleListCmd(store func() *schedule.Store) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "列出定时任务",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			jobs, err := store().List()
+			if err != nil {
+				return err
+			}
+			if len(jobs) == 0 {
+				fmt.Println("没有定时任务")
+				return nil
+			}
+
+			now := time.Now()
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\t名称\t表达式\t下次执行\t上次执行\t状态\t次数/失败")
+			for _, job := range jobs {
+				next := "已暂停"
+				if !job.Paused {
+					t, _ := job.NextRun(now)
+					next = formatScheduleTime(t)
+				}
+				status := job.LastStatus
+				if status == "" {
+					status = "-"
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%d/%d\n",
+					job.ID, job.Name, job.Expr, next, formatScheduleTime(job.LastRun), status, job.Runs, job.Failures)
+			}
+			return w.Flush()
+		},
+	}
+}
+
+func newScheduleRemoveCmd(store func() *schedule.Store) *cobra.Command {
+	return &cobra.Command{
+		Use:   "remove <id|name>",
+		Short: "删除定时任务",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			job, err := store().Remove(args[0])
+			if err != nil {
+				return err
+			}
+			fmt.Printf("定时任务 %s (%s) 已删除\n", job.ID, job.Name)
+			return nil
+		},
+	}
+}
+
+func newSchedulePauseCmd(store func() *schedule.Store, pause bool) *cobra.Command {
+	use, short, done := "resume", "恢复定时任务", "已恢复"
+	if pause {
+		use, short, done = "pause", "暂停定时任务", "已暂停"
+	}
+	return &cobra.Command{
+		Use:   use + " <id|name>",
+		Short: short,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			job, err := store().Update(args[0], func(j *schedule.Job) {
+				j.Paused = pause
+				// 恢复后从当前时间开始计划，暂停期间的执行不视为错过
+				if !pause {
+					j.LastScheduled = time.Now()
+				}
+			})
+			if err != nil {
+				return err
+			}
+			fmt.Printf("定时任务 %s (%s) %s\n", job.ID, job.Name, done)
+			return nil
+		},
+	}
+}
+
+func newScheduleRunCmd(store func() *schedule.Store) *cobra.Command {
+	var quiet bool
+
+	cmd := &cobra.Command{
+		Use:   "run",
+		Short: "在前台运行调度器",
+		Long: `在前台运行调度器，按计划执行定时任务，按 Ctrl+C 停止。
+运行期间通过 schedule add/remove/pause 修改的任务会立即生效。
+每次执行记录为一个后台任务（可用 task list 查看），并写入命令历史。`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts := schedule.Options{
+				Tasks:  task.Manager(),
+				Output: cmd.OutOrStdout(),
+			}
+			if !quiet {
+				opts.Stdout = cmd.OutOrStdout()
+				opts.Stderr = cmd.ErrOrStderr()
+			}
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			s := store()
+			fmt.Fprintf(cmd.OutOrStdout(), "调度器已启动，任务文件: %s\n", s.Path())
+			return schedule.NewScheduler(s, opts).Run(ctx)
+		},
+	}
+
+	cmd.Flags().BoolVarP(&quiet, "quiet", "q", false, "不输出命令的标准输出和标准错误")
+	return cmd
+}
+
+// formatScheduleTime 格式化计划时间，零值显示为 -
+func formatScheduleTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04:05")
+}
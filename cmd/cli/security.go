@@ -8,6 +8,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/Lzww0608/ClixGo/pkg/commands"
 	"github.com/Lzww0608/ClixGo/pkg/security"
 	"github.com/spf13/cobra"
 )
@@ -26,6 +27,63 @@ func init() {
 		fmt.Printf("初始化命令管理器失败: %v\n", err)
 		os.Exit(1)
 	}
+	commands.SetPolicyFunc(policyLimits)
+	commands.SetPermissionFunc(policyPermission)
+}
+
+// policyLimits 将命令策略中的最大执行时间和资源限制转换为执行时强制应用的限制。
+// 命令匹配多个策略（如 a && b 中的两个程序）时，每一项取最严格的值
+func policyLimits(command string) (time.Duration, commands.Limits, bool) {
+	policies := commandManager.Policies(command)
+	if len(policies) == 0 {
+		return 0, commands.Limits{}, false
+	}
+
+	var timeout time.Duration
+	var limits commands.Limits
+	for _, policy := range policies {
+		d := time.Duration(policy.MaxDuration) * time.Second
+		if d > 0 && (timeout == 0 || d < timeout) {
+			timeout = d
+		}
+		limits = limits.Merge(commands.Limits{
+			CPUTime: time.Duration(policy.MaxCPUTime) * time.Second,
+			Memory:  uint64(policy.MaxMemory) << 20,
+			NoFile:  uint64(policy.MaxOpenFiles),
+			NProc:   uint64(policy.MaxProcesses),
+		})
+	}
+	return timeout, limits, true
+}
+
+// policyPermission 查找命令匹配的所有策略并检查当前用户是否有权执行，
+// 执行命令前和 --dry-run 输出的执行计划都使用它。任一策略禁止执行时命令被禁止
+func policyPermission(command string) commands.PolicyDecision {
+	policies := commandManager.Policies(command)
+	if len(policies) == 0 {
+		return commands.PolicyDecision{Allowed: true}
+	}
+
+	names := make([]string, len(policies))
+	for i, policy := range policies {
+		names[i] = policy.Command
+	}
+	decision := commands.PolicyDecision{Policy: strings.Join(names, ", ")}
+	currentUser, err := user.Current()
+	if err != nil {
+		decision.Reason = fmt.Sprintf("获取当前用户失败: %v", err)
+		return decision
+	}
+	// 获取用户组（简化实现）
+	groups := []string{currentUser.Username}
+	for _, policy := range policies {
+		allowed, reason := commandManager.CheckPermission(policy.Command, currentUser.Username, groups)
+		if !allowed {
+			return commands.PolicyDecision{Policy: policy.Command, Reason: reason}
+		}
+	}
+	decision.Allowed = true
+	return decision
 }
 
 func NewSecurityCmd() *cobra.Command {
@@ -97,15 +155,23 @@ func NewSecurityCmd() *cobra.Command {
 			timeRange, _ := cmd.Flags().GetStringSlice("time-range")
 			maxDuration, _ := cmd.Flags().GetInt("max-duration")
 			maxCalls, _ := cmd.Flags().GetInt("max-calls")
+			maxCPU, _ := cmd.Flags().GetInt("max-cpu")
+			maxMemory, _ := cmd.Flags().GetInt("max-memory")
+			maxOpenFiles, _ := cmd.Flags().GetInt("max-open-files")
+			maxProcesses, _ := cmd.Flags().GetInt("max-procs")
 
 			policy := security.CommandPolicy{
-				Command:     command,
-				Allowed:     allowed,
-				Users:       users,
-				Groups:      groups,
-				TimeRange:   timeRange,
-				MaxDuration: maxDuration,
-				MaxCalls:    maxCalls,
+				Command:      command,
+				Allowed:      allowed,
+				Users:        users,
+				Groups:       groups,
+				TimeRange:    timeRange,
+				MaxDuration:  maxDuration,
+				MaxCalls:     maxCalls,
+				MaxCPUTime:   maxCPU,
+				MaxMemory:    maxMemory,
+				MaxOpenFiles: maxOpenFiles,
+				MaxProcesses: maxProcesses,
 			}
 
 			return commandManager.AddPolicy(policy)
@@ -117,6 +183,10 @@ func NewSecurityCmd() *cobra.Command {
 	cmd.Commands()[1].Flags().StringSliceP("time-range", "t", nil, "允许执行的时间范围")
 	cmd.Commands()[1].Flags().Int("max-duration", 0, "最大执行时间（秒）")
 	cmd.Commands()[1].Flags().IntP("max-calls", "c", 0, "每小时最大调用次数")
+	cmd.Commands()[1].Flags().Int("max-cpu", 0, "最大CPU时间（秒）")
+	cmd.Commands()[1].Flags().Int("max-memory", 0, "最大虚拟内存（MB）")
+	cmd.Commands()[1].Flags().Int("max-open-files", 0, "最大打开文件数")
+	cmd.Commands()[1].Flags().Int("max-procs", 0, "最大进程数")
 
 	// 移除策略命令
 	cmd.AddCommand(&cobra.Command{
@@ -4,10 +4,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"net"
-	"os"
 	"strings"
 	"time"
 
+	"github.com/Lzww0608/ClixGo/cmd/task"
+	"github.com/Lzww0608/ClixGo/pkg/shell"
 	"github.com/Lzww0608/ClixGo/pkg/terminal"
 	"github.com/spf13/cobra"
 )
@@ -33,10 +34,15 @@ func NewTerminalCmd() *cobra.Command {
   clixgo terminal new-session [session-name]  # 创建新会话
   clixgo terminal attach [session-name]       # 连接会话
   clixgo terminal list-sessions               # 列出所有会话
-  clixgo terminal kill-session [session-name] # 销毁会话`,
+  clixgo terminal kill-session [session-name] # 销毁会话
+  clixgo terminal -L work new-session         # 在名为 work 的独立服务器上创建会话
+  clixgo terminal server list                 # 列出所有命名服务器`,
 		Aliases: []string{"term", "tmux"},
 	}
 
+	cmd.PersistentFlags().StringP("socket-name", "L", "", "服务器名称，socket位于 ~/.clixgo/terminal/<name>.sock（默认 default）")
+	cmd.PersistentFlags().StringP("socket-path", "S", "", "服务器socket的完整路径，优先于 --socket-name")
+
 	// 创建新会话
 	cmd.AddCommand(&cobra.Command{
 		Use:     "new-session [session-name]",
@@ -49,7 +55,12 @@ func NewTerminalCmd() *cobra.Command {
 				sessionName = args[0]
 			}
 
-			client, err := connectToServer()
+			socketPath, err := socketPathFromFlags(cmd)
+			if err != nil {
+				return err
+			}
+
+			client, err := connectToServer(socketPath)
 			if err != nil {
 				return fmt.Errorf("连接服务器失败: %v", err)
 			}
@@ -73,18 +84,24 @@ func NewTerminalCmd() *cobra.Command {
 			fmt.Printf("会话创建成功: %s\n", sessionID)
 
 			// 自动连接到新创建的会话
-			return attachToSession(client, sessionID)
+			return attachToSession(client, socketPath, sessionID)
 		},
 	})
 
 	// 连接会话
 	cmd.AddCommand(&cobra.Command{
-		Use:     "attach [session-name]",
-		Short:   "连接到现有会话",
-		Aliases: []string{"a", "at"},
-		Args:    cobra.MaximumNArgs(1),
+		Use:               "attach [session-name]",
+		Short:             "连接到现有会话",
+		Aliases:           []string{"a", "at"},
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: completeSessions,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			client, err := connectToServer()
+			socketPath, err := socketPathFromFlags(cmd)
+			if err != nil {
+				return err
+			}
+
+			client, err := connectToServer(socketPath)
 			if err != nil {
 				return fmt.Errorf("连接服务器失败: %v", err)
 			}
@@ -105,7 +122,7 @@ func NewTerminalCmd() *cobra.Command {
 				sessionIdentifier = sessions[0]["id"].(string)
 			}
 
-			return attachToSession(client, sessionIdentifier)
+			return attachToSession(client, socketPath, sessionIdentifier)
 		},
 	})
 
@@ -115,7 +132,12 @@ func NewTerminalCmd() *cobra.Command {
 		Short:   "列出所有会话",
 		Aliases: []string{"ls", "list"},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			client, err := connectToServer()
+			socketPath, err := socketPathFromFlags(cmd)
+			if err != nil {
+				return err
+			}
+
+			client, err := connectToServer(socketPath)
 			if err != nil {
 				return fmt.Errorf("连接服务器失败: %v", err)
 			}
@@ -151,12 +173,18 @@ func NewTerminalCmd() *cobra.Command {
 
 	// 销毁会话
 	cmd.AddCommand(&cobra.Command{
-		Use:     "kill-session [session-name]",
-		Short:   "销毁指定会话",
-		Aliases: []string{"kill", "ks"},
-		Args:    cobra.ExactArgs(1),
+		Use:               "kill-session [session-name]",
+		Short:             "销毁指定会话",
+		Aliases:           []string{"kill", "ks"},
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeSessions,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			client, err := connectToServer()
+			socketPath, err := socketPathFromFlags(cmd)
+			if err != nil {
+				return err
+			}
+
+			client, err := connectToServer(socketPath)
 			if err != nil {
 				return fmt.Errorf("连接服务器失败: %v", err)
 			}
@@ -165,7 +193,7 @@ func NewTerminalCmd() *cobra.Command {
 			response, err := sendCommand(client, terminal.Command{
 				Type: terminal.CmdKillSession,
 				Payload: map[string]interface{}{
-					"session_id": args[0],
+					"session_id": resolveSessionID(client, args[0]),
 				},
 			})
 			if err != nil {
@@ -197,8 +225,14 @@ func NewTerminalCmd() *cobra.Command {
 		Use:   "start",
 		Short: "启动终端服务器",
 		RunE: func(cmd *cobra.Command, args []string) error {
+			socketPath, err := socketPathFromFlags(cmd)
+			if err != nil {
+				return err
+			}
+
 			config := terminal.DefaultConfig
-			server := terminal.NewTerminalServer(config)
+			server := terminal.NewTerminalServerWithSocket(config, socketPath)
+			server.SetTaskManager(task.Manager())
 
 			if err := server.Start(); err != nil {
 				return fmt.Errorf("启动服务器失败: %v", err)
@@ -216,7 +250,12 @@ func NewTerminalCmd() *cobra.Command {
 		Use:   "status",
 		Short: "查看服务器状态",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			client, err := connectToServer()
+			socketPath, err := socketPathFromFlags(cmd)
+			if err != nil {
+				return err
+			}
+
+			client, err := connectToServer(socketPath)
 			if err != nil {
 				fmt.Println("服务器未运行")
 				return nil
@@ -234,13 +273,55 @@ func NewTerminalCmd() *cobra.Command {
 		},
 	})
 
+	serverCmd.AddCommand(&cobra.Command{
+		Use:     "list",
+		Short:   "列出所有命名服务器",
+		Aliases: []string{"ls"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			servers, err := terminal.ListServers()
+			if err != nil {
+				return err
+			}
+
+			if len(servers) == 0 {
+				fmt.Println("没有找到服务器")
+				return nil
+			}
+
+			fmt.Printf("%-15s %-10s %-8s %s\n", "名称", "状态", "会话数", "Socket路径")
+			fmt.Println(strings.Repeat("-", 80))
+
+			for _, server := range servers {
+				status := "已停止"
+				sessionCount := "-"
+				if server.Running {
+					status = "运行中"
+					if conn, err := net.Dial("unix", server.SocketPath); err == nil {
+						if sessions, err := listSessions(conn); err == nil {
+							sessionCount = fmt.Sprintf("%d", len(sessions))
+						}
+						conn.Close()
+					}
+				}
+				fmt.Printf("%-15s %-10s %-8s %s\n", server.Name, status, sessionCount, server.SocketPath)
+			}
+
+			return nil
+		},
+	})
+
 	// 快捷命令
 	cmd.AddCommand(&cobra.Command{
 		Use:     "split-window",
 		Short:   "分割当前窗口",
 		Aliases: []string{"split"},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			client, err := connectToServer()
+			socketPath, err := socketPathFromFlags(cmd)
+			if err != nil {
+				return err
+			}
+
+			client, err := connectToServer(socketPath)
 			if err != nil {
 				return fmt.Errorf("连接服务器失败: %v", err)
 			}
@@ -273,21 +354,111 @@ func NewTerminalCmd() *cobra.Command {
 	})
 	cmd.Commands()[len(cmd.Commands())-1].Flags().BoolP("vertical", "v", false, "垂直分割")
 
+	cmd.AddCommand(newRunTaskCmd())
+
 	return cmd
 }
 
-// connectToServer 连接到终端服务器
-func connectToServer() (net.Conn, error) {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		homeDir = "/tmp"
+// newRunTaskCmd 在专用窗口或面板中运行命令并作为任务跟踪
+func newRunTaskCmd() *cobra.Command {
+	runTaskCmd := &cobra.Command{
+		Use:   "run-task <name> -- <command>",
+		Short: "在新窗口或面板中运行命令并创建任务",
+		Long: `在终端会话的新窗口（或使用 --split 在当前窗口分割出的面板）中运行命令，
+同时在任务管理器中创建任务。任务的状态、退出码和耗时与面板进程保持同步，
+可通过 'task list' 查看任务所在的会话/窗口/面板，通过 'task attach <task-id>' 直接跳转。`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			dash := cmd.ArgsLenAtDash()
+			if dash != 1 || len(args) < 2 {
+				return fmt.Errorf("用法: run-task <name> -- <command>")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+			// 单个参数视为完整的命令字符串，多个参数按需加引号拼接，保留 sh -c '...' 等参数边界
+			command := args[1]
+			if len(args) > 2 {
+				command = shell.Join(args[1:])
+			}
+			sessionName, _ := cmd.Flags().GetString("session")
+			split, _ := cmd.Flags().GetBool("split")
+			attach, _ := cmd.Flags().GetBool("attach")
+
+			socketPath, err := socketPathFromFlags(cmd)
+			if err != nil {
+				return err
+			}
+
+			client, err := connectToServer(socketPath)
+			if err != nil {
+				return fmt.Errorf("连接服务器失败: %v", err)
+			}
+			defer client.Close()
+
+			response, err := sendCommand(client, terminal.Command{
+				Type: terminal.CmdRunTask,
+				Payload: map[string]interface{}{
+					"name":    name,
+					"command": command,
+					"session": sessionName,
+					"split":   split,
+				},
+			})
+			if err != nil {
+				return err
+			}
+
+			if errMsg, ok := response["error"].(string); ok {
+				return fmt.Errorf(errMsg)
+			}
+
+			taskID, _ := response["task_id"].(string)
+			sessionID, _ := response["session_id"].(string)
+			paneID, _ := response["pane_id"].(string)
+			fmt.Printf("任务已创建，ID: %s\n", taskID)
+			fmt.Printf("运行位置: 会话 %v / 窗口 %v / 面板 %v\n",
+				response["session_name"], response["window_index"], response["pane_index"])
+
+			if !attach {
+				return nil
+			}
+
+			tc := terminal.NewTerminalClientWithSocket(terminal.DefaultConfig, socketPath)
+			if err := tc.Connect(); err != nil {
+				return err
+			}
+			defer tc.Disconnect()
+
+			if err := tc.AttachSession(sessionID); err != nil {
+				return err
+			}
+			if err := tc.SelectPane(paneID); err != nil {
+				return err
+			}
+			return tc.StartInteractiveMode()
+		},
 	}
-	socketPath := fmt.Sprintf("%s/.clixgo/terminal/clixgo-terminal.sock", homeDir)
+	runTaskCmd.Flags().StringP("session", "t", "", "运行任务的目标会话（默认使用最近活动的会话）")
+	runTaskCmd.Flags().BoolP("split", "s", false, "在当前窗口分割出新面板，而不是创建新窗口")
+	runTaskCmd.Flags().BoolP("attach", "a", false, "创建后立即连接到任务所在面板")
+
+	return runTaskCmd
+}
+
+// socketPathFromFlags 根据 -L/--socket-name 或 -S/--socket-path 选项确定服务器socket路径
+func socketPathFromFlags(cmd *cobra.Command) (string, error) {
+	name, _ := cmd.Flags().GetString("socket-name")
+	path, _ := cmd.Flags().GetString("socket-path")
+	return terminal.ResolveSocketPath(name, path)
+}
 
+// connectToServer 连接到终端服务器
+func connectToServer(socketPath string) (net.Conn, error) {
 	conn, err := net.Dial("unix", socketPath)
 	if err != nil {
 		// 尝试启动服务器
-		if err := startServer(); err != nil {
+		if err := startServer(socketPath); err != nil {
 			return nil, fmt.Errorf("无法启动服务器: %v", err)
 		}
 
@@ -350,8 +521,73 @@ func listSessions(conn net.Conn) ([]map[string]interface{}, error) {
 	return result, nil
 }
 
+// resolveSessionID 将会话名称解析为会话ID，参数已经是ID或找不到同名会话时原样返回
+func resolveSessionID(conn net.Conn, identifier string) string {
+	sessions, err := listSessions(conn)
+	if err != nil {
+		return identifier
+	}
+	for _, session := range sessions {
+		if id, _ := session["id"].(string); id == identifier {
+			return id
+		}
+	}
+	for _, session := range sessions {
+		if name, _ := session["name"].(string); name == identifier {
+			id, _ := session["id"].(string)
+			return id
+		}
+	}
+	return identifier
+}
+
+// completeSessions 用服务器上的会话补全会话参数：名称唯一的会话补全名称，其余补全ID。
+// 服务器没有运行时不补全，也不会启动服务器
+func completeSessions(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	socketPath, err := socketPathFromFlags(cmd)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	conn, err := net.DialTimeout("unix", socketPath, 500*time.Millisecond)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(time.Second))
+
+	sessions, err := listSessions(conn)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	names := make(map[string]int)
+	for _, session := range sessions {
+		if name, _ := session["name"].(string); name != "" {
+			names[name]++
+		}
+	}
+
+	var completions []string
+	for _, session := range sessions {
+		id, _ := session["id"].(string)
+		name, _ := session["name"].(string)
+		status, _ := session["status"].(string)
+		windows, _ := session["windows"].([]interface{})
+		value := name
+		if value == "" || names[name] > 1 {
+			value = id
+		}
+		if strings.HasPrefix(value, toComplete) {
+			completions = append(completions, fmt.Sprintf("%s\t%s, %d 个窗口", value, status, len(windows)))
+		}
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
 // attachToSession 连接到会话
-func attachToSession(conn net.Conn, sessionIdentifier string) error {
+func attachToSession(conn net.Conn, socketPath, sessionIdentifier string) error {
 	// 首先尝试按ID连接
 	response, err := sendCommand(conn, terminal.Command{
 		Type: terminal.CmdAttachSession,
@@ -389,26 +625,28 @@ func attachToSession(conn net.Conn, sessionIdentifier string) error {
 	windows, _ := session["windows"].([]interface{})
 	fmt.Printf("会话包含 %d 个窗口\n", len(windows))
 
-	// 这里应该启动交互式终端界面
-	// 目前简化实现，只显示连接成功信息
-	fmt.Println("\n按快捷键操作:")
-	fmt.Println("  Ctrl+B, D    - 断开会话")
-	fmt.Println("  Ctrl+B, C    - 创建新窗口")
-	fmt.Println("  Ctrl+B, \"    - 水平分割面板")
-	fmt.Println("  Ctrl+B, %    - 垂直分割面板")
-	fmt.Println("  Ctrl+B, O    - 切换面板")
-	fmt.Println("  Ctrl+B, X    - 关闭面板")
-	fmt.Println("\n使用 'clixgo terminal detach' 断开会话")
+	// 启动交互式终端界面
+	client := terminal.NewTerminalClientWithSocket(terminal.DefaultConfig, socketPath)
+	if err := client.Connect(); err != nil {
+		return err
+	}
+	defer client.Disconnect()
+
+	sessionID, _ := session["id"].(string)
+	if err := client.AttachSession(sessionID); err != nil {
+		return err
+	}
 
-	return nil
+	return client.StartInteractiveMode()
 }
 
 // startServer 启动服务器
-func startServer() error {
+func startServer(socketPath string) error {
 	fmt.Println("正在启动终端服务器...")
 
 	config := terminal.DefaultConfig
-	server := terminal.NewTerminalServer(config)
+	server := terminal.NewTerminalServerWithSocket(config, socketPath)
+	server.SetTaskManager(task.Manager())
 
 	if err := server.Start(); err != nil {
 		return err
@@ -0,0 +1,96 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/Lzww0608/ClixGo/pkg/commands"
+	"github.com/Lzww0608/ClixGo/pkg/watch"
+	"github.com/spf13/cobra"
+)
+
+func NewWatchCmd() *cobra.Command {
+	var paths []string
+	var patterns []string
+	var ignore []string
+	var debounce time.Duration
+	var clearScreen bool
+	var skipInitial bool
+	var useShell bool
+	var flags execFlags
+
+	cmd := &cobra.Command{
+		Use:   "watch [flags] -- <command> [args...]",
+		Short: "监视文件变更并重新执行命令",
+		Long: `监视文件变更（基于 inotify 等系统通知），变更后重新执行命令，按 Ctrl+C 退出并输出统计。
+短时间内的连续变更在 --debounce 时间内合并为一次执行；上一次执行尚未结束时先终止它再重新执行。
+命令默认不限制执行时间；Linux 上总是在独立的进程组中运行，终止时同时结束命令启动的所有子进程。
+
+--pattern 和 --ignore 使用 shell 通配符：不含 / 的模式匹配文件名，含 / 的模式匹配相对于监视路径的路径。
+默认忽略 .git、node_modules 等目录和编辑器的临时文件。
+
+命令只有一个参数时按shell语法解析，多个参数时直接执行，例如:
+  ClixGo watch --path ./src --pattern '*.go' -- go test ./...
+  ClixGo watch --clear --pattern '*.md' -- "make docs && echo done"`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			retry, err := flags.retryPolicy()
+			if err != nil {
+				return err
+			}
+			limits, sandbox, err := flags.limits()
+			if err != nil {
+				return err
+			}
+			spec := commands.Spec{
+				Shell:   useShell,
+				Timeout: flags.timeout,
+				Retry:   retry,
+				Limits:  limits,
+				Sandbox: sandbox,
+				Stdout:  cmd.OutOrStdout(),
+				Stderr:  cmd.ErrOrStderr(),
+				// 每次保存文件都会执行，不记录到命令历史
+				NoHistory: true,
+			}
+			if len(args) == 1 {
+				spec.Command = args[0]
+			} else {
+				spec.Args = args
+			}
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			summary, err := watch.Watch(ctx, watch.Options{
+				Paths:       paths,
+				Patterns:    patterns,
+				Ignore:      ignore,
+				Debounce:    debounce,
+				Spec:        spec,
+				ClearScreen: clearScreen,
+				SkipInitial: skipInitial,
+				Output:      cmd.OutOrStdout(),
+			})
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "\n停止监视，%s\n", summary)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringArrayVarP(&paths, "path", "p", nil, "监视的文件或目录，目录递归监视，可重复指定（默认当前目录）")
+	cmd.Flags().StringArrayVar(&patterns, "pattern", nil, "只有匹配该模式的文件变更才触发执行，可重复指定")
+	cmd.Flags().StringArrayVar(&ignore, "ignore", nil, "忽略匹配该模式的文件或目录，可重复指定")
+	cmd.Flags().DurationVar(&debounce, "debounce", watch.DefaultDebounce, "最后一次变更后等待多久再执行")
+	cmd.Flags().BoolVar(&clearScreen, "clear", false, "每次执行前清屏")
+	cmd.Flags().BoolVar(&skipInitial, "skip-initial", false, "启动时不立即执行，等待第一次变更")
+	cmd.Flags().BoolVar(&useShell, "shell", false, "使用进程内解释器执行命令")
+	flags.register(cmd)
+	cmd.Flags().Lookup("timeout").Usage = "单次执行的超时时间，如 90s、5m，默认不限制"
+	return cmd
+}
@@ -5,12 +5,15 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
 	"go.uber.org/zap"
 
 	"github.com/Lzww0608/ClixGo/pkg/task"
+	"github.com/Lzww0608/ClixGo/pkg/terminal"
 )
 
 var (
@@ -39,6 +42,11 @@ func init() {
 	}
 }
 
+// Manager 返回共享的任务管理器
+func Manager() *task.TaskManager {
+	return taskManager
+}
+
 // Command 返回任务管理命令
 func Command() *cobra.Command {
 	cmd := &cobra.Command{
@@ -53,6 +61,7 @@ func Command() *cobra.Command {
 		statusCommand(),
 		cancelCommand(),
 		watchCommand(),
+		attachCommand(),
 	)
 
 	return cmd
@@ -100,13 +109,20 @@ func listCommand() *cobra.Command {
 				if t.Error != "" {
 					fmt.Printf("  错误: %s\n", t.Error)
 				}
+				if t.ExitCode != nil {
+					fmt.Printf("  退出码: %d\n", *t.ExitCode)
+				}
 				fmt.Printf("  创建时间: %s\n", t.CreatedAt.Format(time.RFC3339))
 				if t.StartedAt != nil {
 					fmt.Printf("  开始时间: %s\n", t.StartedAt.Format(time.RFC3339))
+					fmt.Printf("  耗时: %s\n", t.Duration().Round(time.Millisecond))
 				}
 				if t.FinishedAt != nil {
 					fmt.Printf("  完成时间: %s\n", t.FinishedAt.Format(time.RFC3339))
 				}
+				if t.Terminal != nil {
+					fmt.Printf("  终端: %s\n", formatHost(t.Terminal))
+				}
 				fmt.Println()
 			}
 
@@ -120,9 +136,10 @@ func listCommand() *cobra.Command {
 // statusCommand 查看任务状态命令
 func statusCommand() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "status [task-id]",
-		Short: "查看任务状态",
-		Args:  cobra.ExactArgs(1),
+		Use:               "status [task-id]",
+		Short:             "查看任务状态",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeTaskIDs(false),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			task, err := taskManager.GetTask(args[0])
 			if err != nil {
@@ -138,13 +155,21 @@ func statusCommand() *cobra.Command {
 			if task.Error != "" {
 				fmt.Printf("错误: %s\n", task.Error)
 			}
+			if task.ExitCode != nil {
+				fmt.Printf("退出码: %d\n", *task.ExitCode)
+			}
 			fmt.Printf("创建时间: %s\n", task.CreatedAt.Format(time.RFC3339))
 			if task.StartedAt != nil {
 				fmt.Printf("开始时间: %s\n", task.StartedAt.Format(time.RFC3339))
+				fmt.Printf("耗时: %s\n", task.Duration().Round(time.Millisecond))
 			}
 			if task.FinishedAt != nil {
 				fmt.Printf("完成时间: %s\n", task.FinishedAt.Format(time.RFC3339))
 			}
+			if task.Terminal != nil {
+				fmt.Printf("终端: %s\n", formatHost(task.Terminal))
+				fmt.Printf("命令: %s\n", task.Terminal.Command)
+			}
 
 			return nil
 		},
@@ -156,10 +181,31 @@ func statusCommand() *cobra.Command {
 // cancelCommand 取消任务命令
 func cancelCommand() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "cancel [task-id]",
-		Short: "取消任务",
-		Args:  cobra.ExactArgs(1),
+		Use:               "cancel [task-id]",
+		Short:             "取消任务",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeTaskIDs(true),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			t, err := taskManager.GetTask(args[0])
+			if err != nil {
+				return err
+			}
+
+			// 在终端面板中运行的任务由终端服务器负责终止进程
+			if t.Terminal != nil && t.Status == task.TaskStatusRunning {
+				client := hostClient(t.Terminal)
+				if err := client.Connect(); err != nil {
+					return err
+				}
+				defer client.Disconnect()
+
+				if err := client.CancelTask(t.ID); err != nil {
+					return err
+				}
+				fmt.Println("任务已取消")
+				return nil
+			}
+
 			if err := taskManager.CancelTask(args[0]); err != nil {
 				return err
 			}
@@ -175,9 +221,10 @@ func cancelCommand() *cobra.Command {
 // watchCommand 监控任务命令
 func watchCommand() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "watch [task-id]",
-		Short: "监控任务进度",
-		Args:  cobra.ExactArgs(1),
+		Use:               "watch [task-id]",
+		Short:             "监控任务进度",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeTaskIDs(true),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			ctx := context.Background()
 			updates := taskManager.SubscribeTask(args[0])
@@ -204,3 +251,77 @@ func watchCommand() *cobra.Command {
 
 	return cmd
 }
+
+// attachCommand 连接到承载任务的终端面板
+func attachCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:               "attach [task-id]",
+		Short:             "连接到运行任务的终端面板",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeTaskIDs(true),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			t, err := taskManager.GetTask(args[0])
+			if err != nil {
+				return err
+			}
+			if t.Terminal == nil {
+				return fmt.Errorf("任务 %s 不在终端面板中运行", t.ID)
+			}
+
+			client := hostClient(t.Terminal)
+			if err := client.Connect(); err != nil {
+				return err
+			}
+			defer client.Disconnect()
+
+			if err := client.AttachSession(t.Terminal.SessionID); err != nil {
+				return err
+			}
+			if err := client.SelectPane(t.Terminal.PaneID); err != nil {
+				return err
+			}
+
+			return client.StartInteractiveMode()
+		},
+	}
+
+	return cmd
+}
+
+// completeTaskIDs 返回补全任务ID的函数，说明中显示任务名称和状态，最新创建的任务在前；
+// active 为真时只补全未结束的任务
+func completeTaskIDs(active bool) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		tasks := taskManager.ListTasks()
+		sort.Slice(tasks, func(i, j int) bool { return tasks[i].CreatedAt.After(tasks[j].CreatedAt) })
+
+		var completions []string
+		for _, t := range tasks {
+			if active && t.Status != task.TaskStatusPending && t.Status != task.TaskStatusRunning {
+				continue
+			}
+			if strings.HasPrefix(t.ID, toComplete) {
+				completions = append(completions, fmt.Sprintf("%s\t%s (%s)", t.ID, t.Name, t.Status))
+			}
+		}
+		return completions, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveKeepOrder
+	}
+}
+
+// hostClient 创建连接到承载任务的终端服务器的客户端
+func hostClient(host *task.TerminalHost) *terminal.TerminalClient {
+	if host.SocketPath != "" {
+		return terminal.NewTerminalClientWithSocket(terminal.DefaultConfig, host.SocketPath)
+	}
+	return terminal.NewTerminalClient(terminal.DefaultConfig)
+}
+
+// formatHost 格式化任务所在的终端位置
+func formatHost(host *task.TerminalHost) string {
+	return fmt.Sprintf("会话 %s / 窗口 %d:%s / 面板 %d",
+		host.SessionName, host.WindowIndex, host.WindowName, host.PaneIndex)
+}
@@ -4,9 +4,13 @@ import (
 	"os"
 
 	"github.com/Lzww0608/ClixGo/cmd/cli"
+	"github.com/Lzww0608/ClixGo/pkg/commands"
 )
 
 func main() {
+	// 作为隔离命令的引导进程启动时在这里执行目标命令并退出
+	commands.SandboxInit()
+
 	if err := cli.Execute(); err != nil {
 		os.Exit(1)
 	}
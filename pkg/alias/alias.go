@@ -1,7 +1,6 @@
 package alias
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -16,61 +15,49 @@ type Alias struct {
 var aliasFile = filepath.Join(os.Getenv("HOME"), ".clixgo", "aliases.json")
 var aliases = make(map[string]string)
 
+// SetAliasFilePath 设置别名文件的路径
+func SetAliasFilePath(path string) {
+	aliasFile = path
+}
+
+// GetAliasFilePath 返回别名文件的路径
+func GetAliasFilePath() string {
+	return aliasFile
+}
+
+// InitAliases 加载全局别名，以及当前配置环境和项目的别名
 func InitAliases() error {
 	if err := os.MkdirAll(filepath.Dir(aliasFile), 0755); err != nil {
 		return fmt.Errorf("创建别名配置目录失败: %v", err)
 	}
-
-	if _, err := os.Stat(aliasFile); os.IsNotExist(err) {
-		return nil
-	}
-
-	data, err := os.ReadFile(aliasFile)
-	if err != nil {
-		return fmt.Errorf("读取别名文件失败: %v", err)
+	if err := readJSONFile(aliasFile, aliases); err != nil {
+		return err
 	}
-
-	var aliasList []Alias
-	if err := json.Unmarshal(data, &aliasList); err != nil {
-		return fmt.Errorf("解析别名文件失败: %v", err)
-	}
-
-	for _, a := range aliasList {
-		aliases[a.Name] = a.Command
-	}
-
-	return nil
+	return loadScopes()
 }
 
+// SaveAliases 保存全局别名
 func SaveAliases() error {
-	var aliasList []Alias
-	for name, command := range aliases {
-		aliasList = append(aliasList, Alias{
-			Name:    name,
-			Command: command,
-		})
-	}
+	return writeJSONFile(aliasFile, aliases)
+}
 
-	data, err := json.MarshalIndent(aliasList, "", "  ")
-	if err != nil {
-		return fmt.Errorf("序列化别名失败: %v", err)
+// checkAlias 检查别名的名称和命令
+func checkAlias(name, command string) error {
+	if strings.Contains(name, " ") {
+		return fmt.Errorf("别名不能包含空格")
 	}
-
-	if err := os.WriteFile(aliasFile, data, 0644); err != nil {
-		return fmt.Errorf("保存别名文件失败: %v", err)
+	if err := Validate(command); err != nil {
+		return fmt.Errorf("别名命令无效: %v", err)
 	}
-
 	return nil
 }
 
+// AddAlias 添加全局别名，命令中的位置参数占位符必须符合语法，且不能与已有别名形成循环引用
 func AddAlias(name, command string) error {
-	if strings.Contains(name, " ") {
-		return fmt.Errorf("别名不能包含空格")
-	}
-	aliases[name] = command
-	return SaveAliases()
+	return AddScopedAlias(ScopeGlobal, name, command)
 }
 
+// RemoveAlias 删除全局别名
 func RemoveAlias(name string) error {
 	if _, exists := aliases[name]; !exists {
 		return fmt.Errorf("别名不存在: %s", name)
@@ -79,24 +66,28 @@ func RemoveAlias(name string) error {
 	return SaveAliases()
 }
 
+// GetAlias 返回当前生效的别名命令
 func GetAlias(name string) (string, bool) {
-	command, exists := aliases[name]
-	return command, exists
+	entry, exists := lookup(name)
+	return entry.Command, exists
 }
 
+// ListAliases 返回当前生效的所有别名
 func ListAliases() map[string]string {
-	return aliases
+	list := make(map[string]string)
+	for _, entry := range Entries() {
+		if !entry.Shadowed {
+			list[entry.Name] = entry.Command
+		}
+	}
+	return list
 }
 
+// ExpandCommand 与 Expand 相同，但展开失败（如缺少参数）时返回原命令
 func ExpandCommand(command string) string {
-	parts := strings.Fields(command)
-	if len(parts) == 0 {
+	expanded, err := Expand(command)
+	if err != nil {
 		return command
 	}
-
-	if expanded, exists := aliases[parts[0]]; exists {
-		return expanded + " " + strings.Join(parts[1:], " ")
-	}
-
-	return command
+	return expanded
 }
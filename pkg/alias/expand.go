@@ -0,0 +1,303 @@
+package alias
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Lzww0608/ClixGo/pkg/shell"
+)
+
+// 别名的命令可以包含以下位置参数占位符，语法与shell相同:
+//
+//	$1 ~ $9、${N}     第 N 个参数，未提供时报错
+//	${N:-默认值}       第 N 个参数，未提供或为空时使用默认值
+//	$@、$*、${@}       所有参数
+//
+// 单引号内和以反斜杠转义的 $ 不是占位符；$HOME、${PATH} 等其他变量保持原样，在执行时展开。
+// 不含占位符的别名与之前一样把参数追加到命令末尾
+
+// segment 别名命令中的一段：普通文本或占位符
+type segment struct {
+	text     string // 普通文本；占位符的默认值
+	index    int    // 占位符的参数位置，0 表示 $@
+	param    bool   // 是占位符
+	optional bool   // 有默认值
+	quoted   bool   // 位于双引号内
+}
+
+// parseBody 将别名命令拆分为普通文本和占位符
+func parseBody(body string) ([]segment, error) {
+	var segments []segment
+	var text strings.Builder
+	inSingle, inDouble := false, false
+
+	flush := func() {
+		if text.Len() > 0 {
+			segments = append(segments, segment{text: text.String()})
+			text.Reset()
+		}
+	}
+
+	for i := 0; i < len(body); i++ {
+		c := body[i]
+		switch {
+		case inSingle:
+			if c == '\'' {
+				inSingle = false
+			}
+		case c == '\\' && i+1 < len(body):
+			text.WriteByte(c)
+			i++
+			c = body[i]
+		case c == '\'' && !inDouble:
+			inSingle = true
+		case c == '"':
+			inDouble = !inDouble
+		case c == '$' && i+1 < len(body):
+			seg, n, err := parsePlaceholder(body[i:])
+			if err != nil {
+				return nil, err
+			}
+			if n > 0 {
+				flush()
+				seg.quoted = inDouble
+				segments = append(segments, seg)
+				i += n - 1
+				continue
+			}
+		}
+		text.WriteByte(c)
+	}
+	if inSingle || inDouble {
+		return nil, fmt.Errorf("引号没有闭合")
+	}
+	flush()
+	return segments, nil
+}
+
+// parsePlaceholder 解析 s 开头的占位符，返回占位符和占用的字节数；不是占位符时返回 0
+func parsePlaceholder(s string) (segment, int, error) {
+	next := s[1]
+	switch {
+	case next >= '1' && next <= '9':
+		return segment{param: true, index: int(next - '0')}, 2, nil
+	case next == '@' || next == '*':
+		return segment{param: true}, 2, nil
+	case next != '{':
+		return segment{}, 0, nil
+	}
+
+	end := strings.IndexByte(s, '}')
+	if end < 0 {
+		return segment{}, 0, fmt.Errorf("占位符 %s 缺少 }", s)
+	}
+	inner := s[2:end]
+	name, rest := inner, ""
+	if i := strings.IndexFunc(inner, func(r rune) bool { return r < '0' || r > '9' }); i >= 0 {
+		name, rest = inner[:i], inner[i:]
+	}
+	if inner == "@" || inner == "*" {
+		return segment{param: true}, end + 1, nil
+	}
+	if name == "" {
+		// ${HOME} 等普通变量
+		return segment{}, 0, nil
+	}
+
+	index, err := strconv.Atoi(name)
+	if err != nil || index == 0 {
+		return segment{}, 0, fmt.Errorf("无效的占位符 ${%s}，参数位置从 1 开始", inner)
+	}
+	switch {
+	case rest == "":
+		return segment{param: true, index: index}, end + 1, nil
+	case strings.HasPrefix(rest, ":-"):
+		return segment{param: true, index: index, optional: true, text: rest[2:]}, end + 1, nil
+	default:
+		return segment{}, 0, fmt.Errorf("无效的占位符 ${%s}，只支持 ${N} 和 ${N:-默认值}", inner)
+	}
+}
+
+// Validate 检查别名命令中占位符和引号的语法
+func Validate(command string) error {
+	segments, err := parseBody(command)
+	if err != nil {
+		return err
+	}
+	// 用占位符的示例值替换后检查整个命令能否解析
+	var b strings.Builder
+	for _, seg := range segments {
+		if seg.param {
+			b.WriteString("x")
+			continue
+		}
+		b.WriteString(seg.text)
+	}
+	if _, err := shell.Parse(b.String()); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Params 返回别名命令需要的参数个数（不含有默认值的参数）以及是否接受任意个参数
+func Params(command string) (required int, variadic bool) {
+	segments, err := parseBody(command)
+	if err != nil {
+		return 0, false
+	}
+	for _, seg := range segments {
+		switch {
+		case !seg.param:
+		case seg.index == 0:
+			variadic = true
+		case !seg.optional && seg.index > required:
+			required = seg.index
+		}
+	}
+	return required, variadic
+}
+
+// substitute 用参数替换别名命令中的占位符。没有占位符时把参数追加到命令末尾
+func substitute(name, body string, args []shell.Word) (string, error) {
+	segments, err := parseBody(body)
+	if err != nil {
+		return "", fmt.Errorf("别名 %s 的命令无效: %v", name, err)
+	}
+
+	hasParam := false
+	for _, seg := range segments {
+		hasParam = hasParam || seg.param
+	}
+	if !hasParam {
+		raw := make([]string, len(args))
+		for i, arg := range args {
+			raw[i] = arg.Raw
+		}
+		return strings.TrimSpace(strings.Join(append([]string{body}, raw...), " ")), nil
+	}
+
+	var b strings.Builder
+	for _, seg := range segments {
+		switch {
+		case !seg.param:
+			b.WriteString(seg.text)
+		case seg.index == 0:
+			for i, arg := range args {
+				if i > 0 {
+					if seg.quoted {
+						b.WriteString(`" "`)
+					} else {
+						b.WriteByte(' ')
+					}
+				}
+				b.WriteString(quoteArg(arg, seg.quoted))
+			}
+		case seg.index <= len(args) && !(seg.optional && args[seg.index-1].Literal && args[seg.index-1].Value == ""):
+			b.WriteString(quoteArg(args[seg.index-1], seg.quoted))
+		case seg.optional:
+			b.WriteString(seg.text)
+		default:
+			required, _ := Params(body)
+			return "", fmt.Errorf("别名 %s 需要至少 %d 个参数，缺少第 %d 个参数", name, required, seg.index)
+		}
+	}
+	return b.String(), nil
+}
+
+// quoteArg 返回插入别名命令中的参数文本。不需要运行时展开的参数按所在位置加引号，
+// 保证作为一个词；需要展开的参数（如 $HOME）保持原样，在双引号内时先结束双引号
+func quoteArg(arg shell.Word, inDouble bool) string {
+	switch {
+	case !arg.Literal && inDouble:
+		return `"` + arg.Raw + `"`
+	case !arg.Literal:
+		return arg.Raw
+	case inDouble:
+		return strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`, "`", "\\`").Replace(arg.Value)
+	default:
+		return shell.Join([]string{arg.Value})
+	}
+}
+
+// MaxDepth 递归展开别名的最大层数
+const MaxDepth = 16
+
+// Step 展开别名的一步
+type Step struct {
+	Name     string // 展开的别名
+	Scope    Scope  // 别名的作用域
+	Command  string // 别名的定义
+	Expanded string // 展开后的完整命令
+}
+
+// Expand 展开命令开头的别名，将参数代入别名命令中的占位符。展开后的命令仍以别名开头时继续展开，
+// 最多 MaxDepth 层；别名以自身开头（如 ls='ls --color'）时停止展开，其他循环引用返回错误。
+// 命令不以别名开头时原样返回；缺少必需的参数时返回错误
+func Expand(command string) (string, error) {
+	steps, err := Chain(command)
+	if err != nil {
+		return "", err
+	}
+	if len(steps) == 0 {
+		return command, nil
+	}
+	return steps[len(steps)-1].Expanded, nil
+}
+
+// Chain 返回展开命令的每一步，命令不以别名开头时为空
+func Chain(command string) ([]Step, error) {
+	return walk(command, true)
+}
+
+// Which 返回别名 name 的定义链：依次为 name 的定义、定义开头的别名的定义，直到不以别名开头。
+// 与 Chain 不同，不代入参数
+func Which(name string) ([]Step, error) {
+	if _, exists := lookup(name); !exists {
+		return nil, fmt.Errorf("别名不存在: %s", name)
+	}
+	return walk(name, false)
+}
+
+// walk 逐层展开命令开头的别名，withArgs 为假时不代入参数，只沿着别名的定义查找
+func walk(command string, withArgs bool) ([]Step, error) {
+	var steps []Step
+	var path []string
+	current := command
+	for {
+		words, rest, ok, err := shell.SplitCall(current)
+		if err != nil || !ok || !words[0].Literal {
+			// 无法解析的命令交给执行时报告错误
+			return steps, nil
+		}
+		name := words[0].Value
+		entry, exists := lookup(name)
+		if !exists {
+			return steps, nil
+		}
+		// 别名以自身开头，剩下的部分是同名的程序
+		if len(path) > 0 && path[len(path)-1] == name {
+			return steps, nil
+		}
+		for _, seen := range path {
+			if seen == name {
+				return nil, fmt.Errorf("别名循环引用: %s", strings.Join(append(path, name), " -> "))
+			}
+		}
+		if len(path) == MaxDepth {
+			return nil, fmt.Errorf("别名展开超过 %d 层: %s", MaxDepth, strings.Join(append(path, name), " -> "))
+		}
+
+		body := entry.Command
+		expanded := body
+		if withArgs {
+			if expanded, err = substitute(name, body, words[1:]); err != nil {
+				return nil, err
+			}
+			expanded += rest
+		}
+		steps = append(steps, Step{Name: name, Scope: entry.Scope, Command: body, Expanded: expanded})
+		path = append(path, name)
+		current = expanded
+	}
+}
@@ -0,0 +1,208 @@
+package alias
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// 测试位置参数占位符的展开
+func TestExpandPlaceholders(t *testing.T) {
+	setupTest()
+	aliases["gco"] = "git checkout $1"
+	aliases["gcm"] = `git commit -m "$1"`
+	aliases["greet"] = "echo ${1:-world} ${2:-!}"
+	aliases["each"] = `for f in "$@"; do echo $f; done`
+	aliases["all"] = "printf '%s\\n' $@"
+	aliases["ship"] = "go test ./... && git push ${1:-origin} $2"
+	aliases["lit"] = `echo '$1' \$2 $HOME ${PATH}`
+	aliases["ll"] = "ls -la"
+
+	tests := []struct {
+		command string
+		want    string
+	}{
+		{"gco main", "git checkout main"},
+		{"gco 'feature x'", "git checkout 'feature x'"},
+		{`gcm 'fix "quoted" $bug'`, `git commit -m "fix \"quoted\" \$bug"`},
+		{"gcm $MSG", `git commit -m ""$MSG""`},
+		{"greet", "echo world !"},
+		{"greet bob ''", "echo bob !"},
+		{"each a 'b c'", `for f in "a" "b c"; do echo $f; done`},
+		{"all", "printf '%s\\n' "},
+		{"all x y", "printf '%s\\n' x y"},
+		{"ship '' main", "go test ./... && git push origin main"},
+		{"ship upstream main && echo done", "go test ./... && git push upstream main && echo done"},
+		{"lit a b", `echo '$1' \$2 $HOME ${PATH} a b`},
+		{"ll /tmp | wc -l", "ls -la /tmp | wc -l"},
+		{"unknown $1", "unknown $1"},
+	}
+	for _, tt := range tests {
+		got, err := Expand(tt.command)
+		if err != nil {
+			t.Errorf("展开 %q 不应返回错误: %v", tt.command, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("展开 %q 不正确，期望: %s, 实际: %s", tt.command, tt.want, got)
+		}
+	}
+}
+
+// 测试缺少必需参数时报错
+func TestExpandMissingArgs(t *testing.T) {
+	setupTest()
+	aliases["gco"] = "git checkout $1"
+	aliases["cp2"] = "cp $1 $2"
+
+	if _, err := Expand("gco"); err == nil {
+		t.Errorf("缺少参数时应该返回错误")
+	}
+	_, err := Expand("cp2 a")
+	if err == nil || !strings.Contains(err.Error(), "至少 2 个参数") {
+		t.Errorf("错误信息应该说明需要的参数个数，实际: %v", err)
+	}
+	if got := ExpandCommand("gco"); got != "gco" {
+		t.Errorf("ExpandCommand 展开失败时应该返回原命令，实际: %s", got)
+	}
+}
+
+// 测试占位符语法检查
+func TestValidate(t *testing.T) {
+	for _, command := range []string{
+		"git checkout $1",
+		"echo ${1:-default value} ${2}",
+		"a && b; c $@",
+		"echo ${HOME} $0 '$x'",
+	} {
+		if err := Validate(command); err != nil {
+			t.Errorf("%q 应该是有效的别名命令: %v", command, err)
+		}
+	}
+
+	for _, command := range []string{
+		"echo ${1",
+		"echo ${1:?required}",
+		"echo ${0}",
+		"echo ${1x}",
+		"echo 'unterminated",
+		"echo $1 &&",
+	} {
+		if err := Validate(command); err == nil {
+			t.Errorf("%q 应该是无效的别名命令", command)
+		}
+	}
+
+	setupTest()
+	if err := AddAlias("bad", "echo ${1:?x}"); err == nil {
+		t.Errorf("添加语法错误的别名应该返回错误")
+	}
+}
+
+// 测试参数个数统计
+func TestParams(t *testing.T) {
+	required, variadic := Params("cp $1 ${3} ${4:-x}")
+	if required != 3 || variadic {
+		t.Errorf("参数统计不正确: %d %v", required, variadic)
+	}
+	required, variadic = Params("echo $@")
+	if required != 0 || !variadic {
+		t.Errorf("参数统计不正确: %d %v", required, variadic)
+	}
+}
+
+// 测试递归展开
+func TestExpandRecursive(t *testing.T) {
+	setupTest()
+	aliases["gs"] = "git status"
+	aliases["gss"] = "gs --short"
+	aliases["gco"] = "git checkout $1"
+	aliases["co"] = "gco ${1:-main}"
+	aliases["ls"] = "ls --color"
+	aliases["l"] = "ls -l"
+
+	tests := []struct {
+		command string
+		want    string
+	}{
+		{"gss", "git status --short"},
+		{"gss . && gs", "git status --short . && gs"},
+		{"co", "git checkout main"},
+		{"co dev", "git checkout dev"},
+		{"l /tmp", "ls --color -l /tmp"},
+	}
+	for _, tt := range tests {
+		got, err := Expand(tt.command)
+		if err != nil {
+			t.Errorf("展开 %q 不应返回错误: %v", tt.command, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("展开 %q 不正确，期望: %s, 实际: %s", tt.command, tt.want, got)
+		}
+	}
+
+	steps, err := Chain("co dev")
+	if err != nil || len(steps) != 2 {
+		t.Fatalf("展开链应该有 2 步，实际: %v %v", steps, err)
+	}
+	if steps[0].Name != "co" || steps[0].Expanded != "gco dev" || steps[1].Name != "gco" {
+		t.Errorf("展开链不正确: %+v", steps)
+	}
+}
+
+// 测试循环引用和层数限制
+func TestExpandCycle(t *testing.T) {
+	setupTest()
+	aliases["a"] = "b 1"
+	aliases["b"] = "c 2"
+	aliases["c"] = "a 3"
+
+	_, err := Expand("a")
+	if err == nil || !strings.Contains(err.Error(), "a -> b -> c -> a") {
+		t.Errorf("循环引用应该报告展开路径，实际: %v", err)
+	}
+	if _, err := Which("b"); err == nil || !strings.Contains(err.Error(), "b -> c -> a -> b") {
+		t.Errorf("Which 应该报告循环引用，实际: %v", err)
+	}
+	if got := ExpandCommand("a"); got != "a" {
+		t.Errorf("ExpandCommand 展开失败时应该返回原命令，实际: %s", got)
+	}
+
+	setupTest()
+	for i := 0; i <= MaxDepth; i++ {
+		aliases[fmt.Sprintf("n%d", i)] = fmt.Sprintf("n%d", i+1)
+	}
+	if _, err := Expand("n0"); err == nil || !strings.Contains(err.Error(), "超过") {
+		t.Errorf("超过最大层数应该返回错误，实际: %v", err)
+	}
+	delete(aliases, "n0")
+	if _, err := Expand("n1"); err != nil {
+		t.Errorf("最大层数以内不应返回错误: %v", err)
+	}
+}
+
+// 测试添加形成循环引用的别名
+func TestAddAliasCycle(t *testing.T) {
+	setupTest()
+	aliases["a"] = "b"
+	aliases["b"] = "echo b"
+
+	if err := AddAlias("b", "a --x"); err == nil {
+		t.Errorf("形成循环引用的别名应该添加失败")
+	}
+	if aliases["b"] != "echo b" {
+		t.Errorf("添加失败时应该保留原来的别名，实际: %s", aliases["b"])
+	}
+	if err := AddAlias("c", "c -v"); err != nil {
+		t.Errorf("以自身开头的别名应该可以添加: %v", err)
+	}
+
+	steps, err := Which("a")
+	if err != nil || len(steps) != 2 || steps[1].Command != "echo b" {
+		t.Errorf("Which 返回的定义链不正确: %+v %v", steps, err)
+	}
+	if _, err := Which("missing"); err == nil {
+		t.Errorf("不存在的别名应该返回错误")
+	}
+}
@@ -0,0 +1,285 @@
+package alias
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Scope 别名的作用域，同名别名按 project > profile > global 的优先级生效
+type Scope string
+
+const (
+	ScopeGlobal  Scope = "global"  // 全局别名，保存在 ~/.clixgo/aliases.json
+	ScopeProfile Scope = "profile" // 配置环境的别名，只在使用对应的 --profile 时生效
+	ScopeProject Scope = "project" // 项目别名，只在项目目录及其子目录下生效
+)
+
+// ProjectFileName 项目别名文件名，从当前目录逐级向上查找最近的一个
+const ProjectFileName = ".clixgo-aliases.yaml"
+
+// 按优先级从高到低排列的作用域
+var scopes = []Scope{ScopeProject, ScopeProfile, ScopeGlobal}
+
+var (
+	profile        string
+	profileAliases = make(map[string]string)
+	projectFile    string
+	projectAliases = make(map[string]string)
+	getwd          = os.Getwd
+)
+
+// Entry 一个作用域中的别名定义
+type Entry struct {
+	Name     string
+	Command  string
+	Scope    Scope
+	File     string // 定义别名的文件
+	Shadowed bool   // 被优先级更高的作用域中的同名别名覆盖
+}
+
+// ParseScope 解析作用域名称
+func ParseScope(s string) (Scope, error) {
+	for _, scope := range scopes {
+		if string(scope) == s {
+			return scope, nil
+		}
+	}
+	return "", fmt.Errorf("无效的作用域: %s，可选 global、profile、project", s)
+}
+
+// SetProfile 设置当前的配置环境，InitAliases 时加载该环境的别名。
+// 空字符串和 "default" 表示不使用配置环境的别名
+func SetProfile(name string) {
+	if name == "default" {
+		name = ""
+	}
+	profile = name
+}
+
+// ProfileFilePath 返回配置环境的别名文件路径，与全局别名文件位于同一目录下
+func ProfileFilePath(name string) string {
+	return filepath.Join(filepath.Dir(aliasFile), "profiles", name, "aliases.json")
+}
+
+// FindProjectFile 从 dir 开始逐级向上查找项目别名文件，找不到时返回空字符串
+func FindProjectFile(dir string) string {
+	for {
+		path := filepath.Join(dir, ProjectFileName)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// loadScopes 加载当前配置环境和项目的别名
+func loadScopes() error {
+	profileAliases = make(map[string]string)
+	if profile != "" {
+		if err := readJSONFile(ProfileFilePath(profile), profileAliases); err != nil {
+			return err
+		}
+	}
+
+	projectAliases = make(map[string]string)
+	projectFile = ""
+	dir, err := getwd()
+	if err != nil {
+		return nil
+	}
+	if projectFile = FindProjectFile(dir); projectFile == "" {
+		return nil
+	}
+	data, err := os.ReadFile(projectFile)
+	if err != nil {
+		return fmt.Errorf("读取项目别名文件失败: %v", err)
+	}
+	if err := yaml.Unmarshal(data, &projectAliases); err != nil {
+		return fmt.Errorf("解析项目别名文件 %s 失败: %v", projectFile, err)
+	}
+	if projectAliases == nil {
+		projectAliases = make(map[string]string)
+	}
+	return nil
+}
+
+// readJSONFile 读取 JSON 格式的别名文件，文件不存在时不报错
+func readJSONFile(path string, into map[string]string) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("读取别名文件失败: %v", err)
+	}
+
+	var aliasList []Alias
+	if err := json.Unmarshal(data, &aliasList); err != nil {
+		return fmt.Errorf("解析别名文件 %s 失败: %v", path, err)
+	}
+	for _, a := range aliasList {
+		into[a.Name] = a.Command
+	}
+	return nil
+}
+
+// writeJSONFile 以 JSON 格式保存别名，按名称排序
+func writeJSONFile(path string, from map[string]string) error {
+	aliasList := []Alias{}
+	for name, command := range from {
+		aliasList = append(aliasList, Alias{Name: name, Command: command})
+	}
+	sort.Slice(aliasList, func(i, j int) bool { return aliasList[i].Name < aliasList[j].Name })
+
+	data, err := json.MarshalIndent(aliasList, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化别名失败: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("创建别名配置目录失败: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("保存别名文件失败: %v", err)
+	}
+	return nil
+}
+
+// scopeAliases 返回作用域的别名和保存别名的文件。项目中还没有别名文件时，
+// 文件为当前目录下的 ProjectFileName
+func scopeAliases(scope Scope) (map[string]string, string, error) {
+	switch scope {
+	case ScopeGlobal:
+		return aliases, aliasFile, nil
+	case ScopeProfile:
+		if profile == "" {
+			return nil, "", fmt.Errorf("没有设置配置环境，请使用 --profile 指定")
+		}
+		return profileAliases, ProfileFilePath(profile), nil
+	case ScopeProject:
+		if projectFile != "" {
+			return projectAliases, projectFile, nil
+		}
+		dir, err := getwd()
+		if err != nil {
+			return nil, "", fmt.Errorf("获取当前目录失败: %v", err)
+		}
+		return projectAliases, filepath.Join(dir, ProjectFileName), nil
+	default:
+		return nil, "", fmt.Errorf("无效的作用域: %s", scope)
+	}
+}
+
+// saveScope 保存作用域的别名
+func saveScope(scope Scope) error {
+	if scope == ScopeGlobal {
+		return SaveAliases()
+	}
+	m, path, err := scopeAliases(scope)
+	if err != nil {
+		return err
+	}
+	if scope == ScopeProfile {
+		return writeJSONFile(path, m)
+	}
+
+	data, err := yaml.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("序列化别名失败: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("保存项目别名文件失败: %v", err)
+	}
+	projectFile = path
+	return nil
+}
+
+// lookup 按作用域的优先级查找别名
+func lookup(name string) (Entry, bool) {
+	for _, scope := range scopes {
+		m, path, err := scopeAliases(scope)
+		if err != nil {
+			continue
+		}
+		if command, exists := m[name]; exists {
+			return Entry{Name: name, Command: command, Scope: scope, File: path}, true
+		}
+	}
+	return Entry{}, false
+}
+
+// AddScopedAlias 在指定作用域中添加别名，规则与 AddAlias 相同
+func AddScopedAlias(scope Scope, name, command string) error {
+	if err := checkAlias(name, command); err != nil {
+		return err
+	}
+	m, _, err := scopeAliases(scope)
+	if err != nil {
+		return err
+	}
+
+	previous, existed := m[name]
+	m[name] = command
+	if _, err := Which(name); err != nil {
+		if existed {
+			m[name] = previous
+		} else {
+			delete(m, name)
+		}
+		return err
+	}
+	return saveScope(scope)
+}
+
+// RemoveScopedAlias 删除指定作用域中的别名
+func RemoveScopedAlias(scope Scope, name string) error {
+	m, _, err := scopeAliases(scope)
+	if err != nil {
+		return err
+	}
+	if _, exists := m[name]; !exists {
+		return fmt.Errorf("%s 作用域中不存在别名: %s", scope, name)
+	}
+	delete(m, name)
+	return saveScope(scope)
+}
+
+// Lookup 返回当前生效的别名定义及其作用域
+func Lookup(name string) (Entry, bool) {
+	return lookup(name)
+}
+
+// Entries 返回所有作用域中的别名定义，按名称排序，同名的按优先级从高到低排列
+func Entries() []Entry {
+	var entries []Entry
+	seen := make(map[string]bool)
+	for _, scope := range scopes {
+		m, path, err := scopeAliases(scope)
+		if err != nil {
+			continue
+		}
+		for name, command := range m {
+			entries = append(entries, Entry{Name: name, Command: command, Scope: scope, File: path, Shadowed: seen[name]})
+		}
+		for name := range m {
+			seen[name] = true
+		}
+	}
+
+	rank := map[Scope]int{ScopeProject: 0, ScopeProfile: 1, ScopeGlobal: 2}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Name != entries[j].Name {
+			return entries[i].Name < entries[j].Name
+		}
+		return rank[entries[i].Scope] < rank[entries[j].Scope]
+	})
+	return entries
+}
@@ -0,0 +1,153 @@
+package alias
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// setupScopes 准备带配置环境和项目目录的测试环境，返回项目根目录和其中的子目录
+func setupScopes(t *testing.T, profileName string) (string, string) {
+	setupTest()
+	root := t.TempDir()
+	sub := filepath.Join(root, "a", "b")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatalf("无法创建测试目录: %v", err)
+	}
+
+	previousFile := aliasFile
+	aliasFile = filepath.Join(t.TempDir(), "aliases.json")
+	SetProfile(profileName)
+	getwd = func() (string, error) { return sub, nil }
+	t.Cleanup(func() {
+		aliasFile = previousFile
+		SetProfile("")
+		getwd = os.Getwd
+		profileAliases = make(map[string]string)
+		projectAliases = make(map[string]string)
+		projectFile = ""
+	})
+	return root, sub
+}
+
+// 测试作用域的优先级: project > profile > global
+func TestScopePrecedence(t *testing.T) {
+	root, _ := setupScopes(t, "work")
+
+	project := "t: go test ./...\nb: make build\n"
+	if err := os.WriteFile(filepath.Join(root, ProjectFileName), []byte(project), 0644); err != nil {
+		t.Fatalf("无法写入项目别名文件: %v", err)
+	}
+	if err := writeJSONFile(ProfileFilePath("work"), map[string]string{"b": "bazel build", "d": "deploy work"}); err != nil {
+		t.Fatalf("无法写入配置环境别名文件: %v", err)
+	}
+	if err := writeJSONFile(aliasFile, map[string]string{"b": "go build", "d": "deploy", "g": "git"}); err != nil {
+		t.Fatalf("无法写入全局别名文件: %v", err)
+	}
+
+	if err := InitAliases(); err != nil {
+		t.Fatalf("InitAliases 失败: %v", err)
+	}
+
+	want := map[string]string{"t": "go test ./...", "b": "make build", "d": "deploy work", "g": "git"}
+	for name, command := range want {
+		if got, _ := GetAlias(name); got != command {
+			t.Errorf("别名 %s 的值不正确，期望: %s, 实际: %s", name, command, got)
+		}
+	}
+	if got := ListAliases(); len(got) != len(want) {
+		t.Errorf("生效的别名数量不正确: %v", got)
+	}
+	if got := ExpandCommand("b ./cmd"); got != "make build ./cmd" {
+		t.Errorf("项目别名应该优先，实际: %s", got)
+	}
+
+	var b []Entry
+	for _, entry := range Entries() {
+		if entry.Name == "b" {
+			b = append(b, entry)
+		}
+	}
+	if len(b) != 3 || b[0].Scope != ScopeProject || b[0].Shadowed || !b[1].Shadowed || !b[2].Shadowed {
+		t.Errorf("同名别名应该按优先级排列并标记被覆盖的定义: %+v", b)
+	}
+	if b[0].File != filepath.Join(root, ProjectFileName) {
+		t.Errorf("项目别名的来源文件不正确: %s", b[0].File)
+	}
+}
+
+// 测试在各个作用域中添加和删除别名
+func TestAddScopedAlias(t *testing.T) {
+	root, sub := setupScopes(t, "")
+	if err := InitAliases(); err != nil {
+		t.Fatalf("InitAliases 失败: %v", err)
+	}
+
+	if err := AddScopedAlias(ScopeProfile, "x", "echo x"); err == nil {
+		t.Errorf("没有设置配置环境时添加配置环境别名应该返回错误")
+	}
+
+	// 项目中还没有别名文件时写入当前目录
+	if err := AddScopedAlias(ScopeProject, "t", "go test $@"); err != nil {
+		t.Fatalf("添加项目别名失败: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(sub, ProjectFileName)); err != nil {
+		t.Errorf("应该在当前目录创建项目别名文件: %v", err)
+	}
+
+	// 已有项目别名文件时写入最近的文件
+	os.Remove(filepath.Join(sub, ProjectFileName))
+	os.WriteFile(filepath.Join(root, ProjectFileName), []byte("b: make\n"), 0644)
+	if err := InitAliases(); err != nil {
+		t.Fatalf("InitAliases 失败: %v", err)
+	}
+	if err := AddScopedAlias(ScopeProject, "t", "go test $@"); err != nil {
+		t.Fatalf("添加项目别名失败: %v", err)
+	}
+	data, _ := os.ReadFile(filepath.Join(root, ProjectFileName))
+	if !strings.Contains(string(data), "b: make") || !strings.Contains(string(data), "t: go test $@") {
+		t.Errorf("项目别名文件内容不正确: %s", data)
+	}
+
+	if err := AddScopedAlias(ScopeGlobal, "b", "t"); err != nil {
+		t.Fatalf("添加全局别名失败: %v", err)
+	}
+	if err := AddScopedAlias(ScopeGlobal, "c", "t"); err != nil {
+		t.Fatalf("添加全局别名失败: %v", err)
+	}
+	if err := AddScopedAlias(ScopeProject, "t", "c"); err == nil {
+		t.Errorf("跨作用域的循环引用应该添加失败")
+	}
+
+	if err := RemoveScopedAlias(ScopeProject, "b"); err != nil {
+		t.Fatalf("删除项目别名失败: %v", err)
+	}
+	if entry, _ := Lookup("b"); entry.Scope != ScopeGlobal {
+		t.Errorf("删除项目别名后应该使用全局别名，实际: %+v", entry)
+	}
+	if err := RemoveScopedAlias(ScopeProject, "b"); err == nil {
+		t.Errorf("删除不存在的别名应该返回错误")
+	}
+}
+
+// 测试项目别名文件的查找
+func TestFindProjectFile(t *testing.T) {
+	root := t.TempDir()
+	sub := filepath.Join(root, "x", "y")
+	os.MkdirAll(sub, 0755)
+	if got := FindProjectFile(sub); got != "" {
+		t.Errorf("没有项目别名文件时应该返回空字符串，实际: %s", got)
+	}
+	os.WriteFile(filepath.Join(root, "x", ProjectFileName), nil, 0644)
+	if got := FindProjectFile(sub); got != filepath.Join(root, "x", ProjectFileName) {
+		t.Errorf("应该找到最近的项目别名文件，实际: %s", got)
+	}
+
+	if _, err := ParseScope("project"); err != nil {
+		t.Errorf("project 应该是有效的作用域: %v", err)
+	}
+	if _, err := ParseScope("local"); err == nil {
+		t.Errorf("local 不是有效的作用域")
+	}
+}
@@ -0,0 +1,78 @@
+// Package awk 使用 GoAWK 执行 AWK 程序，支持 POSIX AWK 的全部功能，包括 getline、
+// 管道和输出重定向，不依赖系统中的 awk
+package awk
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+
+	"github.com/benhoyt/goawk/interp"
+	"github.com/benhoyt/goawk/lexer"
+	"github.com/benhoyt/goawk/parser"
+)
+
+// ErrUnsupported 程序调用了 GoAWK 没有实现的 gawk 扩展函数，调用方可以改用系统中的 awk 执行
+var ErrUnsupported = errors.New("GoAWK 不支持 gawk 扩展函数")
+
+// extensionFuncs GoAWK 没有实现的 gawk 扩展函数
+var extensionFuncs = map[string]bool{
+	"gensub": true, "patsplit": true, "asort": true, "asorti": true,
+	"strftime": true, "systime": true, "mktime": true, "strtonum": true,
+	"and": true, "or": true, "xor": true, "lshift": true, "rshift": true, "compl": true,
+	"isarray": true, "typeof": true,
+}
+
+// undefinedFunc 匹配 GoAWK 对未定义函数的报错，自定义的同名函数不会产生该错误
+var undefinedFunc = regexp.MustCompile(`undefined function "(\w+)"`)
+
+// Options 执行选项
+type Options struct {
+	FieldSeparator string            // 字段分隔符(-F)，为空时按空白分隔，转义序列会被处理
+	Vars           map[string]string // 在 BEGIN 之前设置的变量(-v)，值中的转义序列会被处理
+	Stderr         io.Writer         // system() 和管道命令的标准错误，为空时使用 os.Stderr
+}
+
+// Run 解析并执行 AWK 程序，从 input 逐条读取记录，输出写入 output。
+// 返回 exit 语句指定的退出码，语法错误或运行时错误时退出码为2
+func Run(src string, input io.Reader, output io.Writer, opts Options) (int, error) {
+	prog, err := parser.ParseProgram([]byte(src), nil)
+	if err != nil {
+		if m := undefinedFunc.FindStringSubmatch(err.Error()); m != nil && extensionFuncs[m[1]] {
+			return 2, fmt.Errorf("%w: %s", ErrUnsupported, m[1])
+		}
+		return 2, fmt.Errorf("解析 AWK 程序失败: %v", err)
+	}
+
+	config := &interp.Config{
+		Stdin:  input,
+		Output: output,
+		Error:  opts.Stderr,
+		Argv0:  "awk",
+	}
+	if config.Error == nil {
+		config.Error = os.Stderr
+	}
+	if opts.FieldSeparator != "" {
+		config.Vars = append(config.Vars, "FS", unescape(opts.FieldSeparator))
+	}
+	for name, value := range opts.Vars {
+		config.Vars = append(config.Vars, name, unescape(value))
+	}
+
+	status, err := interp.ExecProgram(prog, config)
+	if err != nil {
+		return 2, fmt.Errorf("执行 AWK 程序失败: %v", err)
+	}
+	return status, nil
+}
+
+// unescape 按 AWK 字符串的规则处理转义序列，无效的转义保持原样
+func unescape(s string) string {
+	if unescaped, err := lexer.Unescape(s); err == nil {
+		return unescaped
+	}
+	return s
+}
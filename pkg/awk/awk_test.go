@@ -0,0 +1,132 @@
+package awk
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func run(t *testing.T, src, input string, opts Options) string {
+	t.Helper()
+	var out strings.Builder
+	_, err := Run(src, strings.NewReader(input), &out, opts)
+	require.NoError(t, err, src)
+	return out.String()
+}
+
+// 测试常见的 AWK 程序
+func TestRun(t *testing.T) {
+	input := "alice 30 dev\nbob 25 ops\ncarol 35 dev\n"
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"打印字段", "{print $1}", "alice\nbob\ncarol\n"},
+		{"模式", "$2 > 28 {print $1, $2}", "alice 30\ncarol 35\n"},
+		{"正则模式", "/^b/", "bob 25 ops\n"},
+		{"BEGIN/END", "BEGIN{n=0} {n+=$2} END{print n, NR}", "90 3\n"},
+		{"关联数组", "{c[$3]++} END{print c[\"dev\"], c[\"ops\"]}", "2 1\n"},
+		{"printf", "{printf \"%-5s|%03d\\n\", $1, $2}", "alice|030\nbob  |025\ncarol|035\n"},
+		{"范围模式", "/bob/,/carol/ {print NR}", "2\n3\n"},
+		{"修改字段", "{$2 = $2 * 2; print}", "alice 60 dev\nbob 50 ops\ncarol 70 dev\n"},
+		{"NF", "NR==1 {NF=2; print; print NF}", "alice 30\n2\n"},
+		{"函数", "function max(a, b) { return a > b ? a : b } {m = max(m, $2)} END{print m}", "35\n"},
+		{"数组参数", "function fill(arr) { arr[\"x\"] = 1 } BEGIN{fill(a); print length(a)}", "1\n"},
+		{"字符串函数", "NR==1 {print length($1), substr($1, 2, 3), index($1, \"ic\"), toupper($3)}", "5 lic 3 DEV\n"},
+		{"gsub", "NR==2 {n = gsub(/o/, \"0\"); print n, $0}", "2 b0b 25 0ps\n"},
+		{"split", "BEGIN{n = split(\"a:b:c\", p, \":\"); print n, p[3]}", "3 c\n"},
+		{"match", "NR==3 {print match($0, /[0-9]+/), RSTART, RLENGTH}", "7 7 2\n"},
+		{"循环与 delete", "BEGIN{for (i = 0; i < 3; i++) a[i]; delete a[1]; for (k in a) n++; print n}", "2\n"},
+		{"next", "NR==2 {next} {print $1}", "alice\ncarol\n"},
+		{"exit", "{print $1; exit} END{print \"end\"}", "alice\nend\n"},
+		{"字符串比较", "BEGIN{print (\"10\" < \"9\"), (10 < 9)}", "1 0\n"},
+		{"数字格式", "BEGIN{print 1/3, 1e6, 100000000, 0.1+0.2}", "0.333333 1000000 100000000 0.3\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, run(t, tt.src, input, Options{}))
+		})
+	}
+}
+
+// 测试字段分隔符与命令行变量
+func TestRunOptions(t *testing.T) {
+	got := run(t, "{print $2 + x}", "a:1\nb:2\n", Options{
+		FieldSeparator: ":",
+		Vars:           map[string]string{"x": "10"},
+	})
+	assert.Equal(t, "11\n12\n", got)
+
+	got = run(t, "{print $2}", "a\tb c\n", Options{FieldSeparator: "\\t"})
+	assert.Equal(t, "b c\n", got)
+
+	got = run(t, "BEGIN{printf \"%s\", msg}", "", Options{Vars: map[string]string{"msg": "a\\nb"}})
+	assert.Equal(t, "a\nb", got)
+}
+
+// 测试段落模式（RS 为空）
+func TestRunParagraphMode(t *testing.T) {
+	got := run(t, "BEGIN{RS=\"\"} {print NR\": \"$1\"/\"NF}", "a b\nc\n\n\nd\ne f\n", Options{})
+	assert.Equal(t, "1: a/3\n2: d/3\n", got)
+}
+
+// 测试退出码与语法错误
+func TestRunErrors(t *testing.T) {
+	var out strings.Builder
+	code, err := Run("BEGIN{exit 3}", strings.NewReader(""), &out, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, 3, code)
+
+	for _, src := range []string{"{invalid", "{print $1", "{substr()}", "BEGIN{undefined_fn()}"} {
+		code, err := Run(src, strings.NewReader(""), &out, Options{})
+		assert.Error(t, err, src)
+		assert.Equal(t, 2, code, src)
+	}
+
+	_, err = Run("BEGIN{x[1]=1; x=2}", strings.NewReader(""), &out, Options{})
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrUnsupported)
+}
+
+// 测试 getline、管道和输出重定向
+func TestRunGetlineAndPipes(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "out.txt")
+	src := `NR == 1 {getline; print "getline", $0}
+{print | "sort"}
+END {
+	close("sort")
+	while (("echo piped" | getline line) > 0) print line
+	print "file" > "` + file + `"
+}`
+	got := run(t, src, "b\na\nc\n", Options{})
+	assert.Equal(t, "getline a\na\nc\npiped\n", got)
+
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, "file\n", string(data))
+}
+
+// 测试 gawk 扩展函数返回 ErrUnsupported，调用方据此改用系统中的 awk
+func TestRunUnsupported(t *testing.T) {
+	for _, src := range []string{
+		"{print gensub(/a/, \"b\", \"g\")}",
+		"BEGIN{print strftime(\"%Y\")}",
+		"BEGIN{n = asort(a)}",
+	} {
+		_, err := Run(src, strings.NewReader(""), io.Discard, Options{})
+		assert.ErrorIs(t, err, ErrUnsupported, src)
+	}
+
+	// 与 gawk 扩展函数同名的自定义函数可以使用
+	got := run(t, "function and(a, b) { return a && b } BEGIN{print and(1, 0)}", "", Options{})
+	assert.Equal(t, "0\n", got)
+
+	_, err := Run("{invalid", strings.NewReader(""), io.Discard, Options{})
+	assert.NotErrorIs(t, err, ErrUnsupported)
+}
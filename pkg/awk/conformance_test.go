@@ -0,0 +1,81 @@
+package awk
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 测试 GoAWK 与系统中的 awk 输出一致。只包含 POSIX 规定了结果的用例，
+// 多字节字符的长度、超过 32 位的整数输出格式等在 mawk 与 gawk 之间本就不同
+func TestConformance(t *testing.T) {
+	path, err := exec.LookPath("awk")
+	if err != nil {
+		t.Skip("系统中没有 awk")
+	}
+
+	input := "alice 30 dev\nbob 25 ops\ncarol 35 dev\n\ndave 41 qa\n"
+	tests := []struct {
+		src  string
+		fs   string
+		in   string
+		args []string
+	}{
+		{src: "{print $1, $NF}"},
+		{src: "NR % 2 {print NR\": \"$0}"},
+		{src: "$2 >= 30 && $3 == \"dev\""},
+		{src: "/^$/ {print \"empty at\", NR} END {print NR, NF}"},
+		{src: "{s += $2} END {printf \"%d %.2f %5s|%-5s|\\n\", s, s / NR, \"x\", \"y\"}"},
+		{src: "{c[$3]++} END {n = 0; for (k in c) n += c[k]; print n, length(c)}"},
+		{src: "/bob/,/carol/ {print}"},
+		{src: "{$2 = $2 + 1} 1"},
+		{src: "NF {NF = 2; print}"},
+		{src: "{OFS = \"-\"; $1 = $1; print}"},
+		{src: "BEGIN {print length(\"hello\"), substr(\"hello\", 2), index(\"hello\", \"ll\"), toupper(\"a\") tolower(\"B\")}"},
+		{src: "BEGIN {s = \"aaa\"; n = gsub(/a/, \"<&>\", s); print n, s; t = \"x.y\"; sub(/\\./, \"\\\\&\", t); print t}"},
+		{src: "BEGIN {n = split(\"a,b,,c\", p, \",\"); print n, p[1] p[4]; print match(\"foobar\", /o+/), RSTART, RLENGTH}"},
+		{src: "BEGIN {print 1/3, 2^10, 7 % 3, -7 % 3, int(-3.7), 1e6, 0.1 + 0.2, 1000 * 1000}"},
+		{src: "BEGIN {print (\"10\" < \"9\"), (10 < 9), (\"abc\" ~ /b/), !0, !\"\"}"},
+		{src: "BEGIN {x = \"3x\"; print x + 0, x \"\" , +\"\", \" 12 \" + 1}"},
+		{src: "function fib(n) { return n < 2 ? n : fib(n - 1) + fib(n - 2) } BEGIN {print fib(15)}"},
+		{src: "function fill(a, n,   i) { for (i = 1; i <= n; i++) a[i] = i * i } BEGIN {fill(sq, 4); print sq[3], (4 in sq), (5 in sq)}"},
+		{src: "BEGIN {i = 0; do { i++ } while (i < 5); while (1) { if (++i > 8) break }; print i}"},
+		{src: "NR == 2 {next} NR == 4 {exit} {print NR}"},
+		{src: "BEGIN {printf \"%c%c %o %x %X %e %5.1f%%\\n\", 65, \"bc\", 8, 255, 255, 12345.678, 3.14159}"},
+		{src: "BEGIN {a[1, 2] = 3; for (k in a) {split(k, p, SUBSEP); print p[1], p[2], a[k]}}"},
+		{src: "{print $2}", fs: ":", in: "a:b:c\nd::f\n"},
+		{src: "{print NF, $3}", fs: ",", in: "1,2,3\n,,\n"},
+		{src: "{print $1 + 0}", fs: "[0-9]", in: "a1b2c\n"},
+		{src: "BEGIN {RS = \"\"} {print NR \": \" $1 \"/\" NF}", in: "a b\nc\n\n\nd\ne f\n"},
+		{src: "BEGIN {print v, w}", args: []string{"-v", "v=a\\tb", "-v", "w=2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			in := tt.in
+			if in == "" {
+				in = input
+			}
+			args := append([]string{}, tt.args...)
+			opts := Options{FieldSeparator: tt.fs}
+			for i := 0; i+1 < len(tt.args); i += 2 {
+				name, value, _ := strings.Cut(tt.args[i+1], "=")
+				if opts.Vars == nil {
+					opts.Vars = make(map[string]string)
+				}
+				opts.Vars[name] = value
+			}
+			if tt.fs != "" {
+				args = append(args, "-F", tt.fs)
+			}
+			cmd := exec.Command(path, append(args, "--", tt.src)...)
+			cmd.Stdin = strings.NewReader(in)
+			want, err := cmd.Output()
+			require.NoError(t, err)
+
+			assert.Equal(t, string(want), run(t, tt.src, in, opts))
+		})
+	}
+}
@@ -2,112 +2,234 @@ package commands
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"os/exec"
+	"strconv"
 	"strings"
 
+	"github.com/Lzww0608/ClixGo/pkg/awk"
 	"github.com/Lzww0608/ClixGo/pkg/logger"
+	"github.com/Lzww0608/ClixGo/pkg/textproc"
 	"go.uber.org/zap"
 )
 
-// AWKCommand 执行AWK命令
+// AWKCommand 使用 GoAWK 执行AWK程序
 func AWKCommand(input string, pattern string) (string, error) {
-	cmd := exec.Command("awk", pattern)
-	cmd.Stdin = strings.NewReader(input)
+	return AWKCommandWith(input, pattern, awk.Options{}, false)
+}
 
+// AWKCommandWith 按选项执行AWK程序，external 为 true 时调用系统中的 awk。
+// getline、管道和输出重定向由 GoAWK 直接执行，只有调用 gawk 扩展函数时才自动改用系统中的 awk
+func AWKCommandWith(input string, program string, opts awk.Options, external bool) (string, error) {
 	var out bytes.Buffer
-	cmd.Stdout = &out
-
-	err := cmd.Run()
+	var err error
+	if !external {
+		var exitCode int
+		exitCode, err = awk.Run(program, strings.NewReader(input), &out, opts)
+		if err == nil && exitCode != 0 {
+			err = fmt.Errorf("awk 退出码 %d", exitCode)
+		}
+		external = fallbackExternal("awk", err)
+	}
+	if external {
+		out.Reset()
+		err = runExternal("awk", awkArgs(program, opts), input, &out)
+	}
 	if err != nil {
-		// 捕获错误但不终止程序，没有初始化logger的情况下会默默失败
-		defer func() {
-			recover()
-		}()
-		logger.Error("AWK命令执行失败", zap.Error(err))
+		logTextError("AWK命令执行失败", err, external)
 		return "", err
 	}
 
 	return out.String(), nil
 }
 
-// GrepCommand 执行grep命令
+// GrepCommand 使用内置的 RE2 引擎搜索文本
 func GrepCommand(input string, pattern string) (string, error) {
-	cmd := exec.Command("grep", pattern)
-	cmd.Stdin = strings.NewReader(input)
+	return GrepCommandWith(input, pattern, textproc.GrepOptions{}, false)
+}
 
+// GrepCommandWith 按选项搜索文本，external 为 true 时调用系统中的 grep。
+// RE2 无法表示的模式（如反向引用）自动改用系统中的 grep。
+// 没有匹配时返回 textproc.ErrNoMatch，此时 -c 的计数仍然会输出
+func GrepCommandWith(input string, pattern string, opts textproc.GrepOptions, external bool) (string, error) {
 	var out bytes.Buffer
-	cmd.Stdout = &out
-
-	err := cmd.Run()
+	var err error
+	if !external {
+		_, err = textproc.Grep(strings.NewReader(input), &out, pattern, opts)
+		external = fallbackExternal("grep", err)
+	}
+	if external {
+		out.Reset()
+		err = runExternal("grep", grepArgs(pattern, opts), input, &out)
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+			err = textproc.ErrNoMatch
+		}
+	}
+	if errors.Is(err, textproc.ErrNoMatch) {
+		return out.String(), err
+	}
 	if err != nil {
-		// 捕获错误但不终止程序，没有初始化logger的情况下会默默失败
-		defer func() {
-			recover()
-		}()
-		logger.Error("grep命令执行失败", zap.Error(err))
+		logTextError("grep命令执行失败", err, external)
 		return "", err
 	}
 
 	return out.String(), nil
 }
 
-// SedCommand 执行sed命令
+// SedCommand 使用内置实现执行sed脚本
 func SedCommand(input string, pattern string) (string, error) {
-	cmd := exec.Command("sed", pattern)
-	cmd.Stdin = strings.NewReader(input)
+	return SedCommandWith(input, pattern, textproc.SedOptions{}, false)
+}
 
+// SedCommandWith 按选项执行sed脚本，external 为 true 时调用系统中的 sed。
+// 脚本使用了内置实现不支持的命令或正则时自动改用系统中的 sed
+func SedCommandWith(input string, script string, opts textproc.SedOptions, external bool) (string, error) {
 	var out bytes.Buffer
-	cmd.Stdout = &out
-
-	err := cmd.Run()
+	var err error
+	if !external {
+		err = textproc.Sed(strings.NewReader(input), &out, script, opts)
+		external = fallbackExternal("sed", err)
+	}
+	if external {
+		out.Reset()
+		err = runExternal("sed", sedArgs(script, opts), input, &out)
+	}
 	if err != nil {
-		// 捕获错误但不终止程序，没有初始化logger的情况下会默默失败
-		defer func() {
-			recover()
-		}()
-		logger.Error("sed命令执行失败", zap.Error(err))
+		logTextError("sed命令执行失败", err, external)
 		return "", err
 	}
 
 	return out.String(), nil
 }
 
-// PipeCommands 执行管道命令
-func PipeCommands(commands []string) (string, error) {
-	if len(commands) == 0 {
-		return "", fmt.Errorf("没有提供命令")
+// fallbackExternal 判断内置实现是否因不支持的功能而失败，并且系统中有同名命令可以代替。
+// 不支持的功能在读取输入之前就会被发现，改用外部命令不会重复产生副作用
+func fallbackExternal(name string, err error) (ok bool) {
+	if !errors.Is(err, awk.ErrUnsupported) && !errors.Is(err, textproc.ErrUnsupported) {
+		return false
+	}
+	if _, lookErr := exec.LookPath(name); lookErr != nil {
+		return false
 	}
 
-	var lastOutput bytes.Buffer
-	var err error
+	ok = true
+	// 没有初始化logger的情况下会默默失败
+	defer func() {
+		recover()
+	}()
+	logger.Info("内置实现不支持，改用系统命令", zap.String("command", name), zap.Error(err))
+	return ok
+}
 
-	for i, command := range commands {
-		parts := strings.Fields(command)
-		if len(parts) == 0 {
-			return "", fmt.Errorf("空命令")
-		}
+// logTextError 记录文本处理命令的错误，在单独的函数中捕获 panic，保证调用方仍能返回错误
+func logTextError(msg string, err error, external bool) {
+	// 捕获错误但不终止程序，没有初始化logger的情况下会默默失败
+	defer func() {
+		recover()
+	}()
+	logger.Error(msg, zap.Error(err), zap.Bool("external", external))
+}
 
-		cmd := exec.Command(parts[0], parts[1:]...)
+// runExternal 执行外部文本处理命令，失败时错误信息中附带其标准错误
+func runExternal(name string, args []string, input string, out *bytes.Buffer) error {
+	cmd := exec.Command(name, args...)
+	cmd.Stdin = strings.NewReader(input)
+	cmd.Stdout = out
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
 
-		if i > 0 {
-			// 只有从第二个命令开始才使用前一个命令的输出作为输入
-			cmd.Stdin = strings.NewReader(lastOutput.String())
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%w: %s", err, msg)
 		}
+		return err
+	}
+	return nil
+}
+
+// awkArgs 将选项转换为 awk 的命令行参数
+func awkArgs(program string, opts awk.Options) []string {
+	var args []string
+	if opts.FieldSeparator != "" {
+		args = append(args, "-F", opts.FieldSeparator)
+	}
+	for name, value := range opts.Vars {
+		args = append(args, "-v", name+"="+value)
+	}
+	return append(args, "--", program)
+}
 
-		lastOutput.Reset()
-		cmd.Stdout = &lastOutput
-
-		err = cmd.Run()
-		if err != nil {
-			// 捕获错误但不终止程序，没有初始化logger的情况下会默默失败
-			defer func() {
-				recover()
-			}()
-			logger.Error("管道命令执行失败", zap.Error(err), zap.String("command", command))
-			return "", err
+// grepArgs 将选项转换为 grep 的命令行参数，正则按扩展语法解析以接近 RE2
+func grepArgs(pattern string, opts textproc.GrepOptions) []string {
+	args := []string{"-E"}
+	if opts.Fixed {
+		args = []string{"-F"}
+	}
+	flags := []struct {
+		set  bool
+		flag string
+	}{
+		{opts.IgnoreCase, "-i"},
+		{opts.Invert, "-v"},
+		{opts.LineNumber, "-n"},
+		{opts.Count, "-c"},
+		{opts.OnlyMatching, "-o"},
+	}
+	for _, f := range flags {
+		if f.set {
+			args = append(args, f.flag)
 		}
 	}
+	if opts.Before > 0 {
+		args = append(args, "-B", strconv.Itoa(opts.Before))
+	}
+	if opts.After > 0 {
+		args = append(args, "-A", strconv.Itoa(opts.After))
+	}
+	return append(args, "-e", pattern)
+}
 
-	return lastOutput.String(), nil
+// sedArgs 将选项转换为 sed 的命令行参数
+func sedArgs(script string, opts textproc.SedOptions) []string {
+	var args []string
+	if opts.Quiet {
+		args = append(args, "-n")
+	}
+	if opts.Extended {
+		args = append(args, "-E")
+	}
+	return append(args, "-e", script)
+}
+
+// PipeCommands 执行管道命令，各阶段并发运行，返回最后一个阶段的输出。
+// 任一阶段失败都会返回错误
+func PipeCommands(commands []string) (string, error) {
+	var out bytes.Buffer
+	_, err := RunPipeline(context.Background(), commands, PipeOptions{Stdout: &out, Pipefail: true})
+	if err != nil {
+		// 捕获错误但不终止程序，没有初始化logger的情况下会默默失败
+		defer func() {
+			recover()
+		}()
+		logger.Error("管道命令执行失败", zap.Error(err), zap.Strings("commands", commands))
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// PipeShell 使用进程内解释器执行完整的管道脚本（如 "cat file | grep foo | wc -l"），返回标准输出
+func PipeShell(script string) (string, error) {
+	var out bytes.Buffer
+	if _, err := RunShellPipeline(context.Background(), script, PipeOptions{Stdout: &out, Pipefail: true}); err != nil {
+		// 捕获错误但不终止程序，没有初始化logger的情况下会默默失败
+		defer func() {
+			recover()
+		}()
+		logger.Error("管道命令执行失败", zap.Error(err), zap.String("script", script))
+		return "", err
+	}
+	return out.String(), nil
 }
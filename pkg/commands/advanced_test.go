@@ -1,22 +1,38 @@
 package commands
 
 import (
+	"os"
+	"os/exec"
+	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/Lzww0608/ClixGo/pkg/awk"
 	"github.com/Lzww0608/ClixGo/pkg/logger"
+	"github.com/Lzww0608/ClixGo/pkg/textproc"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
-func init() {
-	// 防止日志系统panic
-	go func() {
-		defer func() {
-			recover()
-		}()
-		logger.SetLogPath("test_commands.log")
-		logger.InitLogger()
-	}()
+func TestMain(m *testing.M) {
+	// 隔离命令重新执行测试程序作为引导进程
+	SandboxInit()
+
+	// 日志写入临时目录，避免在源码目录下留下日志文件
+	tempDir, err := os.MkdirTemp("", "commands_test")
+	if err != nil {
+		panic("无法创建临时目录: " + err.Error())
+	}
+	logger.SetLogPath(filepath.Join(tempDir, "test_commands.log"))
+	if err := logger.InitLogger(); err != nil {
+		panic("初始化日志失败: " + err.Error())
+	}
+
+	exitCode := m.Run()
+
+	logger.Close()
+	os.RemoveAll(tempDir)
+	os.Exit(exitCode)
 }
 
 // 测试AWK命令
@@ -67,6 +83,78 @@ func TestSedCommand(t *testing.T) {
 	assert.Error(t, err, "Sed命令应该返回语法错误")
 }
 
+// 测试内置实现与外部命令的输出一致
+func TestTextCommandsExternal(t *testing.T) {
+	input := "line1 abc\nline2 def\nline3 abc\n"
+
+	for _, external := range []bool{false, true} {
+		output, err := GrepCommandWith(input, "ABC", textproc.GrepOptions{IgnoreCase: true, LineNumber: true}, external)
+		assert.NoError(t, err)
+		assert.Equal(t, "1:line1 abc\n3:line3 abc\n", output)
+
+		output, err = GrepCommandWith(input, "xyz", textproc.GrepOptions{Count: true}, external)
+		assert.ErrorIs(t, err, textproc.ErrNoMatch)
+		assert.Equal(t, "0\n", output)
+
+		output, err = SedCommandWith(input, "/def/p", textproc.SedOptions{Quiet: true}, external)
+		assert.NoError(t, err)
+		assert.Equal(t, "line2 def\n", output)
+
+		output, err = AWKCommandWith(input, "$2 == x {print $1}", awk.Options{Vars: map[string]string{"x": "abc"}}, external)
+		assert.NoError(t, err)
+		assert.Equal(t, "line1\nline3\n", output)
+	}
+}
+
+// 测试内置实现不支持的功能自动改用系统命令
+func TestTextCommandsFallback(t *testing.T) {
+	for _, name := range []string{"awk", "grep", "sed"} {
+		if _, err := exec.LookPath(name); err != nil {
+			t.Skipf("系统中没有 %s", name)
+		}
+	}
+	input := "b\na\naa\nc\n"
+
+	// gawk 扩展函数改用系统中的 awk（mawk 和 gawk 都实现了 strftime）
+	output, err := AWKCommandWith(input, "NR == 1 {print strftime(\"%Y\", 0, 1)}", awk.Options{}, false)
+	assert.NoError(t, err)
+	assert.Equal(t, "1970\n", output)
+
+	output, err = GrepCommandWith(input, "^(a)\\1$", textproc.GrepOptions{}, false)
+	assert.NoError(t, err)
+	assert.Equal(t, "aa\n", output)
+
+	output, err = SedCommandWith(input, "y/abc/xyz/;1!G;h;$!d", textproc.SedOptions{}, false)
+	assert.NoError(t, err)
+	assert.Equal(t, "z\nxx\nx\ny\n", output)
+
+	// 语法错误不会改用系统命令
+	_, err = AWKCommandWith(input, "{print $1", awk.Options{}, false)
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, awk.ErrUnsupported)
+}
+
+// 测试 getline 和管道由 GoAWK 直接执行，不需要系统中的 awk
+func TestAWKGetlineAndPipes(t *testing.T) {
+	sortPath, err := exec.LookPath("sort")
+	if err != nil {
+		t.Skip("系统中没有 sort")
+	}
+	// PATH 中只有 sort，没有 awk
+	dir := t.TempDir()
+	require.NoError(t, os.Symlink(sortPath, filepath.Join(dir, "sort")))
+	t.Setenv("PATH", dir)
+	input := "b\na\naa\nc\n"
+
+	output, err := AWKCommandWith(input, "{print | \"sort\"}", awk.Options{}, false)
+	assert.NoError(t, err)
+	assert.Equal(t, "a\naa\nb\nc\n", output)
+
+	output, err = AWKCommandWith(input, "NR == 1 {getline; print}", awk.Options{}, false)
+	assert.NoError(t, err)
+	assert.Equal(t, "a\n", output)
+}
+
 // 测试管道命令
 func TestPipeCommands(t *testing.T) {
 	// 测试有效的管道命令
@@ -94,3 +182,12 @@ func TestPipeCommands(t *testing.T) {
 	_, err = PipeCommands([]string{"echo test", "invalidcmd"})
 	assert.Error(t, err, "无效命令应该返回错误")
 }
+
+func TestPipeShell(t *testing.T) {
+	output, err := PipeShell(`printf 'b\na\nb\n' | sort | uniq -c | wc -l`)
+	assert.NoError(t, err)
+	assert.Equal(t, "2", strings.TrimSpace(output))
+
+	_, err = PipeShell("  ")
+	assert.Error(t, err)
+}
@@ -3,126 +3,122 @@ package commands
 import (
 	"context"
 	"fmt"
-	"os/exec"
-	"strings"
-	"sync"
+	"os"
 	"time"
 
 	"github.com/Lzww0608/ClixGo/pkg/alias"
-	"github.com/Lzww0608/ClixGo/pkg/history"
 	"github.com/Lzww0608/ClixGo/pkg/logger"
 	"go.uber.org/zap"
 )
 
-// 默认超时时间
+// 默认超时时间，可通过 SetDefaultTimeout 或配置项 commands.timeout 修改
 const defaultCmdTimeout = 30 * time.Second
 
-// ExecuteCommand 执行单个命令
-func ExecuteCommand(command string) error {
-	// 扩展别名
-	expandedCommand := alias.ExpandCommand(command)
-	if expandedCommand != command {
-		logger.Info("扩展别名",
-			zap.String("original", command),
-			zap.String("expanded", expandedCommand))
-		command = expandedCommand
-	}
-
-	startTime := time.Now()
-	cmdHistory := &history.CommandHistory{
-		Command:   command,
-		StartTime: startTime,
-	}
-
-	parts := strings.Fields(command)
-	if len(parts) == 0 {
-		cmdHistory.Status = "failed"
-		cmdHistory.EndTime = time.Now()
-		cmdHistory.Duration = cmdHistory.EndTime.Sub(startTime).String()
-		history.SaveHistory(cmdHistory)
-		return fmt.Errorf("空命令")
-	}
-
-	// 使用默认超时时间
-	ctx, cancel := context.WithTimeout(context.Background(), defaultCmdTimeout)
-	defer cancel()
+// ExecOptions 串行执行时应用到每个命令的选项
+type ExecOptions struct {
+	Shell   bool          // 使用进程内解释器执行
+	Timeout time.Duration // 单个命令的超时时间，0 使用默认超时
+	Retry   RetryPolicy   // 失败后的重试策略
+	Limits  Limits        // 资源限制
+	Sandbox Sandbox       // 进程组与命名空间隔离
+}
 
-	cmd := exec.CommandContext(ctx, parts[0], parts[1:]...)
-	output, err := cmd.CombinedOutput()
+// ExecuteCommand 执行单个命令，按shell语法处理引号、转义、变量赋值和 &&、|| 等操作符
+func ExecuteCommand(command string) error {
+	return ExecuteCommandWith(command, ExecOptions{})
+}
 
-	cmdHistory.EndTime = time.Now()
-	cmdHistory.Duration = cmdHistory.EndTime.Sub(startTime).String()
-	cmdHistory.Output = string(output)
+// ExecuteShellCommand 使用进程内解释器执行整段命令字符串，
+// 多条语句共享变量、工作目录等shell状态
+func ExecuteShellCommand(command string) error {
+	return ExecuteCommandWith(command, ExecOptions{Shell: true})
+}
 
+// ExecuteCommandWith 展开别名后按选项执行单个命令并输出结果
+func ExecuteCommandWith(command string, opts ExecOptions) error {
+	spec := opts.spec()
+	expanded, err := expandAlias(command)
 	if err != nil {
-		cmdHistory.Status = "failed"
-		logger.Error("命令执行失败", zap.Error(err))
-	} else {
-		cmdHistory.Status = "success"
-		logger.Info("命令执行成功", zap.String("output", string(output)))
+		return err
 	}
-
-	if err := history.SaveHistory(cmdHistory); err != nil {
-		logger.Error("保存命令历史失败", zap.Error(err))
+	spec.Command = expanded
+	result, err := Run(context.Background(), spec)
+	if err != nil {
+		if result != nil {
+			return fmt.Errorf("执行命令失败: %v\n输出: %s", err, result.Output)
+		}
+		return err
 	}
+	fmt.Printf("命令输出: %s\n", result.Output)
+	return nil
+}
 
+// expandAlias 扩展命令中的别名，别名缺少必需的参数时返回错误
+func expandAlias(command string) (string, error) {
+	expandedCommand, err := alias.Expand(command)
 	if err != nil {
-		return fmt.Errorf("执行命令失败: %v\n输出: %s", err, string(output))
+		return "", err
 	}
-	fmt.Printf("命令输出: %s\n", string(output))
-	return nil
+	if expandedCommand != command {
+		logger.Info("扩展别名",
+			zap.String("original", command),
+			zap.String("expanded", expandedCommand))
+	}
+	return expandedCommand, nil
 }
 
 // ExecuteCommandsSequentially 串行执行多个命令
 func ExecuteCommandsSequentially(commands []string) error {
+	return ExecuteCommandsSequentiallyWith(commands, ExecOptions{})
+}
+
+// ExecuteCommandsSequentiallyWith 按选项串行执行多个命令，遇到失败立即停止
+func ExecuteCommandsSequentiallyWith(commands []string, opts ExecOptions) error {
 	for _, cmd := range commands {
-		if err := ExecuteCommand(cmd); err != nil {
+		if err := ExecuteCommandWith(cmd, opts); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-// ExecuteCommandsParallel 并行执行多个命令
-func ExecuteCommandsParallel(commands []string) error {
-	var wg sync.WaitGroup
-	errChan := make(chan error, len(commands))
-
-	// 如果没有命令要执行，直接返回成功
-	if len(commands) == 0 {
-		return nil
+// ExplainCommandsWith 生成按选项串行执行多个命令的执行计划，不执行任何命令
+func ExplainCommandsWith(commands []string, opts ExecOptions) []*Plan {
+	plans := make([]*Plan, len(commands))
+	for i, command := range commands {
+		plans[i] = ExplainCommand(command, opts.spec())
 	}
+	return plans
+}
 
-	for _, cmd := range commands {
-		wg.Add(1)
-		go func(command string) {
-			defer wg.Done()
-			if err := ExecuteCommand(command); err != nil {
-				// 使用非阻塞发送避免死锁
-				select {
-				case errChan <- err:
-					// 成功发送错误
-				default:
-					// 通道已满，记录日志但不阻塞
-					logger.Error("无法将错误发送到错误通道，可能通道已满",
-						zap.String("command", command),
-						zap.Error(err))
-				}
-			}
-		}(cmd)
+// spec 将选项转换为单个命令的执行描述
+func (opts ExecOptions) spec() Spec {
+	return Spec{
+		Shell:   opts.Shell,
+		Timeout: opts.Timeout,
+		Retry:   opts.Retry,
+		Limits:  opts.Limits,
+		Sandbox: opts.Sandbox,
 	}
+}
 
-	// 使用额外的goroutine关闭通道，确保所有任务完成后才关闭
-	go func() {
-		wg.Wait()
-		close(errChan)
-	}()
+// ExecuteCommandsParallel 并行执行多个命令，全部执行完后返回第一个错误
+func ExecuteCommandsParallel(commands []string) error {
+	_, err := RunParallel(context.Background(), commands, ParallelOptions{
+		Jobs:      len(commands),
+		KeepGoing: true,
+		Output:    os.Stdout,
+	})
+	return err
+}
 
-	// 收集第一个错误并返回
-	for err := range errChan {
-		if err != nil {
-			return err
-		}
-	}
-	return nil
+// ExecuteShellCommandsParallel 并行执行多个命令，每个命令都交给进程内解释器
+func ExecuteShellCommandsParallel(commands []string) error {
+	_, err := RunParallel(context.Background(), commands, ParallelOptions{
+		Jobs:      len(commands),
+		KeepGoing: true,
+		Shell:     true,
+		Output:    os.Stdout,
+	})
+	return err
 }
@@ -154,3 +154,35 @@ func TestExecuteCommandsParallel(t *testing.T) {
 		})
 	}
 }
+
+// TestExecuteCommandShellSyntax 测试引号、变量赋值和操作符
+func TestExecuteCommandShellSyntax(t *testing.T) {
+	setupTestEnvironment()
+
+	tests := []struct {
+		name    string
+		command string
+		wantErr bool
+	}{
+		{"引号参数", `echo "hello world"`, false},
+		{"变量赋值", `CLIXGO_X=1 sh -c 'test "$CLIXGO_X" = 1'`, false},
+		{"与操作符", "true && echo ok", false},
+		{"或操作符", "false || echo fallback", false},
+		{"失败的与操作", "false && echo never", true},
+		{"语法错误", `echo "unterminated`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ExecuteCommand(tt.command)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ExecuteCommand() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// 解释器模式下语句共享shell状态
+	if err := ExecuteShellCommand("cd /; X=ok; test \"$PWD\" = / && test \"$X\" = ok"); err != nil {
+		t.Errorf("ExecuteShellCommand() error = %v", err)
+	}
+}
@@ -0,0 +1,323 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Lzww0608/ClixGo/pkg/alias"
+	"github.com/Lzww0608/ClixGo/pkg/shell"
+)
+
+// PolicyDecision 命令策略的权限检查结果
+type PolicyDecision struct {
+	Policy  string `json:"policy,omitempty"` // 匹配到的策略，为空表示没有匹配的策略
+	Allowed bool   `json:"allowed"`
+	Reason  string `json:"reason,omitempty"` // 禁止执行的原因
+}
+
+// PermissionFunc 按命令查找匹配的策略并检查当前用户是否有权执行
+type PermissionFunc func(command string) PolicyDecision
+
+// ErrPermissionDenied 命令被安全策略禁止执行
+var ErrPermissionDenied = errors.New("命令被安全策略禁止执行")
+
+var (
+	permissionFunc   PermissionFunc
+	permissionFuncMu sync.RWMutex
+)
+
+// SetPermissionFunc 设置权限检查函数，Run 和管道在执行前使用它拒绝被禁止的命令，
+// 执行计划中也输出它的检查结果。传入 nil 表示不检查
+func SetPermissionFunc(fn PermissionFunc) {
+	permissionFuncMu.Lock()
+	defer permissionFuncMu.Unlock()
+	permissionFunc = fn
+}
+
+// checkPermission 检查命令的权限，没有设置检查函数时允许执行
+func checkPermission(command string) PolicyDecision {
+	permissionFuncMu.RLock()
+	fn := permissionFunc
+	permissionFuncMu.RUnlock()
+	if fn == nil {
+		return PolicyDecision{Allowed: true}
+	}
+	return fn(command)
+}
+
+// permit 检查命令的权限，禁止执行时返回包装了 ErrPermissionDenied 的错误
+func permit(command string) error {
+	decision := checkPermission(command)
+	if decision.Allowed {
+		return nil
+	}
+	if decision.Policy == "" {
+		return fmt.Errorf("%w: %s", ErrPermissionDenied, decision.Reason)
+	}
+	return fmt.Errorf("%w（策略 %q）: %s", ErrPermissionDenied, decision.Policy, decision.Reason)
+}
+
+// Binary 命令调用的程序
+type Binary struct {
+	Name    string `json:"name"`
+	Path    string `json:"path,omitempty"`    // 在 PATH 中解析到的路径
+	Builtin bool   `json:"builtin,omitempty"` // 解释器的内置命令，不启动外部程序
+	Err     error  `json:"-"`                 // 找不到程序时的错误
+}
+
+// Plan 描述 Run 将如何执行一个命令，生成计划时不会执行任何命令
+type Plan struct {
+	Original string         `json:"original"` // 展开别名前的命令
+	Command  string         `json:"command"`  // 实际执行的命令
+	Shell    bool           `json:"shell"`    // 交给进程内解释器执行
+	Args     []string       `json:"args,omitempty"`
+	Binaries []Binary       `json:"binaries"`
+	Env      []string       `json:"env,omitempty"` // 追加到当前环境变量之后的变量
+	Dir      string         `json:"dir"`
+	Timeout  time.Duration  `json:"timeout"`  // 应用命令策略后的超时时间，负数表示不限制
+	Attempts int            `json:"attempts"` // 失败后最多执行的次数（含第一次）
+	Limits   Limits         `json:"limits"`
+	Sandbox  Sandbox        `json:"sandbox"`
+	Policy   PolicyDecision `json:"policy"`
+	Err      error          `json:"-"` // 命令无法解析，执行时会失败
+}
+
+// Explain 按与 Run 相同的规则生成 spec 的执行计划：应用命令策略、解析命令、
+// 在 PATH 中查找程序并检查权限，但不执行命令也不记录历史
+func Explain(spec Spec) *Plan {
+	return explain(spec, true)
+}
+
+// explain 生成执行计划，usePolicy 为假时不应用命令策略中的超时和资源限制
+func explain(spec Spec, usePolicy bool) *Plan {
+	command := spec.Command
+	if len(spec.Args) > 0 {
+		command = strings.Join(spec.Args, " ")
+	}
+	if usePolicy {
+		applyPolicy(&spec, command)
+	}
+
+	plan := &Plan{
+		Original: command,
+		Command:  command,
+		Dir:      spec.Dir,
+		Timeout:  spec.Timeout,
+		Attempts: spec.Retry.attempts(),
+		Limits:   spec.Limits,
+		Sandbox:  spec.Sandbox,
+		Policy:   checkPermission(command),
+	}
+	if plan.Timeout == 0 {
+		plan.Timeout = DefaultTimeout()
+	}
+	if plan.Dir == "" {
+		plan.Dir, _ = os.Getwd()
+	}
+	if strings.TrimSpace(command) == "" {
+		plan.Err = fmt.Errorf("空命令")
+		return plan
+	}
+
+	args, env, useShell, err := resolveCommand(spec, command)
+	if err != nil {
+		plan.Err = err
+		return plan
+	}
+	plan.Env = env
+	plan.Shell = useShell
+	if !useShell {
+		plan.Args = args
+		plan.Binaries = []Binary{resolveBinary(args[0], plan.Dir, env)}
+		return plan
+	}
+
+	names, err := shell.Programs(command)
+	if err != nil {
+		plan.Err = err
+		return plan
+	}
+	for _, name := range names {
+		if shell.IsBuiltin(name) {
+			plan.Binaries = append(plan.Binaries, Binary{Name: name, Builtin: true})
+			continue
+		}
+		plan.Binaries = append(plan.Binaries, resolveBinary(name, plan.Dir, env))
+	}
+	return plan
+}
+
+// ExplainCommand 展开别名后生成命令的执行计划，与 ExecuteCommandWith 的执行方式一致
+func ExplainCommand(command string, spec Spec) *Plan {
+	expanded, err := alias.Expand(command)
+	if err != nil {
+		expanded = command
+	}
+	spec.Command = expanded
+	spec.Args = nil
+	plan := Explain(spec)
+	plan.Original = command
+	if err != nil {
+		plan.Args, plan.Binaries, plan.Err = nil, nil, err
+	}
+	return plan
+}
+
+// ExplainPipeline 生成管道各阶段的执行计划。与 RunPipeline 一致，
+// 各阶段不展开别名，也不应用命令策略中的超时和资源限制
+func ExplainPipeline(commands []string) []*Plan {
+	plans := make([]*Plan, len(commands))
+	for i, command := range commands {
+		plans[i] = explain(Spec{Command: command, Timeout: -1}, false)
+	}
+	return plans
+}
+
+// ExplainShellPipeline 生成由进程内解释器执行的整段管道脚本的执行计划
+func ExplainShellPipeline(script string) *Plan {
+	return explain(Spec{Command: script, Shell: true, Timeout: -1}, false)
+}
+
+// resolveBinary 在 PATH 中查找程序，env 中设置了 PATH 时使用该值，
+// 含路径分隔符的相对路径相对于工作目录 dir
+func resolveBinary(name, dir string, env []string) Binary {
+	bin := Binary{Name: name}
+	if strings.ContainsRune(name, '/') || strings.ContainsRune(name, filepath.Separator) {
+		path := name
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		bin.Path, bin.Err = exec.LookPath(path)
+		return bin
+	}
+
+	pathEnv, override := "", false
+	for _, kv := range env {
+		if value, ok := strings.CutPrefix(kv, "PATH="); ok {
+			pathEnv, override = value, true
+		}
+	}
+	if !override {
+		bin.Path, bin.Err = exec.LookPath(name)
+		return bin
+	}
+	for _, d := range filepath.SplitList(pathEnv) {
+		if d == "" {
+			d = "."
+		}
+		if path, err := exec.LookPath(filepath.Join(d, name)); err == nil {
+			bin.Path = path
+			return bin
+		}
+	}
+	bin.Err = fmt.Errorf("在 PATH 中找不到 %s", name)
+	return bin
+}
+
+// Describe 将执行计划逐行写入 w，每行以 indent 开头
+func (p *Plan) Describe(w io.Writer, indent string) {
+	line := func(label, format string, args ...interface{}) {
+		fmt.Fprintf(w, "%s%s: %s\n", indent, label, fmt.Sprintf(format, args...))
+	}
+
+	if p.Command != p.Original {
+		line("展开别名", "%s", p.Command)
+	}
+	if p.Err != nil {
+		line("错误", "%v", p.Err)
+	} else if p.Shell {
+		line("执行方式", "进程内解释器")
+	} else {
+		line("执行方式", "直接执行")
+		line("参数", "%q", p.Args)
+	}
+	for _, bin := range p.Binaries {
+		switch {
+		case bin.Builtin:
+			line("程序", "%s (内置命令)", bin.Name)
+		case bin.Err != nil:
+			line("程序", "%s (未找到: %v)", bin.Name, bin.Err)
+		default:
+			line("程序", "%s -> %s", bin.Name, bin.Path)
+		}
+	}
+	for _, kv := range p.Env {
+		line("环境变量", "%s", kv)
+	}
+	line("工作目录", "%s", p.Dir)
+	if p.Timeout < 0 {
+		line("超时", "不限制")
+	} else {
+		line("超时", "%s", p.Timeout)
+	}
+	if p.Attempts > 1 {
+		line("重试", "最多执行 %d 次", p.Attempts)
+	}
+	if !p.Limits.IsZero() {
+		line("资源限制", "%s", p.Limits)
+	}
+	if !p.Sandbox.isZero() {
+		line("隔离", "%s", p.Sandbox)
+	}
+
+	switch {
+	case p.Policy.Policy == "" && p.Policy.Allowed:
+		line("安全策略", "没有匹配的策略，允许执行")
+	case p.Policy.Allowed:
+		line("安全策略", "匹配策略 %q，允许执行", p.Policy.Policy)
+	case p.Policy.Policy == "":
+		line("安全策略", "禁止执行: %s", p.Policy.Reason)
+	default:
+		line("安全策略", "匹配策略 %q，禁止执行: %s", p.Policy.Policy, p.Policy.Reason)
+	}
+}
+
+// String 返回资源限制的可读描述
+func (l Limits) String() string {
+	var parts []string
+	if l.CPUTime > 0 {
+		parts = append(parts, "CPU时间 "+l.CPUTime.String())
+	}
+	if l.Memory > 0 {
+		if l.Memory%(1<<20) == 0 {
+			parts = append(parts, fmt.Sprintf("内存 %dMB", l.Memory>>20))
+		} else {
+			parts = append(parts, fmt.Sprintf("内存 %d字节", l.Memory))
+		}
+	}
+	if l.NoFile > 0 {
+		parts = append(parts, fmt.Sprintf("文件数 %d", l.NoFile))
+	}
+	if l.NProc > 0 {
+		parts = append(parts, fmt.Sprintf("进程数 %d", l.NProc))
+	}
+	if len(parts) == 0 {
+		return "不限制"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// String 返回隔离方式的可读描述
+func (s Sandbox) String() string {
+	var parts []string
+	if s.ProcessGroup {
+		parts = append(parts, "独立进程组")
+	}
+	if s.NoNetwork {
+		parts = append(parts, "无网络")
+	}
+	if len(s.ReadOnlyPaths) > 0 {
+		parts = append(parts, "只读 "+strings.Join(s.ReadOnlyPaths, ","))
+	}
+	if len(parts) == 0 {
+		return "无"
+	}
+	return strings.Join(parts, ", ")
+}
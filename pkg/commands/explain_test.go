@@ -0,0 +1,145 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Lzww0608/ClixGo/pkg/alias"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 测试执行计划不执行命令并解析出程序、环境变量和执行方式
+func TestExplain(t *testing.T) {
+	dir := t.TempDir()
+	marker := filepath.Join(dir, "marker")
+
+	plan := Explain(Spec{Command: "GREETING=hi touch " + marker, Dir: dir, Env: []string{"A=1"}})
+	require.NoError(t, plan.Err)
+	assert.False(t, plan.Shell)
+	assert.Equal(t, []string{"touch", marker}, plan.Args)
+	assert.Equal(t, []string{"GREETING=hi", "A=1"}, plan.Env)
+	assert.Equal(t, dir, plan.Dir)
+	assert.Equal(t, DefaultTimeout(), plan.Timeout)
+	require.Len(t, plan.Binaries, 1)
+	assert.NoError(t, plan.Binaries[0].Err)
+	assert.Equal(t, "touch", filepath.Base(plan.Binaries[0].Path))
+	assert.True(t, plan.Policy.Allowed)
+
+	plan = Explain(Spec{Command: "cd /tmp && no-such-clixgo-binary > " + marker})
+	require.NoError(t, plan.Err)
+	assert.True(t, plan.Shell)
+	require.Len(t, plan.Binaries, 2)
+	assert.True(t, plan.Binaries[0].Builtin)
+	assert.Error(t, plan.Binaries[1].Err)
+
+	_, err := os.Stat(marker)
+	assert.True(t, os.IsNotExist(err), "生成执行计划时不应执行命令")
+
+	plan = Explain(Spec{Command: "echo 'unterminated"})
+	assert.Error(t, plan.Err)
+}
+
+// 测试相对路径和环境变量中的 PATH
+func TestExplainResolveBinary(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "run.sh")
+	require.NoError(t, os.WriteFile(script, []byte("#!/bin/sh\n"), 0755))
+
+	plan := Explain(Spec{Command: "./run.sh", Dir: dir})
+	require.Len(t, plan.Binaries, 1)
+	assert.Equal(t, script, plan.Binaries[0].Path)
+
+	plan = Explain(Spec{Command: "run.sh", Env: []string{"PATH=" + dir}})
+	assert.Equal(t, script, plan.Binaries[0].Path)
+
+	plan = Explain(Spec{Command: "run.sh", Env: []string{"PATH=" + t.TempDir()}})
+	assert.Error(t, plan.Binaries[0].Err)
+}
+
+// 测试执行计划应用命令策略并包含权限检查结果
+func TestExplainPolicy(t *testing.T) {
+	defer SetPolicyFunc(nil)
+	defer SetPermissionFunc(nil)
+	SetPolicyFunc(func(command string) (time.Duration, Limits, bool) {
+		return time.Minute, Limits{NoFile: 64}, true
+	})
+	SetPermissionFunc(func(command string) PolicyDecision {
+		return PolicyDecision{Policy: "rm", Reason: "命令被策略禁止执行"}
+	})
+
+	plan := Explain(Spec{Command: "rm -rf /tmp/x", Timeout: -1, Retry: RetryPolicy{MaxAttempts: 3}})
+	assert.Equal(t, time.Minute, plan.Timeout)
+	assert.Equal(t, uint64(64), plan.Limits.NoFile)
+	assert.Equal(t, 3, plan.Attempts)
+	assert.False(t, plan.Policy.Allowed)
+
+	var out bytes.Buffer
+	plan.Describe(&out, "  ")
+	assert.Contains(t, out.String(), `  安全策略: 匹配策略 "rm"，禁止执行: 命令被策略禁止执行`)
+	assert.Contains(t, out.String(), "  资源限制: 文件数 64")
+	assert.Contains(t, out.String(), "  重试: 最多执行 3 次")
+
+	// 管道不应用策略中的超时和资源限制
+	plans := ExplainPipeline([]string{"yes", "head -n 1"})
+	require.Len(t, plans, 2)
+	assert.Equal(t, time.Duration(-1), plans[0].Timeout)
+	assert.True(t, plans[0].Limits.IsZero())
+	assert.Equal(t, []string{"head", "-n", "1"}, plans[1].Args)
+}
+
+// 测试被策略禁止的命令在实际执行时同样被拒绝，而不只是在执行计划中标记
+func TestRunPermissionDenied(t *testing.T) {
+	defer SetPermissionFunc(nil)
+	SetPermissionFunc(func(command string) PolicyDecision {
+		if strings.Contains(command, "touch") {
+			return PolicyDecision{Policy: "touch", Reason: "命令被策略禁止执行"}
+		}
+		return PolicyDecision{Allowed: true}
+	})
+	file := filepath.Join(t.TempDir(), "created")
+
+	for _, spec := range []Spec{
+		{Command: "touch " + file},
+		{Args: []string{"touch", file}},
+		{Command: "true && touch " + file, Shell: true},
+	} {
+		spec.NoHistory = true
+		result, err := Run(context.Background(), spec)
+		assert.ErrorIs(t, err, ErrPermissionDenied)
+		assert.Nil(t, result)
+	}
+
+	_, err := RunPipeline(context.Background(), []string{"echo x", "touch " + file}, PipeOptions{})
+	assert.ErrorIs(t, err, ErrPermissionDenied)
+	_, err = RunShellPipeline(context.Background(), "echo x | touch "+file, PipeOptions{})
+	assert.ErrorIs(t, err, ErrPermissionDenied)
+	assert.NoFileExists(t, file, "被禁止的命令不应执行")
+
+	// 允许的命令照常执行
+	result, err := Run(context.Background(), Spec{Command: "echo ok", NoHistory: true})
+	require.NoError(t, err)
+	assert.Equal(t, "ok\n", result.Stdout)
+}
+
+// 测试别名缺少参数时执行计划包含错误
+func TestExplainCommandAliasError(t *testing.T) {
+	original := alias.GetAliasFilePath()
+	alias.SetAliasFilePath(filepath.Join(t.TempDir(), "aliases.json"))
+	defer alias.SetAliasFilePath(original)
+	require.NoError(t, alias.AddAlias("clixgo-test-gco", "git checkout $1"))
+	defer alias.RemoveAlias("clixgo-test-gco")
+
+	plan := ExplainCommand("clixgo-test-gco main", Spec{})
+	require.NoError(t, plan.Err)
+	assert.Equal(t, "git checkout main", plan.Command)
+
+	plan = ExplainCommand("clixgo-test-gco", Spec{})
+	assert.Error(t, plan.Err)
+	assert.Empty(t, plan.Binaries)
+}
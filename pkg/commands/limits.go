@@ -0,0 +1,119 @@
+package commands
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limits 子进程的资源限制(setrlimit)，字段为0表示不限制，仅支持 Linux。
+// 限制同时作用于命令启动的所有子进程
+type Limits struct {
+	CPUTime time.Duration // CPU时间上限(RLIMIT_CPU)，按秒向上取整
+	Memory  uint64        // 虚拟内存上限，单位字节(RLIMIT_AS)
+	NoFile  uint64        // 可打开的文件数上限(RLIMIT_NOFILE)
+	NProc   uint64        // 当前用户的进程数上限(RLIMIT_NPROC)，对 root 用户无效
+}
+
+// IsZero 判断是否没有设置任何限制
+func (l Limits) IsZero() bool {
+	return l == Limits{}
+}
+
+// Merge 合并两组限制，每一项取更严格的值
+func (l Limits) Merge(other Limits) Limits {
+	return Limits{
+		CPUTime: time.Duration(stricter(uint64(l.CPUTime), uint64(other.CPUTime))),
+		Memory:  stricter(l.Memory, other.Memory),
+		NoFile:  stricter(l.NoFile, other.NoFile),
+		NProc:   stricter(l.NProc, other.NProc),
+	}
+}
+
+// ParseMemory 解析内存大小，纯数字表示字节数，可带 K、M、G、T 后缀（按1024进位，可附加 B 或 iB）
+func ParseMemory(value string) (uint64, error) {
+	v := strings.ToUpper(strings.TrimSpace(value))
+	v = strings.TrimSuffix(strings.TrimSuffix(v, "B"), "I")
+
+	shift := 0
+	if n := len(v); n > 0 {
+		if i := strings.IndexByte("KMGT", v[n-1]); i >= 0 {
+			shift = 10 * (i + 1)
+			v = v[:n-1]
+		}
+	}
+	size, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+	if err != nil || size < 0 {
+		return 0, fmt.Errorf("无效的内存大小: %s", value)
+	}
+	return uint64(size * float64(uint64(1)<<shift)), nil
+}
+
+// stricter 返回两个限制中更小的非零值
+func stricter(a, b uint64) uint64 {
+	if a == 0 || (b != 0 && b < a) {
+		return b
+	}
+	return a
+}
+
+// Sandbox 子进程的隔离方式，仅支持 Linux
+type Sandbox struct {
+	ProcessGroup  bool     // 在独立的进程组中运行，超时或取消时杀死整个进程组
+	NoNetwork     bool     // 在新的网络命名空间中运行，只有未启用的回环网卡
+	ReadOnlyPaths []string // 在新的挂载命名空间中将这些路径重新绑定为只读（不递归到其下的挂载点）
+}
+
+// namespaces 判断是否需要创建新的命名空间
+func (s Sandbox) namespaces() bool {
+	return s.NoNetwork || len(s.ReadOnlyPaths) > 0
+}
+
+// isZero 判断是否没有设置任何隔离方式
+func (s Sandbox) isZero() bool {
+	return !s.ProcessGroup && !s.namespaces()
+}
+
+// PolicyFunc 按命令查找需要强制执行的超时时间和资源限制，没有对应策略时 ok 返回 false
+type PolicyFunc func(command string) (timeout time.Duration, limits Limits, ok bool)
+
+var (
+	policyFunc   PolicyFunc
+	policyFuncMu sync.RWMutex
+)
+
+// SetPolicyFunc 设置命令策略的查找函数，Run 执行每个命令前都会调用。
+// 策略中的超时和资源限制与 Spec 中的设置取更严格者，传入 nil 表示不再应用策略
+func SetPolicyFunc(fn PolicyFunc) {
+	policyFuncMu.Lock()
+	defer policyFuncMu.Unlock()
+	policyFunc = fn
+}
+
+// applyPolicy 将命令策略合并到 spec 中
+func applyPolicy(spec *Spec, command string) bool {
+	policyFuncMu.RLock()
+	fn := policyFunc
+	policyFuncMu.RUnlock()
+	if fn == nil {
+		return false
+	}
+
+	timeout, limits, ok := fn(command)
+	if !ok {
+		return false
+	}
+	if timeout > 0 {
+		current := spec.Timeout
+		if current == 0 {
+			current = DefaultTimeout()
+		}
+		if current < 0 || timeout < current {
+			spec.Timeout = timeout
+		}
+	}
+	spec.Limits = spec.Limits.Merge(limits)
+	return true
+}
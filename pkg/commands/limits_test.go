@@ -0,0 +1,78 @@
+package commands
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 测试资源限制合并取更严格的值
+func TestLimitsMerge(t *testing.T) {
+	a := Limits{CPUTime: 10 * time.Second, Memory: 1 << 30}
+	b := Limits{CPUTime: 5 * time.Second, NoFile: 64}
+
+	merged := a.Merge(b)
+	assert.Equal(t, 5*time.Second, merged.CPUTime)
+	assert.Equal(t, uint64(1<<30), merged.Memory)
+	assert.Equal(t, uint64(64), merged.NoFile)
+	assert.Zero(t, merged.NProc)
+	assert.True(t, Limits{}.IsZero())
+	assert.False(t, merged.IsZero())
+}
+
+// 测试内存大小解析
+func TestParseMemory(t *testing.T) {
+	tests := []struct {
+		value string
+		want  uint64
+	}{
+		{"4096", 4096},
+		{"512K", 512 << 10},
+		{"256M", 256 << 20},
+		{"1.5g", 3 << 29},
+		{"2GiB", 2 << 30},
+		{"1TB", 1 << 40},
+	}
+	for _, tt := range tests {
+		got, err := ParseMemory(tt.value)
+		require.NoError(t, err, tt.value)
+		assert.Equal(t, tt.want, got, tt.value)
+	}
+
+	_, err := ParseMemory("lots")
+	assert.Error(t, err)
+	_, err = ParseMemory("-1M")
+	assert.Error(t, err)
+}
+
+// 测试命令策略中的超时和资源限制
+func TestApplyPolicy(t *testing.T) {
+	defer SetPolicyFunc(nil)
+	SetPolicyFunc(func(command string) (time.Duration, Limits, bool) {
+		if command != "make test" {
+			return 0, Limits{}, false
+		}
+		return time.Minute, Limits{NoFile: 128}, true
+	})
+
+	spec := Spec{Timeout: time.Hour, Limits: Limits{NoFile: 256, NProc: 10}}
+	assert.True(t, applyPolicy(&spec, "make test"))
+	assert.Equal(t, time.Minute, spec.Timeout)
+	assert.Equal(t, Limits{NoFile: 128, NProc: 10}, spec.Limits)
+
+	// Spec 中更短的超时保持不变
+	spec = Spec{Timeout: time.Second}
+	applyPolicy(&spec, "make test")
+	assert.Equal(t, time.Second, spec.Timeout)
+
+	// 不限制超时时使用策略的最大执行时间
+	spec = Spec{Timeout: -1}
+	applyPolicy(&spec, "make test")
+	assert.Equal(t, time.Minute, spec.Timeout)
+
+	spec = Spec{}
+	assert.False(t, applyPolicy(&spec, "go test"))
+	assert.Zero(t, spec.Timeout)
+}
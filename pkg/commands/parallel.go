@@ -0,0 +1,231 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// 并行命令的最终状态
+const (
+	ParallelSuccess   = "success"
+	ParallelFailed    = "failed"
+	ParallelCancelled = "cancelled" // 运行中因其他命令失败被取消
+	ParallelSkipped   = "skipped"   // 因其他命令失败而未启动
+)
+
+// tagColors 各命令前缀轮流使用的颜色
+var tagColors = []color.Attribute{
+	color.FgCyan, color.FgGreen, color.FgYellow, color.FgBlue, color.FgMagenta, color.FgRed,
+}
+
+// ParallelOptions 并行执行选项
+type ParallelOptions struct {
+	Jobs      int           // 最大并发数，小于等于0时使用CPU核数
+	KeepGoing bool          // 某个命令失败后继续执行其余命令，否则取消其余命令
+	Shell     bool          // 每个命令都交给进程内解释器执行
+	Timeout   time.Duration // 单个命令的超时时间，0 使用默认超时
+	Retry     RetryPolicy   // 单个命令失败后的重试策略
+	Limits    Limits        // 单个命令的资源限制
+	Sandbox   Sandbox       // 单个命令的进程组与命名空间隔离
+	Output    io.Writer     // 各命令输出按行加前缀实时写入，为空时丢弃
+	Color     bool          // 前缀使用颜色区分
+}
+
+// ParallelResult 单个并行命令的执行结果
+type ParallelResult struct {
+	Index    int           `json:"index"`
+	Command  string        `json:"command"`
+	Status   string        `json:"status"`
+	ExitCode int           `json:"exit_code"`
+	Attempts int           `json:"attempts"`
+	Duration time.Duration `json:"duration"`
+	Err      error         `json:"-"`
+}
+
+// RunParallel 以最多 opts.Jobs 的并发度执行多个命令，输出按行加上命令标签实时写出。
+// 未设置 KeepGoing 时第一个失败会取消正在运行的命令并跳过尚未启动的命令。
+// 返回与 commands 顺序一致的结果，以及第一个失败命令的错误
+func RunParallel(ctx context.Context, commands []string, opts ParallelOptions) ([]ParallelResult, error) {
+	results := make([]ParallelResult, len(commands))
+	if len(commands) == 0 {
+		return results, nil
+	}
+
+	jobs := opts.Jobs
+	if jobs <= 0 {
+		jobs = runtime.NumCPU()
+	}
+
+	parent := ctx
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var outMu sync.Mutex
+	var failMu sync.Mutex
+	var firstErr error
+
+	sem := make(chan struct{}, jobs)
+	var wg sync.WaitGroup
+
+	for i, command := range commands {
+		results[i] = ParallelResult{Index: i, Command: command, Status: ParallelSkipped}
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, command string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			out := NewPrefixWriter(opts.Output, &outMu, commandTag(i, command, opts.Color))
+			var result *Result
+			expanded, err := expandAlias(command)
+			if err == nil {
+				result, err = Run(ctx, Spec{
+					Command: expanded,
+					Shell:   opts.Shell,
+					Timeout: opts.Timeout,
+					Retry:   opts.Retry,
+					Limits:  opts.Limits,
+					Sandbox: opts.Sandbox,
+					Stdout:  out,
+					Stderr:  out,
+				})
+			}
+			out.Flush()
+
+			r := &results[i]
+			if result != nil {
+				r.ExitCode = result.ExitCode
+				r.Attempts = result.Attempts
+				r.Duration = result.Duration
+			}
+			if err == nil {
+				r.Status = ParallelSuccess
+				return
+			}
+			r.Err = err
+
+			failMu.Lock()
+			defer failMu.Unlock()
+			if firstErr == nil && ctx.Err() == nil {
+				r.Status = ParallelFailed
+				firstErr = fmt.Errorf("命令 %q 执行失败: %v", command, err)
+				if !opts.KeepGoing {
+					cancel()
+				}
+				return
+			}
+			if ctx.Err() != nil && !opts.KeepGoing {
+				r.Status = ParallelCancelled
+				return
+			}
+			r.Status = ParallelFailed
+		}(i, command)
+	}
+
+	wg.Wait()
+	if firstErr == nil && parent.Err() != nil {
+		return results, parent.Err()
+	}
+	return results, firstErr
+}
+
+// ExplainParallel 生成并行执行多个命令的执行计划，不执行任何命令
+func ExplainParallel(commands []string, opts ParallelOptions) []*Plan {
+	plans := make([]*Plan, len(commands))
+	for i, command := range commands {
+		plans[i] = ExplainCommand(command, Spec{
+			Shell:   opts.Shell,
+			Timeout: opts.Timeout,
+			Retry:   opts.Retry,
+			Limits:  opts.Limits,
+			Sandbox: opts.Sandbox,
+		})
+	}
+	return plans
+}
+
+// commandTag 生成命令输出前缀，形如 [1:ping]
+func commandTag(index int, command string, colored bool) string {
+	name := command
+	if fields := strings.Fields(command); len(fields) > 0 {
+		name = fields[0]
+	}
+	return ColorTag(fmt.Sprintf("[%d:%s]", index+1, name), index, colored) + " "
+}
+
+// ColorTag 按序号为输出标签选择颜色，colored 为假时原样返回
+func ColorTag(tag string, index int, colored bool) string {
+	if colored {
+		c := color.New(tagColors[index%len(tagColors)])
+		c.EnableColor()
+		tag = c.Sprint(tag)
+	}
+	return tag
+}
+
+// PrefixWriter 行缓冲的输出：只在得到完整一行后加上前缀整体写出，
+// 多个命令共享同一个锁，保证行之间不会互相穿插。写完后需调用 Flush
+type PrefixWriter struct {
+	w      io.Writer
+	mu     *sync.Mutex
+	prefix string
+	buf    bytes.Buffer
+}
+
+// NewPrefixWriter 创建写入 w 的带前缀输出，mu 由共享同一个 w 的所有 PrefixWriter 共用
+func NewPrefixWriter(w io.Writer, mu *sync.Mutex, prefix string) *PrefixWriter {
+	return &PrefixWriter{w: w, mu: mu, prefix: prefix}
+}
+
+func (p *PrefixWriter) Write(data []byte) (int, error) {
+	if p.w == nil {
+		return len(data), nil
+	}
+
+	p.buf.Write(data)
+	for {
+		line, err := p.buf.ReadBytes('\n')
+		if err != nil {
+			// 不完整的行放回缓冲区等待后续数据
+			rest := append([]byte(nil), line...)
+			p.buf.Reset()
+			p.buf.Write(rest)
+			break
+		}
+		p.writeLine(line)
+	}
+	return len(data), nil
+}
+
+// Flush 写出缓冲区中剩余的不完整行
+func (p *PrefixWriter) Flush() {
+	if p.w == nil || p.buf.Len() == 0 {
+		return
+	}
+	line := append(p.buf.Bytes(), '\n')
+	p.buf.Reset()
+	p.writeLine(line)
+}
+
+func (p *PrefixWriter) writeLine(line []byte) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	io.WriteString(p.w, p.prefix)
+	p.w.Write(line)
+}
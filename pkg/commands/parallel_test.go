@@ -0,0 +1,89 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 测试输出按行加前缀且不互相穿插
+func TestRunParallelPrefixedOutput(t *testing.T) {
+	setupTestEnvironment()
+
+	var out bytes.Buffer
+	results, err := RunParallel(context.Background(), []string{
+		"sh -c 'echo a1; sleep 0.05; echo a2'",
+		"printf 'b1\\nb2'",
+	}, ParallelOptions{Output: &out, KeepGoing: true})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.ElementsMatch(t, []string{"[1:sh] a1", "[1:sh] a2", "[2:printf] b1", "[2:printf] b2"}, lines)
+	for _, r := range results {
+		assert.Equal(t, ParallelSuccess, r.Status)
+		assert.Equal(t, 0, r.ExitCode)
+	}
+}
+
+// 测试并发数限制
+func TestRunParallelJobsLimit(t *testing.T) {
+	setupTestEnvironment()
+
+	var running, peak int32
+	var mu sync.Mutex
+	out := writerFunc(func(p []byte) (int, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, line := range strings.Split(strings.TrimSpace(string(p)), "\n") {
+			if strings.HasSuffix(line, "start") {
+				if n := atomic.AddInt32(&running, 1); n > peak {
+					peak = n
+				}
+			} else if strings.HasSuffix(line, "end") {
+				atomic.AddInt32(&running, -1)
+			}
+		}
+		return len(p), nil
+	})
+
+	commands := make([]string, 6)
+	for i := range commands {
+		commands[i] = "sh -c 'echo start; sleep 0.1; echo end'"
+	}
+	_, err := RunParallel(context.Background(), commands, ParallelOptions{Jobs: 2, Output: out})
+	require.NoError(t, err)
+	assert.LessOrEqual(t, peak, int32(2))
+}
+
+// 测试快速失败取消其余命令，以及 KeepGoing 继续执行
+func TestRunParallelFailFast(t *testing.T) {
+	setupTestEnvironment()
+
+	commands := []string{"sh -c 'sleep 0.1; exit 3'", "sleep 5", "echo later"}
+
+	start := time.Now()
+	results, err := RunParallel(context.Background(), commands, ParallelOptions{Jobs: 2})
+	require.Error(t, err)
+	assert.Less(t, time.Since(start), 5*time.Second)
+	assert.Equal(t, ParallelFailed, results[0].Status)
+	assert.Equal(t, 3, results[0].ExitCode)
+	assert.Equal(t, ParallelCancelled, results[1].Status)
+	assert.Equal(t, ParallelSkipped, results[2].Status)
+
+	results, err = RunParallel(context.Background(), []string{"false", "echo ok"}, ParallelOptions{Jobs: 1, KeepGoing: true})
+	require.Error(t, err)
+	assert.Equal(t, ParallelFailed, results[0].Status)
+	assert.Equal(t, ParallelSuccess, results[1].Status)
+}
+
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) { return f(p) }
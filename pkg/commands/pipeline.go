@@ -0,0 +1,250 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/Lzww0608/ClixGo/pkg/shell"
+)
+
+// 命令无法启动时使用的退出码，与shell的 "command not found" 保持一致
+const exitCodeNotFound = 127
+
+// PipeOptions 管道执行选项
+type PipeOptions struct {
+	Stdin    io.Reader // 第一个阶段的输入
+	Stdout   io.Writer // 最后一个阶段的输出，数据产生时即写入
+	Stderr   io.Writer // 所有阶段的错误输出，为空时丢弃
+	Pipefail bool      // 任一阶段失败即视为管道失败，否则只看最后一个阶段
+}
+
+// StageResult 管道中单个阶段的执行结果
+type StageResult struct {
+	Command  string `json:"command"`
+	ExitCode int    `json:"exit_code"`
+	Signal   string `json:"signal,omitempty"`
+	Err      error  `json:"-"`
+}
+
+// PipelineResult 管道执行结果
+type PipelineResult struct {
+	Stages   []StageResult `json:"stages"`
+	ExitCode int           `json:"exit_code"`
+}
+
+// RunPipeline 并发启动管道的所有阶段，阶段之间通过操作系统管道连接，
+// 数据边产生边流向下一阶段，因此可以处理无限输出的生产者和大量数据。
+// 管道的退出码在 Pipefail 时取最右侧失败阶段的退出码，否则取最后一个阶段的退出码。
+// 下游提前退出导致上游因 SIGPIPE 终止不视为失败。任一阶段被安全策略禁止时不启动任何阶段
+func RunPipeline(ctx context.Context, commands []string, opts PipeOptions) (*PipelineResult, error) {
+	if len(commands) == 0 {
+		return nil, fmt.Errorf("没有提供命令")
+	}
+
+	// 启动任何进程之前先完成校验和解析
+	parsed := make([]*shell.Command, len(commands))
+	for i, command := range commands {
+		if strings.TrimSpace(command) == "" {
+			return nil, fmt.Errorf("空命令")
+		}
+		if err := permit(command); err != nil {
+			return nil, err
+		}
+		cmd, simple, err := shell.ParseCommand(command)
+		if err != nil {
+			return nil, err
+		}
+		if simple {
+			parsed[i] = cmd
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	result := &PipelineResult{Stages: make([]StageResult, len(commands))}
+	var wg sync.WaitGroup
+	var stdin io.Reader = opts.Stdin
+	var stdinPipe *os.File
+	var setupErr error
+
+	for i, command := range commands {
+		stage := &result.Stages[i]
+		stage.Command = command
+
+		stdout := opts.Stdout
+		var readPipe, writePipe *os.File
+		if i < len(commands)-1 {
+			var err error
+			readPipe, writePipe, err = os.Pipe()
+			if err != nil {
+				setupErr = fmt.Errorf("创建管道失败: %v", err)
+				if stdinPipe != nil {
+					stdinPipe.Close()
+				}
+				break
+			}
+			stdout = writePipe
+		}
+
+		// 本阶段持有的管道端，阶段启动（外部命令）或结束（解释器）后关闭，
+		// 使下游能读到 EOF、上游在下游退出后收到 SIGPIPE
+		var owned []*os.File
+		if stdinPipe != nil {
+			owned = append(owned, stdinPipe)
+		}
+		if writePipe != nil {
+			owned = append(owned, writePipe)
+		}
+
+		startStage(ctx, stage, parsed[i], stdin, stdout, opts.Stderr, owned, &wg)
+
+		if readPipe != nil {
+			stdin, stdinPipe = readPipe, readPipe
+		}
+	}
+
+	if setupErr != nil {
+		cancel()
+	}
+	wg.Wait()
+	if setupErr != nil {
+		return result, setupErr
+	}
+
+	failed := -1
+	last := len(result.Stages) - 1
+	if opts.Pipefail {
+		for i := last; i >= 0; i-- {
+			if result.Stages[i].ExitCode != 0 {
+				failed = i
+				break
+			}
+		}
+	} else if result.Stages[last].ExitCode != 0 {
+		failed = last
+	}
+
+	if failed >= 0 {
+		stage := result.Stages[failed]
+		result.ExitCode = stage.ExitCode
+		if stage.Err != nil && stage.ExitCode < 0 {
+			return result, fmt.Errorf("管道命令 %q 执行失败: %v", stage.Command, stage.Err)
+		}
+		return result, fmt.Errorf("管道命令 %q 执行失败，退出码 %d", stage.Command, stage.ExitCode)
+	}
+	return result, nil
+}
+
+// startStage 启动单个阶段。parsed 为空时交给进程内解释器执行
+func startStage(ctx context.Context, stage *StageResult, parsed *shell.Command, stdin io.Reader, stdout, stderr io.Writer, owned []*os.File, wg *sync.WaitGroup) {
+	closeOwned := func() {
+		for _, f := range owned {
+			f.Close()
+		}
+	}
+
+	if parsed == nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := shell.Run(ctx, stage.Command, shell.Options{Stdin: stdin, Stdout: stdout, Stderr: stderr})
+			closeOwned()
+			stage.setResult(err, false)
+		}()
+		return
+	}
+
+	cmd := exec.CommandContext(ctx, parsed.Args[0], parsed.Args[1:]...)
+	if len(parsed.Env) > 0 {
+		cmd.Env = append(os.Environ(), parsed.Env...)
+	}
+	cmd.Stdin = stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	if err := cmd.Start(); err != nil {
+		closeOwned()
+		stage.ExitCode = exitCodeNotFound
+		stage.Err = err
+		if stderr != nil {
+			fmt.Fprintln(stderr, err)
+		}
+		return
+	}
+	closeOwned()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		stage.setResult(cmd.Wait(), ctx.Err() != nil)
+	}()
+}
+
+// setResult 根据阶段的执行错误设置退出码，因 SIGPIPE 终止且不是被取消时视为成功
+func (s *StageResult) setResult(err error, cancelled bool) {
+	s.Err = err
+	if err == nil {
+		return
+	}
+
+	s.ExitCode, s.Signal = exitStatus(err)
+	if s.Signal == syscall.SIGPIPE.String() && !cancelled {
+		s.ExitCode = 0
+		s.Err = nil
+	}
+}
+
+// exitStatus 从执行错误中提取退出码和终止信号。被信号终止时退出码为 128+信号值，
+// 命令无法启动时为 127，无法判断时为 -1
+func exitStatus(err error) (int, string) {
+	if err == nil {
+		return 0, ""
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+			return 128 + int(status.Signal()), status.Signal().String()
+		}
+		return exitErr.ExitCode(), ""
+	}
+	var execErr *exec.Error
+	if errors.As(err, &execErr) || errors.Is(err, os.ErrNotExist) {
+		return exitCodeNotFound, ""
+	}
+	if code, ok := shell.ExitCode(err); ok {
+		return code, ""
+	}
+	return -1, ""
+}
+
+// RunShellPipeline 使用进程内解释器执行完整的管道脚本并流式输出，
+// Pipefail 时启用 set -o pipefail。返回脚本的退出码
+func RunShellPipeline(ctx context.Context, script string, opts PipeOptions) (int, error) {
+	if strings.TrimSpace(script) == "" {
+		return 0, fmt.Errorf("没有提供命令")
+	}
+	if err := permit(script); err != nil {
+		return -1, err
+	}
+	if opts.Pipefail {
+		script = "set -o pipefail\n" + script
+	}
+
+	err := shell.Run(ctx, script, shell.Options{Stdin: opts.Stdin, Stdout: opts.Stdout, Stderr: opts.Stderr})
+	if err == nil {
+		return 0, nil
+	}
+	if code, ok := shell.ExitCode(err); ok {
+		return code, fmt.Errorf("管道命令执行失败，退出码 %d", code)
+	}
+	return -1, err
+}
@@ -0,0 +1,83 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 测试无限输出的生产者在下游退出后正常结束
+func TestRunPipelineInfiniteProducer(t *testing.T) {
+	var out bytes.Buffer
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := RunPipeline(ctx, []string{"yes", "head -n 3"}, PipeOptions{Stdout: &out, Pipefail: true})
+	require.NoError(t, err)
+	assert.Equal(t, "y\ny\ny\n", out.String())
+	assert.Equal(t, 0, result.ExitCode)
+	require.Len(t, result.Stages, 2)
+	assert.Equal(t, 0, result.Stages[0].ExitCode)
+}
+
+// 测试大量数据流经管道
+func TestRunPipelineLargeData(t *testing.T) {
+	var out bytes.Buffer
+	_, err := RunPipeline(context.Background(), []string{"seq 1 200000", "tail -n 1"}, PipeOptions{Stdout: &out})
+	require.NoError(t, err)
+	assert.Equal(t, "200000", strings.TrimSpace(out.String()))
+}
+
+// 测试 pipefail 语义与各阶段退出码
+func TestRunPipelinePipefail(t *testing.T) {
+	commands := []string{"sh -c 'exit 3'", "cat", "true"}
+
+	result, err := RunPipeline(context.Background(), commands, PipeOptions{Pipefail: true})
+	require.Error(t, err)
+	assert.Equal(t, 3, result.ExitCode)
+	assert.Equal(t, []int{3, 0, 0}, stageCodes(result))
+
+	result, err = RunPipeline(context.Background(), commands, PipeOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 0, result.ExitCode)
+
+	// 无法启动的命令使用 127
+	result, err = RunPipeline(context.Background(), []string{"echo a", "invalidcmd_xyz"}, PipeOptions{Pipefail: true})
+	require.Error(t, err)
+	assert.Equal(t, 127, result.ExitCode)
+}
+
+// 测试输入与解释器阶段
+func TestRunPipelineStdinAndShellStage(t *testing.T) {
+	var out bytes.Buffer
+	_, err := RunPipeline(context.Background(), []string{"tr a-z A-Z", "grep -v B && true"}, PipeOptions{
+		Stdin:  strings.NewReader("a\nb\nc\n"),
+		Stdout: &out,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "A\nC\n", out.String())
+}
+
+// 测试解释器模式的 pipefail
+func TestRunShellPipeline(t *testing.T) {
+	code, err := RunShellPipeline(context.Background(), "false | true", PipeOptions{Pipefail: true})
+	assert.Error(t, err)
+	assert.Equal(t, 1, code)
+
+	code, err = RunShellPipeline(context.Background(), "false | true", PipeOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, code)
+}
+
+func stageCodes(result *PipelineResult) []int {
+	codes := make([]int, len(result.Stages))
+	for i, stage := range result.Stages {
+		codes[i] = stage.ExitCode
+	}
+	return codes
+}
@@ -0,0 +1,135 @@
+package commands
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 默认的退避倍数
+const defaultBackoffMultiplier = 2.0
+
+var (
+	defaultTimeout   = defaultCmdTimeout
+	defaultTimeoutMu sync.RWMutex
+)
+
+// SetDefaultTimeout 设置未指定超时的命令使用的默认超时时间，小于等于0表示不限制
+func SetDefaultTimeout(timeout time.Duration) {
+	defaultTimeoutMu.Lock()
+	defer defaultTimeoutMu.Unlock()
+	if timeout <= 0 {
+		timeout = -1
+	}
+	defaultTimeout = timeout
+}
+
+// DefaultTimeout 返回当前的默认超时时间，负数表示不限制
+func DefaultTimeout() time.Duration {
+	defaultTimeoutMu.RLock()
+	defer defaultTimeoutMu.RUnlock()
+	return defaultTimeout
+}
+
+// ParseTimeout 解析超时配置：整数表示秒数，字符串可带单位（如 "90s"、"5m"）
+func ParseTimeout(value interface{}) (time.Duration, error) {
+	switch v := value.(type) {
+	case int:
+		return time.Duration(v) * time.Second, nil
+	case int64:
+		return time.Duration(v) * time.Second, nil
+	case float64:
+		return time.Duration(v * float64(time.Second)), nil
+	case time.Duration:
+		return v, nil
+	case string:
+		v = strings.TrimSpace(v)
+		if seconds, err := strconv.ParseFloat(v, 64); err == nil {
+			return time.Duration(seconds * float64(time.Second)), nil
+		}
+		return time.ParseDuration(v)
+	default:
+		return 0, fmt.Errorf("无效的超时时间: %v", value)
+	}
+}
+
+// RetryPolicy 命令失败后的重试策略。RetryOnExitCodes 和 RetryOnOutput 都为空时
+// 任何失败都会重试；任一条件满足（退出码在列表中或输出匹配正则）时才重试
+type RetryPolicy struct {
+	MaxAttempts      int              // 最多执行次数（含第一次），小于等于1表示不重试
+	InitialDelay     time.Duration    // 第一次重试前的等待时间
+	MaxDelay         time.Duration    // 等待时间上限，0 表示不限制
+	Multiplier       float64          // 每次重试等待时间的增长倍数，0 使用默认值2
+	Jitter           float64          // 随机抖动比例(0~1)，实际等待时间在 delay*(1±Jitter) 之间
+	RetryOnExitCodes []int            // 只在这些退出码时重试
+	RetryOnOutput    []*regexp.Regexp // 只在输出匹配这些正则时重试
+}
+
+// CompileRetryPatterns 编译重试匹配的输出正则
+func CompileRetryPatterns(patterns []string) ([]*regexp.Regexp, error) {
+	regexps := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("无效的重试匹配正则 %q: %v", p, err)
+		}
+		regexps = append(regexps, re)
+	}
+	return regexps, nil
+}
+
+// attempts 返回最多执行次数
+func (p RetryPolicy) attempts() int {
+	if p.MaxAttempts < 1 {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+// ShouldRetry 判断一次失败的执行是否应该重试
+func (p RetryPolicy) ShouldRetry(result *Result) bool {
+	if result == nil {
+		// 解析失败或空命令，重试没有意义
+		return false
+	}
+	if len(p.RetryOnExitCodes) == 0 && len(p.RetryOnOutput) == 0 {
+		return true
+	}
+	for _, code := range p.RetryOnExitCodes {
+		if result.ExitCode == code {
+			return true
+		}
+	}
+	for _, re := range p.RetryOnOutput {
+		if re.MatchString(result.Output) {
+			return true
+		}
+	}
+	return false
+}
+
+// Delay 返回第 attempt 次执行失败后的等待时间（attempt 从1开始），按指数退避并加入抖动
+func (p RetryPolicy) Delay(attempt int) time.Duration {
+	if p.InitialDelay <= 0 {
+		return 0
+	}
+	multiplier := p.Multiplier
+	if multiplier <= 0 {
+		multiplier = defaultBackoffMultiplier
+	}
+
+	delay := float64(p.InitialDelay) * math.Pow(multiplier, float64(attempt-1))
+	if p.MaxDelay > 0 && delay > float64(p.MaxDelay) {
+		delay = float64(p.MaxDelay)
+	}
+	if p.Jitter > 0 {
+		jitter := math.Min(p.Jitter, 1)
+		delay *= 1 - jitter + rand.Float64()*2*jitter
+	}
+	return time.Duration(delay)
+}
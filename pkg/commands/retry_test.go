@@ -0,0 +1,104 @@
+package commands
+
+import (
+	"context"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 测试指数退避与抖动
+func TestRetryPolicyDelay(t *testing.T) {
+	p := RetryPolicy{InitialDelay: 100 * time.Millisecond, MaxDelay: 350 * time.Millisecond}
+	assert.Equal(t, 100*time.Millisecond, p.Delay(1))
+	assert.Equal(t, 200*time.Millisecond, p.Delay(2))
+	assert.Equal(t, 350*time.Millisecond, p.Delay(3))
+
+	p = RetryPolicy{InitialDelay: 100 * time.Millisecond, Multiplier: 3, Jitter: 0.5}
+	for i := 0; i < 20; i++ {
+		d := p.Delay(2)
+		assert.GreaterOrEqual(t, d, 150*time.Millisecond)
+		assert.LessOrEqual(t, d, 450*time.Millisecond)
+	}
+
+	assert.Equal(t, time.Duration(0), RetryPolicy{}.Delay(3))
+}
+
+// 测试重试条件
+func TestRetryPolicyShouldRetry(t *testing.T) {
+	failed := &Result{ExitCode: 2, Output: "connection reset by peer"}
+
+	assert.True(t, RetryPolicy{}.ShouldRetry(failed))
+	assert.False(t, RetryPolicy{}.ShouldRetry(nil))
+	assert.True(t, RetryPolicy{RetryOnExitCodes: []int{1, 2}}.ShouldRetry(failed))
+	assert.False(t, RetryPolicy{RetryOnExitCodes: []int{1}}.ShouldRetry(failed))
+	assert.True(t, RetryPolicy{RetryOnOutput: []*regexp.Regexp{regexp.MustCompile(`reset|timeout`)}}.ShouldRetry(failed))
+	assert.False(t, RetryPolicy{RetryOnOutput: []*regexp.Regexp{regexp.MustCompile(`refused`)}}.ShouldRetry(failed))
+
+	_, err := CompileRetryPatterns([]string{"("})
+	assert.Error(t, err)
+}
+
+// 测试失败后重试直到成功
+func TestRunWithRetry(t *testing.T) {
+	setupTestEnvironment()
+	counter := filepath.Join(t.TempDir(), "count")
+
+	result, err := Run(context.Background(), Spec{
+		Command:   `echo x >> ` + counter + ` && test $(wc -l < ` + counter + `) -ge 3`,
+		Retry:     RetryPolicy{MaxAttempts: 5, InitialDelay: time.Millisecond},
+		NoHistory: true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.Attempts)
+
+	// 退出码不在重试列表中时不重试
+	result, err = Run(context.Background(), Spec{
+		Command:   "sh -c 'exit 4'",
+		Retry:     RetryPolicy{MaxAttempts: 3, RetryOnExitCodes: []int{1}},
+		NoHistory: true,
+	})
+	require.Error(t, err)
+	assert.Equal(t, 1, result.Attempts)
+	assert.Equal(t, 4, result.ExitCode)
+}
+
+// 测试超时配置
+func TestTimeoutConfig(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		want  time.Duration
+	}{
+		{30, 30 * time.Second},
+		{1.5, 1500 * time.Millisecond},
+		{"45", 45 * time.Second},
+		{"2m", 2 * time.Minute},
+	}
+	for _, tt := range tests {
+		got, err := ParseTimeout(tt.value)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, got)
+	}
+	_, err := ParseTimeout("soon")
+	assert.Error(t, err)
+
+	defer SetDefaultTimeout(defaultCmdTimeout)
+	SetDefaultTimeout(100 * time.Millisecond)
+	assert.Equal(t, 100*time.Millisecond, DefaultTimeout())
+
+	setupTestEnvironment()
+	result, err := Run(context.Background(), Spec{Command: "sleep 2", NoHistory: true})
+	require.Error(t, err)
+	assert.True(t, result.TimedOut)
+
+	// 单个命令的超时覆盖默认值
+	_, err = Run(context.Background(), Spec{Command: "sleep 0.2", Timeout: time.Second, NoHistory: true})
+	assert.NoError(t, err)
+
+	SetDefaultTimeout(0)
+	assert.True(t, DefaultTimeout() < 0)
+}
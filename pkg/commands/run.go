@@ -0,0 +1,276 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Lzww0608/ClixGo/pkg/history"
+	"github.com/Lzww0608/ClixGo/pkg/logger"
+	"github.com/Lzww0608/ClixGo/pkg/shell"
+	"go.uber.org/zap"
+)
+
+// 进程退出后等待其输出管道关闭的最长时间，避免后台子进程占用管道导致 Wait 挂起
+const outputWaitDelay = time.Second
+
+// Spec 描述一次命令执行
+type Spec struct {
+	Command   string        // 命令字符串，按shell语法解析
+	Args      []string      // 非空时直接执行该参数列表，不经过shell解析
+	Shell     bool          // 使用进程内解释器执行整个 Command
+	Timeout   time.Duration // 单次执行的超时时间，0 使用默认超时时间，负数表示不限制
+	Retry     RetryPolicy   // 失败后的重试策略，重试时不会重放已读取的标准输入
+	Env       []string      // 追加到当前环境变量之后，形如 KEY=VALUE
+	Dir       string        // 工作目录，为空时使用当前目录
+	Stdin     io.Reader     // 标准输入，为空时不提供输入
+	Stdout    io.Writer     // 除记录到 Result 外，标准输出同时实时写入该 Writer
+	Stderr    io.Writer     // 除记录到 Result 外，标准错误同时实时写入该 Writer
+	NoHistory bool          // 不记录命令历史
+	Limits    Limits        // 资源限制，与命令策略中的限制取更严格者
+	Sandbox   Sandbox       // 进程组与命名空间隔离
+}
+
+// Result 命令执行结果
+type Result struct {
+	Command   string        `json:"command"`
+	ExitCode  int           `json:"exit_code"`
+	Stdout    string        `json:"stdout"`
+	Stderr    string        `json:"stderr"`
+	Output    string        `json:"output"` // 按产生顺序合并的标准输出和标准错误
+	Signal    string        `json:"signal,omitempty"`
+	TimedOut  bool          `json:"timed_out"`
+	Attempts  int           `json:"attempts"`
+	StartTime time.Time     `json:"start_time"`
+	Duration  time.Duration `json:"duration"`
+}
+
+// Success 判断命令是否成功退出
+func (r *Result) Success() bool {
+	return r.ExitCode == 0 && !r.TimedOut
+}
+
+// Run 按 spec 执行命令并返回结构化结果。简单命令直接执行，
+// 包含 &&、||、重定向等语法的命令或 spec.Shell 为真时交给进程内解释器。
+// 失败时按 spec.Retry 重试，Result 描述最后一次执行，Duration 包含所有重试及等待时间。
+// 命令成功启动后 Result 总是非空；命令以非零状态退出、被信号终止或超时时同时返回错误。
+// 命令被安全策略禁止时不执行，返回包装了 ErrPermissionDenied 的错误
+func Run(ctx context.Context, spec Spec) (*Result, error) {
+	command := spec.Command
+	if len(spec.Args) > 0 {
+		command = strings.Join(spec.Args, " ")
+	}
+
+	if err := permit(command); err != nil {
+		logger.Error("命令被安全策略禁止执行", zap.String("command", command), zap.Error(err))
+		return nil, err
+	}
+
+	if applyPolicy(&spec, command) {
+		logger.Info("应用命令策略",
+			zap.String("command", command),
+			zap.Duration("timeout", spec.Timeout),
+			zap.Any("limits", spec.Limits))
+	}
+
+	startTime := time.Now()
+	cmdHistory := &history.CommandHistory{
+		Command:   command,
+		StartTime: startTime,
+		ExitCode:  -1,
+		Dir:       spec.Dir,
+	}
+
+	var result *Result
+	var err error
+	attempts := spec.Retry.attempts()
+	for attempt := 1; ; attempt++ {
+		result, err = runOnce(ctx, spec, command)
+		if result != nil {
+			result.Attempts = attempt
+		}
+		if err == nil || attempt >= attempts || !spec.Retry.ShouldRetry(result) {
+			break
+		}
+
+		delay := spec.Retry.Delay(attempt)
+		logger.Info("命令执行失败，准备重试",
+			zap.String("command", command),
+			zap.Int("attempt", attempt),
+			zap.Duration("delay", delay),
+			zap.Error(err))
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	cmdHistory.EndTime = time.Now()
+	cmdHistory.Duration = cmdHistory.EndTime.Sub(startTime).String()
+	if result != nil {
+		result.StartTime = startTime
+		result.Duration = cmdHistory.EndTime.Sub(startTime)
+		cmdHistory.Output = result.Output
+		cmdHistory.ExitCode = result.ExitCode
+	}
+
+	if err != nil {
+		cmdHistory.Status = "failed"
+		logger.Error("命令执行失败", zap.Error(err))
+	} else {
+		cmdHistory.Status = "success"
+		logger.Info("命令执行成功", zap.String("output", result.Output))
+	}
+
+	if !spec.NoHistory {
+		if err := history.SaveHistory(cmdHistory); err != nil {
+			logger.Error("保存命令历史失败", zap.Error(err))
+		}
+	}
+
+	return result, err
+}
+
+// runOnce 执行一次命令。命令为空或解析失败时 Result 为空
+func runOnce(ctx context.Context, spec Spec, command string) (*Result, error) {
+	if strings.TrimSpace(command) == "" {
+		return nil, fmt.Errorf("空命令")
+	}
+
+	args, env, useShell, err := resolveCommand(spec, command)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := spec.Timeout
+	if timeout == 0 {
+		timeout = DefaultTimeout()
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	result := &Result{Command: command, StartTime: time.Now()}
+	out := &capture{}
+	stdout := &captureWriter{c: out, tee: spec.Stdout}
+	stderr := &captureWriter{c: out, stderr: true, tee: spec.Stderr}
+
+	var cmdEnv []string
+	if len(env) > 0 {
+		cmdEnv = append(os.Environ(), env...)
+	}
+
+	// 设置了资源限制或隔离时，即使是解释器执行的脚本也要放到子进程中
+	isolated := !spec.Limits.IsZero() || !spec.Sandbox.isZero()
+	if useShell && !isolated {
+		err = shell.Run(ctx, command, shell.Options{
+			Stdin:  spec.Stdin,
+			Stdout: stdout,
+			Stderr: stderr,
+			Env:    cmdEnv,
+			Dir:    spec.Dir,
+		})
+	} else {
+		var cmd *exec.Cmd
+		if isolated {
+			var script string
+			if useShell {
+				script = command
+			}
+			cmd, err = isolatedCommand(ctx, spec, args, script, cmdEnv)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			cmd = exec.CommandContext(ctx, args[0], args[1:]...)
+			cmd.Env = cmdEnv
+		}
+		cmd.Dir = spec.Dir
+		cmd.Stdin = spec.Stdin
+		cmd.Stdout = stdout
+		cmd.Stderr = stderr
+		cmd.WaitDelay = outputWaitDelay
+		err = cmd.Run()
+	}
+
+	result.Duration = time.Since(result.StartTime)
+	result.Stdout = out.stdout.String()
+	result.Stderr = out.stderr.String()
+	result.Output = out.combined.String()
+	if err != nil {
+		result.ExitCode, result.Signal = exitStatus(err)
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			result.TimedOut = true
+			err = fmt.Errorf("命令执行超时(%v)", timeout)
+		}
+	}
+	return result, err
+}
+
+// resolveCommand 决定命令的执行方式：简单命令解析为参数列表和变量赋值后直接执行，
+// 其余命令交给进程内解释器。返回的 env 已追加 spec.Env
+func resolveCommand(spec Spec, command string) (args, env []string, useShell bool, err error) {
+	args = spec.Args
+	useShell = spec.Shell
+	if len(args) == 0 && !useShell {
+		parseOpts := shell.Options{Dir: spec.Dir}
+		if len(spec.Env) > 0 {
+			parseOpts.Env = append(os.Environ(), spec.Env...)
+		}
+		parsed, simple, err := shell.ParseCommandWith(command, parseOpts)
+		if err != nil {
+			return nil, nil, false, err
+		}
+		if simple {
+			args, env = parsed.Args, parsed.Env
+		} else {
+			useShell = true
+		}
+	}
+	return args, append(env, spec.Env...), useShell, nil
+}
+
+// capture 分别记录标准输出、标准错误以及两者按顺序合并的内容，
+// 管道中的多个阶段可能同时写入，因此需要加锁
+type capture struct {
+	mu       sync.Mutex
+	stdout   bytes.Buffer
+	stderr   bytes.Buffer
+	combined bytes.Buffer
+}
+
+// captureWriter 写入 capture 的一路输出，并可同时转发给调用者
+type captureWriter struct {
+	c      *capture
+	stderr bool
+	tee    io.Writer
+}
+
+func (w *captureWriter) Write(p []byte) (int, error) {
+	w.c.mu.Lock()
+	defer w.c.mu.Unlock()
+
+	if w.stderr {
+		w.c.stderr.Write(p)
+	} else {
+		w.c.stdout.Write(p)
+	}
+	w.c.combined.Write(p)
+	if w.tee != nil {
+		// 调用者的 Writer 出错不影响命令执行和结果记录
+		w.tee.Write(p)
+	}
+	return len(p), nil
+}
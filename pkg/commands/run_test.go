@@ -0,0 +1,114 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Lzww0608/ClixGo/pkg/history"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 测试分离的标准输出与标准错误
+func TestRunSeparateOutput(t *testing.T) {
+	setupTestEnvironment()
+
+	var live bytes.Buffer
+	result, err := Run(context.Background(), Spec{
+		Command:   `sh -c 'echo out; echo err >&2'`,
+		Stdout:    &live,
+		NoHistory: true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 0, result.ExitCode)
+	assert.True(t, result.Success())
+	assert.Equal(t, "out\n", result.Stdout)
+	assert.Equal(t, "err\n", result.Stderr)
+	assert.Equal(t, "out\n", live.String())
+	assert.Contains(t, result.Output, "out\n")
+	assert.Contains(t, result.Output, "err\n")
+	assert.True(t, result.Duration > 0)
+}
+
+// 测试环境变量、工作目录与标准输入
+func TestRunEnvDirStdin(t *testing.T) {
+	setupTestEnvironment()
+
+	dir := t.TempDir()
+	result, err := Run(context.Background(), Spec{
+		Args:      []string{"sh", "-c", `echo "$CLIXGO_SPEC_VAR $(pwd)"; cat`},
+		Env:       []string{"CLIXGO_SPEC_VAR=hello"},
+		Dir:       dir,
+		Stdin:     strings.NewReader("input"),
+		NoHistory: true,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "hello "+dir+"\ninput", result.Stdout)
+
+	// 解释器模式同样使用指定的环境与目录
+	result, err = Run(context.Background(), Spec{
+		Command:   `test "$CLIXGO_SPEC_VAR" = hello && cd .. && echo ok`,
+		Env:       []string{"CLIXGO_SPEC_VAR=hello"},
+		Dir:       dir,
+		Shell:     true,
+		NoHistory: true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "ok\n", result.Stdout)
+}
+
+// 测试退出码、信号与超时
+func TestRunExitCodeSignalTimeout(t *testing.T) {
+	setupTestEnvironment()
+
+	result, err := Run(context.Background(), Spec{Command: "sh -c 'exit 4'", NoHistory: true})
+	require.Error(t, err)
+	assert.Equal(t, 4, result.ExitCode)
+
+	result, err = Run(context.Background(), Spec{Command: "false || exit 5", NoHistory: true})
+	require.Error(t, err)
+	assert.Equal(t, 5, result.ExitCode)
+
+	result, err = Run(context.Background(), Spec{Command: "sh -c 'kill -TERM $$'", NoHistory: true})
+	require.Error(t, err)
+	assert.Equal(t, 143, result.ExitCode)
+	assert.Equal(t, "terminated", result.Signal)
+
+	result, err = Run(context.Background(), Spec{Command: "sleep 5", Timeout: 100 * time.Millisecond, NoHistory: true})
+	require.Error(t, err)
+	assert.True(t, result.TimedOut)
+	assert.False(t, result.Success())
+	assert.Less(t, result.Duration, 5*time.Second)
+
+	result, err = Run(context.Background(), Spec{Command: "invalid_command_xyz", NoHistory: true})
+	require.Error(t, err)
+	assert.Equal(t, 127, result.ExitCode)
+
+	_, err = Run(context.Background(), Spec{Command: "  ", NoHistory: true})
+	assert.Error(t, err)
+}
+
+// 测试命令历史记录退出码和工作目录
+func TestRunRecordsHistory(t *testing.T) {
+	setupTestEnvironment()
+	original := history.GetHistoryFilePath()
+	history.SetHistoryFilePath(filepath.Join(t.TempDir(), "history.jsonl"))
+	defer history.SetHistoryFilePath(original)
+
+	dir := t.TempDir()
+	_, err := Run(context.Background(), Spec{Command: "sh -c 'exit 3'", Dir: dir})
+	require.Error(t, err)
+
+	last, err := history.GetLastHistory()
+	require.NoError(t, err)
+	require.NotNil(t, last)
+	assert.Equal(t, "failed", last.Status)
+	assert.Equal(t, 3, last.ExitCode)
+	assert.Equal(t, dir, last.Dir)
+	assert.NotEmpty(t, last.SessionID)
+}
@@ -0,0 +1,217 @@
+//go:build linux
+
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/Lzww0608/ClixGo/pkg/shell"
+	"golang.org/x/sys/unix"
+)
+
+// 引导进程的环境变量，值为 JSON 编码的 sandboxConfig
+const sandboxInitEnv = "CLIXGO_SANDBOX_INIT"
+
+// 引导进程自身出错时的退出码，与shell中命令无法执行的退出码一致
+const exitCodeCannotExecute = 126
+
+// sandboxConfig 传递给引导进程的设置
+type sandboxConfig struct {
+	Limits        Limits   `json:"limits"`
+	ReadOnlyPaths []string `json:"read_only_paths,omitempty"`
+	Args          []string `json:"args,omitempty"`
+	Script        string   `json:"script,omitempty"`
+}
+
+// sandboxHooked 记录当前程序是否调用了 SandboxInit，没有调用时重新执行当前程序不会进入引导逻辑
+var sandboxHooked atomic.Bool
+
+// SandboxInit 是隔离子进程的引导入口，需要在 main 开头调用。以引导进程身份启动时完成设置后
+// 执行目标命令并退出，不再返回；否则立即返回，之后才能启动带资源限制或隔离设置的命令
+func SandboxInit() {
+	if data, ok := os.LookupEnv(sandboxInitEnv); ok {
+		os.Exit(sandboxInit(data))
+	}
+	sandboxHooked.Store(true)
+}
+
+// isolatedCommand 创建受资源限制和隔离的子进程。资源限制和只读挂载必须在目标命令启动前
+// 由子进程自己设置，因此重新执行当前程序作为引导进程，设置完成后再替换为目标命令；
+// script 非空时引导进程使用解释器执行脚本。env 为空时继承当前进程的环境变量
+func isolatedCommand(ctx context.Context, spec Spec, args []string, script string, env []string) (*exec.Cmd, error) {
+	cfg := sandboxConfig{Limits: spec.Limits, Args: args, Script: script}
+	for _, path := range spec.Sandbox.ReadOnlyPaths {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return nil, fmt.Errorf("无效的只读路径 %s: %v", path, err)
+		}
+		cfg.ReadOnlyPaths = append(cfg.ReadOnlyPaths, abs)
+	}
+
+	var cmd *exec.Cmd
+	if script != "" || !cfg.Limits.IsZero() || len(cfg.ReadOnlyPaths) > 0 {
+		if !sandboxHooked.Load() {
+			return nil, fmt.Errorf("当前程序没有调用 commands.SandboxInit，无法启动受限制或隔离的命令")
+		}
+		self, err := os.Executable()
+		if err != nil {
+			return nil, fmt.Errorf("获取当前程序路径失败: %v", err)
+		}
+		data, err := json.Marshal(cfg)
+		if err != nil {
+			return nil, err
+		}
+		if env == nil {
+			env = os.Environ()
+		}
+		cmd = exec.CommandContext(ctx, self)
+		cmd.Env = append(env[:len(env):len(env)], sandboxInitEnv+"="+string(data))
+	} else {
+		cmd = exec.CommandContext(ctx, args[0], args[1:]...)
+		cmd.Env = env
+	}
+
+	attr := &syscall.SysProcAttr{}
+	if spec.Sandbox.ProcessGroup {
+		attr.Setpgid = true
+		cmd.Cancel = func() error {
+			// 进程组ID与子进程ID相同，负数表示向整个进程组发送信号
+			return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+		}
+	}
+	if spec.Sandbox.namespaces() {
+		// 借助用户命名空间，非 root 用户也能创建网络和挂载命名空间
+		attr.Cloneflags = syscall.CLONE_NEWUSER
+		if spec.Sandbox.NoNetwork {
+			attr.Cloneflags |= syscall.CLONE_NEWNET
+		}
+		if len(cfg.ReadOnlyPaths) > 0 {
+			attr.Cloneflags |= syscall.CLONE_NEWNS
+		}
+		attr.UidMappings = []syscall.SysProcIDMap{{ContainerID: os.Getuid(), HostID: os.Getuid(), Size: 1}}
+		attr.GidMappings = []syscall.SysProcIDMap{{ContainerID: os.Getgid(), HostID: os.Getgid(), Size: 1}}
+	}
+	cmd.SysProcAttr = attr
+	return cmd, nil
+}
+
+// sandboxInit 引导进程的入口，返回值作为退出码
+func sandboxInit(data string) int {
+	os.Unsetenv(sandboxInitEnv)
+
+	var cfg sandboxConfig
+	if err := json.Unmarshal([]byte(data), &cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "解析隔离设置失败: %v\n", err)
+		return exitCodeCannotExecute
+	}
+	if len(cfg.ReadOnlyPaths) > 0 {
+		if err := mountReadOnly(cfg.ReadOnlyPaths); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitCodeCannotExecute
+		}
+	}
+	if err := setLimits(cfg.Limits); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitCodeCannotExecute
+	}
+
+	if cfg.Script != "" {
+		err := shell.Run(context.Background(), cfg.Script, shell.Options{
+			Stdin:  os.Stdin,
+			Stdout: os.Stdout,
+			Stderr: os.Stderr,
+		})
+		if err == nil {
+			return 0
+		}
+		if code, ok := shell.ExitCode(err); ok {
+			return code
+		}
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if len(cfg.Args) == 0 {
+		fmt.Fprintln(os.Stderr, "空命令")
+		return exitCodeCannotExecute
+	}
+	path, err := exec.LookPath(cfg.Args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: 未找到命令\n", cfg.Args[0])
+		return exitCodeNotFound
+	}
+	err = syscall.Exec(path, cfg.Args, os.Environ())
+	fmt.Fprintf(os.Stderr, "执行 %s 失败: %v\n", cfg.Args[0], err)
+	return exitCodeCannotExecute
+}
+
+// setLimits 为当前进程设置资源限制，执行的目标命令会继承这些限制
+func setLimits(limits Limits) error {
+	set := func(resource int, name string, value uint64) error {
+		if value == 0 {
+			return nil
+		}
+		rlimit := &syscall.Rlimit{Cur: value, Max: value}
+		if err := syscall.Setrlimit(resource, rlimit); err != nil {
+			return fmt.Errorf("设置资源限制 %s=%d 失败: %v", name, value, err)
+		}
+		return nil
+	}
+
+	cpu := uint64((limits.CPUTime + time.Second - 1) / time.Second)
+	if err := set(unix.RLIMIT_CPU, "cpu", cpu); err != nil {
+		return err
+	}
+	if err := set(unix.RLIMIT_AS, "memory", limits.Memory); err != nil {
+		return err
+	}
+	if err := set(unix.RLIMIT_NOFILE, "nofile", limits.NoFile); err != nil {
+		return err
+	}
+	return set(unix.RLIMIT_NPROC, "nproc", limits.NProc)
+}
+
+// mountReadOnly 在新的挂载命名空间中将路径重新绑定挂载为只读
+func mountReadOnly(paths []string) error {
+	// 避免挂载事件传播回宿主的挂载命名空间
+	if err := unix.Mount("", "/", "", unix.MS_REC|unix.MS_PRIVATE, ""); err != nil {
+		return fmt.Errorf("设置挂载传播方式失败: %v", err)
+	}
+
+	for _, path := range paths {
+		if err := unix.Mount(path, path, "", unix.MS_BIND|unix.MS_REC, ""); err != nil {
+			return fmt.Errorf("绑定挂载 %s 失败: %v", path, err)
+		}
+
+		// 用户命名空间中原挂载点的 nosuid、nodev 等标志被锁定，重新挂载时必须保留
+		var st unix.Statfs_t
+		if err := unix.Statfs(path, &st); err != nil {
+			return fmt.Errorf("读取 %s 的挂载信息失败: %v", path, err)
+		}
+		flags := uintptr(unix.MS_BIND | unix.MS_REMOUNT | unix.MS_RDONLY)
+		for stFlag, msFlag := range map[int64]uintptr{
+			unix.ST_NOSUID:     unix.MS_NOSUID,
+			unix.ST_NODEV:      unix.MS_NODEV,
+			unix.ST_NOEXEC:     unix.MS_NOEXEC,
+			unix.ST_NOATIME:    unix.MS_NOATIME,
+			unix.ST_NODIRATIME: unix.MS_NODIRATIME,
+			unix.ST_RELATIME:   unix.MS_RELATIME,
+		} {
+			if int64(st.Flags)&stFlag != 0 {
+				flags |= msFlag
+			}
+		}
+		if err := unix.Mount("", path, "", flags, ""); err != nil {
+			return fmt.Errorf("将 %s 重新挂载为只读失败: %v", path, err)
+		}
+	}
+	return nil
+}
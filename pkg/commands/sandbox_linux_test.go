@@ -0,0 +1,130 @@
+//go:build linux
+
+package commands
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 测试没有调用 SandboxInit 的程序拒绝启动需要引导进程的命令
+func TestRunWithoutSandboxInit(t *testing.T) {
+	setupTestEnvironment()
+	sandboxHooked.Store(false)
+	defer sandboxHooked.Store(true)
+
+	_, err := Run(context.Background(), Spec{
+		Command:   "true",
+		Limits:    Limits{NoFile: 64},
+		NoHistory: true,
+	})
+	assert.ErrorContains(t, err, "SandboxInit")
+}
+
+// 测试通过 setrlimit 限制资源
+func TestRunWithLimits(t *testing.T) {
+	setupTestEnvironment()
+
+	result, err := Run(context.Background(), Spec{
+		Command:   `sh -c 'ulimit -n; ulimit -v; ulimit -t'`,
+		Limits:    Limits{NoFile: 64, Memory: 256 << 20, CPUTime: 1500 * time.Millisecond},
+		NoHistory: true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "64\n262144\n2\n", result.Stdout)
+
+	// 解释器执行的脚本同样受到限制
+	result, err = Run(context.Background(), Spec{
+		Command:   `true && sh -c 'ulimit -n'`,
+		Limits:    Limits{NoFile: 32},
+		NoHistory: true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "32\n", result.Stdout)
+
+	// CPU 时间超限时被信号终止
+	result, err = Run(context.Background(), Spec{
+		Command:   `sh -c 'while :; do :; done'`,
+		Limits:    Limits{CPUTime: time.Second},
+		Timeout:   10 * time.Second,
+		NoHistory: true,
+	})
+	require.Error(t, err)
+	assert.False(t, result.TimedOut)
+	assert.NotEmpty(t, result.Signal)
+
+	result, err = Run(context.Background(), Spec{
+		Command:   "nonexistent-command-xyz",
+		Limits:    Limits{NoFile: 64},
+		NoHistory: true,
+	})
+	require.Error(t, err)
+	assert.Equal(t, exitCodeNotFound, result.ExitCode)
+}
+
+// 测试超时时杀死整个进程组
+func TestRunProcessGroup(t *testing.T) {
+	setupTestEnvironment()
+	pidFile := filepath.Join(t.TempDir(), "pid")
+
+	start := time.Now()
+	result, err := Run(context.Background(), Spec{
+		Command:   `sh -c 'sleep 30 & echo $! > ` + pidFile + `; wait'`,
+		Timeout:   300 * time.Millisecond,
+		Sandbox:   Sandbox{ProcessGroup: true},
+		NoHistory: true,
+	})
+	require.Error(t, err)
+	assert.True(t, result.TimedOut)
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	data, err := os.ReadFile(pidFile)
+	require.NoError(t, err)
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	require.NoError(t, err)
+
+	// 后台子进程已退出（可能仍是等待回收的僵尸进程）
+	assert.Eventually(t, func() bool {
+		stat, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "stat"))
+		return err != nil || strings.Contains(string(stat), ") Z ")
+	}, 2*time.Second, 20*time.Millisecond)
+}
+
+// 测试在新的命名空间中运行
+func TestRunSandboxNamespaces(t *testing.T) {
+	if err := exec.Command("unshare", "-rnm", "true").Run(); err != nil {
+		t.Skipf("当前环境不支持用户命名空间: %v", err)
+	}
+	setupTestEnvironment()
+
+	result, err := Run(context.Background(), Spec{
+		Command:   "cat /proc/net/dev",
+		Sandbox:   Sandbox{NoNetwork: true},
+		NoHistory: true,
+	})
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(result.Stdout), "\n")
+	require.Len(t, lines, 3)
+	assert.Contains(t, lines[2], "lo:")
+
+	dir := t.TempDir()
+	_, err = Run(context.Background(), Spec{
+		Command:   "touch " + filepath.Join(dir, "blocked"),
+		Sandbox:   Sandbox{ReadOnlyPaths: []string{dir}},
+		NoHistory: true,
+	})
+	assert.Error(t, err)
+	assert.NoFileExists(t, filepath.Join(dir, "blocked"))
+
+	// 只读挂载只在子进程的命名空间中生效
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "allowed"), nil, 0644))
+}
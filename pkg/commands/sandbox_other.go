@@ -0,0 +1,17 @@
+//go:build !linux
+
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+)
+
+// SandboxInit 当前平台不使用引导进程，直接返回
+func SandboxInit() {}
+
+// isolatedCommand 资源限制和隔离依赖 setrlimit 与 Linux 命名空间，其他平台不支持
+func isolatedCommand(ctx context.Context, spec Spec, args []string, script string, env []string) (*exec.Cmd, error) {
+	return nil, fmt.Errorf("当前平台不支持资源限制和进程隔离")
+}
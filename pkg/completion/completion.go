@@ -1,101 +1,129 @@
 package completion
 
 import (
+	"bytes"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
-	"strings"
+	"runtime"
 
 	"github.com/spf13/cobra"
 )
 
+// 支持的shell
+const (
+	Bash       = "bash"
+	Zsh        = "zsh"
+	Fish       = "fish"
+	PowerShell = "powershell"
+)
+
+// Shells 支持生成补全脚本的shell
+var Shells = []string{Bash, Zsh, Fish, PowerShell}
+
 // 包级可替换的文件操作函数，便于测试mock
 var (
 	userHomeDirFunc = os.UserHomeDir
 	mkdirAllFunc    = os.MkdirAll
 	writeFileFunc   = os.WriteFile
-	readFileFunc    = os.ReadFile
-	openFileFunc    = os.OpenFile
+	removeFunc      = os.Remove
 )
 
-func GenerateCompletionScript(cmd *cobra.Command) error {
-	// 生成bash补全脚本
-	bashScript := `#!/bin/bash
-
-_gocli_completion() {
-    local cur prev words cword
-    _init_completion || return
-
-    case "${prev}" in
-        alias)
-            COMPREPLY=($(compgen -W "add remove list" -- "${cur}"))
-            return
-            ;;
-        history)
-            COMPREPLY=($(compgen -W "list clear show" -- "${cur}"))
-            return
-            ;;
-        show)
-            # 这里可以添加历史记录索引的补全
-            return
-            ;;
-    esac
+// Generate 使用 cobra 的生成器将 shell 的补全脚本写入 w，脚本中的命令名为 root 的名称
+func Generate(root *cobra.Command, shell string, w io.Writer) error {
+	switch shell {
+	case Bash:
+		return root.GenBashCompletionV2(w, true)
+	case Zsh:
+		return root.GenZshCompletion(w)
+	case Fish:
+		return root.GenFishCompletion(w, true)
+	case PowerShell:
+		return root.GenPowerShellCompletionWithDesc(w)
+	default:
+		return fmt.Errorf("不支持的shell: %s，可选 bash、zsh、fish、powershell", shell)
+	}
+}
 
-    # 获取所有子命令
-    local subcommands
-    subcommands=$(gocli --help 2>&1 | awk '/^  [a-z]/ {print $1}')
-    
-    # 添加别名到补全列表
-    local aliases=""
-    for name in $(gocli alias list 2>/dev/null | awk '{print $1}'); do
-        aliases="$aliases $name"
-    done
+// InstallPath 返回 shell 补全脚本的安装位置:
+//
+//	bash        $XDG_DATA_HOME/bash-completion/completions/<name>，由 bash-completion 自动加载
+//	zsh         ~/.zsh/completions/_<name>，需要在 fpath 中
+//	fish        $XDG_CONFIG_HOME/fish/completions/<name>.fish，由 fish 自动加载
+//	powershell  $XDG_CONFIG_HOME/powershell/completions/<name>.ps1，需要在 $PROFILE 中加载
+func InstallPath(root *cobra.Command, shell string) (string, error) {
+	home, err := userHomeDirFunc()
+	if err != nil {
+		return "", fmt.Errorf("获取用户目录失败: %v", err)
+	}
+	xdg := func(env string, def ...string) string {
+		if dir := os.Getenv(env); filepath.IsAbs(dir) {
+			return dir
+		}
+		return filepath.Join(append([]string{home}, def...)...)
+	}
 
-    COMPREPLY=($(compgen -W "${subcommands} ${aliases}" -- "${cur}"))
+	name := root.Name()
+	switch shell {
+	case Bash:
+		return filepath.Join(xdg("XDG_DATA_HOME", ".local", "share"), "bash-completion", "completions", name), nil
+	case Zsh:
+		return filepath.Join(home, ".zsh", "completions", "_"+name), nil
+	case Fish:
+		return filepath.Join(xdg("XDG_CONFIG_HOME", ".config"), "fish", "completions", name+".fish"), nil
+	case PowerShell:
+		dir := xdg("XDG_CONFIG_HOME", ".config")
+		if runtime.GOOS == "windows" {
+			dir = filepath.Join(home, "Documents")
+		}
+		return filepath.Join(dir, "powershell", "completions", name+".ps1"), nil
+	default:
+		return "", fmt.Errorf("不支持的shell: %s，可选 bash、zsh、fish、powershell", shell)
+	}
 }
 
-complete -F _gocli_completion gocli
-`
-
-	// 获取用户主目录
-	homeDir, err := userHomeDirFunc()
+// Install 将补全脚本写入 InstallPath，不修改shell的启动文件。
+// 返回安装位置，以及需要用户手动完成的配置说明（不需要时为空）
+func Install(root *cobra.Command, shell string) (path, hint string, err error) {
+	path, err = InstallPath(root, shell)
 	if err != nil {
-		return err
+		return "", "", err
 	}
 
-	// 创建补全脚本目录
-	completionDir := filepath.Join(homeDir, ".bash_completion.d")
-	if err := mkdirAllFunc(completionDir, 0755); err != nil {
-		return err
+	var script bytes.Buffer
+	if err := Generate(root, shell, &script); err != nil {
+		return "", "", fmt.Errorf("生成补全脚本失败: %v", err)
 	}
-
-	// 写入补全脚本
-	completionFile := filepath.Join(completionDir, "gocli")
-	if err := writeFileFunc(completionFile, []byte(bashScript), 0644); err != nil {
-		return err
+	if err := mkdirAllFunc(filepath.Dir(path), 0755); err != nil {
+		return "", "", fmt.Errorf("创建补全脚本目录失败: %v", err)
+	}
+	if err := writeFileFunc(path, script.Bytes(), 0644); err != nil {
+		return "", "", fmt.Errorf("写入补全脚本失败: %v", err)
 	}
 
-	// 确保.bashrc中包含补全脚本
-	bashrc := filepath.Join(homeDir, ".bashrc")
-	completionLine := `source ~/.bash_completion.d/gocli`
+	switch shell {
+	case Bash:
+		hint = "需要安装 bash-completion 2.x，新打开的 bash 会自动加载"
+	case Zsh:
+		hint = fmt.Sprintf("请确保 ~/.zshrc 在 compinit 之前包含: fpath=(%s $fpath)", filepath.Dir(path))
+	case PowerShell:
+		hint = fmt.Sprintf("请在 $PROFILE 中添加: . '%s'", path)
+	}
+	return path, hint, nil
+}
 
-	// 检查是否已经包含补全脚本
-	data, err := readFileFunc(bashrc)
+// Uninstall 删除 Install 安装的补全脚本，返回删除的文件
+func Uninstall(root *cobra.Command, shell string) (string, error) {
+	path, err := InstallPath(root, shell)
 	if err != nil {
-		return err
+		return "", err
 	}
-
-	if !strings.Contains(string(data), completionLine) {
-		// 添加补全脚本到.bashrc
-		f, err := openFileFunc(bashrc, os.O_APPEND|os.O_WRONLY, 0644)
-		if err != nil {
-			return err
-		}
-		defer f.Close()
-
-		if _, err := f.WriteString("\n" + completionLine + "\n"); err != nil {
-			return err
+	if err := removeFunc(path); err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("%s 的补全脚本未安装: %s", shell, path)
 		}
+		return "", fmt.Errorf("删除补全脚本失败: %v", err)
 	}
-
-	return nil
+	return path, nil
 }
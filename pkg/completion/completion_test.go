@@ -7,130 +7,124 @@ import (
 	"bytes"
 	"errors"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/spf13/cobra"
 )
 
-// 为了测试，将文件操作相关函数抽象为变量，便于mock
-// 注意: 这些变量已在主文件中声明，此处不要重复声明
-
-// 模拟文件结构，实现WriteString和Close方法
-type mockFile struct {
-	*bytes.Buffer
+// newRoot 返回带子命令的测试用根命令
+func newRoot() *cobra.Command {
+	root := &cobra.Command{Use: "ClixGo"}
+	root.AddCommand(&cobra.Command{Use: "alias", Run: func(*cobra.Command, []string) {}})
+	return root
 }
 
-func (m *mockFile) Close() error {
-	return nil
+// patchHome 将用户目录替换为临时目录并清除 XDG 环境变量
+func patchHome(t *testing.T) string {
+	home := t.TempDir()
+	orig := userHomeDirFunc
+	userHomeDirFunc = func() (string, error) { return home, nil }
+	t.Cleanup(func() { userHomeDirFunc = orig })
+	t.Setenv("XDG_DATA_HOME", "")
+	t.Setenv("XDG_CONFIG_HOME", "")
+	return home
 }
 
-func (m *mockFile) WriteString(s string) (n int, err error) {
-	return m.Buffer.WriteString(s)
-}
+func TestGenerate(t *testing.T) {
+	for _, shell := range Shells {
+		var buf bytes.Buffer
+		if err := Generate(newRoot(), shell, &buf); err != nil {
+			t.Fatalf("生成 %s 补全脚本失败: %v", shell, err)
+		}
+		if !strings.Contains(buf.String(), "ClixGo") {
+			t.Errorf("%s 补全脚本应该使用根命令的名称", shell)
+		}
+		if strings.Contains(buf.String(), "gocli") {
+			t.Errorf("%s 补全脚本不应该引用 gocli", shell)
+		}
+	}
 
-// 测试中的mock辅助函数
-func patchOSMock(
-	home string, mkdirErr, writeErr, readErr error, fileContent string,
-) (restore func(), called *bool) {
-	calledWrite := false
+	if err := Generate(newRoot(), "tcsh", &bytes.Buffer{}); err == nil {
+		t.Errorf("不支持的shell应返回错误")
+	}
+}
 
-	// 保存原始函数
-	origUserHomeDirFunc := userHomeDirFunc
-	origMkdirAllFunc := mkdirAllFunc
-	origWriteFileFunc := writeFileFunc
-	origReadFileFunc := readFileFunc
-	origOpenFileFunc := openFileFunc
+func TestInstallPath(t *testing.T) {
+	home := patchHome(t)
+	root := newRoot()
 
-	// 替换为mock函数
-	userHomeDirFunc = func() (string, error) { return home, nil }
-	mkdirAllFunc = func(path string, perm os.FileMode) error { return mkdirErr }
-	writeFileFunc = func(name string, data []byte, perm os.FileMode) error {
-		calledWrite = true
-		return writeErr
+	want := map[string]string{
+		Bash: filepath.Join(home, ".local", "share", "bash-completion", "completions", "ClixGo"),
+		Zsh:  filepath.Join(home, ".zsh", "completions", "_ClixGo"),
+		Fish: filepath.Join(home, ".config", "fish", "completions", "ClixGo.fish"),
 	}
-	readFileFunc = func(name string) ([]byte, error) {
-		if readErr != nil {
-			return nil, readErr
+	for shell, path := range want {
+		got, err := InstallPath(root, shell)
+		if err != nil || got != path {
+			t.Errorf("%s 的安装位置不正确，期望: %s, 实际: %s (%v)", shell, path, got, err)
 		}
-		return []byte(fileContent), nil
 	}
 
-	openFileFunc = func(name string, flag int, perm os.FileMode) (*os.File, error) {
-		// 成功测试中返回nil错误，不真正打开文件
-		// 在测试中会返回模拟的文件接口
-		return nil, nil
+	t.Setenv("XDG_CONFIG_HOME", "/xdg/config")
+	if got, _ := InstallPath(root, Fish); got != "/xdg/config/fish/completions/ClixGo.fish" {
+		t.Errorf("应该使用 XDG_CONFIG_HOME，实际: %s", got)
 	}
-
-	// 返回恢复函数
-	return func() {
-		userHomeDirFunc = origUserHomeDirFunc
-		mkdirAllFunc = origMkdirAllFunc
-		writeFileFunc = origWriteFileFunc
-		readFileFunc = origReadFileFunc
-		openFileFunc = origOpenFileFunc
-	}, &calledWrite
 }
 
-// 重构completion.go以便注入mock（需同步修改production代码）
+func TestInstallAndUninstall(t *testing.T) {
+	patchHome(t)
+	root := newRoot()
 
-func TestGenerateCompletionScript_Success(t *testing.T) {
-	// 在成功情况下，直接mock到不尝试写入bashrc
-	restore, called := patchOSMock("/tmp/testhome", nil, nil, nil, "source ~/.bash_completion.d/gocli")
-	defer restore()
+	for _, shell := range Shells {
+		path, _, err := Install(root, shell)
+		if err != nil {
+			t.Fatalf("安装 %s 补全脚本失败: %v", shell, err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil || len(data) == 0 {
+			t.Errorf("补全脚本应该写入 %s: %v", path, err)
+		}
 
-	cmd := &cobra.Command{Use: "gocli"}
-	err := GenerateCompletionScript(cmd)
-	if err != nil {
-		t.Fatalf("GenerateCompletionScript 应该成功，实际: %v", err)
-	}
-	if !*called {
-		t.Errorf("应该调用WriteFile写入补全脚本")
+		removed, err := Uninstall(root, shell)
+		if err != nil || removed != path {
+			t.Errorf("卸载 %s 补全脚本失败: %s %v", shell, removed, err)
+		}
+		if _, err := Uninstall(root, shell); err == nil || !strings.Contains(err.Error(), "未安装") {
+			t.Errorf("重复卸载应返回未安装的错误，实际: %v", err)
+		}
 	}
 }
 
-func TestGenerateCompletionScript_UserHomeDirError(t *testing.T) {
-	// 保存原始函数
+func TestInstall_UserHomeDirError(t *testing.T) {
 	origFunc := userHomeDirFunc
 	userHomeDirFunc = func() (string, error) { return "", errors.New("fail") }
 	defer func() { userHomeDirFunc = origFunc }()
 
-	cmd := &cobra.Command{Use: "gocli"}
-	err := GenerateCompletionScript(cmd)
-	if err == nil || !strings.Contains(err.Error(), "fail") {
+	if _, _, err := Install(newRoot(), Bash); err == nil || !strings.Contains(err.Error(), "fail") {
 		t.Errorf("UserHomeDir失败时应返回错误")
 	}
 }
 
-func TestGenerateCompletionScript_MkdirAllError(t *testing.T) {
-	restore, _ := patchOSMock("/tmp/testhome", errors.New("mkdir fail"), nil, nil, "")
-	defer restore()
+func TestInstall_MkdirAllError(t *testing.T) {
+	patchHome(t)
+	origFunc := mkdirAllFunc
+	mkdirAllFunc = func(path string, perm os.FileMode) error { return errors.New("mkdir fail") }
+	defer func() { mkdirAllFunc = origFunc }()
 
-	cmd := &cobra.Command{Use: "gocli"}
-	err := GenerateCompletionScript(cmd)
-	if err == nil || !strings.Contains(err.Error(), "mkdir fail") {
+	if _, _, err := Install(newRoot(), Zsh); err == nil || !strings.Contains(err.Error(), "mkdir fail") {
 		t.Errorf("MkdirAll失败时应返回错误")
 	}
 }
 
-func TestGenerateCompletionScript_WriteFileError(t *testing.T) {
-	restore, _ := patchOSMock("/tmp/testhome", nil, errors.New("write fail"), nil, "")
-	defer restore()
+func TestInstall_WriteFileError(t *testing.T) {
+	patchHome(t)
+	origFunc := writeFileFunc
+	writeFileFunc = func(name string, data []byte, perm os.FileMode) error { return errors.New("write fail") }
+	defer func() { writeFileFunc = origFunc }()
 
-	cmd := &cobra.Command{Use: "gocli"}
-	err := GenerateCompletionScript(cmd)
-	if err == nil || !strings.Contains(err.Error(), "write fail") {
+	if _, _, err := Install(newRoot(), Fish); err == nil || !strings.Contains(err.Error(), "write fail") {
 		t.Errorf("WriteFile失败时应返回错误")
 	}
 }
-
-func TestGenerateCompletionScript_ReadFileError(t *testing.T) {
-	restore, _ := patchOSMock("/tmp/testhome", nil, nil, errors.New("read fail"), "")
-	defer restore()
-
-	cmd := &cobra.Command{Use: "gocli"}
-	err := GenerateCompletionScript(cmd)
-	if err == nil || !strings.Contains(err.Error(), "read fail") {
-		t.Errorf("ReadFile失败时应返回错误")
-	}
-}
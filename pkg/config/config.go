@@ -38,33 +38,88 @@ type ConfigManager struct {
 	mu         sync.RWMutex
 	configPath string
 	profiles   []string
+
+	keyMu   sync.Mutex
+	keyring *Keyring
 }
 
-// 全局配置管理器实例
-var instance *ConfigManager
-var once sync.Once
+// 全局配置管理器实例，instanceMu 保护 instance 的读写
+var (
+	instance   *ConfigManager
+	instanceMu sync.RWMutex
+	once       sync.Once
+)
+
+// LookupString 在全局配置没有初始化时使用的默认配置，只加载一次
+var (
+	lookupOnce sync.Once
+	lookupCM   *ConfigManager
+	lookupErr  error
+)
 
 // InitConfig 初始化全局配置
 func InitConfig(configPath string) error {
 	var err error
 	once.Do(func() {
 		if configPath == "" {
-			homeDir, e := os.UserHomeDir()
-			if e != nil {
-				err = fmt.Errorf("获取用户目录失败: %v", e)
+			if configPath, err = defaultConfigPath(); err != nil {
 				return
 			}
-			configPath = filepath.Join(homeDir, ".clixgo", "config.yaml")
 		}
 
-		instance = NewConfigManager(configPath)
-		err = instance.Load()
+		cm := NewConfigManager(configPath)
+		err = cm.Load()
+		instanceMu.Lock()
+		instance = cm
+		instanceMu.Unlock()
 	})
 	return err
 }
 
+// defaultConfigPath 返回默认的配置文件路径 ~/.clixgo/config.yaml
+func defaultConfigPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("获取用户目录失败: %v", err)
+	}
+	return filepath.Join(homeDir, ".clixgo", "config.yaml"), nil
+}
+
+// LookupString 读取全局配置中的字符串值，加密的值会被解密。全局配置还没有初始化时从默认位置读取，
+// 但不初始化全局配置，供插件等不经过根命令的代码使用；默认位置的配置只在第一次调用时加载。
+// 配置项不存在时返回空字符串，读取配置文件或解密失败时返回错误
+func LookupString(key string) (string, error) {
+	instanceMu.RLock()
+	cm := instance
+	instanceMu.RUnlock()
+	if cm == nil {
+		lookupOnce.Do(func() {
+			var path string
+			if path, lookupErr = defaultConfigPath(); lookupErr != nil {
+				return
+			}
+			lookupCM = NewConfigManager(path)
+			lookupErr = lookupCM.Load()
+		})
+		if lookupErr != nil {
+			return "", lookupErr
+		}
+		cm = lookupCM
+	}
+
+	cm.mu.RLock()
+	_, ok := cm.values[key]
+	cm.mu.RUnlock()
+	if !ok {
+		return "", nil
+	}
+	return cm.GetString(key)
+}
+
 // GetInstance 获取全局配置实例
 func GetInstance() *ConfigManager {
+	instanceMu.RLock()
+	defer instanceMu.RUnlock()
 	if instance == nil {
 		panic("配置未初始化，请先调用 InitConfig")
 	}
@@ -74,9 +129,14 @@ func GetInstance() *ConfigManager {
 // NewConfigManager 创建新的配置管理器
 func NewConfigManager(configPath string) *ConfigManager {
 	v := viper.New()
-	v.SetConfigName("config")
-	v.SetConfigType("yaml")
-	v.AddConfigPath(configPath)
+	if filepath.Ext(configPath) != "" {
+		// 指向具体的配置文件
+		v.SetConfigFile(configPath)
+	} else {
+		v.SetConfigName("config")
+		v.SetConfigType("yaml")
+		v.AddConfigPath(configPath)
+	}
 
 	cm := &ConfigManager{
 		viper:      v,
@@ -123,7 +183,7 @@ func (cm *ConfigManager) Load() error {
 
 	// 加载配置文件
 	if err := cm.viper.ReadInConfig(); err != nil {
-		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
+		if _, ok := err.(viper.ConfigFileNotFoundError); !ok && !os.IsNotExist(err) {
 			return fmt.Errorf("读取配置文件失败: %v", err)
 		}
 	}
@@ -132,13 +192,17 @@ func (cm *ConfigManager) Load() error {
 	cm.viper.AutomaticEnv()
 	cm.viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 
-	// 合并配置
+	// 合并配置，加密的值在 Get 时解密
 	for _, key := range cm.viper.AllKeys() {
 		value := cm.viper.Get(key)
+		source := SourceFile
+		if IsEncryptedValue(value) {
+			source = SourceEncrypted
+		}
 		cm.values[key] = ConfigValue{
 			Value:       value,
-			Source:      SourceFile,
-			IsEncrypted: false,
+			Source:      source,
+			IsEncrypted: source == SourceEncrypted,
 			Metadata:    make(map[string]interface{}),
 		}
 	}
@@ -172,7 +236,7 @@ func (cm *ConfigManager) Save() error {
 	// 准备配置数据
 	configData := make(map[string]interface{})
 	for key, value := range cm.values {
-		if value.Source == SourceFile {
+		if value.Source == SourceFile || value.Source == SourceEncrypted {
 			configData[key] = value.Value
 		}
 	}
@@ -190,17 +254,23 @@ func (cm *ConfigManager) Save() error {
 	return nil
 }
 
-// Get 获取配置值
+// Get 获取配置值，加密的值使用 Keyring 解密后返回
 func (cm *ConfigManager) Get(key string) (interface{}, error) {
 	cm.mu.RLock()
-	defer cm.mu.RUnlock()
-
 	value, ok := cm.values[key]
+	cm.mu.RUnlock()
 	if !ok {
 		return nil, fmt.Errorf("配置项 %s 不存在", key)
 	}
+	if !value.IsEncrypted {
+		return value.Value, nil
+	}
 
-	return value.Value, nil
+	keyring, err := cm.Keyring()
+	if err != nil {
+		return nil, fmt.Errorf("配置项 %s 已加密: %v", key, err)
+	}
+	return keyring.Decrypt(key, fmt.Sprint(value.Value))
 }
 
 // Set 设置配置值
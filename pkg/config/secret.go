@@ -0,0 +1,254 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// 加密配置值的格式:
+//
+//	enc:v1:key:<base64(nonce+密文)>                  使用 32 字节的密钥
+//	enc:v1:scrypt:<base64(salt)>:<base64(nonce+密文)>  使用口令经 scrypt 派生的密钥
+//
+// 加密使用 AES-256-GCM，以配置项的名称作为附加数据，密文不能挪到其他配置项下使用
+const encryptedPrefix = "enc:v1:"
+
+// 密钥的来源
+const (
+	// KeyEnv 环境变量，值为 base64 编码的 32 字节密钥，优先级最高
+	KeyEnv = "CLIXGO_CONFIG_KEY"
+	// KeyFileName 配置目录下的密钥文件，内容为 base64 编码的 32 字节密钥
+	KeyFileName = "config.key"
+	// PassphraseEnv 环境变量，值为口令，没有密钥时使用
+	PassphraseEnv = "CLIXGO_CONFIG_PASSPHRASE"
+)
+
+// scrypt 参数
+const (
+	scryptN   = 1 << 15
+	scryptR   = 8
+	scryptP   = 1
+	keySize   = 32
+	saltSize  = 16
+	keyFormat = "key"
+	kdfFormat = "scrypt"
+)
+
+// ErrNoKey 没有可用的密钥
+var ErrNoKey = errors.New("没有可用的配置密钥，请设置环境变量 " + KeyEnv + "、" + PassphraseEnv + " 或创建密钥文件")
+
+// Keyring 加解密配置值的密钥：32 字节的密钥或口令
+type Keyring struct {
+	key        []byte
+	passphrase []byte
+	source     string // 密钥的来源，用于提示
+
+	mu      sync.Mutex
+	derived map[string][]byte // salt -> 派生的密钥
+}
+
+// NewKeyring 使用 32 字节的密钥创建 Keyring
+func NewKeyring(key []byte, source string) (*Keyring, error) {
+	if len(key) != keySize {
+		return nil, fmt.Errorf("密钥长度必须为 %d 字节，实际为 %d 字节", keySize, len(key))
+	}
+	return &Keyring{key: key, source: source}, nil
+}
+
+// NewPassphraseKeyring 使用口令创建 Keyring，加密时为每个值生成随机的 salt 并用 scrypt 派生密钥
+func NewPassphraseKeyring(passphrase, source string) (*Keyring, error) {
+	if passphrase == "" {
+		return nil, fmt.Errorf("口令不能为空")
+	}
+	return &Keyring{passphrase: []byte(passphrase), source: source, derived: make(map[string][]byte)}, nil
+}
+
+// GenerateKey 生成随机的 32 字节密钥
+func GenerateKey() ([]byte, error) {
+	key := make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("生成密钥失败: %v", err)
+	}
+	return key, nil
+}
+
+// ParseKey 解析 base64 编码的密钥
+func ParseKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("密钥不是有效的 base64: %v", err)
+	}
+	return key, nil
+}
+
+// WriteKeyFile 将密钥以 base64 写入 path，文件权限为 0600
+func WriteKeyFile(path string, key []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("创建密钥目录失败: %v", err)
+	}
+	data := base64.StdEncoding.EncodeToString(key) + "\n"
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		return fmt.Errorf("写入密钥文件失败: %v", err)
+	}
+	return nil
+}
+
+// LoadKeyring 按优先级加载密钥：环境变量 KeyEnv、dir 下的密钥文件、环境变量 PassphraseEnv。
+// 都没有时返回 ErrNoKey
+func LoadKeyring(dir string) (*Keyring, error) {
+	if encoded := os.Getenv(KeyEnv); encoded != "" {
+		key, err := ParseKey(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("环境变量 %s 无效: %v", KeyEnv, err)
+		}
+		return NewKeyring(key, "环境变量 "+KeyEnv)
+	}
+
+	path := filepath.Join(dir, KeyFileName)
+	data, err := os.ReadFile(path)
+	if err == nil {
+		key, err := ParseKey(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("密钥文件 %s 无效: %v", path, err)
+		}
+		return NewKeyring(key, "密钥文件 "+path)
+	}
+	if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("读取密钥文件失败: %v", err)
+	}
+
+	if passphrase := os.Getenv(PassphraseEnv); passphrase != "" {
+		return NewPassphraseKeyring(passphrase, "环境变量 "+PassphraseEnv)
+	}
+	return nil, ErrNoKey
+}
+
+// Source 返回密钥的来源
+func (k *Keyring) Source() string {
+	return k.source
+}
+
+// IsEncryptedValue 判断配置值是否是加密后的值
+func IsEncryptedValue(value interface{}) bool {
+	s, ok := value.(string)
+	return ok && strings.HasPrefix(s, encryptedPrefix)
+}
+
+// Encrypt 加密配置项 name 的值
+func (k *Keyring) Encrypt(name, plaintext string) (string, error) {
+	format := keyFormat
+	key := k.key
+	var salt []byte
+	if key == nil {
+		format = kdfFormat
+		salt = make([]byte, saltSize)
+		if _, err := rand.Read(salt); err != nil {
+			return "", fmt.Errorf("生成 salt 失败: %v", err)
+		}
+		var err error
+		if key, err = k.derive(salt); err != nil {
+			return "", err
+		}
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("生成 nonce 失败: %v", err)
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), []byte(name))
+
+	parts := []string{format}
+	if salt != nil {
+		parts = append(parts, base64.StdEncoding.EncodeToString(salt))
+	}
+	parts = append(parts, base64.StdEncoding.EncodeToString(sealed))
+	return encryptedPrefix + strings.Join(parts, ":"), nil
+}
+
+// Decrypt 解密配置项 name 的值
+func (k *Keyring) Decrypt(name, value string) (string, error) {
+	body, ok := strings.CutPrefix(value, encryptedPrefix)
+	if !ok {
+		return "", fmt.Errorf("配置项 %s 不是加密的值", name)
+	}
+
+	parts := strings.Split(body, ":")
+	var key []byte
+	var data string
+	switch {
+	case parts[0] == keyFormat && len(parts) == 2:
+		if k.key == nil {
+			return "", fmt.Errorf("配置项 %s 使用密钥加密，但%s提供的是口令", name, k.source)
+		}
+		key, data = k.key, parts[1]
+	case parts[0] == kdfFormat && len(parts) == 3:
+		if k.passphrase == nil {
+			return "", fmt.Errorf("配置项 %s 使用口令加密，但%s提供的是密钥", name, k.source)
+		}
+		salt, err := base64.StdEncoding.DecodeString(parts[1])
+		if err != nil {
+			return "", fmt.Errorf("配置项 %s 的 salt 无效: %v", name, err)
+		}
+		if key, err = k.derive(salt); err != nil {
+			return "", err
+		}
+		data = parts[2]
+	default:
+		return "", fmt.Errorf("配置项 %s 的加密格式无效", name)
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return "", fmt.Errorf("配置项 %s 的密文无效: %v", name, err)
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return "", fmt.Errorf("配置项 %s 的密文无效", name)
+	}
+	plaintext, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], []byte(name))
+	if err != nil {
+		return "", fmt.Errorf("解密配置项 %s 失败，密钥不正确或密文已损坏", name)
+	}
+	return string(plaintext), nil
+}
+
+// derive 用 scrypt 从口令派生密钥，同一个 salt 的结果会被缓存
+func (k *Keyring) derive(salt []byte) ([]byte, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if key, ok := k.derived[string(salt)]; ok {
+		return key, nil
+	}
+	key, err := scrypt.Key(k.passphrase, salt, scryptN, scryptR, scryptP, keySize)
+	if err != nil {
+		return nil, fmt.Errorf("派生密钥失败: %v", err)
+	}
+	k.derived[string(salt)] = key
+	return key, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("创建加密器失败: %v", err)
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,254 @@
+package config
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// clearKeyEnv 清除密钥相关的环境变量
+func clearKeyEnv(t *testing.T) {
+	t.Setenv(KeyEnv, "")
+	t.Setenv(PassphraseEnv, "")
+}
+
+func newTestKeyring(t *testing.T) *Keyring {
+	key, err := GenerateKey()
+	require.NoError(t, err)
+	keyring, err := NewKeyring(key, "测试")
+	require.NoError(t, err)
+	return keyring
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	passphrase, err := NewPassphraseKeyring("correct horse", "测试")
+	require.NoError(t, err)
+
+	for name, keyring := range map[string]*Keyring{"key": newTestKeyring(t), "scrypt": passphrase} {
+		t.Run(name, func(t *testing.T) {
+			encrypted, err := keyring.Encrypt("translate.api_key", "secret")
+			require.NoError(t, err)
+			assert.True(t, IsEncryptedValue(encrypted))
+			assert.True(t, strings.HasPrefix(encrypted, encryptedPrefix+name+":"))
+			assert.NotContains(t, encrypted, "secret")
+
+			plaintext, err := keyring.Decrypt("translate.api_key", encrypted)
+			require.NoError(t, err)
+			assert.Equal(t, "secret", plaintext)
+
+			// 密文与配置项绑定
+			_, err = keyring.Decrypt("weather.api_key", encrypted)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestDecryptErrors(t *testing.T) {
+	keyring := newTestKeyring(t)
+	encrypted, err := keyring.Encrypt("a", "secret")
+	require.NoError(t, err)
+
+	_, err = newTestKeyring(t).Decrypt("a", encrypted)
+	assert.Error(t, err, "使用其他密钥应解密失败")
+
+	_, err = keyring.Decrypt("a", "plain")
+	assert.Error(t, err)
+	_, err = keyring.Decrypt("a", "enc:v1:rot13:abc")
+	assert.Error(t, err)
+
+	passphrase, err := NewPassphraseKeyring("pw", "测试")
+	require.NoError(t, err)
+	_, err = passphrase.Decrypt("a", encrypted)
+	assert.Error(t, err, "口令不能解密使用密钥加密的值")
+
+	_, err = NewKeyring([]byte("short"), "测试")
+	assert.Error(t, err)
+	_, err = NewPassphraseKeyring("", "测试")
+	assert.Error(t, err)
+}
+
+func TestLoadKeyring(t *testing.T) {
+	clearKeyEnv(t)
+	dir := t.TempDir()
+
+	_, err := LoadKeyring(dir)
+	assert.Equal(t, ErrNoKey, err)
+
+	t.Setenv(PassphraseEnv, "pw")
+	keyring, err := LoadKeyring(dir)
+	require.NoError(t, err)
+	assert.Contains(t, keyring.Source(), PassphraseEnv)
+
+	fileKey, err := GenerateKey()
+	require.NoError(t, err)
+	require.NoError(t, WriteKeyFile(filepath.Join(dir, KeyFileName), fileKey))
+	info, err := os.Stat(filepath.Join(dir, KeyFileName))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	keyring, err = LoadKeyring(dir)
+	require.NoError(t, err)
+	assert.Contains(t, keyring.Source(), "密钥文件")
+
+	t.Setenv(KeyEnv, "not base64!")
+	_, err = LoadKeyring(dir)
+	assert.Error(t, err)
+
+	envKey, err := GenerateKey()
+	require.NoError(t, err)
+	encrypted, err := (&Keyring{key: envKey}).Encrypt("a", "secret")
+	require.NoError(t, err)
+	t.Setenv(KeyEnv, base64.StdEncoding.EncodeToString(envKey))
+	keyring, err = LoadKeyring(dir)
+	require.NoError(t, err)
+	plaintext, err := keyring.Decrypt("a", encrypted)
+	require.NoError(t, err)
+	assert.Equal(t, "secret", plaintext)
+}
+
+// newTestManager 在临时目录中创建配置文件并加载
+func newTestManager(t *testing.T, content string) *ConfigManager {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	cm := NewConfigManager(path)
+	require.NoError(t, cm.Load())
+	return cm
+}
+
+func TestSetSecret(t *testing.T) {
+	clearKeyEnv(t)
+	cm := newTestManager(t, "# 主配置\ncommands:\n  timeout: 30 # 秒\n")
+
+	generated, err := cm.SetSecret("Translate.API_Key", "secret")
+	require.NoError(t, err)
+	assert.Equal(t, cm.KeyFile(), generated, "没有密钥时应生成密钥文件")
+
+	value, err := cm.GetString("translate.api_key")
+	require.NoError(t, err)
+	assert.Equal(t, "secret", value)
+
+	data, err := os.ReadFile(cm.ConfigFile())
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "# 主配置")
+	assert.Contains(t, string(data), "timeout: 30 # 秒")
+	assert.NotContains(t, string(data), "secret")
+
+	// 重新加载后透明解密
+	reloaded := NewConfigManager(cm.ConfigFile())
+	require.NoError(t, reloaded.Load())
+	value, err = reloaded.GetString("translate.api_key")
+	require.NoError(t, err)
+	assert.Equal(t, "secret", value)
+
+	generated, err = reloaded.SetSecret("weather.api_key", "other")
+	require.NoError(t, err)
+	assert.Empty(t, generated, "已有密钥文件时不应重新生成")
+
+	// 没有密钥时返回错误而不是密文
+	require.NoError(t, os.Remove(cm.KeyFile()))
+	reloaded = NewConfigManager(cm.ConfigFile())
+	require.NoError(t, reloaded.Load())
+	_, err = reloaded.Get("translate.api_key")
+	assert.Error(t, err)
+}
+
+func TestSetValue(t *testing.T) {
+	cm := newTestManager(t, "commands:\n  timeout: 30 # 秒\nlist:\n  - a\n")
+
+	require.NoError(t, cm.SetValue("commands.timeout", "60"))
+	value, err := cm.Get("commands.timeout")
+	require.NoError(t, err)
+	assert.Equal(t, 60, value)
+
+	data, err := os.ReadFile(cm.ConfigFile())
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "timeout: 60 # 秒")
+
+	assert.Error(t, cm.SetValue("list.x", "1"))
+	assert.Error(t, cm.SetValue("commands..x", "1"))
+}
+
+func TestRotateKey(t *testing.T) {
+	clearKeyEnv(t)
+	cm := newTestManager(t, "app:\n  name: ClixGo\n")
+	_, err := cm.SetSecret("translate.api_key", "secret")
+	require.NoError(t, err)
+	before, err := os.ReadFile(cm.KeyFile())
+	require.NoError(t, err)
+
+	newKey, err := GenerateKey()
+	require.NoError(t, err)
+	keys, err := cm.RotateKey(newKey, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"translate.api_key"}, keys)
+
+	after, err := os.ReadFile(cm.KeyFile())
+	require.NoError(t, err)
+	assert.NotEqual(t, before, after)
+	assert.NoFileExists(t, cm.KeyFile()+".new")
+
+	reloaded := NewConfigManager(cm.ConfigFile())
+	require.NoError(t, reloaded.Load())
+	value, err := reloaded.GetString("translate.api_key")
+	require.NoError(t, err)
+	assert.Equal(t, "secret", value)
+
+	// 改为使用口令后删除密钥文件
+	_, err = reloaded.RotateKey(nil, "pw")
+	require.NoError(t, err)
+	assert.NoFileExists(t, cm.KeyFile())
+
+	t.Setenv(PassphraseEnv, "pw")
+	reloaded = NewConfigManager(cm.ConfigFile())
+	require.NoError(t, reloaded.Load())
+	value, err = reloaded.GetString("translate.api_key")
+	require.NoError(t, err)
+	assert.Equal(t, "secret", value)
+
+	t.Setenv(KeyEnv, base64.StdEncoding.EncodeToString(newKey))
+	_, err = reloaded.RotateKey(newKey, "")
+	assert.Error(t, err, "密钥来自环境变量时不能轮换")
+}
+
+// 测试没有初始化全局配置时从默认位置读取，配置只加载一次
+func TestLookupString(t *testing.T) {
+	clearKeyEnv(t)
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	encrypted, err := newTestKeyring(t).Encrypt("translate.api_key", "secret")
+	require.NoError(t, err)
+	path := filepath.Join(home, ".clixgo", "config.yaml")
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0700))
+	content := "weather:\n  api_key: plain\ntranslate:\n  api_key: " + encrypted + "\n"
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+
+	// 并发读取不产生数据竞争
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, err := LookupString("weather.api_key")
+			assert.NoError(t, err)
+			assert.Equal(t, "plain", value)
+		}()
+	}
+	wg.Wait()
+
+	value, err := LookupString("missing.key")
+	assert.NoError(t, err, "配置项不存在不是错误")
+	assert.Empty(t, value)
+
+	_, err = LookupString("translate.api_key")
+	assert.Error(t, err, "没有密钥时解密失败应返回错误")
+
+	require.NoError(t, os.WriteFile(path, []byte("weather:\n  api_key: changed\n"), 0600))
+	value, err = LookupString("weather.api_key")
+	require.NoError(t, err)
+	assert.Equal(t, "plain", value, "配置只在第一次读取时加载")
+}
@@ -0,0 +1,319 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFile 返回配置文件的路径
+func (cm *ConfigManager) ConfigFile() string {
+	if used := cm.viper.ConfigFileUsed(); used != "" {
+		return used
+	}
+	if filepath.Ext(cm.configPath) != "" {
+		return cm.configPath
+	}
+	return filepath.Join(cm.configPath, "config.yaml")
+}
+
+// KeyFile 返回密钥文件的路径，与配置文件位于同一目录
+func (cm *ConfigManager) KeyFile() string {
+	return filepath.Join(filepath.Dir(cm.ConfigFile()), KeyFileName)
+}
+
+// SetKeyring 设置加解密配置值使用的密钥
+func (cm *ConfigManager) SetKeyring(k *Keyring) {
+	cm.keyMu.Lock()
+	defer cm.keyMu.Unlock()
+	cm.keyring = k
+}
+
+// Keyring 返回加解密配置值使用的密钥，没有设置时按 LoadKeyring 的顺序从配置目录加载
+func (cm *ConfigManager) Keyring() (*Keyring, error) {
+	cm.keyMu.Lock()
+	defer cm.keyMu.Unlock()
+
+	if cm.keyring == nil {
+		keyring, err := LoadKeyring(filepath.Dir(cm.ConfigFile()))
+		if err != nil {
+			return nil, err
+		}
+		cm.keyring = keyring
+	}
+	return cm.keyring, nil
+}
+
+// SetValue 将明文的配置项写入配置文件并更新当前配置，文件中的其他内容和注释保持不变。
+// 值按 YAML 的规则解析类型，如 30 为整数、true 为布尔值
+func (cm *ConfigManager) SetValue(key, value string) error {
+	key = strings.ToLower(key)
+	if err := cm.writeKey(key, &yaml.Node{Kind: yaml.ScalarNode, Value: value}); err != nil {
+		return err
+	}
+
+	var parsed interface{}
+	if err := yaml.Unmarshal([]byte(value), &parsed); err != nil || parsed == nil {
+		parsed = value
+	}
+	cm.Set(key, parsed, SourceFile)
+	return nil
+}
+
+// SetSecret 加密配置项后写入配置文件。没有可用的密钥时在配置目录下生成密钥文件，
+// 返回生成的密钥文件路径（没有生成时为空）
+func (cm *ConfigManager) SetSecret(key, value string) (string, error) {
+	key = strings.ToLower(key)
+	generated := ""
+	keyring, err := cm.Keyring()
+	if err == ErrNoKey {
+		secret, err := GenerateKey()
+		if err != nil {
+			return "", err
+		}
+		generated = cm.KeyFile()
+		if err := WriteKeyFile(generated, secret); err != nil {
+			return "", err
+		}
+		if keyring, err = NewKeyring(secret, "密钥文件 "+generated); err != nil {
+			return "", err
+		}
+		cm.SetKeyring(keyring)
+	} else if err != nil {
+		return "", err
+	}
+
+	encrypted, err := keyring.Encrypt(key, value)
+	if err != nil {
+		return "", err
+	}
+	if err := cm.writeKey(key, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: encrypted}); err != nil {
+		return "", err
+	}
+
+	cm.mu.Lock()
+	cm.values[key] = ConfigValue{
+		Value:       encrypted,
+		Source:      SourceEncrypted,
+		IsEncrypted: true,
+		Metadata:    make(map[string]interface{}),
+	}
+	cm.mu.Unlock()
+	return generated, nil
+}
+
+// RotateKey 用新的密钥重新加密配置文件中所有加密的值，返回重新加密的配置项。
+// newKey 不为空时使用新的密钥：先写入 <密钥文件>.new，配置文件写入成功后再替换原密钥文件；
+// newKey 为空时改用口令 passphrase，并删除原密钥文件。
+// 密钥来自环境变量 KeyEnv 时无法自动轮换
+func (cm *ConfigManager) RotateKey(newKey []byte, passphrase string) ([]string, error) {
+	if os.Getenv(KeyEnv) != "" {
+		return nil, fmt.Errorf("密钥来自环境变量 %s，无法自动轮换，请取消该环境变量后重试", KeyEnv)
+	}
+
+	var next *Keyring
+	var err error
+	if newKey != nil {
+		next, err = NewKeyring(newKey, "密钥文件 "+cm.KeyFile())
+	} else {
+		next, err = NewPassphraseKeyring(passphrase, "口令")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	path := cm.ConfigFile()
+	doc, err := readYAML(path)
+	if err != nil {
+		return nil, err
+	}
+
+	type secret struct {
+		key  string
+		node *yaml.Node
+	}
+	var secrets []secret
+	walkScalars(doc, "", func(key string, node *yaml.Node) {
+		if IsEncryptedValue(node.Value) {
+			secrets = append(secrets, secret{key, node})
+		}
+	})
+
+	var current *Keyring
+	if len(secrets) > 0 {
+		if current, err = cm.Keyring(); err != nil {
+			return nil, fmt.Errorf("无法解密现有的加密配置: %v", err)
+		}
+	}
+
+	keys := make([]string, 0, len(secrets))
+	for _, s := range secrets {
+		plaintext, err := current.Decrypt(s.key, s.node.Value)
+		if err != nil {
+			return nil, err
+		}
+		if s.node.Value, err = next.Encrypt(s.key, plaintext); err != nil {
+			return nil, err
+		}
+		keys = append(keys, s.key)
+	}
+
+	keyFile := cm.KeyFile()
+	if newKey != nil {
+		if err := WriteKeyFile(keyFile+".new", newKey); err != nil {
+			return nil, err
+		}
+	}
+	if len(secrets) > 0 {
+		if err := writeYAML(path, doc); err != nil {
+			return nil, err
+		}
+	}
+	if newKey != nil {
+		if err := os.Rename(keyFile+".new", keyFile); err != nil {
+			return nil, fmt.Errorf("替换密钥文件失败，新的密钥保存在 %s.new: %v", keyFile, err)
+		}
+	} else if err := os.Remove(keyFile); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("删除原密钥文件失败: %v", err)
+	}
+
+	cm.mu.Lock()
+	for _, s := range secrets {
+		if value, ok := cm.values[s.key]; ok && value.IsEncrypted {
+			value.Value = s.node.Value
+			cm.values[s.key] = value
+		}
+	}
+	cm.mu.Unlock()
+	cm.SetKeyring(next)
+
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// writeKey 将配置文件中 key（以 . 分隔的路径）的值替换为 value，不存在的中间层级会被创建
+func (cm *ConfigManager) writeKey(key string, value *yaml.Node) error {
+	path := cm.ConfigFile()
+	doc, err := readYAML(path)
+	if err != nil {
+		return err
+	}
+	if err := setNode(doc, key, value); err != nil {
+		return err
+	}
+	return writeYAML(path, doc)
+}
+
+// readYAML 读取 YAML 文件，文件不存在或为空时返回只包含空映射的文档
+func readYAML(path string) (*yaml.Node, error) {
+	doc := &yaml.Node{Kind: yaml.DocumentNode}
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("读取配置文件失败: %v", err)
+	}
+	if len(data) > 0 {
+		if err := yaml.Unmarshal(data, doc); err != nil {
+			return nil, fmt.Errorf("解析配置文件失败: %v", err)
+		}
+	}
+	if len(doc.Content) == 0 {
+		doc.Kind = yaml.DocumentNode
+		doc.Content = []*yaml.Node{{Kind: yaml.MappingNode}}
+	}
+	if doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("配置文件 %s 的顶层不是映射", path)
+	}
+	return doc, nil
+}
+
+// writeYAML 写入 YAML 文件，先写入临时文件再替换，保留原文件的权限
+func writeYAML(path string, doc *yaml.Node) error {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("序列化配置失败: %v", err)
+	}
+	enc.Close()
+	data := buf.Bytes()
+
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("创建配置目录失败: %v", err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, mode); err != nil {
+		return fmt.Errorf("写入配置文件失败: %v", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("写入配置文件失败: %v", err)
+	}
+	return nil
+}
+
+// setNode 在文档中设置 key 的值，键名不区分大小写
+func setNode(doc *yaml.Node, key string, value *yaml.Node) error {
+	node := doc.Content[0]
+	parts := strings.Split(key, ".")
+	for i, part := range parts {
+		if part == "" {
+			return fmt.Errorf("无效的配置项: %s", key)
+		}
+		var child *yaml.Node
+		for j := 0; j+1 < len(node.Content); j += 2 {
+			if strings.EqualFold(node.Content[j].Value, part) {
+				child = node.Content[j+1]
+				if i == len(parts)-1 {
+					value.HeadComment, value.LineComment = child.HeadComment, child.LineComment
+					node.Content[j+1] = value
+					return nil
+				}
+				break
+			}
+		}
+
+		if i == len(parts)-1 {
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: part}, value)
+			return nil
+		}
+		if child == nil {
+			child = &yaml.Node{Kind: yaml.MappingNode}
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: part}, child)
+		}
+		if child.Kind != yaml.MappingNode {
+			return fmt.Errorf("配置项 %s 不是映射，无法设置 %s", strings.Join(parts[:i+1], "."), key)
+		}
+		node = child
+	}
+	return nil
+}
+
+// walkScalars 遍历映射中的所有标量值，key 为小写的以 . 分隔的路径
+func walkScalars(node *yaml.Node, prefix string, fn func(key string, node *yaml.Node)) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			walkScalars(child, prefix, fn)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := strings.ToLower(node.Content[i].Value)
+			if prefix != "" {
+				key = prefix + "." + key
+			}
+			walkScalars(node.Content[i+1], key, fn)
+		}
+	case yaml.ScalarNode:
+		fn(prefix, node)
+	}
+}
@@ -4,23 +4,51 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"os/user"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
+
+	"github.com/Lzww0608/ClixGo/pkg/redact"
 )
 
+// CommandHistory 一条命令历史记录
 type CommandHistory struct {
 	Command   string    `json:"command"`
 	Status    string    `json:"status"`
+	ExitCode  int       `json:"exit_code"`
 	Output    string    `json:"output"`
 	StartTime time.Time `json:"start_time"`
 	EndTime   time.Time `json:"end_time"`
 	Duration  string    `json:"duration"`
+	Dir       string    `json:"dir,omitempty"` // 执行命令的工作目录
+	Hostname  string    `json:"hostname,omitempty"`
+	User      string    `json:"user,omitempty"`
+	SessionID string    `json:"session_id,omitempty"` // 执行命令的终端会话
+}
+
+// Retention 历史记录的保留策略，字段为0表示不限制
+type Retention struct {
+	MaxEntries int           // 最多保留的记录数
+	MaxAge     time.Duration // 记录保留的最长时间
 }
 
+// DefaultRetention 默认的保留策略，可通过配置项 history.max_entries、history.max_age 修改
+var DefaultRetention = Retention{MaxEntries: 10000}
+
+// SessionEnv 指定会话ID的环境变量，未设置时使用父进程（通常是所在的shell）的进程号
+const SessionEnv = "CLIXGO_SESSION_ID"
+
 var (
-	historyFile = filepath.Join(os.Getenv("HOME"), ".clixgo", "history.json")
-	mutex       = &sync.Mutex{} // 添加互斥锁以保护文件访问
+	defaultHistoryFile = filepath.Join(os.Getenv("HOME"), ".clixgo", "history.jsonl")
+	// 旧版本使用的 JSON 数组格式的历史文件，使用默认路径时自动导入
+	legacyHistoryFile = filepath.Join(os.Getenv("HOME"), ".clixgo", "history.json")
+
+	historyFile = defaultHistoryFile
+	retention   = DefaultRetention
+	mutex       = &sync.Mutex{} // 保护进程内的文件访问，进程之间通过文件锁互斥
 )
 
 // SetHistoryFilePath 允许自定义历史文件的路径
@@ -37,72 +65,87 @@ func GetHistoryFilePath() string {
 	return historyFile
 }
 
-func SaveHistory(cmd *CommandHistory) error {
+// SetRetention 设置历史记录的保留策略
+func SetRetention(r Retention) {
 	mutex.Lock()
 	defer mutex.Unlock()
+	retention = r
+}
 
-	// 确保目录存在
-	if err := os.MkdirAll(filepath.Dir(historyFile), 0755); err != nil {
-		return fmt.Errorf("创建历史记录目录失败: %v", err)
-	}
-
-	// 读取现有历史记录
-	var history []CommandHistory
-	if _, err := os.Stat(historyFile); err == nil {
-		data, err := os.ReadFile(historyFile)
-		if err != nil {
-			return fmt.Errorf("读取历史记录失败: %v", err)
-		}
-		if err := json.Unmarshal(data, &history); err != nil {
-			return fmt.Errorf("解析历史记录失败: %v", err)
+// ParseAge 解析保留时间，除 time.ParseDuration 支持的格式外还支持以 d 结尾的天数，如 90d
+func ParseAge(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.ParseFloat(days, 64)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("无效的保留时间: %s", value)
 		}
+		return time.Duration(n * float64(24*time.Hour)), nil
 	}
-
-	// 添加新记录
-	history = append(history, *cmd)
-
-	// 只保留最近的100条记录
-	if len(history) > 100 {
-		history = history[len(history)-100:]
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("无效的保留时间: %s", value)
 	}
+	return d, nil
+}
 
-	// 保存历史记录
-	data, err := json.MarshalIndent(history, "", "  ")
+// SaveHistory 追加一条历史记录，未设置的工作目录、主机名、用户和会话ID自动填充，
+// 命令和输出中的密码、令牌等敏感信息在写入前被替换。多个进程可以同时写入同一个历史文件
+func SaveHistory(cmd *CommandHistory) error {
+	fillMetadata(cmd)
+	redactRecord(cmd)
+	line, err := json.Marshal(cmd)
 	if err != nil {
 		return fmt.Errorf("序列化历史记录失败: %v", err)
 	}
 
-	if err := os.WriteFile(historyFile, data, 0644); err != nil {
-		return fmt.Errorf("保存历史记录失败: %v", err)
+	mutex.Lock()
+	defer mutex.Unlock()
+	if err := migrateDefault(); err != nil {
+		return err
 	}
-
-	return nil
+	return appendRecord(historyFile, line, retention, time.Now())
 }
 
+// GetHistory 按时间顺序返回保留策略范围内的所有历史记录
 func GetHistory() ([]CommandHistory, error) {
 	mutex.Lock()
 	defer mutex.Unlock()
-
-	if _, err := os.Stat(historyFile); os.IsNotExist(err) {
-		return []CommandHistory{}, nil
+	if err := migrateDefault(); err != nil {
+		return nil, err
 	}
 
-	data, err := os.ReadFile(historyFile)
+	history, err := readAll(historyFile)
 	if err != nil {
-		return nil, fmt.Errorf("读取历史记录失败: %v", err)
+		return nil, err
 	}
+	return retention.apply(history, time.Now()), nil
+}
 
-	var history []CommandHistory
-	if err := json.Unmarshal(data, &history); err != nil {
-		return nil, fmt.Errorf("解析历史记录失败: %v", err)
+// GetRecent 返回最近的 n 条历史记录，通过索引直接定位，不需要读取整个历史文件
+func GetRecent(n int) ([]CommandHistory, error) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	if err := migrateDefault(); err != nil {
+		return nil, err
 	}
 
-	return history, nil
+	if retention.MaxEntries > 0 && n > retention.MaxEntries {
+		n = retention.MaxEntries
+	}
+	if n <= 0 {
+		return []CommandHistory{}, nil
+	}
+	history, err := readRecords(historyFile, n)
+	if err != nil {
+		return nil, err
+	}
+	return retention.apply(history, time.Now()), nil
 }
 
 // GetLastHistory 返回最近的一条历史记录
 func GetLastHistory() (*CommandHistory, error) {
-	history, err := GetHistory()
+	history, err := GetRecent(1)
 	if err != nil {
 		return nil, err
 	}
@@ -114,12 +157,95 @@ func GetLastHistory() (*CommandHistory, error) {
 	return &history[len(history)-1], nil
 }
 
+// Import 将外部历史记录合并到历史文件，已存在的记录（命令和开始时间相同）被跳过，
+// 合并后的记录按开始时间排序，返回新增的记录数
+func Import(records []CommandHistory) (int, error) {
+	for i := range records {
+		redactRecord(&records[i])
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if err := migrateDefault(); err != nil {
+		return 0, err
+	}
+	return mergeRecords(historyFile, records, retention, time.Now())
+}
+
+// ClearHistory 删除所有历史记录
 func ClearHistory() error {
 	mutex.Lock()
 	defer mutex.Unlock()
 
-	if err := os.Remove(historyFile); err != nil && !os.IsNotExist(err) {
+	if err := removeAll(historyFile); err != nil {
 		return fmt.Errorf("清除历史记录失败: %v", err)
 	}
 	return nil
 }
+
+// migrateDefault 使用默认路径时导入旧版本的历史文件
+func migrateDefault() error {
+	if historyFile != defaultHistoryFile {
+		return nil
+	}
+	return migrateFile(legacyHistoryFile, historyFile)
+}
+
+// apply 去掉超出保留策略的记录，只在读取时过滤，文件由写入时的压缩清理
+func (r Retention) apply(history []CommandHistory, now time.Time) []CommandHistory {
+	if r.MaxAge > 0 {
+		kept := history[:0]
+		for _, h := range history {
+			if h.StartTime.IsZero() || now.Sub(h.StartTime) <= r.MaxAge {
+				kept = append(kept, h)
+			}
+		}
+		history = kept
+	}
+	if r.MaxEntries > 0 && len(history) > r.MaxEntries {
+		history = history[len(history)-r.MaxEntries:]
+	}
+	return history
+}
+
+var (
+	userOnce    sync.Once
+	currentUser string
+)
+
+// fillMetadata 填充记录中未设置的执行环境信息
+func fillMetadata(cmd *CommandHistory) {
+	if cmd.Dir == "" {
+		cmd.Dir, _ = os.Getwd()
+	}
+	if cmd.Hostname == "" {
+		cmd.Hostname, _ = os.Hostname()
+	}
+	if cmd.User == "" {
+		userOnce.Do(func() {
+			if u, err := user.Current(); err == nil {
+				currentUser = u.Username
+			} else {
+				currentUser = os.Getenv("USER")
+			}
+		})
+		cmd.User = currentUser
+	}
+	if cmd.SessionID == "" {
+		cmd.SessionID = SessionID()
+	}
+}
+
+// redactRecord 替换记录中的敏感信息
+func redactRecord(cmd *CommandHistory) {
+	cmd.Command = redact.String(cmd.Command)
+	cmd.Output = redact.String(cmd.Output)
+}
+
+// SessionID 返回当前终端会话的ID
+func SessionID() string {
+	if id := os.Getenv(SessionEnv); id != "" {
+		return id
+	}
+	return fmt.Sprintf("ppid-%d", os.Getppid())
+}
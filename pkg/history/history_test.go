@@ -110,6 +110,10 @@ func TestSaveHistory_MaxEntries(t *testing.T) {
 	// 设置临时历史文件
 	setupTempHistory(t)
 
+	// 保留条数可配置，这里限制为100条
+	SetRetention(Retention{MaxEntries: 100})
+	t.Cleanup(func() { SetRetention(DefaultRetention) })
+
 	// 创建多条历史记录（超过100条）
 	now := time.Now()
 	for i := 0; i < 110; i++ {
@@ -280,3 +284,25 @@ func TestSetAndGetHistoryFilePath(t *testing.T) {
 	currentPath := GetHistoryFilePath()
 	assert.Equal(t, newPath, currentPath, "历史文件路径应该已更改")
 }
+
+// 测试保存前替换命令和输出中的敏感信息
+func TestSaveHistory_Redaction(t *testing.T) {
+	original := GetHistoryFilePath()
+	SetHistoryFilePath(filepath.Join(t.TempDir(), "history.jsonl"))
+	defer SetHistoryFilePath(original)
+
+	require.NoError(t, SaveHistory(&CommandHistory{
+		Command:   "curl -H 'Authorization: Bearer abcdefgh12345678' https://api",
+		Output:    "export DB_PASSWORD=hunter2",
+		StartTime: time.Now(),
+	}))
+
+	data, err := os.ReadFile(GetHistoryFilePath())
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "abcdefgh12345678")
+	assert.NotContains(t, string(data), "hunter2")
+
+	last, err := GetLastHistory()
+	require.NoError(t, err)
+	assert.Equal(t, "export DB_PASSWORD=[REDACTED]", last.Output)
+}
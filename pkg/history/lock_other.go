@@ -0,0 +1,15 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd
+
+package history
+
+import "os"
+
+// lockFile 当前平台不支持跨进程的文件锁，只依赖进程内的互斥锁
+func lockFile(f *os.File, exclusive bool) error {
+	return nil
+}
+
+// unlockFile 当前平台不支持跨进程的文件锁
+func unlockFile(f *os.File) error {
+	return nil
+}
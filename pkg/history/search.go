@@ -0,0 +1,199 @@
+package history
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Filter 按状态、退出码、工作目录和时间范围筛选历史记录，零值字段不参与筛选
+type Filter struct {
+	Status   string    // 执行状态，如 success、failed
+	ExitCode *int      // 退出码
+	Dir      string    // 工作目录，匹配该目录及其子目录
+	Since    time.Time // 开始时间不早于该时间
+	Until    time.Time // 开始时间早于该时间
+}
+
+// Match 判断历史记录是否满足筛选条件
+func (f Filter) Match(h *CommandHistory) bool {
+	if f.Status != "" && !strings.EqualFold(h.Status, f.Status) {
+		return false
+	}
+	if f.ExitCode != nil && h.ExitCode != *f.ExitCode {
+		return false
+	}
+	if f.Dir != "" {
+		dir := filepath.Clean(f.Dir)
+		if h.Dir != dir && !strings.HasPrefix(h.Dir, strings.TrimSuffix(dir, string(filepath.Separator))+string(filepath.Separator)) {
+			return false
+		}
+	}
+	if !f.Since.IsZero() && h.StartTime.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !h.StartTime.Before(f.Until) {
+		return false
+	}
+	return true
+}
+
+// Match 一条匹配的历史记录，Index 是记录在 GetHistory 结果中的位置，可用于 history show
+type Match struct {
+	Index int `json:"index"`
+	Score int `json:"score,omitempty"`
+	CommandHistory
+}
+
+// Search 在历史记录中模糊搜索命令，query 按空白拆分为多个词，每个词都要匹配。
+// 结果按匹配得分从高到低排列，得分相同时较新的记录在前；query 为空时按时间倒序返回所有满足筛选条件的记录
+func Search(entries []CommandHistory, query string, f Filter) []Match {
+	terms := strings.Fields(query)
+	var matches []Match
+	for i := range entries {
+		if !f.Match(&entries[i]) {
+			continue
+		}
+		total, ok := 0, true
+		for _, term := range terms {
+			score, matched := FuzzyScore(term, entries[i].Command)
+			if !matched {
+				ok = false
+				break
+			}
+			total += score
+		}
+		if ok {
+			matches = append(matches, Match{Index: i, Score: total, CommandHistory: entries[i]})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].Index > matches[j].Index
+	})
+	return matches
+}
+
+// Grep 返回命令（inOutput 为真时也包括输出）匹配正则表达式的记录，按时间顺序排列
+func Grep(entries []CommandHistory, re *regexp.Regexp, f Filter, inOutput bool) []Match {
+	var matches []Match
+	for i := range entries {
+		h := &entries[i]
+		if !f.Match(h) {
+			continue
+		}
+		if re.MatchString(h.Command) || (inOutput && re.MatchString(h.Output)) {
+			matches = append(matches, Match{Index: i, CommandHistory: *h})
+		}
+	}
+	return matches
+}
+
+// 模糊匹配的得分规则，与 fzf 类似：连续匹配和单词开头的匹配得分更高，跳过的字符扣分
+const (
+	scoreMatch       = 16
+	bonusConsecutive = 8
+	bonusBoundary    = 10
+	bonusFirstChar   = 4
+	penaltyGap       = 1
+)
+
+// FuzzyScore 判断 pattern 的字符是否按顺序出现在 text 中，并返回匹配得分。
+// pattern 全部为小写时忽略大小写
+func FuzzyScore(pattern, text string) (int, bool) {
+	if pattern == "" {
+		return 0, true
+	}
+	p := []rune(pattern)
+	t := []rune(text)
+	caseSensitive := strings.ToLower(pattern) != pattern
+	if !caseSensitive {
+		t = []rune(strings.ToLower(text))
+	}
+	if len(p) > len(t) {
+		return 0, false
+	}
+
+	// 先找到能匹配的最早结束位置，再从该位置向前找最短的匹配区间，
+	// 避免 "ab" 在 "a....ab" 中从第一个 a 开始匹配而得到很低的分数
+	end := -1
+	for ti, pi := 0, 0; ti < len(t); ti++ {
+		if t[ti] == p[pi] {
+			pi++
+			if pi == len(p) {
+				end = ti
+				break
+			}
+		}
+	}
+	if end < 0 {
+		return 0, false
+	}
+	start := end
+	for ti, pi := end, len(p)-1; ti >= 0; ti-- {
+		if t[ti] == p[pi] {
+			start = ti
+			pi--
+			if pi < 0 {
+				break
+			}
+		}
+	}
+
+	score, prev := 0, -1
+	for ti, pi := start, 0; ti <= end && pi < len(p); ti++ {
+		if t[ti] != p[pi] {
+			continue
+		}
+		score += scoreMatch
+		switch {
+		case ti == 0:
+			score += bonusBoundary + bonusFirstChar
+		case isBoundary(t[ti-1], t[ti]):
+			score += bonusBoundary
+		}
+		if prev >= 0 {
+			if ti == prev+1 {
+				score += bonusConsecutive
+			} else {
+				score -= penaltyGap * (ti - prev - 1)
+			}
+		}
+		prev = ti
+		pi++
+	}
+	return score, true
+}
+
+// isBoundary 判断 cur 是否位于单词开头：前一个字符是分隔符，或从小写变为大写
+func isBoundary(prev, cur rune) bool {
+	if unicode.IsSpace(prev) || strings.ContainsRune("/-_.:=,;|&'\"", prev) {
+		return true
+	}
+	return unicode.IsLower(prev) && unicode.IsUpper(cur)
+}
+
+// ParseTime 解析时间范围的端点，支持 2006-01-02、2006-01-02 15:04、RFC3339，
+// 以及相对于 now 的时长，如 2h、7d 表示 now 之前的时间
+func ParseTime(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range []string{"2006-01-02", "2006-01-02 15:04", "2006-01-02 15:04:05"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if d, err := ParseAge(value); err == nil {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("无效的时间: %s", value)
+}
@@ -0,0 +1,115 @@
+package history
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func searchEntries(now time.Time) []CommandHistory {
+	return []CommandHistory{
+		{Command: "git status", Status: "success", Dir: "/src/app", StartTime: now.Add(-72 * time.Hour)},
+		{Command: "go test ./...", Status: "failed", ExitCode: 1, Dir: "/src/app/pkg", Output: "FAIL pkg/history", StartTime: now.Add(-2 * time.Hour)},
+		{Command: "git stash", Status: "success", Dir: "/src/other", StartTime: now.Add(-time.Hour)},
+		{Command: "make test", Status: "failed", ExitCode: 2, Dir: "/src/application", StartTime: now},
+	}
+}
+
+// 测试模糊匹配得分
+func TestFuzzyScore(t *testing.T) {
+	_, ok := FuzzyScore("gst", "git status")
+	assert.True(t, ok)
+	_, ok = FuzzyScore("gts", "git status")
+	assert.True(t, ok)
+	_, ok = FuzzyScore("xyz", "git status")
+	assert.False(t, ok)
+
+	// 单词开头和连续匹配的得分更高
+	boundary, _ := FuzzyScore("gs", "git status")
+	inner, _ := FuzzyScore("gs", "logs")
+	assert.Greater(t, boundary, inner)
+	consecutive, _ := FuzzyScore("test", "make test")
+	scattered, _ := FuzzyScore("test", "txexsxt")
+	assert.Greater(t, consecutive, scattered)
+
+	// 模式含大写字母时区分大小写
+	_, ok = FuzzyScore("Git", "git status")
+	assert.False(t, ok)
+	_, ok = FuzzyScore("git", "Git Status")
+	assert.True(t, ok)
+}
+
+// 测试搜索结果的筛选和排序
+func TestSearch(t *testing.T) {
+	now := time.Now()
+	entries := searchEntries(now)
+
+	matches := Search(entries, "gstat", Filter{})
+	require.Len(t, matches, 1)
+	assert.Equal(t, "git status", matches[0].Command)
+
+	// 得分高的在前
+	matches = Search(entries, "gst", Filter{})
+	require.Len(t, matches, 3)
+	assert.Equal(t, "go test ./...", matches[2].Command)
+
+	// 空查询按时间倒序返回
+	matches = Search(entries, "", Filter{})
+	require.Len(t, matches, 4)
+	assert.Equal(t, 3, matches[0].Index)
+
+	// 多个词都要匹配
+	matches = Search(entries, "git sta", Filter{})
+	assert.Len(t, matches, 2)
+	matches = Search(entries, "git test", Filter{})
+	assert.Empty(t, matches)
+
+	code := 1
+	matches = Search(entries, "", Filter{ExitCode: &code})
+	require.Len(t, matches, 1)
+	assert.Equal(t, 1, matches[0].Index)
+
+	matches = Search(entries, "", Filter{Status: "failed", Dir: "/src/app"})
+	require.Len(t, matches, 1, "目录筛选应包含子目录但不匹配同前缀的其他目录")
+	assert.Equal(t, "go test ./...", matches[0].Command)
+
+	matches = Search(entries, "", Filter{Since: now.Add(-3 * time.Hour), Until: now})
+	assert.Len(t, matches, 2)
+}
+
+// 测试正则搜索
+func TestGrep(t *testing.T) {
+	entries := searchEntries(time.Now())
+
+	matches := Grep(entries, regexp.MustCompile(`^git `), Filter{}, false)
+	require.Len(t, matches, 2)
+	assert.Equal(t, []int{0, 2}, []int{matches[0].Index, matches[1].Index})
+
+	assert.Empty(t, Grep(entries, regexp.MustCompile(`FAIL`), Filter{}, false))
+	matches = Grep(entries, regexp.MustCompile(`FAIL`), Filter{}, true)
+	require.Len(t, matches, 1)
+	assert.Equal(t, "go test ./...", matches[0].Command)
+}
+
+// 测试解析时间范围
+func TestParseTime(t *testing.T) {
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.Local)
+
+	tm, err := ParseTime("2024-05-01", now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 5, 1, 0, 0, 0, 0, time.Local), tm)
+
+	tm, err = ParseTime("7d", now)
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(-7*24*time.Hour), tm)
+
+	tm, err = ParseTime("2h", now)
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(-2*time.Hour), tm)
+
+	_, err = ParseTime("yesterday", now)
+	assert.Error(t, err)
+}
@@ -0,0 +1,354 @@
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 支持导入和导出的历史文件格式
+const (
+	FormatBash = "bash"
+	FormatZsh  = "zsh"
+	FormatFish = "fish"
+	FormatJSON = "json"
+	FormatCSV  = "csv"
+)
+
+// StatusImported 从shell历史导入的记录的状态，这些记录没有退出码（ExitCode 为 -1）
+const StatusImported = "imported"
+
+// DefaultShellFile 返回shell默认的历史文件路径，bash 和 zsh 优先使用 HISTFILE
+func DefaultShellFile(shell string) (string, error) {
+	home := os.Getenv("HOME")
+	switch shell {
+	case FormatBash, FormatZsh:
+		if file := os.Getenv("HISTFILE"); file != "" {
+			return file, nil
+		}
+		if shell == FormatBash {
+			return filepath.Join(home, ".bash_history"), nil
+		}
+		return filepath.Join(home, ".zsh_history"), nil
+	case FormatFish:
+		dataHome := os.Getenv("XDG_DATA_HOME")
+		if dataHome == "" {
+			dataHome = filepath.Join(home, ".local", "share")
+		}
+		return filepath.Join(dataHome, "fish", "fish_history"), nil
+	default:
+		return "", fmt.Errorf("不支持的shell: %s", shell)
+	}
+}
+
+// ParseShell 解析 bash、zsh 或 fish 的历史文件，按文件中的顺序返回记录。
+// 没有时间戳的记录使用前一条记录的时间，文件开头没有时间戳的记录使用之后第一条记录的时间，
+// 整个文件都没有时间戳时使用 fallback（通常是文件的修改时间），这样重复导入同一个文件不会产生重复记录
+func ParseShell(r io.Reader, shell string, fallback time.Time) ([]CommandHistory, error) {
+	var records []CommandHistory
+	var err error
+	switch shell {
+	case FormatBash:
+		records, err = parseBash(r)
+	case FormatZsh:
+		records, err = parseZsh(r)
+	case FormatFish:
+		records, err = parseFish(r)
+	default:
+		return nil, fmt.Errorf("不支持的shell: %s", shell)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("解析 %s 历史文件失败: %v", shell, err)
+	}
+
+	fillTimes(records, fallback)
+	for i := range records {
+		records[i].Status = StatusImported
+		records[i].ExitCode = -1
+		if records[i].EndTime.IsZero() {
+			records[i].EndTime = records[i].StartTime
+		}
+	}
+	return records, nil
+}
+
+// fillTimes 为没有时间戳的记录填充时间
+func fillTimes(records []CommandHistory, fallback time.Time) {
+	last := time.Time{}
+	for i := range records {
+		if !records[i].StartTime.IsZero() {
+			last = records[i].StartTime
+			continue
+		}
+		if last.IsZero() {
+			for j := i + 1; j < len(records); j++ {
+				if !records[j].StartTime.IsZero() {
+					last = records[j].StartTime
+					break
+				}
+			}
+			if last.IsZero() {
+				last = fallback
+			}
+		}
+		records[i].StartTime = last
+	}
+}
+
+// newLineScanner 返回不限制行长度的按行扫描器
+func newLineScanner(r io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	return scanner
+}
+
+var bashTimestamp = regexp.MustCompile(`^#(\d{9,})$`)
+
+// parseBash 解析 bash 历史文件。设置了 HISTTIMEFORMAT 时每条命令前有 "#<秒级时间戳>" 行，
+// 此时两个时间戳之间的所有行属于同一条（多行）命令；没有时间戳时每行是一条命令
+func parseBash(r io.Reader) ([]CommandHistory, error) {
+	var records []CommandHistory
+	var lines []string
+	var when time.Time
+	timed := false
+
+	flush := func() {
+		if command := strings.Join(lines, "\n"); strings.TrimSpace(command) != "" {
+			records = append(records, CommandHistory{Command: command, StartTime: when})
+		}
+		lines = nil
+	}
+
+	scanner := newLineScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if m := bashTimestamp.FindStringSubmatch(line); m != nil {
+			flush()
+			sec, _ := strconv.ParseInt(m[1], 10, 64)
+			when, timed = time.Unix(sec, 0), true
+			continue
+		}
+		lines = append(lines, line)
+		if !timed {
+			flush()
+		}
+	}
+	flush()
+	return records, scanner.Err()
+}
+
+var zshExtended = regexp.MustCompile(`(?s)^: *(\d+):(\d+);(.*)$`)
+
+// parseZsh 解析 zsh 历史文件，支持 EXTENDED_HISTORY 格式 ": <开始时间>:<耗时>;<命令>"，
+// 多行命令的每一行（最后一行除外）以反斜杠结尾
+func parseZsh(r io.Reader) ([]CommandHistory, error) {
+	var records []CommandHistory
+	var entry []string
+
+	flush := func() {
+		if len(entry) == 0 {
+			return
+		}
+		text := strings.Join(entry, "\n")
+		entry = nil
+
+		record := CommandHistory{Command: text}
+		if m := zshExtended.FindStringSubmatch(text); m != nil {
+			sec, _ := strconv.ParseInt(m[1], 10, 64)
+			elapsed, _ := strconv.ParseInt(m[2], 10, 64)
+			record.Command = m[3]
+			record.StartTime = time.Unix(sec, 0)
+			record.EndTime = record.StartTime.Add(time.Duration(elapsed) * time.Second)
+			record.Duration = (time.Duration(elapsed) * time.Second).String()
+		}
+		if strings.TrimSpace(record.Command) != "" {
+			records = append(records, record)
+		}
+	}
+
+	scanner := newLineScanner(r)
+	for scanner.Scan() {
+		line := string(unmetafy(bytes.TrimSuffix(scanner.Bytes(), []byte("\r"))))
+		if continued, ok := strings.CutSuffix(line, "\\"); ok && !strings.HasSuffix(continued, "\\") {
+			entry = append(entry, continued)
+			continue
+		}
+		entry = append(entry, line)
+		flush()
+	}
+	flush()
+	return records, scanner.Err()
+}
+
+// parseFish 解析 fish 的历史文件。文件为类 YAML 的列表，每条记录以 "- cmd: <命令>" 开头，
+// 之后缩进的 "when: <秒级时间戳>" 为执行时间，paths 等其他字段被忽略
+func parseFish(r io.Reader) ([]CommandHistory, error) {
+	var records []CommandHistory
+	scanner := newLineScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if command, ok := strings.CutPrefix(line, "- cmd: "); ok {
+			records = append(records, CommandHistory{Command: fishUnescape(command)})
+			continue
+		}
+		if value, ok := strings.CutPrefix(strings.TrimSpace(line), "when: "); ok && len(records) > 0 {
+			sec, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("无效的时间: %s", value)
+			}
+			records[len(records)-1].StartTime = time.Unix(sec, 0)
+		}
+	}
+	return records, scanner.Err()
+}
+
+// fishUnescape 还原 fish 历史中转义的换行和反斜杠
+func fishUnescape(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			switch s[i+1] {
+			case 'n':
+				b.WriteByte('\n')
+				i++
+				continue
+			case '\\':
+				b.WriteByte('\\')
+				i++
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// fishEscape 按 fish 历史文件的规则转义换行和反斜杠
+func fishEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(s)
+}
+
+// zsh 在历史文件中对字节 0x83 以及 0x84~0xa2 的内部标记字符和 0 做转义：
+// 写入 zshMeta 后跟原字节异或 32
+const zshMeta = 0x83
+
+func isZshMeta(c byte) bool {
+	return c == 0 || (c >= zshMeta && c <= 0xa2)
+}
+
+// unmetafy 还原 zsh 转义过的字节
+func unmetafy(b []byte) []byte {
+	if bytes.IndexByte(b, zshMeta) < 0 {
+		return b
+	}
+	out := make([]byte, 0, len(b))
+	for i := 0; i < len(b); i++ {
+		if b[i] == zshMeta && i+1 < len(b) {
+			i++
+			out = append(out, b[i]^32)
+			continue
+		}
+		out = append(out, b[i])
+	}
+	return out
+}
+
+// metafy 按 zsh 的规则转义字节
+func metafy(s string) []byte {
+	out := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		if isZshMeta(s[i]) {
+			out = append(out, zshMeta, s[i]^32)
+			continue
+		}
+		out = append(out, s[i])
+	}
+	return out
+}
+
+// Dedup 每个命令只保留最后一次出现的记录，其余记录保持原来的顺序
+func Dedup(records []CommandHistory) []CommandHistory {
+	last := make(map[string]int, len(records))
+	for i := range records {
+		last[records[i].Command] = i
+	}
+	kept := make([]CommandHistory, 0, len(last))
+	for i := range records {
+		if last[records[i].Command] == i {
+			kept = append(kept, records[i])
+		}
+	}
+	return kept
+}
+
+// Export 将记录按 format 写入 w：bash 和 zsh 为带时间戳的历史文件格式，
+// fish 为 fish_history 格式，json 为记录数组，csv 第一行为表头
+func Export(w io.Writer, records []CommandHistory, format string) error {
+	bw := bufio.NewWriter(w)
+	switch format {
+	case FormatBash:
+		for _, h := range records {
+			fmt.Fprintf(bw, "#%d\n%s\n", h.StartTime.Unix(), h.Command)
+		}
+	case FormatZsh:
+		for _, h := range records {
+			elapsed := int64(0)
+			if !h.EndTime.IsZero() && h.EndTime.After(h.StartTime) {
+				elapsed = int64(h.EndTime.Sub(h.StartTime) / time.Second)
+			}
+			command := strings.ReplaceAll(h.Command, "\n", "\\\n")
+			fmt.Fprintf(bw, ": %d:%d;", h.StartTime.Unix(), elapsed)
+			bw.Write(metafy(command))
+			bw.WriteByte('\n')
+		}
+	case FormatFish:
+		for _, h := range records {
+			fmt.Fprintf(bw, "- cmd: %s\n  when: %d\n", fishEscape(h.Command), h.StartTime.Unix())
+		}
+	case FormatJSON:
+		if records == nil {
+			records = []CommandHistory{}
+		}
+		enc := json.NewEncoder(bw)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(records); err != nil {
+			return err
+		}
+	case FormatCSV:
+		cw := csv.NewWriter(bw)
+		cw.Write([]string{"start_time", "end_time", "command", "status", "exit_code", "duration", "dir", "hostname", "user", "session_id"})
+		for _, h := range records {
+			cw.Write([]string{
+				h.StartTime.Format(time.RFC3339),
+				h.EndTime.Format(time.RFC3339),
+				h.Command,
+				h.Status,
+				strconv.Itoa(h.ExitCode),
+				h.Duration,
+				h.Dir,
+				h.Hostname,
+				h.User,
+				h.SessionID,
+			})
+		}
+		cw.Flush()
+		if err := cw.Error(); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("不支持的导出格式: %s", format)
+	}
+	return bw.Flush()
+}
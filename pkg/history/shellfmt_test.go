@@ -0,0 +1,152 @@
+package history
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 测试解析 bash 历史文件
+func TestParseShell_Bash(t *testing.T) {
+	fallback := time.Unix(1600000000, 0)
+
+	records, err := ParseShell(strings.NewReader("ls -la\ncd /tmp\n\nmake\n"), FormatBash, fallback)
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	assert.Equal(t, "cd /tmp", records[1].Command)
+	assert.Equal(t, fallback, records[1].StartTime)
+	assert.Equal(t, StatusImported, records[1].Status)
+	assert.Equal(t, -1, records[1].ExitCode)
+
+	// 有时间戳时两个时间戳之间的多行属于同一条命令
+	records, err = ParseShell(strings.NewReader("#1700000000\nfor i in 1 2; do\n  echo $i\ndone\n#1700000100\npwd\n"), FormatBash, fallback)
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, "for i in 1 2; do\n  echo $i\ndone", records[0].Command)
+	assert.Equal(t, time.Unix(1700000000, 0), records[0].StartTime)
+	assert.Equal(t, time.Unix(1700000100, 0), records[1].StartTime)
+}
+
+// 测试解析 zsh 历史文件
+func TestParseShell_Zsh(t *testing.T) {
+	input := ": 1700000000:3;make build\n" +
+		": 1700000010:0;echo one\\\necho two\n" +
+		"plain command\n" +
+		": 1700000020:0;echo " + string(metafy("你好")) + "\n"
+
+	records, err := ParseShell(strings.NewReader(input), FormatZsh, time.Now())
+	require.NoError(t, err)
+	require.Len(t, records, 4)
+	assert.Equal(t, "make build", records[0].Command)
+	assert.Equal(t, "3s", records[0].Duration)
+	assert.Equal(t, time.Unix(1700000003, 0), records[0].EndTime)
+	assert.Equal(t, "echo one\necho two", records[1].Command)
+	assert.Equal(t, "plain command", records[2].Command)
+	assert.Equal(t, time.Unix(1700000010, 0), records[2].StartTime, "没有时间戳的记录使用前一条记录的时间")
+	assert.Equal(t, "echo 你好", records[3].Command)
+}
+
+// 测试解析 fish 历史文件
+func TestParseShell_Fish(t *testing.T) {
+	input := "- cmd: git status\n  when: 1700000000\n- cmd: echo a\\nb \\\\ c\n  when: 1700000050\n  paths:\n    - /tmp\n"
+
+	records, err := ParseShell(strings.NewReader(input), FormatFish, time.Now())
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, "git status", records[0].Command)
+	assert.Equal(t, "echo a\nb \\ c", records[1].Command)
+	assert.Equal(t, time.Unix(1700000050, 0), records[1].StartTime)
+
+	_, err = ParseShell(strings.NewReader(input), "tcsh", time.Now())
+	assert.Error(t, err)
+}
+
+// 测试导出后能按相同格式解析还原
+func TestExport_RoundTrip(t *testing.T) {
+	records := []CommandHistory{
+		{Command: "ls -la", StartTime: time.Unix(1700000000, 0), EndTime: time.Unix(1700000002, 0)},
+		{Command: "echo 'a\nb' \\ 你好", StartTime: time.Unix(1700000100, 0)},
+	}
+
+	for _, format := range []string{FormatBash, FormatZsh, FormatFish} {
+		var buf bytes.Buffer
+		require.NoError(t, Export(&buf, records, format), format)
+		parsed, err := ParseShell(&buf, format, time.Now())
+		require.NoError(t, err, format)
+		require.Len(t, parsed, 2, format)
+		for i := range records {
+			assert.Equal(t, records[i].Command, parsed[i].Command, format)
+			assert.Equal(t, records[i].StartTime, parsed[i].StartTime, format)
+		}
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, Export(&buf, records, FormatJSON))
+	var decoded []CommandHistory
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, records[1].Command, decoded[1].Command)
+
+	buf.Reset()
+	require.NoError(t, Export(&buf, records, FormatCSV))
+	rows, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 3)
+	assert.Equal(t, "command", rows[0][2])
+	assert.Equal(t, records[1].Command, rows[2][2])
+
+	assert.Error(t, Export(&buf, records, "xml"))
+}
+
+// 测试去重保留每个命令最后一次出现的记录
+func TestDedup(t *testing.T) {
+	records := []CommandHistory{{Command: "a"}, {Command: "b"}, {Command: "a"}, {Command: "c"}}
+	var commands []string
+	for _, h := range Dedup(records) {
+		commands = append(commands, h.Command)
+	}
+	assert.Equal(t, []string{"b", "a", "c"}, commands)
+}
+
+// 测试导入时去重并按时间排序
+func TestImport(t *testing.T) {
+	original := GetHistoryFilePath()
+	SetHistoryFilePath(filepath.Join(t.TempDir(), "history.jsonl"))
+	defer SetHistoryFilePath(original)
+
+	require.NoError(t, SaveHistory(&CommandHistory{Command: "local", StartTime: time.Unix(1700000050, 0)}))
+
+	imported := []CommandHistory{
+		{Command: "late", StartTime: time.Unix(1700000100, 0)},
+		{Command: "early", StartTime: time.Unix(1700000000, 0)},
+		{Command: "early", StartTime: time.Unix(1700000000, 0)},
+	}
+	added, err := Import(imported)
+	require.NoError(t, err)
+	assert.Equal(t, 2, added)
+
+	// 重复导入不会产生重复记录
+	added, err = Import(imported)
+	require.NoError(t, err)
+	assert.Equal(t, 0, added)
+
+	records, err := GetHistory()
+	require.NoError(t, err)
+	var commands []string
+	for _, h := range records {
+		commands = append(commands, h.Command)
+	}
+	assert.Equal(t, []string{"early", "local", "late"}, commands)
+
+	// 导入后索引仍然可用
+	recent, err := GetRecent(1)
+	require.NoError(t, err)
+	require.Len(t, recent, 1)
+	assert.Equal(t, "late", recent[0].Command)
+}
@@ -0,0 +1,200 @@
+package history
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// StatsOptions 统计选项
+type StatsOptions struct {
+	ByProgram bool // 按程序名（命令的第一个词）而不是完整命令分组
+	Top       int  // 最常用命令和最慢命令各保留的条数，0 表示不限制
+}
+
+// Percentiles 耗时的分位数，JSON 中以毫秒表示
+type Percentiles struct {
+	P50 time.Duration
+	P90 time.Duration
+	P99 time.Duration
+	Max time.Duration
+}
+
+// MarshalJSON 以毫秒输出分位数
+func (p Percentiles) MarshalJSON() ([]byte, error) {
+	ms := func(d time.Duration) float64 {
+		return math.Round(float64(d)/float64(time.Microsecond)) / 1000
+	}
+	return json.Marshal(map[string]float64{
+		"p50_ms": ms(p.P50),
+		"p90_ms": ms(p.P90),
+		"p99_ms": ms(p.P99),
+		"max_ms": ms(p.Max),
+	})
+}
+
+// CommandStat 一个命令（或程序）的使用统计
+type CommandStat struct {
+	Command  string `json:"command"`
+	Count    int    `json:"count"`
+	Failures int    `json:"failures"`
+	// FailureRate 失败次数占已知状态执行次数的比例，导入的记录没有执行状态，不参与计算
+	FailureRate float64     `json:"failure_rate"`
+	Duration    Percentiles `json:"duration"`
+	LastRun     time.Time   `json:"last_run"`
+}
+
+// Report 历史记录的使用统计
+type Report struct {
+	Total     int           `json:"total"`
+	Failures  int           `json:"failures"`
+	Since     time.Time     `json:"since"`
+	Until     time.Time     `json:"until"`
+	Duration  Percentiles   `json:"duration"`
+	Commands  []CommandStat `json:"commands"`   // 按使用次数从多到少排列
+	ByHour    [24]int       `json:"by_hour"`    // 按开始时间的小时（本地时间）统计的执行次数
+	ByWeekday [7]int        `json:"by_weekday"` // 按星期统计的执行次数，0 为星期日
+	Slowest   []Match       `json:"slowest"`    // 耗时最长的记录
+}
+
+// Stats 统计历史记录中命令的使用次数、失败率、耗时分布和使用时段
+func Stats(entries []CommandHistory, opts StatsOptions) *Report {
+	report := &Report{Total: len(entries), Commands: []CommandStat{}, Slowest: []Match{}}
+
+	type group struct {
+		stat      CommandStat
+		known     int
+		durations []time.Duration
+	}
+	groups := make(map[string]*group)
+	var all []time.Duration
+	var timed []Match
+
+	for i := range entries {
+		h := &entries[i]
+		key := h.Command
+		if opts.ByProgram {
+			key = Program(h.Command)
+		}
+		g := groups[key]
+		if g == nil {
+			g = &group{stat: CommandStat{Command: key}}
+			groups[key] = g
+		}
+
+		g.stat.Count++
+		if h.StartTime.After(g.stat.LastRun) {
+			g.stat.LastRun = h.StartTime
+		}
+		if h.Status != StatusImported {
+			g.known++
+		}
+		if h.Status == "failed" {
+			g.stat.Failures++
+			report.Failures++
+		}
+		if d, ok := h.Elapsed(); ok {
+			g.durations = append(g.durations, d)
+			all = append(all, d)
+			timed = append(timed, Match{Index: i, CommandHistory: *h})
+		}
+
+		if !h.StartTime.IsZero() {
+			local := h.StartTime.Local()
+			report.ByHour[local.Hour()]++
+			report.ByWeekday[local.Weekday()]++
+			if report.Since.IsZero() || h.StartTime.Before(report.Since) {
+				report.Since = h.StartTime
+			}
+			if h.StartTime.After(report.Until) {
+				report.Until = h.StartTime
+			}
+		}
+	}
+
+	for _, g := range groups {
+		if g.known > 0 {
+			g.stat.FailureRate = float64(g.stat.Failures) / float64(g.known)
+		}
+		g.stat.Duration = percentiles(g.durations)
+		report.Commands = append(report.Commands, g.stat)
+	}
+	sort.Slice(report.Commands, func(i, j int) bool {
+		a, b := report.Commands[i], report.Commands[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Command < b.Command
+	})
+	report.Duration = percentiles(all)
+
+	sort.SliceStable(timed, func(i, j int) bool {
+		a, _ := timed[i].Elapsed()
+		b, _ := timed[j].Elapsed()
+		return a > b
+	})
+	report.Slowest = append(report.Slowest, timed...)
+
+	if opts.Top > 0 {
+		if len(report.Commands) > opts.Top {
+			report.Commands = report.Commands[:opts.Top]
+		}
+		if len(report.Slowest) > opts.Top {
+			report.Slowest = report.Slowest[:opts.Top]
+		}
+	}
+	return report
+}
+
+// Elapsed 返回命令的耗时，优先使用 Duration 字段，没有时使用结束时间与开始时间之差。
+// 导入的 shell 历史记录没有真实的耗时（zsh 的 ": <时间>:0;" 只表示未记录或不足一秒），
+// 耗时为 0 时视为未知，不参与耗时统计
+func (h *CommandHistory) Elapsed() (time.Duration, bool) {
+	d, ok := h.elapsed()
+	if ok && d == 0 && h.Status == StatusImported {
+		return 0, false
+	}
+	return d, ok
+}
+
+func (h *CommandHistory) elapsed() (time.Duration, bool) {
+	if h.Duration != "" {
+		if d, err := time.ParseDuration(h.Duration); err == nil {
+			return d, true
+		}
+	}
+	if !h.StartTime.IsZero() && h.EndTime.After(h.StartTime) {
+		return h.EndTime.Sub(h.StartTime), true
+	}
+	return 0, false
+}
+
+// percentiles 按最近秩法计算分位数
+func percentiles(durations []time.Duration) Percentiles {
+	if len(durations) == 0 {
+		return Percentiles{}
+	}
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	rank := func(p float64) time.Duration {
+		i := int(math.Ceil(p*float64(len(sorted)))) - 1
+		if i < 0 {
+			i = 0
+		}
+		return sorted[i]
+	}
+	return Percentiles{P50: rank(0.5), P90: rank(0.9), P99: rank(0.99), Max: sorted[len(sorted)-1]}
+}
+
+// Program 返回命令的程序名：跳过开头的环境变量赋值后的第一个词
+func Program(command string) string {
+	for _, word := range strings.Fields(command) {
+		if name, _, ok := strings.Cut(word, "="); ok && name != "" && !strings.ContainsAny(name, `/'"$`) {
+			continue
+		}
+		return strings.Trim(word, `'"`)
+	}
+	return strings.TrimSpace(command)
+}
@@ -0,0 +1,86 @@
+package history
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 测试使用统计
+func TestStats(t *testing.T) {
+	monday := time.Date(2024, 5, 6, 9, 30, 0, 0, time.Local)
+	entries := []CommandHistory{
+		{Command: "go test ./...", Status: "success", Duration: "2s", StartTime: monday},
+		{Command: "go test ./...", Status: "failed", Duration: "4s", StartTime: monday.Add(time.Hour)},
+		{Command: "go build", Status: "success", Duration: "1s", StartTime: monday.Add(2 * time.Hour)},
+		{Command: "git status", Status: "success", StartTime: monday.Add(24 * time.Hour), EndTime: monday.Add(24*time.Hour + 100*time.Millisecond)},
+		{Command: "git status", Status: StatusImported, StartTime: monday.Add(25 * time.Hour)},
+		{Command: "GOOS=linux go build", Status: "failed", Duration: "10s", StartTime: monday.Add(26 * time.Hour)},
+	}
+
+	report := Stats(entries, StatsOptions{})
+	assert.Equal(t, 6, report.Total)
+	assert.Equal(t, 2, report.Failures)
+	assert.Equal(t, monday, report.Since)
+	require.Len(t, report.Commands, 4)
+	assert.Equal(t, "git status", report.Commands[0].Command, "次数相同时按命令排序")
+	assert.Equal(t, 2, report.Commands[0].Count)
+	assert.Equal(t, 0.0, report.Commands[0].FailureRate, "导入的记录不参与失败率计算")
+	assert.Equal(t, "go test ./...", report.Commands[1].Command)
+	assert.Equal(t, 0.5, report.Commands[1].FailureRate)
+	assert.Equal(t, 2*time.Second, report.Commands[1].Duration.P50)
+	assert.Equal(t, 4*time.Second, report.Commands[1].Duration.Max)
+
+	assert.Equal(t, 2, report.ByHour[9])
+	assert.Equal(t, 3, report.ByWeekday[time.Monday])
+	assert.Equal(t, 3, report.ByWeekday[time.Tuesday])
+
+	require.Len(t, report.Slowest, 5)
+	assert.Equal(t, "GOOS=linux go build", report.Slowest[0].Command)
+	assert.Equal(t, 5, report.Slowest[0].Index)
+	assert.Equal(t, 2*time.Second, report.Duration.P50)
+}
+
+// 测试导入的记录没有真实耗时时不参与耗时统计
+func TestStats_ImportedDuration(t *testing.T) {
+	history := ": 1714980000:0;ls\n: 1714980001:0;cd src\n: 1714980002:0;pwd\n: 1714980010:7;make\n"
+	entries, err := ParseShell(strings.NewReader(history), FormatZsh, time.Time{})
+	require.NoError(t, err)
+	entries = append(entries, CommandHistory{Command: "go build", Status: "success", Duration: "3s", Dir: "/src", SessionID: "s1"})
+
+	report := Stats(entries, StatsOptions{})
+	assert.Equal(t, 3*time.Second, report.Duration.P50, "耗时为 0 的导入记录不计入分位数")
+	assert.Equal(t, 7*time.Second, report.Duration.Max)
+	require.Len(t, report.Slowest, 2)
+	assert.Equal(t, "make", report.Slowest[0].Command)
+	assert.Equal(t, "go build", report.Slowest[1].Command)
+
+	_, ok := entries[0].Elapsed()
+	assert.False(t, ok)
+}
+
+// 测试按程序名分组和条数限制
+func TestStats_ByProgram(t *testing.T) {
+	entries := []CommandHistory{
+		{Command: "go test ./..."},
+		{Command: "GOOS=linux go build"},
+		{Command: "git status"},
+		{Command: "'/usr/bin/git' log"},
+	}
+
+	report := Stats(entries, StatsOptions{ByProgram: true, Top: 1})
+	require.Len(t, report.Commands, 1)
+	assert.Equal(t, "go", report.Commands[0].Command)
+	assert.Equal(t, 2, report.Commands[0].Count)
+
+	assert.Equal(t, "/usr/bin/git", Program("'/usr/bin/git' log"))
+	assert.Equal(t, "env", Program("env"))
+
+	data, err := json.Marshal(Percentiles{P50: 1500 * time.Microsecond})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"p50_ms":1.5,"p90_ms":0,"p99_ms":0,"max_ms":0}`, string(data))
+}
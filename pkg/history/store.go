@@ -0,0 +1,553 @@
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// 历史记录以 JSON Lines 格式追加写入数据文件，每行一条记录。
+// 索引文件按顺序保存每条记录在数据文件中的起始偏移（8字节大端序），用于快速读取最近的记录；
+// 锁文件用于多个进程之间的互斥，写入时加排他锁，读取时加共享锁。
+// 索引与数据不一致（例如写入过程中进程退出）时在下一次写入时从数据文件重建
+const (
+	indexSuffix = ".idx"
+	lockSuffix  = ".lock"
+	offsetSize  = 8
+)
+
+// acquire 获取历史文件的跨进程锁，返回释放锁的函数
+func acquire(path string, exclusive bool) (func(), error) {
+	f, err := os.OpenFile(path+lockSuffix, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(f, exclusive); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return func() {
+		unlockFile(f)
+		f.Close()
+	}, nil
+}
+
+// appendRecord 在排他锁下向数据文件追加一行记录并更新索引，
+// 记录数或记录时间超出保留策略一定比例后压缩数据文件
+func appendRecord(path string, line []byte, r Retention, now time.Time) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("创建历史记录目录失败: %v", err)
+	}
+	release, err := acquire(path, true)
+	if err != nil {
+		return fmt.Errorf("锁定历史记录失败: %v", err)
+	}
+	defer release()
+
+	if err := convertLegacy(path); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("打开历史记录失败: %v", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("读取历史记录失败: %v", err)
+	}
+	size := info.Size()
+
+	offsets, ok := readIndex(path, f, size)
+	if !ok {
+		var end int64
+		offsets, end, err = scanOffsets(f, size)
+		if err != nil {
+			return fmt.Errorf("读取历史记录失败: %v", err)
+		}
+		// 上一次写入没有完成，丢弃不完整的最后一行
+		if end < size {
+			if err := f.Truncate(end); err != nil {
+				return fmt.Errorf("修复历史记录失败: %v", err)
+			}
+			size = end
+		}
+		if err := writeIndex(path, offsets); err != nil {
+			return fmt.Errorf("重建历史记录索引失败: %v", err)
+		}
+	}
+
+	if _, err := f.WriteAt(append(line, '\n'), size); err != nil {
+		return fmt.Errorf("保存历史记录失败: %v", err)
+	}
+	if err := appendIndex(path, size); err != nil {
+		return fmt.Errorf("更新历史记录索引失败: %v", err)
+	}
+	offsets = append(offsets, size)
+	size += int64(len(line)) + 1
+
+	if r.shouldCompact(f, offsets, size, now) {
+		if err := compact(path, f, offsets, size, r, now); err != nil {
+			return fmt.Errorf("压缩历史记录失败: %v", err)
+		}
+	}
+	return nil
+}
+
+// readAll 在共享锁下读取全部记录
+func readAll(path string) ([]CommandHistory, error) {
+	return readRecords(path, -1)
+}
+
+// readRecords 在共享锁下读取最近的 n 条记录，n 为负数时读取全部记录
+func readRecords(path string, n int) ([]CommandHistory, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return []CommandHistory{}, nil
+	}
+	// 目录只读等原因无法创建锁文件时不加锁读取
+	if release, err := acquire(path, false); err == nil {
+		defer release()
+	}
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return []CommandHistory{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取历史记录失败: %v", err)
+	}
+	defer f.Close()
+
+	if legacy, err := isLegacy(f); err != nil {
+		return nil, fmt.Errorf("读取历史记录失败: %v", err)
+	} else if legacy {
+		records, err := readLegacy(f)
+		if err != nil {
+			return nil, err
+		}
+		if n >= 0 && len(records) > n {
+			records = records[len(records)-n:]
+		}
+		return records, nil
+	}
+
+	if n < 0 {
+		return decodeLines(f)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("读取历史记录失败: %v", err)
+	}
+	size := info.Size()
+	offsets, ok := readIndex(path, f, size)
+	if !ok {
+		if offsets, _, err = scanOffsets(f, size); err != nil {
+			return nil, fmt.Errorf("读取历史记录失败: %v", err)
+		}
+	}
+	if n > len(offsets) {
+		n = len(offsets)
+	}
+	if n == 0 {
+		return []CommandHistory{}, nil
+	}
+	start := offsets[len(offsets)-n]
+	return decodeLines(io.NewSectionReader(f, start, size-start))
+}
+
+// decodeLines 逐行解析记录，忽略空行
+func decodeLines(r io.Reader) ([]CommandHistory, error) {
+	reader := bufio.NewReader(r)
+	records := []CommandHistory{}
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			var record CommandHistory
+			if err := json.Unmarshal(line, &record); err != nil {
+				return nil, fmt.Errorf("解析历史记录失败: %v", err)
+			}
+			records = append(records, record)
+		}
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("读取历史记录失败: %v", err)
+		}
+	}
+}
+
+// mergeRecords 在排他锁下将 records 合并到数据文件，跳过命令和开始时间（精确到秒）都相同的重复记录，
+// 合并后按开始时间排序并应用保留策略，返回新增的记录数
+func mergeRecords(path string, records []CommandHistory, r Retention, now time.Time) (int, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return 0, fmt.Errorf("创建历史记录目录失败: %v", err)
+	}
+	release, err := acquire(path, true)
+	if err != nil {
+		return 0, fmt.Errorf("锁定历史记录失败: %v", err)
+	}
+	defer release()
+
+	if err := convertLegacy(path); err != nil {
+		return 0, err
+	}
+
+	existing := []CommandHistory{}
+	if f, err := os.Open(path); err == nil {
+		info, err := f.Stat()
+		if err == nil {
+			// 忽略上一次写入没有完成的最后一行
+			var end int64
+			if _, end, err = scanOffsets(f, info.Size()); err == nil {
+				existing, err = decodeLines(io.NewSectionReader(f, 0, end))
+			}
+		}
+		f.Close()
+		if err != nil {
+			return 0, fmt.Errorf("读取历史记录失败: %v", err)
+		}
+	} else if !os.IsNotExist(err) {
+		return 0, fmt.Errorf("读取历史记录失败: %v", err)
+	}
+
+	seen := make(map[string]bool, len(existing)+len(records))
+	for i := range existing {
+		seen[recordKey(&existing[i])] = true
+	}
+	merged := existing
+	added := 0
+	for i := range records {
+		key := recordKey(&records[i])
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		merged = append(merged, records[i])
+		added++
+	}
+	if added == 0 {
+		return 0, nil
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].StartTime.Before(merged[j].StartTime)
+	})
+	return added, writeRecords(path, r.apply(merged, now))
+}
+
+// recordKey 返回判断重复记录使用的键
+func recordKey(h *CommandHistory) string {
+	return fmt.Sprintf("%d\x00%s", h.StartTime.Unix(), h.Command)
+}
+
+// removeAll 在排他锁下删除数据文件和索引，保留锁文件以免其他进程锁住已删除的文件
+func removeAll(path string) error {
+	if _, err := os.Stat(filepath.Dir(path)); os.IsNotExist(err) {
+		return nil
+	}
+	release, err := acquire(path, true)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	for _, name := range []string{path, path + indexSuffix} {
+		if err := os.Remove(name); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// readIndex 读取索引并校验：最后一条索引指向的记录必须恰好以数据文件末尾的换行符结束
+func readIndex(path string, f *os.File, size int64) ([]int64, bool) {
+	data, err := os.ReadFile(path + indexSuffix)
+	if err != nil {
+		return nil, size == 0
+	}
+	if len(data)%offsetSize != 0 {
+		return nil, false
+	}
+
+	offsets := make([]int64, len(data)/offsetSize)
+	for i := range offsets {
+		offsets[i] = int64(binary.BigEndian.Uint64(data[i*offsetSize:]))
+	}
+	if len(offsets) == 0 {
+		return offsets, size == 0
+	}
+
+	last := offsets[len(offsets)-1]
+	if last >= size {
+		return nil, false
+	}
+	line, err := bufio.NewReader(io.NewSectionReader(f, last, size-last)).ReadBytes('\n')
+	if err != nil || int64(len(line)) != size-last {
+		return nil, false
+	}
+	return offsets, true
+}
+
+// scanOffsets 扫描数据文件得到每条完整记录的偏移，end 为最后一个完整行的结束位置
+func scanOffsets(f *os.File, size int64) (offsets []int64, end int64, err error) {
+	reader := bufio.NewReader(io.NewSectionReader(f, 0, size))
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			return offsets, end, nil
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		if len(bytes.TrimSpace(line)) > 0 {
+			offsets = append(offsets, end)
+		}
+		end += int64(len(line))
+	}
+}
+
+// writeIndex 重写整个索引文件
+func writeIndex(path string, offsets []int64) error {
+	data := make([]byte, len(offsets)*offsetSize)
+	for i, offset := range offsets {
+		binary.BigEndian.PutUint64(data[i*offsetSize:], uint64(offset))
+	}
+	tmp := path + indexSuffix + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path+indexSuffix)
+}
+
+// appendIndex 向索引追加一条记录的偏移
+func appendIndex(path string, offset int64) error {
+	f, err := os.OpenFile(path+indexSuffix, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	var buf [offsetSize]byte
+	binary.BigEndian.PutUint64(buf[:], uint64(offset))
+	if _, err := f.Write(buf[:]); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// shouldCompact 记录数超过上限的 10% 或最早的记录超过保留时间的 10% 后才压缩，
+// 避免每次写入都重写数据文件
+func (r Retention) shouldCompact(f *os.File, offsets []int64, size int64, now time.Time) bool {
+	n := len(offsets)
+	if r.MaxEntries > 0 && n > r.MaxEntries+r.MaxEntries/10 {
+		return true
+	}
+	if r.MaxAge <= 0 || n == 0 {
+		return false
+	}
+	end := size
+	if n > 1 {
+		end = offsets[1]
+	}
+	line := make([]byte, end-offsets[0])
+	if _, err := f.ReadAt(line, offsets[0]); err != nil {
+		return false
+	}
+	start, ok := startTime(line)
+	return !ok || (!start.IsZero() && now.Sub(start) > r.MaxAge+r.MaxAge/10)
+}
+
+// compact 按保留策略重写数据文件和索引，无法解析的记录被丢弃
+func compact(path string, f *os.File, offsets []int64, size int64, r Retention, now time.Time) error {
+	first := 0
+	if r.MaxEntries > 0 && len(offsets) > r.MaxEntries {
+		first = len(offsets) - r.MaxEntries
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	reader := bufio.NewReader(io.NewSectionReader(f, offsets[first], size-offsets[first]))
+	var kept []int64
+	var pos int64
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 && r.keep(line, now) {
+			kept = append(kept, pos)
+			w.Write(line)
+			pos += int64(len(line))
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	return writeIndex(path, kept)
+}
+
+// keep 判断一行记录是否在保留时间内
+func (r Retention) keep(line []byte, now time.Time) bool {
+	start, ok := startTime(line)
+	if !ok {
+		return false
+	}
+	return r.MaxAge <= 0 || start.IsZero() || now.Sub(start) <= r.MaxAge
+}
+
+// startTime 只解析记录的开始时间
+func startTime(line []byte) (time.Time, bool) {
+	var record struct {
+		StartTime time.Time `json:"start_time"`
+	}
+	if err := json.Unmarshal(line, &record); err != nil {
+		return time.Time{}, false
+	}
+	return record.StartTime, true
+}
+
+// isLegacy 判断数据文件是否为旧版本的 JSON 数组格式
+func isLegacy(f *os.File) (bool, error) {
+	reader := bufio.NewReader(io.NewSectionReader(f, 0, 1<<62))
+	for {
+		b, err := reader.ReadByte()
+		if err == io.EOF {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		switch b {
+		case ' ', '\t', '\r', '\n':
+			continue
+		}
+		return b == '[', nil
+	}
+}
+
+// readLegacy 读取旧版本 JSON 数组格式的历史文件
+func readLegacy(f *os.File) ([]CommandHistory, error) {
+	data, err := io.ReadAll(io.NewSectionReader(f, 0, 1<<62))
+	if err != nil {
+		return nil, fmt.Errorf("读取历史记录失败: %v", err)
+	}
+	var records []CommandHistory
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("解析历史记录失败: %v", err)
+	}
+	return records, nil
+}
+
+// convertLegacy 将旧版本 JSON 数组格式的数据文件原地转换为 JSON Lines 格式
+func convertLegacy(path string) error {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("读取历史记录失败: %v", err)
+	}
+	defer f.Close()
+
+	legacy, err := isLegacy(f)
+	if err != nil {
+		return fmt.Errorf("读取历史记录失败: %v", err)
+	}
+	if !legacy {
+		return nil
+	}
+	records, err := readLegacy(f)
+	if err != nil {
+		return err
+	}
+	return writeRecords(path, records)
+}
+
+// migrateFile 将 from 中旧格式的历史记录导入尚不存在的 path，完成后把 from 重命名为 .bak
+func migrateFile(from, path string) error {
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		return nil
+	}
+	if _, err := os.Stat(from); err != nil {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("创建历史记录目录失败: %v", err)
+	}
+	release, err := acquire(path, true)
+	if err != nil {
+		return fmt.Errorf("锁定历史记录失败: %v", err)
+	}
+	defer release()
+
+	// 其他进程可能已经完成迁移
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		return nil
+	}
+	f, err := os.Open(from)
+	if err != nil {
+		return nil
+	}
+	records, err := readLegacy(f)
+	f.Close()
+	if err != nil {
+		return err
+	}
+	if err := writeRecords(path, records); err != nil {
+		return err
+	}
+	return os.Rename(from, from+".bak")
+}
+
+// writeRecords 用 records 替换数据文件并重建索引
+func writeRecords(path string, records []CommandHistory) error {
+	var buf bytes.Buffer
+	offsets := make([]int64, 0, len(records))
+	for i := range records {
+		line, err := json.Marshal(&records[i])
+		if err != nil {
+			return fmt.Errorf("序列化历史记录失败: %v", err)
+		}
+		offsets = append(offsets, int64(buf.Len()))
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("保存历史记录失败: %v", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("保存历史记录失败: %v", err)
+	}
+	if err := writeIndex(path, offsets); err != nil {
+		return fmt.Errorf("重建历史记录索引失败: %v", err)
+	}
+	return nil
+}
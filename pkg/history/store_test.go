@@ -0,0 +1,203 @@
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 测试记录按行追加并自动填充执行环境
+func TestSaveHistoryAppendAndMetadata(t *testing.T) {
+	path := setupTempHistory(t)
+	t.Setenv(SessionEnv, "test-session")
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, SaveHistory(&CommandHistory{Command: "cmd " + strconv.Itoa(i), ExitCode: i, StartTime: time.Now()}))
+	}
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 3, "每条记录占一行")
+
+	var record CommandHistory
+	require.NoError(t, json.Unmarshal([]byte(lines[2]), &record))
+	assert.Equal(t, "cmd 2", record.Command)
+	assert.Equal(t, 2, record.ExitCode)
+	assert.Equal(t, "test-session", record.SessionID)
+	assert.NotEmpty(t, record.Hostname)
+	assert.NotEmpty(t, record.User)
+	wd, _ := os.Getwd()
+	assert.Equal(t, wd, record.Dir)
+
+	index, err := os.Stat(path + indexSuffix)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3*offsetSize), index.Size())
+
+	recent, err := GetRecent(2)
+	require.NoError(t, err)
+	require.Len(t, recent, 2)
+	assert.Equal(t, "cmd 1", recent[0].Command)
+	assert.Equal(t, "cmd 2", recent[1].Command)
+}
+
+// 测试索引损坏和不完整的最后一行在下一次写入时被修复
+func TestSaveHistoryRepair(t *testing.T) {
+	path := setupTempHistory(t)
+	require.NoError(t, SaveHistory(&CommandHistory{Command: "first"}))
+
+	// 模拟写入过程中进程退出：数据只写了一半且索引没有更新
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"command":"half`)
+	require.NoError(t, err)
+	f.Close()
+	require.NoError(t, os.WriteFile(path+indexSuffix, []byte{1, 2, 3}, 0644))
+
+	require.NoError(t, SaveHistory(&CommandHistory{Command: "second"}))
+	history, err := GetHistory()
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, "first", history[0].Command)
+	assert.Equal(t, "second", history[1].Command)
+
+	last, err := GetLastHistory()
+	require.NoError(t, err)
+	assert.Equal(t, "second", last.Command)
+}
+
+// 测试按条数和时间压缩历史文件
+func TestRetentionCompaction(t *testing.T) {
+	path := setupTempHistory(t)
+	SetRetention(Retention{MaxEntries: 20, MaxAge: time.Hour})
+	t.Cleanup(func() { SetRetention(DefaultRetention) })
+
+	require.NoError(t, SaveHistory(&CommandHistory{Command: "old", StartTime: time.Now().Add(-2 * time.Hour)}))
+	for i := 0; i < 30; i++ {
+		require.NoError(t, SaveHistory(&CommandHistory{Command: "cmd " + strconv.Itoa(i), StartTime: time.Now()}))
+	}
+
+	history, err := GetHistory()
+	require.NoError(t, err)
+	require.Len(t, history, 20)
+	assert.Equal(t, "cmd 10", history[0].Command)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Count(string(data), "\n")
+	assert.LessOrEqual(t, lines, 22, "超出上限的记录应被压缩掉")
+	assert.NotContains(t, string(data), `"old"`)
+
+	index, err := os.Stat(path + indexSuffix)
+	require.NoError(t, err)
+	assert.Equal(t, int64(lines*offsetSize), index.Size())
+}
+
+// 测试读取和转换旧版本的 JSON 数组格式
+func TestLegacyFormat(t *testing.T) {
+	path := setupTempHistory(t)
+	legacy := []CommandHistory{{Command: "legacy 1", Status: "success"}, {Command: "legacy 2", Status: "failed"}}
+	data, err := json.MarshalIndent(legacy, "", "  ")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, data, 0644))
+
+	history, err := GetHistory()
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+
+	require.NoError(t, SaveHistory(&CommandHistory{Command: "new"}))
+	history, err = GetHistory()
+	require.NoError(t, err)
+	require.Len(t, history, 3)
+	assert.Equal(t, "legacy 1", history[0].Command)
+	assert.Equal(t, "new", history[2].Command)
+
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, 3, strings.Count(string(data), "\n"), "旧格式应被转换为每行一条记录")
+
+	// 默认路径下自动导入旧的历史文件
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "history.json"), []byte(`[{"command":"old"}]`), 0644))
+	require.NoError(t, migrateFile(filepath.Join(dir, "history.json"), filepath.Join(dir, "history.jsonl")))
+	_, err = os.Stat(filepath.Join(dir, "history.json.bak"))
+	assert.NoError(t, err)
+	records, err := readAll(filepath.Join(dir, "history.jsonl"))
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "old", records[0].Command)
+}
+
+// 测试同一进程内的并发写入
+func TestSaveHistoryConcurrent(t *testing.T) {
+	setupTempHistory(t)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			assert.NoError(t, SaveHistory(&CommandHistory{Command: fmt.Sprintf("cmd %d", i)}))
+		}(i)
+	}
+	wg.Wait()
+
+	history, err := GetHistory()
+	require.NoError(t, err)
+	assert.Len(t, history, 50)
+}
+
+// 测试多个进程同时写入同一个历史文件
+func TestSaveHistoryMultiProcess(t *testing.T) {
+	if path := os.Getenv("CLIXGO_HISTORY_WRITER"); path != "" {
+		SetHistoryFilePath(path)
+		for i := 0; i < 50; i++ {
+			if err := SaveHistory(&CommandHistory{Command: fmt.Sprintf("%d-%d", os.Getpid(), i)}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return
+	}
+
+	path := setupTempHistory(t)
+	var cmds []*exec.Cmd
+	for i := 0; i < 4; i++ {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestSaveHistoryMultiProcess$")
+		cmd.Env = append(os.Environ(), "CLIXGO_HISTORY_WRITER="+path)
+		require.NoError(t, cmd.Start())
+		cmds = append(cmds, cmd)
+	}
+	for _, cmd := range cmds {
+		require.NoError(t, cmd.Wait())
+	}
+
+	history, err := GetHistory()
+	require.NoError(t, err)
+	assert.Len(t, history, 200)
+
+	index, err := os.Stat(path + indexSuffix)
+	require.NoError(t, err)
+	assert.Equal(t, int64(200*offsetSize), index.Size())
+}
+
+// 测试保留时间的解析
+func TestParseAge(t *testing.T) {
+	d, err := ParseAge("90d")
+	require.NoError(t, err)
+	assert.Equal(t, 90*24*time.Hour, d)
+	d, err = ParseAge("36h")
+	require.NoError(t, err)
+	assert.Equal(t, 36*time.Hour, d)
+	_, err = ParseAge("soon")
+	assert.Error(t, err)
+}
@@ -61,7 +61,7 @@ func InitLogger() error {
 		zapcore.NewCore(consoleEncoder, zapcore.AddSync(os.Stdout), defaultLogLevel),
 	)
 
-	Log = zap.New(core, zap.AddCaller(), zap.AddStacktrace(zapcore.ErrorLevel))
+	Log = zap.New(redactCore{core}, zap.AddCaller(), zap.AddStacktrace(zapcore.ErrorLevel))
 	initialized = true
 	return nil
 }
@@ -0,0 +1,94 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/Lzww0608/ClixGo/pkg/redact"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// redactCore 在写入前替换日志消息和字段中的敏感信息
+type redactCore struct {
+	zapcore.Core
+}
+
+func (c redactCore) With(fields []zapcore.Field) zapcore.Core {
+	return redactCore{c.Core.With(redactFields(fields))}
+}
+
+func (c redactCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func (c redactCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	entry.Message = redact.String(entry.Message)
+	return c.Core.Write(entry, redactFields(fields))
+}
+
+// redactFields 替换字段中的敏感信息：字符串、字节串和错误直接替换，
+// 数组、对象、Stringer 和任意值先编码再替换其中的每个字符串，数值等其他类型保持不变
+func redactFields(fields []zapcore.Field) []zapcore.Field {
+	out := make([]zapcore.Field, len(fields))
+	for i, f := range fields {
+		switch f.Type {
+		case zapcore.StringType:
+			f.String = redact.String(f.String)
+		case zapcore.ByteStringType:
+			if b, ok := f.Interface.([]byte); ok {
+				f = zap.String(f.Key, redact.String(string(b)))
+			}
+		case zapcore.ErrorType:
+			if err, ok := f.Interface.(error); ok && err != nil {
+				f = zap.String(f.Key, redact.String(err.Error()))
+			}
+		case zapcore.ArrayMarshalerType, zapcore.ObjectMarshalerType, zapcore.StringerType:
+			// 由 zap 自身编码，Stringer 的 panic 也按 zap 的方式处理
+			enc := zapcore.NewMapObjectEncoder()
+			f.AddTo(enc)
+			f = zap.Any(f.Key, redactValue(enc.Fields[f.Key]))
+		case zapcore.ReflectType:
+			f = redactReflected(f)
+		}
+		out[i] = f
+	}
+	return out
+}
+
+// redactReflected 将任意值按 JSON 编码后替换其中的字符串，无法编码时按字符串格式化
+func redactReflected(f zapcore.Field) zapcore.Field {
+	data, err := json.Marshal(f.Interface)
+	if err == nil {
+		var v interface{}
+		if err = json.Unmarshal(data, &v); err == nil {
+			return zap.Any(f.Key, redactValue(v))
+		}
+	}
+	return zap.String(f.Key, redact.String(fmt.Sprintf("%+v", f.Interface)))
+}
+
+// redactValue 递归替换编码结果中的字符串
+func redactValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case string:
+		return redact.String(v)
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = redactValue(item)
+		}
+		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			out[k] = redactValue(item)
+		}
+		return out
+	default:
+		return v
+	}
+}
@@ -0,0 +1,156 @@
+package redact
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+)
+
+// Placeholder 替换敏感信息使用的文本
+const Placeholder = "[REDACTED]"
+
+// Rule 一条脱敏规则。表达式中包含名为 secret 的分组时只替换该分组（可以有多个同名分组，替换其中匹配的一个），
+// 例如 password=xxx 只替换 xxx，保留前面的键名；否则替换整个匹配
+type Rule struct {
+	Name    string
+	Pattern *regexp.Regexp
+}
+
+// 内置的检测规则
+var builtinRules = []Rule{
+	{"private-key", regexp.MustCompile(`(?s)-----BEGIN [A-Z0-9 ]*PRIVATE KEY-----.*?(?:-----END [A-Z0-9 ]*PRIVATE KEY-----|$)`)},
+	{"aws-access-key", regexp.MustCompile(`\b(?:AKIA|ASIA|AGPA|AIDA|AROA|ANPA|ANVA|AIPA)[A-Z0-9]{16}\b`)},
+	{"aws-secret-key", regexp.MustCompile(`(?i)aws_?secret_?(?:access_?)?key["']?\s*[:=]\s*["']?(?P<secret>[A-Za-z0-9/+=]{40})`)},
+	{"jwt", regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]+`)},
+	{"bearer-token", regexp.MustCompile(`(?i)\b(?:authorization:\s*(?:bearer|basic|token)|bearer)\s+(?P<secret>[A-Za-z0-9\-._~+/]{8,}=*)`)},
+	{"github-token", regexp.MustCompile(`\b(?:gh[pousr]|github_pat)_[A-Za-z0-9_]{20,}`)},
+	{"url-credentials", regexp.MustCompile(`(?i)\b[a-z][a-z0-9+.-]*://[^/\s:@]+:(?P<secret>[^/\s@]+)@`)},
+	{"password", regexp.MustCompile(`(?i)\b[a-z0-9_.-]*(?:password|passwd|pwd|secret|api[_-]?key|access[_-]?key|token)["']?\s*[:=]\s*(?:"(?P<secret>[^"]+)"|'(?P<secret>[^']+)'|(?P<secret>[^\s"'&;,]+))`)},
+	{"password-flag", regexp.MustCompile(`(?i)(?:^|\s)--?(?:password|passwd|token|api-key|secret)\s+(?:"(?P<secret>[^"]+)"|'(?P<secret>[^']+)'|(?P<secret>[^\s"']+))`)},
+}
+
+// Redactor 按规则替换文本中的敏感信息，可以在多个 goroutine 中使用
+type Redactor struct {
+	mu      sync.RWMutex
+	rules   []Rule
+	enabled bool
+}
+
+// New 创建使用内置规则和 rules 的脱敏器
+func New(rules ...Rule) *Redactor {
+	r := &Redactor{enabled: true}
+	r.rules = append(append(r.rules, builtinRules...), rules...)
+	return r
+}
+
+// Compile 将用户定义的正则表达式编译为规则
+func Compile(patterns []string) ([]Rule, error) {
+	rules := make([]Rule, 0, len(patterns))
+	for i, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("无效的脱敏规则 %q: %v", pattern, err)
+		}
+		rules = append(rules, Rule{Name: fmt.Sprintf("custom-%d", i+1), Pattern: re})
+	}
+	return rules, nil
+}
+
+// SetRules 用内置规则和 rules 替换当前的规则
+func (r *Redactor) SetRules(rules []Rule) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rules = append(append([]Rule(nil), builtinRules...), rules...)
+}
+
+// SetEnabled 启用或关闭脱敏
+func (r *Redactor) SetEnabled(enabled bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.enabled = enabled
+}
+
+// Rules 返回当前使用的规则
+func (r *Redactor) Rules() []Rule {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]Rule(nil), r.rules...)
+}
+
+// String 返回替换了敏感信息的文本
+func (r *Redactor) String(s string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if !r.enabled || s == "" {
+		return s
+	}
+	for _, rule := range r.rules {
+		s = apply(rule.Pattern, s)
+	}
+	return s
+}
+
+// Strings 逐个替换切片中的敏感信息，返回新的切片
+func (r *Redactor) Strings(values []string) []string {
+	if values == nil {
+		return nil
+	}
+	out := make([]string, len(values))
+	for i, v := range values {
+		out[i] = r.String(v)
+	}
+	return out
+}
+
+// apply 替换 re 的所有匹配，有 secret 分组时只替换分组
+func apply(re *regexp.Regexp, s string) string {
+	var groups []int
+	for i, name := range re.SubexpNames() {
+		if name == "secret" {
+			groups = append(groups, i)
+		}
+	}
+	if len(groups) == 0 {
+		return re.ReplaceAllLiteralString(s, Placeholder)
+	}
+
+	matches := re.FindAllStringSubmatchIndex(s, -1)
+	if matches == nil {
+		return s
+	}
+	var out []byte
+	last := 0
+	for _, m := range matches {
+		start, end := -1, -1
+		for _, g := range groups {
+			if m[2*g] >= 0 {
+				start, end = m[2*g], m[2*g+1]
+				break
+			}
+		}
+		if start < 0 {
+			continue
+		}
+		out = append(out, s[last:start]...)
+		out = append(out, Placeholder...)
+		last = end
+	}
+	return string(append(out, s[last:]...))
+}
+
+var defaultRedactor = New()
+
+// Default 返回全局脱敏器，历史记录、日志和命令统计写入前都经过它处理
+func Default() *Redactor {
+	return defaultRedactor
+}
+
+// String 使用全局脱敏器替换文本中的敏感信息
+func String(s string) string {
+	return defaultRedactor.String(s)
+}
+
+// Strings 使用全局脱敏器替换切片中的敏感信息
+func Strings(values []string) []string {
+	return defaultRedactor.Strings(values)
+}
@@ -0,0 +1,62 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustTime(t *testing.T, s string) time.Time {
+	tm, err := time.ParseInLocation("2006-01-02 15:04", s, time.UTC)
+	require.NoError(t, err)
+	return tm
+}
+
+// 测试 cron 表达式计算下一次执行时间
+func TestParseNext(t *testing.T) {
+	tests := []struct {
+		expr string
+		from string
+		want string
+	}{
+		{"*/5 * * * *", "2024-03-10 10:03", "2024-03-10 10:05"},
+		{"*/5 * * * *", "2024-03-10 10:05", "2024-03-10 10:10"},
+		{"0 9 * * 1-5", "2024-03-08 09:00", "2024-03-11 09:00"}, // 周五之后是下周一
+		{"30 2 1 * *", "2024-01-31 12:00", "2024-02-01 02:30"},
+		{"0 0 29 2 *", "2024-03-01 00:00", "2028-02-29 00:00"},
+		{"15,45 8-10/2 * * *", "2024-03-10 08:50", "2024-03-10 10:15"},
+		{"0 12 * jan,jul sun", "2024-01-01 00:00", "2024-01-07 12:00"},
+		{"0 0 * * 7", "2024-03-10 00:00", "2024-03-17 00:00"},
+		{"0 0 13 * 5", "2024-09-10 00:00", "2024-09-13 00:00"}, // 日和星期满足其一
+		{"5/20 * * * *", "2024-03-10 10:30", "2024-03-10 10:45"},
+		{"@hourly", "2024-03-10 10:30", "2024-03-10 11:00"},
+		{"@daily", "2024-12-31 23:59", "2025-01-01 00:00"},
+		{"@weekly", "2024-03-10 00:00", "2024-03-17 00:00"},
+	}
+	for _, tt := range tests {
+		sched, err := Parse(tt.expr)
+		require.NoError(t, err, tt.expr)
+		assert.Equal(t, mustTime(t, tt.want), sched.Next(mustTime(t, tt.from)), tt.expr)
+	}
+}
+
+// 测试固定间隔
+func TestParseEvery(t *testing.T) {
+	sched, err := Parse("@every 90s")
+	require.NoError(t, err)
+	from := mustTime(t, "2024-03-10 10:00")
+	assert.Equal(t, from.Add(90*time.Second), sched.Next(from))
+}
+
+// 测试无效的表达式
+func TestParseErrors(t *testing.T) {
+	for _, expr := range []string{
+		"* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 8",
+		"*/0 * * * *", "5-1 * * * *", "a * * * *", "@often", "@every 10ms", "@every soon",
+	} {
+		_, err := Parse(expr)
+		assert.Error(t, err, expr)
+	}
+}
@@ -0,0 +1,292 @@
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/Lzww0608/ClixGo/pkg/commands"
+	"github.com/Lzww0608/ClixGo/pkg/logger"
+	"github.com/Lzww0608/ClixGo/pkg/task"
+	"go.uber.org/zap"
+)
+
+const (
+	// 检查到期任务的间隔
+	tickInterval = time.Second
+	// 计划时间过去超过该时长才视为错过执行
+	missedGrace = time.Minute
+	// 逐次推算错过的 cron 计划时间的最大次数，超过后从当前时间向前查找
+	maxCatchUp = 100000
+)
+
+// 最近一次执行的状态
+const (
+	StatusSuccess = "success"
+	StatusFailed  = "failed"
+	StatusSkipped = "skipped" // 上一次执行尚未结束，本次跳过
+	StatusMissed  = "missed"  // 调度器未运行期间错过且按策略跳过
+)
+
+// Options 调度器选项
+type Options struct {
+	Tasks  *task.TaskManager // 每次执行记录为一个任务，为空时不记录
+	Output io.Writer         // 执行开始与结束的提示，为空时丢弃
+	Stdout io.Writer         // 命令的标准输出，为空时只记录到任务结果中
+	Stderr io.Writer         // 命令的标准错误
+}
+
+// Scheduler 按计划执行 Store 中的任务
+type Scheduler struct {
+	store *Store
+	opts  Options
+
+	mu      sync.Mutex
+	running map[string]int // 每个任务正在进行的执行数
+	wg      sync.WaitGroup
+}
+
+// NewScheduler 创建调度器
+func NewScheduler(store *Store, opts Options) *Scheduler {
+	if opts.Output == nil {
+		opts.Output = io.Discard
+	}
+	return &Scheduler{store: store, opts: opts, running: make(map[string]int)}
+}
+
+// Run 在前台运行调度器直到 ctx 结束，退出前等待正在进行的执行结束。
+// 每次检查前重新读取任务文件，运行期间添加或删除的任务立即生效
+func (s *Scheduler) Run(ctx context.Context) error {
+	logger.Info("定时任务调度器启动", zap.String("store", s.store.Path()))
+	ticker := time.NewTicker(tickInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Tick(ctx, time.Now()); err != nil {
+			logger.Error("检查定时任务失败", zap.Error(err))
+		}
+		select {
+		case <-ctx.Done():
+			s.wg.Wait()
+			logger.Info("定时任务调度器停止")
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Wait 等待所有已启动的执行结束
+func (s *Scheduler) Wait() {
+	s.wg.Wait()
+}
+
+// Tick 检查 now 时刻到期的任务并在后台启动执行
+func (s *Scheduler) Tick(ctx context.Context, now time.Time) error {
+	jobs, err := s.store.List()
+	if err != nil {
+		return err
+	}
+	for _, job := range jobs {
+		if job.Paused {
+			continue
+		}
+		sched, err := Parse(job.Expr)
+		if err != nil {
+			logger.Error("定时任务表达式无效", zap.String("id", job.ID), zap.Error(err))
+			continue
+		}
+
+		base := job.LastScheduled
+		if base.IsZero() {
+			base = job.CreatedAt
+		}
+		due := sched.Next(base)
+		if due.IsZero() || due.After(now) {
+			continue
+		}
+
+		// 推算 now 之前最近的一次计划时间，之前的计划时间都已错过
+		latest, missed := catchUp(sched, due, now)
+		late := now.Sub(latest) > missedGrace
+		if late {
+			missed++
+		}
+
+		run := !late || job.Missed == MissedOnce
+		if missed > 0 {
+			logger.Info("定时任务错过执行",
+				zap.String("id", job.ID),
+				zap.Int("missed", missed),
+				zap.String("policy", string(job.Missed)),
+				zap.Bool("run", run))
+		}
+
+		status := ""
+		if !run {
+			status = StatusMissed
+			fmt.Fprintf(s.opts.Output, "%s [%s] %s 错过 %d 次执行，按策略跳过\n",
+				now.Format(time.DateTime), job.ID, job.Name, missed)
+		} else if !job.AllowOverlap && s.isRunning(job.ID) {
+			status = StatusSkipped
+			run = false
+			logger.Info("定时任务上一次执行尚未结束，跳过本次执行", zap.String("id", job.ID))
+			fmt.Fprintf(s.opts.Output, "%s [%s] %s 上一次执行尚未结束，跳过\n",
+				now.Format(time.DateTime), job.ID, job.Name)
+		}
+
+		if _, err := s.store.Update(job.ID, func(j *Job) {
+			j.LastScheduled = latest
+			if status != "" {
+				j.LastStatus = status
+			}
+		}); err != nil {
+			return err
+		}
+		if run {
+			s.start(ctx, job, latest)
+		}
+	}
+	return nil
+}
+
+func (s *Scheduler) isRunning(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.running[id] > 0
+}
+
+// start 在后台执行一次任务，结果记录到任务管理器、命令历史和任务文件
+func (s *Scheduler) start(ctx context.Context, job *Job, scheduled time.Time) {
+	s.mu.Lock()
+	s.running[job.ID]++
+	s.mu.Unlock()
+	s.wg.Add(1)
+
+	fmt.Fprintf(s.opts.Output, "%s [%s] 开始执行 %s: %s\n",
+		time.Now().Format(time.DateTime), job.ID, job.Name, job.Command)
+
+	exec := func(ctx context.Context, taskID string) error {
+		defer s.wg.Done()
+		defer func() {
+			s.mu.Lock()
+			s.running[job.ID]--
+			s.mu.Unlock()
+		}()
+
+		result, err := commands.Run(ctx, commands.Spec{
+			Command: job.Command,
+			Shell:   job.Shell,
+			Timeout: job.Timeout,
+			Stdout:  s.opts.Stdout,
+			Stderr:  s.opts.Stderr,
+		})
+		s.finish(job, taskID, result, err)
+		return err
+	}
+
+	if s.opts.Tasks == nil {
+		go exec(ctx, "")
+		return
+	}
+
+	t, err := s.opts.Tasks.CreateTask(job.Name, job.Command, map[string]interface{}{
+		"schedule_id": job.ID,
+		"expr":        job.Expr,
+		"scheduled":   scheduled,
+	})
+	if err != nil {
+		logger.Error("创建定时任务记录失败", zap.String("id", job.ID), zap.Error(err))
+		go exec(ctx, "")
+		return
+	}
+	err = s.opts.Tasks.StartTask(ctx, t.ID, func(ctx context.Context, _ *task.Task) error {
+		return exec(ctx, t.ID)
+	})
+	if err != nil {
+		logger.Error("启动定时任务记录失败", zap.String("id", job.ID), zap.Error(err))
+		go exec(ctx, "")
+	}
+}
+
+// finish 记录一次执行的结果
+func (s *Scheduler) finish(job *Job, taskID string, result *commands.Result, err error) {
+	exitCode := -1
+	var duration time.Duration
+	startTime := time.Now()
+	if result != nil {
+		exitCode = result.ExitCode
+		duration = result.Duration
+		startTime = result.StartTime
+	}
+	if taskID != "" && result != nil {
+		if err := s.opts.Tasks.SetExitCode(taskID, exitCode); err != nil {
+			logger.Error("记录定时任务退出码失败", zap.String("id", job.ID), zap.Error(err))
+		}
+	}
+
+	status := StatusSuccess
+	if err != nil {
+		status = StatusFailed
+		fmt.Fprintf(s.opts.Output, "%s [%s] %s 执行失败(退出码 %d，耗时 %s): %v\n",
+			time.Now().Format(time.DateTime), job.ID, job.Name, exitCode, duration.Round(time.Millisecond), err)
+	} else {
+		fmt.Fprintf(s.opts.Output, "%s [%s] %s 执行成功(耗时 %s)\n",
+			time.Now().Format(time.DateTime), job.ID, job.Name, duration.Round(time.Millisecond))
+	}
+
+	_, updateErr := s.store.Update(job.ID, func(j *Job) {
+		j.LastRun = startTime
+		j.LastStatus = status
+		j.LastExitCode = exitCode
+		j.LastTaskID = taskID
+		j.Runs++
+		if err != nil {
+			j.Failures++
+		}
+	})
+	if updateErr != nil {
+		// 执行期间任务可能已被删除
+		logger.Info("更新定时任务状态失败", zap.String("id", job.ID), zap.Error(updateErr))
+	}
+}
+
+// catchUp 返回不晚于 now 的最近一次计划时间，以及 due 之后错过的次数，due 不能晚于 now。
+// 固定间隔直接计算；cron 表达式逐次推算，超过 maxCatchUp 次后从 now 向前按倍增的窗口
+// 查找最近的计划时间，此时错过的次数只是下限。返回的时间总会追上 now，
+// 否则 missed=once 会在之后的每次检查中重复补执行
+func catchUp(sched Schedule, due, now time.Time) (time.Time, int) {
+	if every, ok := sched.(everySchedule); ok {
+		n := now.Sub(due) / every.interval
+		return due.Add(n * every.interval), int(n)
+	}
+
+	latest, missed := due, 0
+	for i := 0; i < maxCatchUp; i++ {
+		next := sched.Next(latest)
+		if next.IsZero() || next.After(now) {
+			return latest, missed
+		}
+		latest = next
+		missed++
+	}
+
+	for window := time.Minute; ; window *= 2 {
+		start := now.Add(-window)
+		if !start.After(latest) {
+			start = latest
+		}
+		next := sched.Next(start)
+		if !next.IsZero() && !next.After(now) {
+			// 更短的窗口内没有计划时间，这里只需推算少量几次
+			for after := sched.Next(next); !after.IsZero() && !after.After(now); after = sched.Next(after) {
+				next = after
+			}
+			return next, missed + 1
+		}
+		if start.Equal(latest) {
+			return latest, missed
+		}
+	}
+}
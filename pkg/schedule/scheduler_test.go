@@ -0,0 +1,209 @@
+package schedule
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Lzww0608/ClixGo/pkg/history"
+	"github.com/Lzww0608/ClixGo/pkg/logger"
+	"github.com/Lzww0608/ClixGo/pkg/task"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func setupTest(t *testing.T) (*Store, *task.TaskManager) {
+	dir := t.TempDir()
+	logger.SetLogPath(filepath.Join(dir, "test.log"))
+	logger.InitLogger()
+	history.SetHistoryFilePath(filepath.Join(dir, "history.json"))
+	tm, err := task.NewTaskManager(zap.NewNop(), filepath.Join(dir, "tasks.json"))
+	require.NoError(t, err)
+	return NewStore(filepath.Join(dir, "schedules.json")), tm
+}
+
+var created = time.Date(2024, 3, 10, 10, 0, 0, 0, time.Local)
+
+func addJob(t *testing.T, store *Store, job *Job) *Job {
+	job.CreatedAt = created
+	require.NoError(t, store.Add(job))
+	return job
+}
+
+// 测试任务的增删查
+func TestStore(t *testing.T) {
+	store, _ := setupTest(t)
+
+	job := addJob(t, store, &Job{Expr: "*/5 * * * *", Command: "echo hi"})
+	assert.Len(t, job.ID, 8)
+	assert.Equal(t, "echo hi", job.Name)
+	assert.Equal(t, MissedSkip, job.Missed)
+
+	got, err := store.Get(job.ID[:4])
+	require.NoError(t, err)
+	assert.Equal(t, job.ID, got.ID)
+	_, err = store.Get("echo hi")
+	assert.NoError(t, err)
+
+	assert.Error(t, store.Add(&Job{Expr: "bad", Command: "x"}))
+	assert.Error(t, store.Add(&Job{Expr: "@daily", Command: " "}))
+	assert.Error(t, store.Add(&Job{Expr: "@daily", Command: "x", Missed: "all"}))
+
+	_, err = store.Remove(job.ID)
+	require.NoError(t, err)
+	jobs, err := store.List()
+	require.NoError(t, err)
+	assert.Empty(t, jobs)
+	_, err = store.Remove(job.ID)
+	assert.Error(t, err)
+}
+
+// 测试到期执行并记录到任务管理器、命令历史和任务文件
+func TestSchedulerRunsDueJob(t *testing.T) {
+	store, tm := setupTest(t)
+	job := addJob(t, store, &Job{Name: "greet", Expr: "@every 1m", Command: "echo scheduled"})
+	s := NewScheduler(store, Options{Tasks: tm})
+	ctx := context.Background()
+
+	require.NoError(t, s.Tick(ctx, created.Add(30*time.Second)))
+	s.Wait()
+	assert.Empty(t, tm.ListTasks())
+
+	require.NoError(t, s.Tick(ctx, created.Add(time.Minute+time.Second)))
+	s.Wait()
+
+	got, err := store.Get(job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, got.Runs)
+	assert.Equal(t, StatusSuccess, got.LastStatus)
+	assert.WithinDuration(t, created.Add(time.Minute), got.LastScheduled, 0)
+	next, err := got.NextRun(created.Add(90 * time.Second))
+	require.NoError(t, err)
+	assert.WithinDuration(t, created.Add(2*time.Minute), next, 0)
+	require.NotEmpty(t, got.LastTaskID)
+
+	require.Eventually(t, func() bool {
+		tk, err := tm.GetTask(got.LastTaskID)
+		return err == nil && tk.Status == task.TaskStatusComplete
+	}, 5*time.Second, 10*time.Millisecond)
+	tk, _ := tm.GetTask(got.LastTaskID)
+	assert.Equal(t, "greet", tk.Name)
+	require.NotNil(t, tk.ExitCode)
+	assert.Equal(t, 0, *tk.ExitCode)
+
+	entries, err := history.GetHistory()
+	require.NoError(t, err)
+	require.NotEmpty(t, entries)
+	assert.Equal(t, "echo scheduled", entries[len(entries)-1].Command)
+
+	// 同一计划时间不会重复执行
+	require.NoError(t, s.Tick(ctx, created.Add(time.Minute+2*time.Second)))
+	s.Wait()
+	got, _ = store.Get(job.ID)
+	assert.Equal(t, 1, got.Runs)
+}
+
+// 测试错过执行的处理策略
+func TestSchedulerMissedPolicy(t *testing.T) {
+	store, _ := setupTest(t)
+	skip := addJob(t, store, &Job{Expr: "@hourly", Command: "true", Missed: MissedSkip})
+	once := addJob(t, store, &Job{Expr: "@hourly", Command: "true", Missed: MissedOnce})
+	s := NewScheduler(store, Options{})
+
+	// 最近的计划时间 15:00 已过去30分钟
+	now := created.Add(5*time.Hour + 30*time.Minute)
+	require.NoError(t, s.Tick(context.Background(), now))
+	s.Wait()
+
+	got, _ := store.Get(skip.ID)
+	assert.Equal(t, 0, got.Runs)
+	assert.Equal(t, StatusMissed, got.LastStatus)
+	assert.WithinDuration(t, created.Add(5*time.Hour), got.LastScheduled, 0)
+
+	got, _ = store.Get(once.ID)
+	assert.Equal(t, 1, got.Runs)
+	assert.Equal(t, StatusSuccess, got.LastStatus)
+
+	// 补执行后从最近的计划时间继续
+	require.NoError(t, s.Tick(context.Background(), now.Add(time.Second)))
+	s.Wait()
+	got, _ = store.Get(once.ID)
+	assert.Equal(t, 1, got.Runs)
+}
+
+// 测试长时间停机后错过的次数超过推算上限时仍只补执行一次
+func TestSchedulerLongDowntime(t *testing.T) {
+	store, _ := setupTest(t)
+	once := addJob(t, store, &Job{Expr: "@every 1s", Command: "true", Missed: MissedOnce})
+	s := NewScheduler(store, Options{})
+
+	now := created.Add(48*time.Hour + 500*time.Millisecond)
+	require.NoError(t, s.Tick(context.Background(), now))
+	s.Wait()
+	got, _ := store.Get(once.ID)
+	assert.Equal(t, 1, got.Runs)
+	assert.WithinDuration(t, created.Add(48*time.Hour), got.LastScheduled, 0)
+
+	require.NoError(t, s.Tick(context.Background(), now.Add(100*time.Millisecond)))
+	s.Wait()
+	got, _ = store.Get(once.ID)
+	assert.Equal(t, 1, got.Runs)
+}
+
+// 测试 cron 表达式错过的次数超过推算上限时直接找到最近的计划时间
+func TestCatchUp(t *testing.T) {
+	sched, err := Parse("*/2 * * * *")
+	require.NoError(t, err)
+	now := created.Add(time.Duration(maxCatchUp)*4*time.Minute + 3*time.Minute)
+
+	latest, missed := catchUp(sched, sched.Next(created), now)
+	assert.WithinDuration(t, now.Add(-time.Minute), latest, 0)
+	assert.GreaterOrEqual(t, missed, maxCatchUp)
+
+	next, err := (&Job{Expr: "*/2 * * * *", CreatedAt: created}).NextRun(now)
+	require.NoError(t, err)
+	assert.WithinDuration(t, now.Add(time.Minute), next, 0)
+
+	latest, missed = catchUp(everySchedule{interval: 3 * time.Second}, created, created.Add(10*time.Second))
+	assert.WithinDuration(t, created.Add(9*time.Second), latest, 0)
+	assert.Equal(t, 3, missed)
+}
+
+// 测试上一次执行未结束时跳过
+func TestSchedulerOverlap(t *testing.T) {
+	store, _ := setupTest(t)
+	single := addJob(t, store, &Job{Expr: "@every 1m", Command: "sleep 0.5"})
+	overlap := addJob(t, store, &Job{Expr: "@every 1m", Command: "sleep 0.5", AllowOverlap: true})
+	s := NewScheduler(store, Options{})
+	ctx := context.Background()
+
+	require.NoError(t, s.Tick(ctx, created.Add(time.Minute)))
+	require.NoError(t, s.Tick(ctx, created.Add(2*time.Minute)))
+	s.Wait()
+
+	got, _ := store.Get(single.ID)
+	assert.Equal(t, 1, got.Runs)
+	got, _ = store.Get(overlap.ID)
+	assert.Equal(t, 2, got.Runs)
+}
+
+// 测试失败的执行与暂停的任务
+func TestSchedulerFailureAndPause(t *testing.T) {
+	store, _ := setupTest(t)
+	failing := addJob(t, store, &Job{Expr: "* * * * *", Command: "exit 4", Shell: true})
+	paused := addJob(t, store, &Job{Expr: "* * * * *", Command: "true", Paused: true})
+	s := NewScheduler(store, Options{})
+
+	require.NoError(t, s.Tick(context.Background(), created.Add(time.Minute)))
+	s.Wait()
+
+	got, _ := store.Get(failing.ID)
+	assert.Equal(t, StatusFailed, got.LastStatus)
+	assert.Equal(t, 4, got.LastExitCode)
+	assert.Equal(t, 1, got.Failures)
+
+	got, _ = store.Get(paused.ID)
+	assert.Equal(t, 0, got.Runs)
+}
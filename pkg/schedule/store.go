@@ -0,0 +1,245 @@
+package schedule
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MissedPolicy 调度器停止期间错过执行时的处理方式
+type MissedPolicy string
+
+const (
+	MissedSkip MissedPolicy = "skip" // 跳过错过的执行，等待下一次
+	MissedOnce MissedPolicy = "once" // 启动后立即补执行一次
+)
+
+// Job 一个定时任务
+type Job struct {
+	ID           string        `json:"id"`
+	Name         string        `json:"name"`
+	Expr         string        `json:"expr"`
+	Command      string        `json:"command"`
+	Shell        bool          `json:"shell,omitempty"`
+	Timeout      time.Duration `json:"timeout,omitempty"`
+	Missed       MissedPolicy  `json:"missed"`
+	AllowOverlap bool          `json:"allow_overlap,omitempty"` // 允许上一次执行未结束时再次执行
+	Paused       bool          `json:"paused,omitempty"`
+	CreatedAt    time.Time     `json:"created_at"`
+
+	// 以下字段由调度器维护
+	LastScheduled time.Time `json:"last_scheduled,omitempty"` // 最近一次已处理的计划执行时间
+	LastRun       time.Time `json:"last_run,omitempty"`
+	LastStatus    string    `json:"last_status,omitempty"`
+	LastExitCode  int       `json:"last_exit_code,omitempty"`
+	LastTaskID    string    `json:"last_task_id,omitempty"`
+	Runs          int       `json:"runs"`
+	Failures      int       `json:"failures"`
+}
+
+// Validate 校验任务的表达式与策略
+func (j *Job) Validate() error {
+	if strings.TrimSpace(j.Command) == "" {
+		return fmt.Errorf("命令不能为空")
+	}
+	if _, err := Parse(j.Expr); err != nil {
+		return err
+	}
+	switch j.Missed {
+	case MissedSkip, MissedOnce:
+	default:
+		return fmt.Errorf("未知的错过执行策略 %q，可选 skip、once", j.Missed)
+	}
+	return nil
+}
+
+// NextRun 返回 now 之后的下一次计划执行时间，固定间隔从最近一次计划时间开始推算
+func (j *Job) NextRun(now time.Time) (time.Time, error) {
+	sched, err := Parse(j.Expr)
+	if err != nil {
+		return time.Time{}, err
+	}
+	base := j.LastScheduled
+	if base.IsZero() {
+		base = j.CreatedAt
+	}
+	next := sched.Next(base)
+	if !next.IsZero() && !next.After(now) {
+		latest, _ := catchUp(sched, next, now)
+		next = sched.Next(latest)
+	}
+	return next, nil
+}
+
+// Store 将定时任务保存在 JSON 文件中，调度进程与命令行通过该文件共享任务
+type Store struct {
+	path string
+	mu   sync.Mutex
+}
+
+// DefaultStorePath 默认的任务文件路径
+func DefaultStorePath() string {
+	return filepath.Join(os.Getenv("HOME"), ".clixgo", "schedules.json")
+}
+
+// NewStore 创建使用 path 的任务存储
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+// Path 返回任务文件路径
+func (s *Store) Path() string {
+	return s.path
+}
+
+// List 返回所有任务，按创建时间排序
+func (s *Store) List() ([]*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.load()
+}
+
+// Get 按 ID 或名称查找任务，ID 可以是唯一的前缀
+func (s *Store) Get(ref string) (*Job, error) {
+	jobs, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	return find(jobs, ref)
+}
+
+// Add 校验并保存新任务，自动生成 ID 并填充默认值
+func (s *Store) Add(job *Job) error {
+	if job.Missed == "" {
+		job.Missed = MissedSkip
+	}
+	if err := job.Validate(); err != nil {
+		return err
+	}
+	if job.ID == "" {
+		job.ID = strings.SplitN(uuid.New().String(), "-", 2)[0]
+	}
+	if job.Name == "" {
+		job.Name = job.Command
+	}
+	if job.CreatedAt.IsZero() {
+		job.CreatedAt = time.Now()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	jobs, err := s.load()
+	if err != nil {
+		return err
+	}
+	for _, j := range jobs {
+		if j.ID == job.ID {
+			return fmt.Errorf("任务 %s 已存在", job.ID)
+		}
+	}
+	return s.save(append(jobs, job))
+}
+
+// Remove 删除任务
+func (s *Store) Remove(ref string) (*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	jobs, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	job, err := find(jobs, ref)
+	if err != nil {
+		return nil, err
+	}
+	kept := jobs[:0]
+	for _, j := range jobs {
+		if j.ID != job.ID {
+			kept = append(kept, j)
+		}
+	}
+	return job, s.save(kept)
+}
+
+// Update 读取最新的任务文件并修改指定任务，任务已被删除时返回错误
+func (s *Store) Update(ref string, fn func(*Job)) (*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	jobs, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	job, err := find(jobs, ref)
+	if err != nil {
+		return nil, err
+	}
+	fn(job)
+	return job, s.save(jobs)
+}
+
+// find 按 ID、ID 前缀或名称查找任务
+func find(jobs []*Job, ref string) (*Job, error) {
+	var matches []*Job
+	for _, j := range jobs {
+		if j.ID == ref {
+			return j, nil
+		}
+		if strings.HasPrefix(j.ID, ref) || j.Name == ref {
+			matches = append(matches, j)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("定时任务 %q 不存在", ref)
+	case 1:
+		return matches[0], nil
+	}
+	return nil, fmt.Errorf("%q 匹配多个定时任务，请使用完整的ID", ref)
+}
+
+func (s *Store) load() ([]*Job, error) {
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取定时任务失败: %v", err)
+	}
+	var jobs []*Job
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &jobs); err != nil {
+			return nil, fmt.Errorf("解析定时任务失败: %v", err)
+		}
+	}
+	sort.SliceStable(jobs, func(i, k int) bool { return jobs[i].CreatedAt.Before(jobs[k].CreatedAt) })
+	return jobs, nil
+}
+
+// save 先写入临时文件再重命名，避免其他进程读到写了一半的文件
+func (s *Store) save(jobs []*Job) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("创建定时任务目录失败: %v", err)
+	}
+	if jobs == nil {
+		jobs = []*Job{}
+	}
+	data, err := json.MarshalIndent(jobs, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化定时任务失败: %v", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("保存定时任务失败: %v", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("保存定时任务失败: %v", err)
+	}
+	return nil
+}
@@ -5,8 +5,12 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
+
+	"github.com/Lzww0608/ClixGo/pkg/redact"
+	"github.com/Lzww0608/ClixGo/pkg/shell"
 )
 
 // CommandStats 表示命令执行统计信息
@@ -30,6 +34,11 @@ type CommandPolicy struct {
 	TimeRange   []string `json:"time_range,omitempty"` // ["09:00", "18:00"]
 	MaxDuration int      `json:"max_duration_seconds,omitempty"`
 	MaxCalls    int      `json:"max_calls_per_hour,omitempty"`
+	// 执行命令时通过 setrlimit 强制执行的资源限制
+	MaxCPUTime   int `json:"max_cpu_seconds,omitempty"`
+	MaxMemory    int `json:"max_memory_mb,omitempty"`
+	MaxOpenFiles int `json:"max_open_files,omitempty"`
+	MaxProcesses int `json:"max_processes,omitempty"`
 }
 
 // CommandManager 管理命令执行统计和权限控制
@@ -61,8 +70,13 @@ func NewCommandManager(statsFile, policiesFile string) (*CommandManager, error)
 	return cm, nil
 }
 
-// RecordCommand 记录命令执行
+// RecordCommand 记录命令执行，命令、参数、错误和环境变量中的敏感信息在保存前被替换
 func (cm *CommandManager) RecordCommand(stats CommandStats) error {
+	stats.Command = redact.String(stats.Command)
+	stats.Args = redact.Strings(stats.Args)
+	stats.Error = redact.String(stats.Error)
+	stats.Environment = redact.String(stats.Environment)
+
 	cm.mu.Lock()
 	defer cm.mu.Unlock()
 
@@ -147,6 +161,57 @@ func (cm *CommandManager) CheckPermission(command string, user string, groups []
 	return true, ""
 }
 
+// GetPolicy 查找命令对应的策略，命令匹配多个策略时返回第一个，匹配规则见 Policies
+func (cm *CommandManager) GetPolicy(command string) (CommandPolicy, bool) {
+	policies := cm.Policies(command)
+	if len(policies) == 0 {
+		return CommandPolicy{}, false
+	}
+	return policies[0], true
+}
+
+// Policies 返回命令匹配的所有策略。与完整命令相同的策略优先且只返回该策略；
+// 否则将命令解析为 shell 脚本，返回其中每个被调用程序对应的策略，
+// 赋值前缀、&&、管道、子 shell 和命令替换中调用的程序都会被检查，
+// 带路径的程序同时按文件名匹配。无法解析的命令按第一个单词匹配
+func (cm *CommandManager) Policies(command string) []CommandPolicy {
+	command = strings.TrimSpace(command)
+	programs, err := shell.Programs(command)
+	if err != nil {
+		programs = nil
+		if fields := strings.Fields(command); len(fields) > 0 {
+			programs = fields[:1]
+		}
+	}
+
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	for _, policy := range cm.policies {
+		if policy.Command == command {
+			return []CommandPolicy{policy}
+		}
+	}
+
+	var matched []CommandPolicy
+	seen := make(map[string]bool)
+	for _, program := range programs {
+		for _, name := range []string{program, filepath.Base(program)} {
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			for _, policy := range cm.policies {
+				if policy.Command == name {
+					matched = append(matched, policy)
+					break
+				}
+			}
+		}
+	}
+	return matched
+}
+
 // GetCommandStats 获取命令执行统计信息
 func (cm *CommandManager) GetCommandStats(command string, duration time.Duration) []CommandStats {
 	cm.mu.RLock()
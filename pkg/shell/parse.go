@@ -0,0 +1,336 @@
+package shell
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"mvdan.cc/sh/v3/expand"
+	"mvdan.cc/sh/v3/interp"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// Command 解析并展开后的简单命令
+type Command struct {
+	Args []string // 命令及参数
+	Env  []string // 命令前的变量赋值，形如 KEY=VALUE
+}
+
+// Options 解释执行脚本时的输入输出及环境
+type Options struct {
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+	Env    []string // 为空时继承当前进程的环境变量
+	Dir    string   // 为空时使用当前工作目录
+}
+
+// Parse 按 POSIX/Bash 语法解析命令字符串
+func Parse(command string) (*syntax.File, error) {
+	file, err := syntax.NewParser().Parse(strings.NewReader(command), "")
+	if err != nil {
+		return nil, fmt.Errorf("解析命令失败: %v", err)
+	}
+	return file, nil
+}
+
+// ParseCommand 将命令字符串解析为可直接执行的简单命令，处理引号、转义、
+// 变量展开和路径通配。命令包含 &&、||、管道、重定向、命令替换或多条语句等
+// 需要解释器的语法时，ok 返回 false
+func ParseCommand(command string) (cmd *Command, ok bool, err error) {
+	return ParseCommandWith(command, Options{})
+}
+
+// ParseCommandWith 与 ParseCommand 相同，但使用 opts 中的环境变量展开变量、
+// 在 opts.Dir 下展开路径通配，输入输出字段被忽略
+func ParseCommandWith(command string, opts Options) (cmd *Command, ok bool, err error) {
+	file, err := Parse(command)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(file.Stmts) != 1 {
+		return nil, false, nil
+	}
+
+	stmt := file.Stmts[0]
+	if stmt.Negated || stmt.Background || stmt.Coprocess || len(stmt.Redirs) > 0 {
+		return nil, false, nil
+	}
+	call, isCall := stmt.Cmd.(*syntax.CallExpr)
+	if !isCall || len(call.Args) == 0 || needsInterpreter(call) {
+		return nil, false, nil
+	}
+
+	cfg := expandConfig(opts)
+	cmd = &Command{}
+	for _, assign := range call.Assigns {
+		if assign.Append || assign.Naked || assign.Index != nil || assign.Array != nil {
+			return nil, false, nil
+		}
+		value, err := expand.Literal(cfg, assign.Value)
+		if err != nil {
+			return nil, false, fmt.Errorf("展开变量 %s 失败: %v", assign.Name.Value, err)
+		}
+		cmd.Env = append(cmd.Env, assign.Name.Value+"="+value)
+	}
+
+	cmd.Args, err = expand.Fields(cfg, call.Args...)
+	if err != nil {
+		return nil, false, fmt.Errorf("展开命令参数失败: %v", err)
+	}
+	if len(cmd.Args) == 0 {
+		return nil, false, nil
+	}
+	return cmd, true, nil
+}
+
+// needsInterpreter 检查命令中是否包含需要执行才能展开的结构
+func needsInterpreter(call *syntax.CallExpr) bool {
+	found := false
+	syntax.Walk(call, func(node syntax.Node) bool {
+		switch node.(type) {
+		case *syntax.CmdSubst, *syntax.ProcSubst, *syntax.ArithmExp:
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// expandConfig 创建展开配置，未指定环境变量时使用当前进程的环境变量
+func expandConfig(opts Options) *expand.Config {
+	env := opts.Env
+	if env == nil {
+		env = os.Environ()
+	}
+	if opts.Dir != "" {
+		env = append(env[:len(env):len(env)], "PWD="+opts.Dir)
+	}
+	return &expand.Config{
+		Env:      expand.ListEnviron(env...),
+		ReadDir2: os.ReadDir,
+	}
+}
+
+// Run 使用进程内解释器执行完整的脚本，支持 &&、||、管道、重定向、
+// 子shell、变量赋值等语法。脚本以非零状态退出时可通过 ExitCode 获取退出码
+func Run(ctx context.Context, script string, opts Options) error {
+	file, err := Parse(script)
+	if err != nil {
+		return err
+	}
+
+	env := opts.Env
+	if env == nil {
+		env = os.Environ()
+	}
+	stdout, stderr := opts.Stdout, opts.Stderr
+	if stdout == nil {
+		stdout = io.Discard
+	}
+	if stderr == nil {
+		stderr = io.Discard
+	}
+
+	runnerOpts := []interp.RunnerOption{
+		interp.StdIO(opts.Stdin, stdout, stderr),
+		interp.Env(expand.ListEnviron(env...)),
+	}
+	if opts.Dir != "" {
+		runnerOpts = append(runnerOpts, interp.Dir(opts.Dir))
+	}
+
+	runner, err := interp.New(runnerOpts...)
+	if err != nil {
+		return fmt.Errorf("创建解释器失败: %v", err)
+	}
+	return runner.Run(ctx, file)
+}
+
+// ExitCode 从 Run 返回的错误中提取退出码
+func ExitCode(err error) (int, bool) {
+	status, ok := interp.IsExitStatus(err)
+	return int(status), ok
+}
+
+// Join 将参数列表拼接为命令字符串，必要时为参数加上引号，使其能被 Parse 还原
+func Join(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		q, err := syntax.Quote(arg, syntax.LangBash)
+		if err != nil {
+			q = arg
+		}
+		quoted[i] = q
+	}
+	return strings.Join(quoted, " ")
+}
+
+// Word 命令中的一个词
+type Word struct {
+	Raw     string // 源文本，包括引号和转义
+	Value   string // 去掉引号和转义后的值，Literal 为假时为空
+	Literal bool   // 不含变量、命令替换、通配符等需要运行时展开的内容
+}
+
+// SplitCall 解析以简单命令开头的命令字符串，返回该简单命令的各个词和之后的剩余文本，
+// 例如 "gs --short && ls" 返回 [gs --short] 和 " && ls"。
+// 命令不以简单命令开头（如以变量赋值、重定向、!、( 开头）或为空时 ok 为假
+func SplitCall(command string) (words []Word, rest string, ok bool, err error) {
+	file, err := Parse(command)
+	if err != nil {
+		return nil, "", false, err
+	}
+	if len(file.Stmts) == 0 {
+		return nil, "", false, nil
+	}
+
+	// a && b、a | b 等二元命令的第一个简单命令在最左侧
+	stmt := file.Stmts[0]
+	for {
+		bin, isBinary := stmt.Cmd.(*syntax.BinaryCmd)
+		if !isBinary {
+			break
+		}
+		stmt = bin.X
+	}
+	call, isCall := stmt.Cmd.(*syntax.CallExpr)
+	if !isCall || len(call.Assigns) > 0 || len(call.Args) == 0 || stmt.Negated {
+		return nil, "", false, nil
+	}
+	start := int(call.Pos().Offset())
+	if strings.TrimSpace(command[:start]) != "" || (len(stmt.Redirs) > 0 && stmt.Redirs[0].Pos().Offset() < call.Pos().Offset()) {
+		return nil, "", false, nil
+	}
+
+	for _, w := range call.Args {
+		word := Word{Raw: command[w.Pos().Offset():w.End().Offset()]}
+		word.Value, word.Literal = literal(w)
+		words = append(words, word)
+	}
+	return words, command[call.End().Offset():], true, nil
+}
+
+// literal 返回不需要运行时展开的词去掉引号和转义后的值
+func literal(w *syntax.Word) (string, bool) {
+	var b strings.Builder
+	for i, part := range w.Parts {
+		switch p := part.(type) {
+		case *syntax.Lit:
+			// 通配符、花括号和开头的 ~ 在运行时展开
+			if strings.ContainsAny(p.Value, "*?[{") || (i == 0 && strings.HasPrefix(p.Value, "~")) {
+				return "", false
+			}
+			b.WriteString(unescape(p.Value, ""))
+		case *syntax.SglQuoted:
+			if p.Dollar {
+				return "", false
+			}
+			b.WriteString(p.Value)
+		case *syntax.DblQuoted:
+			if p.Dollar {
+				return "", false
+			}
+			for _, inner := range p.Parts {
+				lit, ok := inner.(*syntax.Lit)
+				if !ok {
+					return "", false
+				}
+				b.WriteString(unescape(lit.Value, "\\\"$`\n"))
+			}
+		default:
+			return "", false
+		}
+	}
+	return b.String(), true
+}
+
+// unescape 去掉反斜杠转义。special 为空时（引号外）反斜杠转义任意字符，
+// 否则（双引号内）只转义 special 中的字符；反斜杠加换行表示续行，一并删除
+func unescape(s, special string) string {
+	if !strings.Contains(s, "\\") {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) && (special == "" || strings.IndexByte(special, s[i+1]) >= 0) {
+			i++
+			if s[i] != '\n' {
+				b.WriteByte(s[i])
+			}
+			continue
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// Programs 返回脚本中各简单命令调用的程序名，按首次出现的顺序去重。
+// 程序名中的引号和转义被去掉，通过 command 或 exec 执行的程序同样会被列出；
+// 程序名需要运行时展开（如 $CMD）的命令和脚本中定义的函数被忽略
+func Programs(script string) ([]string, error) {
+	file, err := Parse(script)
+	if err != nil {
+		return nil, err
+	}
+
+	funcs := make(map[string]bool)
+	syntax.Walk(file, func(node syntax.Node) bool {
+		if decl, ok := node.(*syntax.FuncDecl); ok {
+			funcs[decl.Name.Value] = true
+		}
+		return true
+	})
+
+	var names []string
+	seen := make(map[string]bool)
+	syntax.Walk(file, func(node syntax.Node) bool {
+		call, ok := node.(*syntax.CallExpr)
+		if !ok {
+			return true
+		}
+		for args := call.Args; len(args) > 0; {
+			name, ok := literal(args[0])
+			if !ok || name == "" {
+				break
+			}
+			if !funcs[name] && !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+			if name != "command" && name != "exec" {
+				break
+			}
+			// 跳过 command -p、exec -a name 等选项，剩下的第一个词是实际执行的程序
+			args = args[1:]
+			for len(args) > 0 {
+				opt, ok := literal(args[0])
+				if !ok || !strings.HasPrefix(opt, "-") {
+					break
+				}
+				args = args[1:]
+				if opt == "-a" && len(args) > 0 {
+					args = args[1:]
+				}
+			}
+		}
+		return true
+	})
+	return names, nil
+}
+
+// IsBuiltin 判断 name 是否为进程内解释器的内置命令，内置命令不会启动外部程序
+func IsBuiltin(name string) bool {
+	switch name {
+	case "true", ":", "false", "exit", "set", "shift", "unset",
+		"echo", "printf", "break", "continue", "pwd", "cd",
+		"wait", "builtin", "trap", "type", "source", ".", "command",
+		"dirs", "pushd", "popd", "umask", "alias", "unalias",
+		"fg", "bg", "getopts", "eval", "test", "[", "exec",
+		"return", "read", "mapfile", "readarray", "shopt":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,174 @@
+package shell
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 测试按顶层分号拆分语句
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{"普通分隔", "ls; pwd", []string{"ls", "pwd"}},
+		{"保留空语句", "ls;;pwd", []string{"ls", "", "pwd"}},
+		{"单引号中的分号", `echo 'a;b'; pwd`, []string{`echo 'a;b'`, "pwd"}},
+		{"双引号中的分号", `echo "a;b" && ls`, []string{`echo "a;b" && ls`}},
+		{"转义的分号", `echo a\;b;pwd`, []string{`echo a\;b`, "pwd"}},
+		{"命令替换", `echo $(date; id);pwd`, []string{`echo $(date; id)`, "pwd"}},
+		{"引号内的命令替换", `echo "$(echo "a;b")";pwd`, []string{`echo "$(echo "a;b")"`, "pwd"}},
+		{"反引号", "echo `a;b`;pwd", []string{"echo $(a; b)", "pwd"}},
+		{"注释", "echo a # x;y\npwd", []string{"echo a # x;y", "pwd"}},
+		{"变量中的井号", "echo a#b;pwd", []string{"echo a#b", "pwd"}},
+		{"for 循环", "for i in 1 2; do echo $i; done; pwd", []string{"for i in 1 2; do echo $i; done", "pwd"}},
+		{"if 语句", "if true; then echo a; fi\nls", []string{"if true; then echo a; fi", "ls"}},
+		{"命令组", "{ a; b; }; c", []string{"{ a; b; }", "c"}},
+		{"子 shell", "(a; b); c", []string{"(a; b)", "c"}},
+		{"case 语句", "case $x in a) echo a;; *) echo b;; esac; c", []string{"case $x in a) echo a ;; *) echo b ;; esac", "c"}},
+		{"while 循环", "while read l; do echo $l; done < f; c", []string{"while read l; do echo $l; done <f", "c"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, SplitStatements(tt.input))
+		})
+	}
+}
+
+// 测试按管道符拆分
+func TestSplitPipeline(t *testing.T) {
+	assert.Equal(t, []string{"ls -la", "grep .txt", "sort"}, SplitPipeline("ls -la | grep .txt | sort"))
+	assert.Equal(t, []string{"a || b"}, SplitPipeline("a || b"))
+	assert.Equal(t, []string{`echo 'a|b'`, "wc -c"}, SplitPipeline(`echo 'a|b' | wc -c`))
+
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{"for 循环", "for i in 1 2; do echo $i | tr 1 x; done | sort", []string{"for i in 1 2; do echo $i | tr 1 x; done", "sort"}},
+		{"if 语句", "if true; then echo a | cat; fi | wc -l", []string{"if true; then echo a | cat; fi", "wc -l"}},
+		{"命令组", "{ a | b; c; } | d", []string{"{ a | b; c; }", "d"}},
+		{"子 shell", "(a | b) | c", []string{"(a | b)", "c"}},
+		{"case 语句", "case $x in a) echo a | cat;; esac | c", []string{"case $x in a) echo a | cat ;; esac", "c"}},
+		{"|& 不拆分", "a |& b", []string{"a |& b"}},
+		{"与或列表", "a && b | c", []string{"a && b | c"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, SplitPipeline(tt.input))
+		})
+	}
+}
+
+// 测试解析简单命令
+func TestParseCommand(t *testing.T) {
+	t.Setenv("CLIXGO_TEST_VAR", "value")
+
+	cmd, ok, err := ParseCommand(`FOO=bar grep -e "hello world" 'it''s' a\ b $CLIXGO_TEST_VAR`)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, []string{"FOO=bar"}, cmd.Env)
+	assert.Equal(t, []string{"grep", "-e", "hello world", "its", "a b", "value"}, cmd.Args)
+
+	for _, command := range []string{
+		"ls && pwd",
+		"ls || pwd",
+		"ls | wc -l",
+		"echo hi > out.txt",
+		"echo $(date)",
+		"ls; pwd",
+		"FOO=bar",
+	} {
+		_, ok, err := ParseCommand(command)
+		require.NoError(t, err, command)
+		assert.False(t, ok, command)
+	}
+
+	_, _, err = ParseCommand(`echo "unterminated`)
+	assert.Error(t, err)
+}
+
+// 测试拼接的命令能被解析还原
+func TestJoin(t *testing.T) {
+	args := []string{"grep", "-e", "hello world", "it's", "$HOME", "a;b", ""}
+	command := Join(args)
+	assert.Equal(t, "grep -e 'hello world' \"it's\" '$HOME' 'a;b' ''", command)
+
+	cmd, ok, err := ParseCommand(command)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, args, cmd.Args)
+}
+
+// 测试拆分开头的简单命令
+func TestSplitCall(t *testing.T) {
+	words, rest, ok, err := SplitCall(`gco 'a b' "x\"y" c\ d $HOME *.go && ls > out`)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, " && ls > out", rest)
+	require.Len(t, words, 6)
+	assert.Equal(t, Word{Raw: "gco", Value: "gco", Literal: true}, words[0])
+	assert.Equal(t, Word{Raw: "'a b'", Value: "a b", Literal: true}, words[1])
+	assert.Equal(t, `x"y`, words[2].Value)
+	assert.Equal(t, "c d", words[3].Value)
+	assert.Equal(t, Word{Raw: "$HOME"}, words[4])
+	assert.False(t, words[5].Literal)
+
+	words, rest, ok, err = SplitCall("gs; pwd")
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Len(t, words, 1)
+	assert.Equal(t, "; pwd", rest)
+
+	for _, command := range []string{"FOO=1 gs", "! gs", "(gs)", "> out gs", ""} {
+		_, _, ok, err := SplitCall(command)
+		require.NoError(t, err, command)
+		assert.False(t, ok, command)
+	}
+
+	_, _, _, err = SplitCall("gs 'unterminated")
+	assert.Error(t, err)
+}
+
+// 测试提取脚本调用的程序
+func TestPrograms(t *testing.T) {
+	names, err := Programs(`greet() { echo hi; }; ls -l | grep go && greet; $CMD x; echo "$(date)"; ls`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"echo", "ls", "grep", "date"}, names)
+	assert.True(t, IsBuiltin("cd"))
+	assert.False(t, IsBuiltin("ls"))
+
+	// 引号、转义、赋值前缀、子 shell 和 command/exec 都不能隐藏程序名
+	names, err = Programs(`FOO=1 \rm a; ("rm" b); { 'sudo' x; }; command -p /bin/rm c; exec -a x rm`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"rm", "sudo", "command", "/bin/rm", "exec"}, names)
+
+	_, err = Programs("echo 'unterminated")
+	assert.Error(t, err)
+}
+
+// 测试解释器执行
+func TestRun(t *testing.T) {
+	var out bytes.Buffer
+	err := Run(context.Background(), `X=1; [ "$X" = 1 ] && echo yes || echo no; echo "a b" | tr ' ' '-'`, Options{Stdout: &out})
+	require.NoError(t, err)
+	assert.Equal(t, "yes\na-b\n", out.String())
+
+	out.Reset()
+	err = Run(context.Background(), "cat", Options{Stdin: strings.NewReader("input"), Stdout: &out})
+	require.NoError(t, err)
+	assert.Equal(t, "input", out.String())
+
+	err = Run(context.Background(), "exit 3", Options{})
+	code, ok := ExitCode(err)
+	require.True(t, ok)
+	assert.Equal(t, 3, code)
+}
@@ -0,0 +1,183 @@
+package shell
+
+import (
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// 扫描时所处的语法上下文
+const (
+	ctxParen    = iota // ( ... ) 或 $( ... )
+	ctxSingle          // '...'
+	ctxDouble          // "..."
+	ctxBacktick        // `...`
+	ctxBrace           // ${...}
+)
+
+// SplitStatements 按顶层的分号和换行拆分命令字符串，返回的每条语句由解析器重新输出。
+// for、if、case、{ } 和 ( ) 等复合命令作为一条语句，不会在其内部拆分。
+// 命令无法解析时（如包含空语句 "ls;;pwd"）退回按顶层分号扫描，保留空语句供调用方报告
+func SplitStatements(command string) []string {
+	file, err := parseKeepComments(command)
+	if err != nil || len(file.Stmts) == 0 {
+		return splitTopLevel(command, func(s string, i int) bool {
+			return s[i] == ';' || s[i] == '\n'
+		})
+	}
+	parts := make([]string, len(file.Stmts))
+	for i, stmt := range file.Stmts {
+		parts[i] = printNode(stmt)
+	}
+	return parts
+}
+
+// SplitPipeline 将单条语句按顶层的管道符 | 拆分为各阶段，||、|& 和复合命令内部的管道不会被拆分，
+// 命令包含多条语句或无法解析时退回按顶层管道符扫描
+func SplitPipeline(command string) []string {
+	file, err := parseKeepComments(command)
+	if err != nil || len(file.Stmts) != 1 {
+		return splitTopLevel(command, func(s string, i int) bool {
+			if s[i] != '|' {
+				return false
+			}
+			if i+1 < len(s) && (s[i+1] == '|' || s[i+1] == '&') {
+				return false
+			}
+			return i == 0 || s[i-1] != '|'
+		})
+	}
+
+	var parts []string
+	var walk func(stmt *syntax.Stmt)
+	walk = func(stmt *syntax.Stmt) {
+		if bin, ok := stmt.Cmd.(*syntax.BinaryCmd); ok && bin.Op == syntax.Pipe && plainStmt(stmt) {
+			walk(bin.X)
+			walk(bin.Y)
+			return
+		}
+		parts = append(parts, printNode(stmt))
+	}
+	walk(file.Stmts[0])
+	return parts
+}
+
+// plainStmt 判断语句是否没有取反、后台执行和重定向等修饰
+func plainStmt(stmt *syntax.Stmt) bool {
+	return !stmt.Negated && !stmt.Background && !stmt.Coprocess && len(stmt.Redirs) == 0
+}
+
+// parseKeepComments 解析命令字符串并保留注释
+func parseKeepComments(command string) (*syntax.File, error) {
+	return syntax.NewParser(syntax.KeepComments(true)).Parse(strings.NewReader(command), "")
+}
+
+// printNode 将语法节点输出为单行命令字符串
+func printNode(node syntax.Node) string {
+	var b strings.Builder
+	syntax.NewPrinter(syntax.SingleLine(true)).Print(&b, node)
+	return strings.TrimSpace(b.String())
+}
+
+// splitTopLevel 按顶层分隔符扫描无法解析的命令字符串，在不处于任何引号或子结构中的位置调用 isSep 判断是否为分隔符
+func splitTopLevel(s string, isSep func(s string, i int) bool) []string {
+	var parts []string
+	var stack []int
+	start := 0
+
+	push := func(ctx int) { stack = append(stack, ctx) }
+	pop := func() { stack = stack[:len(stack)-1] }
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+
+		top := -1
+		if len(stack) > 0 {
+			top = stack[len(stack)-1]
+		}
+
+		switch top {
+		case ctxSingle:
+			if c == '\'' {
+				pop()
+			}
+			continue
+		case ctxBacktick:
+			if c == '\\' {
+				i++
+			} else if c == '`' {
+				pop()
+			}
+			continue
+		case ctxDouble:
+			switch {
+			case c == '\\':
+				i++
+			case c == '"':
+				pop()
+			case c == '`':
+				push(ctxBacktick)
+			case c == '$' && i+1 < len(s) && s[i+1] == '(':
+				push(ctxParen)
+				i++
+			case c == '$' && i+1 < len(s) && s[i+1] == '{':
+				push(ctxBrace)
+				i++
+			}
+			continue
+		}
+
+		switch {
+		case c == '\\':
+			i++
+			continue
+		case c == '\'':
+			push(ctxSingle)
+			continue
+		case c == '"':
+			push(ctxDouble)
+			continue
+		case c == '`':
+			push(ctxBacktick)
+			continue
+		case c == '$' && i+1 < len(s) && s[i+1] == '(':
+			push(ctxParen)
+			i++
+			continue
+		case c == '$' && i+1 < len(s) && s[i+1] == '{':
+			push(ctxBrace)
+			i++
+			continue
+		case c == '(':
+			push(ctxParen)
+			continue
+		case c == ')' && top == ctxParen:
+			pop()
+			continue
+		case c == '}' && top == ctxBrace:
+			pop()
+			continue
+		case c == '#' && top != ctxBrace && wordStart(s, i):
+			// 注释一直持续到行尾
+			for i+1 < len(s) && s[i+1] != '\n' {
+				i++
+			}
+			continue
+		}
+
+		if top == -1 && isSep(s, i) {
+			parts = append(parts, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+
+	return append(parts, strings.TrimSpace(s[start:]))
+}
+
+// wordStart 判断位置 i 是否为一个单词的开头
+func wordStart(s string, i int) bool {
+	if i == 0 {
+		return true
+	}
+	return strings.IndexByte(" \t\n;|&(", s[i-1]) >= 0
+}
@@ -26,17 +26,43 @@ const (
 
 // Task 表示一个后台任务
 type Task struct {
-	ID          string      `json:"id"`
-	Name        string      `json:"name"`
-	Description string      `json:"description"`
-	Status      TaskStatus  `json:"status"`
-	Progress    float64     `json:"progress"`
-	Result      string      `json:"result"`
-	Error       string      `json:"error,omitempty"`
-	CreatedAt   time.Time   `json:"created_at"`
-	StartedAt   *time.Time  `json:"started_at,omitempty"`
-	FinishedAt  *time.Time  `json:"finished_at,omitempty"`
-	Metadata    interface{} `json:"metadata,omitempty"`
+	ID          string        `json:"id"`
+	Name        string        `json:"name"`
+	Description string        `json:"description"`
+	Status      TaskStatus    `json:"status"`
+	Progress    float64       `json:"progress"`
+	Result      string        `json:"result"`
+	Error       string        `json:"error,omitempty"`
+	CreatedAt   time.Time     `json:"created_at"`
+	StartedAt   *time.Time    `json:"started_at,omitempty"`
+	FinishedAt  *time.Time    `json:"finished_at,omitempty"`
+	UpdatedAt   time.Time     `json:"updated_at"`
+	ExitCode    *int          `json:"exit_code,omitempty"`
+	Terminal    *TerminalHost `json:"terminal,omitempty"`
+	Metadata    interface{}   `json:"metadata,omitempty"`
+}
+
+// TerminalHost 记录承载任务进程的终端会话、窗口和面板
+type TerminalHost struct {
+	SessionID   string `json:"session_id"`
+	SessionName string `json:"session_name"`
+	WindowIndex int    `json:"window_index"`
+	WindowName  string `json:"window_name"`
+	PaneID      string `json:"pane_id"`
+	PaneIndex   int    `json:"pane_index"`
+	Command     string `json:"command"`
+	SocketPath  string `json:"socket_path,omitempty"`
+}
+
+// Duration 返回任务的运行时长，运行中的任务计算到当前时间
+func (t *Task) Duration() time.Duration {
+	if t.StartedAt == nil {
+		return 0
+	}
+	if t.FinishedAt == nil {
+		return time.Since(*t.StartedAt)
+	}
+	return t.FinishedAt.Sub(*t.StartedAt)
 }
 
 // TaskManager 管理后台任务
@@ -46,6 +72,7 @@ type TaskManager struct {
 	subscribers map[string][]chan *Task
 	logger      *zap.Logger
 	storePath   string
+	saveMu      sync.Mutex // 串行化文件的读取合并与写入
 }
 
 // NewTaskManager 创建任务管理器
@@ -82,6 +109,7 @@ func (tm *TaskManager) CreateTask(name, description string, metadata interface{}
 		CreatedAt:   time.Now(),
 		Metadata:    metadata,
 	}
+	task.UpdatedAt = task.CreatedAt
 
 	tm.mu.Lock()
 	tm.tasks[task.ID] = task
@@ -110,6 +138,7 @@ func (tm *TaskManager) StartTask(ctx context.Context, taskID string, fn func(con
 	now := time.Now()
 	task.Status = TaskStatusRunning
 	task.StartedAt = &now
+	task.UpdatedAt = now
 	task.Progress = 0.0 // 确保进度初始化为0
 
 	// 创建任务副本，避免并发修改
@@ -137,11 +166,16 @@ func (tm *TaskManager) StartTask(ctx context.Context, taskID string, fn func(con
 		}
 
 		now := time.Now()
-		task.FinishedAt = &now
-		if err != nil {
+		task.UpdatedAt = now
+		switch {
+		case task.Status == TaskStatusCancelled:
+			// 任务已被取消，保留取消状态和取消时间
+		case err != nil:
+			task.FinishedAt = &now
 			task.Status = TaskStatusFailed
 			task.Error = err.Error()
-		} else {
+		default:
+			task.FinishedAt = &now
 			task.Status = TaskStatusComplete
 			task.Progress = 1.0 // 确保进度设为100%
 		}
@@ -179,6 +213,7 @@ func (tm *TaskManager) UpdateTaskProgress(taskID string, progress float64) error
 
 	// 更新进度
 	task.Progress = progress
+	task.UpdatedAt = time.Now()
 
 	// 创建任务副本用于通知
 	taskCopy := *task
@@ -207,6 +242,7 @@ func (tm *TaskManager) CancelTask(taskID string) error {
 	now := time.Now()
 	task.Status = TaskStatusCancelled
 	task.FinishedAt = &now
+	task.UpdatedAt = now
 
 	// 创建任务副本用于通知
 	taskCopy := *task
@@ -221,6 +257,41 @@ func (tm *TaskManager) CancelTask(taskID string) error {
 	return err
 }
 
+// SetTerminalHost 记录承载任务的终端面板
+func (tm *TaskManager) SetTerminalHost(taskID string, host *TerminalHost) error {
+	tm.mu.Lock()
+	task, ok := tm.tasks[taskID]
+	if !ok {
+		tm.mu.Unlock()
+		return errors.New("任务不存在")
+	}
+
+	hostCopy := *host
+	task.Terminal = &hostCopy
+	task.UpdatedAt = time.Now()
+	taskCopy := *task
+	tm.mu.Unlock()
+
+	tm.notifySubscribers(&taskCopy)
+	return tm.saveTasks()
+}
+
+// SetExitCode 记录任务进程的退出码
+func (tm *TaskManager) SetExitCode(taskID string, code int) error {
+	tm.mu.Lock()
+	task, ok := tm.tasks[taskID]
+	if !ok {
+		tm.mu.Unlock()
+		return errors.New("任务不存在")
+	}
+
+	task.ExitCode = &code
+	task.UpdatedAt = time.Now()
+	tm.mu.Unlock()
+
+	return nil
+}
+
 // GetTask 获取任务信息
 func (tm *TaskManager) GetTask(taskID string) (*Task, error) {
 	tm.mu.RLock()
@@ -329,8 +400,17 @@ func (tm *TaskManager) loadTasks() error {
 	return nil
 }
 
-// saveTasks 保存任务到文件
+// saveTasks 保存任务到文件。
+// 多个进程（例如终端服务器和命令行）可能共享同一个存储文件，
+// 保存前先合并文件中的任务，同一任务以更新时间较新的版本为准
 func (tm *TaskManager) saveTasks() error {
+	tm.saveMu.Lock()
+	defer tm.saveMu.Unlock()
+
+	if err := tm.mergeStoredTasks(); err != nil && tm.logger != nil {
+		tm.logger.Warn("合并已保存的任务失败", zap.Error(err))
+	}
+
 	// 首先创建任务的一个深拷贝
 	tm.mu.RLock()
 	tasksCopy := make(map[string]*Task, len(tm.tasks))
@@ -353,6 +433,33 @@ func (tm *TaskManager) saveTasks() error {
 	return nil
 }
 
+// mergeStoredTasks 将文件中由其他进程创建或更新的任务合并到内存
+func (tm *TaskManager) mergeStoredTasks() error {
+	data, err := os.ReadFile(tm.storePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "读取任务文件失败")
+	}
+
+	var stored map[string]*Task
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return errors.Wrap(err, "解析任务数据失败")
+	}
+
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	for id, task := range stored {
+		if current, ok := tm.tasks[id]; !ok || task.UpdatedAt.After(current.UpdatedAt) {
+			tm.tasks[id] = task
+		}
+	}
+
+	return nil
+}
+
 // periodicSave 定期保存任务状态
 func (tm *TaskManager) periodicSave() {
 	ticker := time.NewTicker(1 * time.Minute)
@@ -458,3 +458,99 @@ func TestProgressRaceCondition(t *testing.T) {
 	// 进度应该是最后设置的0.5或1.0，不应该是0.2
 	assert.NotEqual(t, 0.2, finalTask.Progress, "任务进度不应为0.2（来自任务副本的直接修改）")
 }
+
+// TestCancelRunningTask 测试取消运行中的任务后，任务函数返回不会覆盖取消状态
+func TestCancelRunningTask(t *testing.T) {
+	tmpDir, cleanup := setupTestDir(t)
+	defer cleanup()
+
+	storePath := filepath.Join(tmpDir, "tasks.json")
+	logger := zaptest.NewLogger(t)
+
+	tm, err := NewTaskManager(logger, storePath)
+	require.NoError(t, err)
+
+	task, err := tm.CreateTask("测试任务", "这是一个测试任务", nil)
+	require.NoError(t, err)
+
+	release := make(chan struct{})
+	err = tm.StartTask(context.Background(), task.ID, func(ctx context.Context, task *Task) error {
+		<-release
+		return assert.AnError
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, tm.CancelTask(task.ID))
+	close(release)
+	time.Sleep(50 * time.Millisecond)
+
+	finalTask, err := tm.GetTask(task.ID)
+	require.NoError(t, err)
+	assert.Equal(t, TaskStatusCancelled, finalTask.Status, "任务应保持cancelled状态")
+	assert.Empty(t, finalTask.Error)
+}
+
+// TestTerminalHostAndExitCode 测试记录终端位置和退出码
+func TestTerminalHostAndExitCode(t *testing.T) {
+	tmpDir, cleanup := setupTestDir(t)
+	defer cleanup()
+
+	storePath := filepath.Join(tmpDir, "tasks.json")
+	logger := zaptest.NewLogger(t)
+
+	tm, err := NewTaskManager(logger, storePath)
+	require.NoError(t, err)
+
+	task, err := tm.CreateTask("构建", "make", nil)
+	require.NoError(t, err)
+
+	host := &TerminalHost{SessionName: "dev", WindowIndex: 1, WindowName: "构建", PaneID: "pane-1"}
+	require.NoError(t, tm.SetTerminalHost(task.ID, host))
+	require.NoError(t, tm.SetExitCode(task.ID, 2))
+
+	got, err := tm.GetTask(task.ID)
+	require.NoError(t, err)
+	require.NotNil(t, got.Terminal)
+	assert.Equal(t, "pane-1", got.Terminal.PaneID)
+	require.NotNil(t, got.ExitCode)
+	assert.Equal(t, 2, *got.ExitCode)
+	assert.Equal(t, time.Duration(0), got.Duration(), "未启动的任务耗时应为0")
+
+	assert.Error(t, tm.SetTerminalHost("missing", host))
+	assert.Error(t, tm.SetExitCode("missing", 0))
+}
+
+// TestSaveMergesOtherProcesses 测试多个管理器共享存储文件时不会互相覆盖
+func TestSaveMergesOtherProcesses(t *testing.T) {
+	tmpDir, cleanup := setupTestDir(t)
+	defer cleanup()
+
+	storePath := filepath.Join(tmpDir, "tasks.json")
+	logger := zaptest.NewLogger(t)
+
+	tm1, err := NewTaskManager(logger, storePath)
+	require.NoError(t, err)
+	tm2, err := NewTaskManager(logger, storePath)
+	require.NoError(t, err)
+
+	task1, err := tm1.CreateTask("任务1", "由第一个管理器创建", nil)
+	require.NoError(t, err)
+	task2, err := tm2.CreateTask("任务2", "由第二个管理器创建", nil)
+	require.NoError(t, err)
+
+	// 第二个管理器保存时合并了第一个管理器的任务
+	_, err = tm2.GetTask(task1.ID)
+	assert.NoError(t, err)
+
+	// 较新的更新不会被旧版本覆盖
+	require.NoError(t, tm1.CancelTask(task1.ID))
+	require.NoError(t, tm2.saveTasks())
+
+	tm3, err := NewTaskManager(logger, storePath)
+	require.NoError(t, err)
+	loaded, err := tm3.GetTask(task1.ID)
+	require.NoError(t, err)
+	assert.Equal(t, TaskStatusCancelled, loaded.Status)
+	_, err = tm3.GetTask(task2.ID)
+	assert.NoError(t, err)
+}
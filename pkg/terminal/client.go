@@ -13,39 +13,57 @@ import (
 
 	"github.com/Lzww0608/ClixGo/pkg/logger"
 	"go.uber.org/zap"
+	"golang.org/x/term"
 )
 
 // TerminalClient 终端客户端
 type TerminalClient struct {
-	conn      net.Conn
-	sessionID string
-	config    *TerminalConfig
-	running   bool
-	keyMode   bool // 是否在快捷键模式
+	conn       net.Conn
+	socketPath string
+	sessionID  string
+	config     *TerminalConfig
+	running    bool
+	keyMode    bool // 是否在快捷键模式
+
+	lines    chan string     // 用户输入的行
+	mouse    chan MouseEvent // 鼠标事件
+	resize   chan os.Signal  // 终端大小变化
+	session  *Session        // 最近一次获取的会话快照，用于鼠标定位
+	tabs     []WindowTab     // 状态栏窗口标签位置
+	drag     *dragState      // 正在拖动的面板边框
+	ttyState *ttyState       // 开启鼠标模式前的终端属性
 }
 
-// NewTerminalClient 创建终端客户端
+// dragState 边框拖动状态
+type dragState struct {
+	paneIndex int
+	vertical  bool
+	last      int
+}
+
+// NewTerminalClient 创建连接默认服务器的终端客户端
 func NewTerminalClient(config *TerminalConfig) *TerminalClient {
+	socketPath, _ := SocketPathForName(DefaultServerName)
+	return NewTerminalClientWithSocket(config, socketPath)
+}
+
+// NewTerminalClientWithSocket 创建连接指定socket路径上服务器的终端客户端
+func NewTerminalClientWithSocket(config *TerminalConfig, socketPath string) *TerminalClient {
 	if config == nil {
 		config = DefaultConfig
 	}
 
 	return &TerminalClient{
-		config:  config,
-		running: false,
-		keyMode: false,
+		config:     config,
+		socketPath: socketPath,
+		running:    false,
+		keyMode:    false,
 	}
 }
 
 // Connect 连接到服务器
 func (tc *TerminalClient) Connect() error {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		homeDir = "/tmp"
-	}
-	socketPath := fmt.Sprintf("%s/.clixgo/terminal/clixgo-terminal.sock", homeDir)
-
-	conn, err := net.Dial("unix", socketPath)
+	conn, err := net.Dial("unix", tc.socketPath)
 	if err != nil {
 		return fmt.Errorf("连接服务器失败: %v", err)
 	}
@@ -156,15 +174,39 @@ func (tc *TerminalClient) StartInteractiveMode() error {
 	}
 
 	tc.running = true
+	tc.lines = make(chan string)
+	tc.mouse = make(chan MouseEvent, 64)
+	tc.resize = make(chan os.Signal, 1)
 
 	// 设置信号处理
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
+	if len(resizeSignals) > 0 {
+		signal.Notify(tc.resize, resizeSignals...)
+		defer signal.Stop(tc.resize)
+	}
+
+	// 开启鼠标报告，失败时（例如输入不是终端）退回到按行输入
+	if tc.config.MouseEnabled {
+		if err := tc.enableMouse(); err != nil {
+			logger.Warn("Mouse mode disabled", zap.Error(err))
+		} else {
+			defer tc.disableMouse()
+		}
+	}
 
 	// 显示欢迎信息
 	tc.showWelcome()
+	tc.reportSize()
+	tc.refreshSession()
 
-	// 启动输入处理
+	// 启动输入读取和处理
+	if tc.ttyState != nil {
+		go newInputReader(os.Stdin, os.Stdout, tc.lines, tc.mouse).run()
+	} else {
+		go tc.scanLines()
+	}
 	go tc.handleInput()
 
 	// 等待退出信号
@@ -184,6 +226,45 @@ func (tc *TerminalClient) StartInteractiveMode() error {
 	return nil
 }
 
+// enableMouse 切换终端到逐字节输入并开启 SGR 鼠标报告
+func (tc *TerminalClient) enableMouse() error {
+	state, err := enableCbreak(int(os.Stdin.Fd()))
+	if err != nil {
+		return err
+	}
+	tc.ttyState = state
+	fmt.Print(MouseEnableSeq)
+	return nil
+}
+
+// disableMouse 关闭鼠标报告并恢复终端属性
+func (tc *TerminalClient) disableMouse() {
+	if tc.ttyState == nil {
+		return
+	}
+	fmt.Print(MouseDisableSeq)
+	if err := restoreTTY(int(os.Stdin.Fd()), tc.ttyState); err != nil {
+		logger.Error("Failed to restore terminal", zap.Error(err))
+	}
+	tc.ttyState = nil
+}
+
+// scanLines 以普通按行模式读取标准输入
+func (tc *TerminalClient) scanLines() {
+	defer close(tc.lines)
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		tc.lines <- scanner.Text()
+	}
+}
+
+// readLine 读取一行输入，输入结束时返回 false
+func (tc *TerminalClient) readLine() (string, bool) {
+	line, ok := <-tc.lines
+	return line, ok
+}
+
 // showWelcome 显示欢迎信息
 func (tc *TerminalClient) showWelcome() {
 	fmt.Println("┌─────────────────────────────────────────────────────────────┐")
@@ -205,29 +286,39 @@ func (tc *TerminalClient) showWelcome() {
 	fmt.Println("\n开始输入命令或使用快捷键...")
 }
 
-// handleInput 处理用户输入
+// handleInput 处理用户输入和鼠标事件
 func (tc *TerminalClient) handleInput() {
-	scanner := bufio.NewScanner(os.Stdin)
+	for tc.running {
+		select {
+		case line, ok := <-tc.lines:
+			if !ok {
+				tc.running = false
+				return
+			}
 
-	for tc.running && scanner.Scan() {
-		input := strings.TrimSpace(scanner.Text())
+			input := strings.TrimSpace(line)
+			if input == "" {
+				continue
+			}
 
-		if input == "" {
-			continue
-		}
+			// 检查是否是快捷键
+			if tc.handleShortcut(input) {
+				continue
+			}
 
-		// 检查是否是快捷键
-		if tc.handleShortcut(input) {
-			continue
-		}
+			// 检查是否是内置命令
+			if tc.handleBuiltinCommand(input) {
+				continue
+			}
 
-		// 检查是否是内置命令
-		if tc.handleBuiltinCommand(input) {
-			continue
+			// 发送按键到活动面板
+			tc.sendKeys(input)
+		case ev := <-tc.mouse:
+			tc.handleMouse(ev)
+		case <-tc.resize:
+			tc.reportSize()
+			tc.refreshSession()
 		}
-
-		// 发送按键到活动面板
-		tc.sendKeys(input)
 	}
 }
 
@@ -313,11 +404,8 @@ func (tc *TerminalClient) handleDetach() {
 // handleCreateWindow 处理创建窗口
 func (tc *TerminalClient) handleCreateWindow() {
 	fmt.Print("输入新窗口名称 (回车使用默认): ")
-	scanner := bufio.NewScanner(os.Stdin)
-	var name string
-	if scanner.Scan() {
-		name = strings.TrimSpace(scanner.Text())
-	}
+	line, _ := tc.readLine()
+	name := strings.TrimSpace(line)
 
 	response, err := tc.sendCommand(Command{
 		Type: CmdCreateWindow,
@@ -336,6 +424,7 @@ func (tc *TerminalClient) handleCreateWindow() {
 	}
 
 	fmt.Println("新窗口创建成功")
+	tc.refreshSession()
 }
 
 // handleSplitPane 处理分割面板
@@ -358,18 +447,19 @@ func (tc *TerminalClient) handleSplitPane(direction string) {
 	}
 
 	fmt.Printf("面板已分割 (%s)\n", direction)
+	tc.refreshSession()
 }
 
 // handleSwitchPane 处理切换面板
 func (tc *TerminalClient) handleSwitchPane() {
 	fmt.Print("输入面板索引: ")
-	scanner := bufio.NewScanner(os.Stdin)
-	if !scanner.Scan() {
+	line, ok := tc.readLine()
+	if !ok {
 		return
 	}
 
 	var paneIndex int
-	if _, err := fmt.Sscanf(scanner.Text(), "%d", &paneIndex); err != nil {
+	if _, err := fmt.Sscanf(line, "%d", &paneIndex); err != nil {
 		fmt.Printf("无效的面板索引: %v\n", err)
 		return
 	}
@@ -392,18 +482,19 @@ func (tc *TerminalClient) handleSwitchPane() {
 	}
 
 	fmt.Printf("已切换到面板 %d\n", paneIndex)
+	tc.refreshSession()
 }
 
 // handleClosePane 处理关闭面板
 func (tc *TerminalClient) handleClosePane() {
 	fmt.Print("输入要关闭的面板索引: ")
-	scanner := bufio.NewScanner(os.Stdin)
-	if !scanner.Scan() {
+	line, ok := tc.readLine()
+	if !ok {
 		return
 	}
 
 	var paneIndex int
-	if _, err := fmt.Sscanf(scanner.Text(), "%d", &paneIndex); err != nil {
+	if _, err := fmt.Sscanf(line, "%d", &paneIndex); err != nil {
 		fmt.Printf("无效的面板索引: %v\n", err)
 		return
 	}
@@ -426,6 +517,7 @@ func (tc *TerminalClient) handleClosePane() {
 	}
 
 	fmt.Printf("面板 %d 已关闭\n", paneIndex)
+	tc.refreshSession()
 }
 
 // handleShowHelp 显示帮助信息
@@ -443,6 +535,13 @@ func (tc *TerminalClient) handleShowHelp() {
 	fmt.Println("  clear        - 清屏")
 	fmt.Println("  exit/quit    - 退出客户端")
 
+	if tc.config.MouseEnabled {
+		fmt.Println("\n鼠标操作:")
+		fmt.Println("  单击面板/状态栏窗口标签 - 切换焦点")
+		fmt.Println("  拖动面板边框            - 调整面板大小")
+		fmt.Println("  滚轮                    - 进入复制模式并滚动回滚缓冲区")
+	}
+
 	fmt.Println("\nClixGo 集成命令:")
 	fmt.Println("  network ping <host>    - 网络诊断")
 	fmt.Println("  text count <file>      - 文本统计")
@@ -484,7 +583,7 @@ func (tc *TerminalClient) sendKeys(keys string) {
 	response, err := tc.sendCommand(Command{
 		Type: CmdSendKeys,
 		Payload: map[string]interface{}{
-			"keys": keys,
+			"keys": keys + "\n",
 		},
 	})
 	if err != nil {
@@ -522,3 +621,292 @@ func (tc *TerminalClient) sendCommand(cmd Command) (map[string]interface{}, erro
 
 	return response, nil
 }
+
+// refreshSession 获取最新的会话快照并重绘状态栏
+func (tc *TerminalClient) refreshSession() {
+	response, err := tc.sendCommand(Command{
+		Type:    CmdGetSession,
+		Payload: nil,
+	})
+	if err != nil {
+		logger.Error("Failed to refresh session", zap.Error(err))
+		return
+	}
+
+	if errMsg, ok := response["error"].(string); ok {
+		logger.Error("Failed to refresh session", zap.String("error", errMsg))
+		return
+	}
+
+	data, err := json.Marshal(response["session"])
+	if err != nil {
+		return
+	}
+
+	var session Session
+	if err := json.Unmarshal(data, &session); err != nil {
+		logger.Error("Invalid session data", zap.Error(err))
+		return
+	}
+
+	tc.session = &session
+	tc.renderStatusBar()
+}
+
+// reportSize 向服务器报告当前终端的大小，服务器据此计算面板布局和伪终端大小
+func (tc *TerminalClient) reportSize() {
+	cols, rows, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil {
+		return
+	}
+
+	response, err := tc.sendCommand(Command{
+		Type: CmdResizeClient,
+		Payload: map[string]interface{}{
+			"cols": cols,
+			"rows": rows,
+		},
+	})
+	if err != nil {
+		logger.Error("Failed to report terminal size", zap.Error(err))
+		return
+	}
+	if errMsg, ok := response["error"].(string); ok {
+		logger.Error("Failed to report terminal size", zap.String("error", errMsg))
+	}
+}
+
+// screenSize 返回面板区域的大小，状态栏位于其下方一行
+func (tc *TerminalClient) screenSize() (int, int) {
+	if tc.session != nil && tc.session.Width > 0 && tc.session.Height > 0 {
+		return tc.session.Width, tc.session.Height
+	}
+	return DefaultTermWidth, DefaultTermHeight
+}
+
+// statusPrefix 状态栏中窗口标签之前的会话标识
+func (tc *TerminalClient) statusPrefix() string {
+	return fmt.Sprintf("[%s] ", tc.session.Name)
+}
+
+// renderStatusBar 在面板区域下方绘制状态栏，并记录窗口标签位置供鼠标点击使用
+func (tc *TerminalClient) renderStatusBar() {
+	if tc.session == nil {
+		return
+	}
+
+	prefix := tc.statusPrefix()
+	tc.tabs = StatusBarTabs(tc.session, prefix)
+	if !tc.config.StatusBar {
+		return
+	}
+
+	labels := make([]string, 0, len(tc.tabs))
+	for _, tab := range tc.tabs {
+		labels = append(labels, tab.Label)
+	}
+
+	// 保存光标，移动到状态栏行绘制反色文本后恢复
+	width, height := tc.screenSize()
+	fmt.Printf("\x1b7\x1b[%d;1H\x1b[7m%-*s\x1b[0m\x1b8",
+		height+1, width, prefix+strings.Join(labels, " "))
+}
+
+// activeWindow 返回快照中的活动窗口
+func (tc *TerminalClient) activeWindow() *Window {
+	if tc.session == nil || len(tc.session.Windows) == 0 {
+		return nil
+	}
+	index := tc.session.ActiveWindow
+	if index < 0 || index >= len(tc.session.Windows) {
+		index = 0
+	}
+	return tc.session.Windows[index]
+}
+
+// handleMouse 处理鼠标事件：点击切换焦点、拖动边框调整大小、滚轮滚动回滚缓冲区，
+// 面板程序自行请求了鼠标跟踪时将事件转发给它
+func (tc *TerminalClient) handleMouse(ev MouseEvent) {
+	if tc.session == nil {
+		tc.refreshSession()
+	}
+	window := tc.activeWindow()
+	if window == nil {
+		return
+	}
+
+	// 状态栏：点击窗口标签切换窗口
+	if _, height := tc.screenSize(); ev.Y == height {
+		if ev.Action == MousePress && ev.Button == MouseButtonLeft {
+			for _, tab := range tc.tabs {
+				if ev.X >= tab.Start && ev.X < tab.End {
+					tc.mouseCommand(CmdSwitchWindow, map[string]interface{}{
+						"window_index": tab.Index,
+					})
+					break
+				}
+			}
+		}
+		return
+	}
+
+	switch ev.Action {
+	case MousePress:
+		if ev.Button == MouseButtonLeft {
+			if index, vertical, ok := BorderAt(window.Panes, ev.X, ev.Y); ok {
+				last := ev.Y
+				if vertical {
+					last = ev.X
+				}
+				tc.drag = &dragState{paneIndex: index, vertical: vertical, last: last}
+				return
+			}
+		}
+
+		index := PaneAt(window.Panes, ev.X, ev.Y)
+		if index < 0 {
+			return
+		}
+		if index != window.ActivePane {
+			tc.mouseCommand(CmdSwitchPane, map[string]interface{}{
+				"window_index": window.Index,
+				"pane_index":   index,
+			})
+			return
+		}
+		tc.forwardMouse(window, index, ev)
+
+	case MouseDrag:
+		if tc.drag != nil {
+			pos := ev.Y
+			if tc.drag.vertical {
+				pos = ev.X
+			}
+			if delta := pos - tc.drag.last; delta != 0 {
+				tc.drag.last = pos
+				tc.mouseCommand(CmdResizePane, map[string]interface{}{
+					"window_index": window.Index,
+					"pane_index":   tc.drag.paneIndex,
+					"vertical":     tc.drag.vertical,
+					"delta":        delta,
+				})
+			}
+			return
+		}
+		tc.forwardMouse(window, window.ActivePane, ev)
+
+	case MouseRelease:
+		if tc.drag != nil {
+			tc.drag = nil
+			return
+		}
+		tc.forwardMouse(window, window.ActivePane, ev)
+
+	case MouseWheelUp, MouseWheelDown:
+		index := PaneAt(window.Panes, ev.X, ev.Y)
+		if index < 0 {
+			return
+		}
+
+		pane := window.Panes[index]
+		if !pane.CopyMode && tc.forwardMouse(window, index, ev) {
+			return
+		}
+
+		lines := 3
+		if ev.Action == MouseWheelDown {
+			lines = -3
+		}
+		tc.mouseCommand(CmdCopyMode, map[string]interface{}{
+			"window_index": window.Index,
+			"pane_index":   index,
+			"action":       "scroll",
+			"lines":        lines,
+		})
+	}
+}
+
+// forwardMouse 面板程序请求了鼠标跟踪时转发事件，返回是否已转发
+func (tc *TerminalClient) forwardMouse(window *Window, paneIndex int, ev MouseEvent) bool {
+	if paneIndex < 0 || paneIndex >= len(window.Panes) {
+		return false
+	}
+
+	pane := window.Panes[paneIndex]
+	if !pane.WantsMouse(ev.Action) {
+		return false
+	}
+
+	response, err := tc.sendCommand(Command{
+		Type: CmdSendKeys,
+		Payload: map[string]interface{}{
+			"window_index": window.Index,
+			"pane_index":   paneIndex,
+			"keys":         pane.EncodeMouseForPane(ev),
+		},
+	})
+	if err != nil {
+		logger.Error("Failed to forward mouse event", zap.Error(err))
+		return false
+	}
+	if errMsg, ok := response["error"].(string); ok {
+		logger.Error("Failed to forward mouse event", zap.String("error", errMsg))
+		return false
+	}
+	return true
+}
+
+// mouseCommand 发送由鼠标触发的命令并刷新会话快照
+func (tc *TerminalClient) mouseCommand(cmdType string, payload map[string]interface{}) {
+	response, err := tc.sendCommand(Command{
+		Type:    cmdType,
+		Payload: payload,
+	})
+	if err != nil {
+		logger.Error("Mouse command failed", zap.String("type", cmdType), zap.Error(err))
+		return
+	}
+	if errMsg, ok := response["error"].(string); ok {
+		logger.Error("Mouse command failed", zap.String("type", cmdType), zap.String("error", errMsg))
+		return
+	}
+	tc.refreshSession()
+}
+
+// SelectPane 切换到指定ID的面板及其所在窗口
+func (tc *TerminalClient) SelectPane(paneID string) error {
+	response, err := tc.sendCommand(Command{
+		Type: CmdSelectPane,
+		Payload: map[string]interface{}{
+			"pane_id": paneID,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	if errMsg, ok := response["error"].(string); ok {
+		return fmt.Errorf(errMsg)
+	}
+
+	return nil
+}
+
+// CancelTask 请求服务器取消在面板中运行的任务并终止其进程
+func (tc *TerminalClient) CancelTask(taskID string) error {
+	response, err := tc.sendCommand(Command{
+		Type: CmdCancelTask,
+		Payload: map[string]interface{}{
+			"task_id": taskID,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	if errMsg, ok := response["error"].(string); ok {
+		return fmt.Errorf(errMsg)
+	}
+
+	return nil
+}
@@ -0,0 +1,170 @@
+package terminal
+
+import (
+	"errors"
+	"io"
+	"time"
+	"unicode/utf8"
+)
+
+// escapeTime 收到 ESC 后等待序列后续字节的时长，超时视为单独的 ESC 按键，同 tmux 的 escape-time
+const escapeTime = 50 * time.Millisecond
+
+// errEscapeTimeout 在 escapeTime 内没有读到后续字节
+var errEscapeTimeout = errors.New("escape sequence timeout")
+
+// inputReader 读取逐字节模式下的终端输入：分离出鼠标序列，
+// 其余按键做简单的行编辑（回显、退格、回车），按行输出
+type inputReader struct {
+	r      io.Reader
+	chunks chan []byte
+	done   chan struct{}
+	buf    []byte
+	echo   io.Writer
+	lines  chan<- string
+	mouse  chan<- MouseEvent
+}
+
+// newInputReader 创建输入读取器
+func newInputReader(r io.Reader, echo io.Writer, lines chan<- string, mouse chan<- MouseEvent) *inputReader {
+	return &inputReader{
+		r:      r,
+		chunks: make(chan []byte),
+		done:   make(chan struct{}),
+		echo:   echo,
+		lines:  lines,
+		mouse:  mouse,
+	}
+}
+
+// run 持续读取输入直到出错或收到 EOF（Ctrl+D 且当前行为空）
+func (ir *inputReader) run() {
+	defer close(ir.lines)
+	defer close(ir.done)
+	go ir.readChunks()
+
+	var line []byte
+	for {
+		b, err := ir.readByte(0)
+		if err != nil {
+			return
+		}
+
+		switch {
+		case b == 0x1b:
+			seq := ir.readEscape()
+			if ev, ok := ParseSGRMouse(seq); ok {
+				select {
+				case ir.mouse <- ev:
+				default:
+					// 事件处理不过来时丢弃，避免阻塞输入
+				}
+			}
+			// 其他转义序列（方向键等）暂不支持，直接忽略
+		case b == '\r' || b == '\n':
+			ir.echo.Write([]byte("\n"))
+			ir.lines <- string(line)
+			line = line[:0]
+		case b == 0x7f || b == 0x08:
+			if len(line) > 0 {
+				_, size := utf8.DecodeLastRune(line)
+				line = line[:len(line)-size]
+				ir.echo.Write([]byte("\b \b"))
+			}
+		case b == 0x04:
+			if len(line) == 0 {
+				return
+			}
+		case b < 0x20:
+			// 忽略其他控制字符
+		default:
+			line = append(line, b)
+			ir.echo.Write([]byte{b})
+		}
+	}
+}
+
+// readChunks 在后台读取输入，使 readByte 可以带超时等待
+func (ir *inputReader) readChunks() {
+	defer close(ir.chunks)
+	for {
+		chunk := make([]byte, 256)
+		n, err := ir.r.Read(chunk)
+		if n > 0 {
+			select {
+			case ir.chunks <- chunk[:n]:
+			case <-ir.done:
+				return
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// readByte 读取下一个字节，timeout 大于0时最多等待该时长
+func (ir *inputReader) readByte(timeout time.Duration) (byte, error) {
+	if len(ir.buf) == 0 {
+		var expired <-chan time.Time
+		if timeout > 0 {
+			timer := time.NewTimer(timeout)
+			defer timer.Stop()
+			expired = timer.C
+		}
+		select {
+		case chunk, ok := <-ir.chunks:
+			if !ok {
+				return 0, io.EOF
+			}
+			ir.buf = chunk
+		case <-expired:
+			return 0, errEscapeTimeout
+		}
+	}
+	b := ir.buf[0]
+	ir.buf = ir.buf[1:]
+	return b, nil
+}
+
+// readEscape 读取 ESC 之后的完整控制序列，返回包含 ESC 的字节。
+// 序列中的每个字节最多等待 escapeTime，单独按下的 ESC 不会阻塞到下一次按键
+func (ir *inputReader) readEscape() []byte {
+	seq := []byte{0x1b}
+
+	b, err := ir.readByte(escapeTime)
+	if err != nil {
+		return seq
+	}
+	seq = append(seq, b)
+
+	switch b {
+	case '[':
+		for {
+			c, err := ir.readByte(escapeTime)
+			if err != nil {
+				return seq
+			}
+			seq = append(seq, c)
+			if c >= 0x40 && c <= 0x7e {
+				break
+			}
+		}
+		// 传统 X10 鼠标序列 ESC [ M 后跟三个字节
+		if len(seq) == 3 && seq[2] == 'M' {
+			for i := 0; i < 3; i++ {
+				c, err := ir.readByte(escapeTime)
+				if err != nil {
+					return seq
+				}
+				seq = append(seq, c)
+			}
+		}
+	case 'O':
+		if c, err := ir.readByte(escapeTime); err == nil {
+			seq = append(seq, c)
+		}
+	}
+
+	return seq
+}
@@ -0,0 +1,314 @@
+package terminal
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// 鼠标报告控制序列
+const (
+	// MouseEnableSeq 开启按键事件跟踪（含拖动）并使用 SGR 扩展编码
+	MouseEnableSeq = "\x1b[?1000h\x1b[?1002h\x1b[?1006h"
+	// MouseDisableSeq 关闭鼠标报告
+	MouseDisableSeq = "\x1b[?1006l\x1b[?1002l\x1b[?1000l"
+)
+
+// MouseMode 面板程序请求的鼠标跟踪模式
+type MouseMode int
+
+const (
+	MouseModeNone   MouseMode = 0
+	MouseModeNormal MouseMode = 1000 // 仅按下/释放
+	MouseModeButton MouseMode = 1002 // 按下时的拖动
+	MouseModeAny    MouseMode = 1003 // 所有移动
+)
+
+// MouseAction 鼠标动作类型
+type MouseAction int
+
+const (
+	MousePress MouseAction = iota
+	MouseRelease
+	MouseDrag
+	MouseWheelUp
+	MouseWheelDown
+)
+
+// 鼠标按键
+const (
+	MouseButtonLeft   = 0
+	MouseButtonMiddle = 1
+	MouseButtonRight  = 2
+)
+
+// MouseEvent 鼠标事件，坐标从0开始
+type MouseEvent struct {
+	Button int
+	Action MouseAction
+	X      int
+	Y      int
+	Shift  bool
+	Alt    bool
+	Ctrl   bool
+}
+
+// ParseSGRMouse 解析完整的 SGR 鼠标序列 ESC [ < Cb ; Cx ; Cy (M|m)
+func ParseSGRMouse(seq []byte) (MouseEvent, bool) {
+	var ev MouseEvent
+	s := string(seq)
+	if !strings.HasPrefix(s, "\x1b[<") || len(s) < 9 {
+		return ev, false
+	}
+
+	final := s[len(s)-1]
+	if final != 'M' && final != 'm' {
+		return ev, false
+	}
+
+	params := strings.Split(s[3:len(s)-1], ";")
+	if len(params) != 3 {
+		return ev, false
+	}
+
+	values := make([]int, 3)
+	for i, p := range params {
+		v, err := strconv.Atoi(p)
+		if err != nil || v < 0 {
+			return ev, false
+		}
+		values[i] = v
+	}
+
+	cb := values[0]
+	ev.X = values[1] - 1
+	ev.Y = values[2] - 1
+	ev.Shift = cb&4 != 0
+	ev.Alt = cb&8 != 0
+	ev.Ctrl = cb&16 != 0
+	ev.Button = cb & 3
+
+	switch {
+	case cb&64 != 0:
+		if cb&1 == 0 {
+			ev.Action = MouseWheelUp
+		} else {
+			ev.Action = MouseWheelDown
+		}
+	case cb&32 != 0:
+		ev.Action = MouseDrag
+	case final == 'm':
+		ev.Action = MouseRelease
+	default:
+		ev.Action = MousePress
+	}
+
+	return ev, true
+}
+
+// buttonCode 计算事件对应的 Cb 编码
+func (ev MouseEvent) buttonCode() int {
+	cb := ev.Button & 3
+	switch ev.Action {
+	case MouseWheelUp:
+		cb = 64
+	case MouseWheelDown:
+		cb = 65
+	case MouseDrag:
+		cb |= 32
+	}
+	if ev.Shift {
+		cb |= 4
+	}
+	if ev.Alt {
+		cb |= 8
+	}
+	if ev.Ctrl {
+		cb |= 16
+	}
+	return cb
+}
+
+// EncodeSGRMouse 将事件编码为 SGR 序列
+func EncodeSGRMouse(ev MouseEvent) string {
+	final := 'M'
+	if ev.Action == MouseRelease {
+		final = 'm'
+	}
+	return fmt.Sprintf("\x1b[<%d;%d;%d%c", ev.buttonCode(), ev.X+1, ev.Y+1, final)
+}
+
+// EncodeX10Mouse 将事件编码为传统 X10 序列，用于未请求 SGR 编码的程序
+func EncodeX10Mouse(ev MouseEvent) string {
+	cb := ev.buttonCode()
+	if ev.Action == MouseRelease {
+		cb = cb&^3 | 3
+	}
+	// X10 编码单字节坐标，超出范围的位置无法表示
+	x, y := ev.X+33, ev.Y+33
+	if x > 255 {
+		x = 255
+	}
+	if y > 255 {
+		y = 255
+	}
+	return string([]byte{0x1b, '[', 'M', byte(cb + 32), byte(x), byte(y)})
+}
+
+// maxModePending 跨输出保留的不完整序列的最大长度，超过后视为普通输出丢弃
+const maxModePending = 64
+
+// UpdateMouseMode 扫描面板输出中的 DECSET/DECRST 序列，记录程序请求的鼠标模式。
+// 伪终端的一次读取可能在序列中间截断，末尾不完整的序列会保留到下次输出时继续解析
+func (p *Pane) UpdateMouseMode(output []byte) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	data := output
+	if len(p.modePending) > 0 {
+		data = append(p.modePending, output...)
+		p.modePending = nil
+	}
+
+	for i := 0; i < len(data); i++ {
+		if data[i] != 0x1b {
+			continue
+		}
+		if (i+1 < len(data) && data[i+1] != '[') || (i+2 < len(data) && data[i+2] != '?') {
+			continue
+		}
+
+		j := i + 3
+		for j < len(data) && (data[j] == ';' || (data[j] >= '0' && data[j] <= '9')) {
+			j++
+		}
+		if j >= len(data) {
+			if len(data)-i <= maxModePending {
+				p.modePending = append([]byte(nil), data[i:]...)
+			}
+			return
+		}
+		if data[j] != 'h' && data[j] != 'l' {
+			continue
+		}
+
+		p.applyMode(string(data[i+3:j]), data[j] == 'h')
+		i = j
+	}
+}
+
+// applyMode 按 DECSET（set 为 true）或 DECRST 的参数更新鼠标模式，调用方需持有锁
+func (p *Pane) applyMode(params string, set bool) {
+	for _, param := range strings.Split(params, ";") {
+		mode, err := strconv.Atoi(param)
+		if err != nil {
+			continue
+		}
+		switch MouseMode(mode) {
+		case MouseModeNormal, MouseModeButton, MouseModeAny:
+			if set {
+				p.MouseMode = MouseMode(mode)
+			} else if p.MouseMode == MouseMode(mode) {
+				p.MouseMode = MouseModeNone
+			}
+		case 1006:
+			p.MouseSGR = set
+		}
+	}
+}
+
+// WantsMouse 判断面板程序是否需要接收该鼠标事件
+func (p *Pane) WantsMouse(action MouseAction) bool {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	switch p.MouseMode {
+	case MouseModeNone:
+		return false
+	case MouseModeNormal:
+		return action != MouseDrag
+	default:
+		return true
+	}
+}
+
+// EncodeMouseForPane 把屏幕坐标的事件转换为面板内坐标并按面板请求的格式编码
+func (p *Pane) EncodeMouseForPane(ev MouseEvent) string {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	ev.X -= p.X
+	ev.Y -= p.Y
+	if p.MouseSGR {
+		return EncodeSGRMouse(ev)
+	}
+	return EncodeX10Mouse(ev)
+}
+
+// PaneAt 返回包含指定坐标的面板索引，不存在时返回-1
+func PaneAt(panes []*Pane, x, y int) int {
+	for i, p := range panes {
+		if x >= p.X && x < p.X+p.Width && y >= p.Y && y < p.Y+p.Height {
+			return i
+		}
+	}
+	return -1
+}
+
+// BorderAt 判断坐标是否落在两个相邻面板之间的边框上。
+// 面板的最后一列（行）在右侧（下方）有相邻面板时视为可拖动的边框，
+// vertical 为 true 表示左右拖动的竖直边框。
+func BorderAt(panes []*Pane, x, y int) (paneIndex int, vertical bool, ok bool) {
+	for i, p := range panes {
+		if x < p.X || x >= p.X+p.Width || y < p.Y || y >= p.Y+p.Height {
+			continue
+		}
+		if x == p.X+p.Width-1 && hasNeighbor(panes, p, true) {
+			return i, true, true
+		}
+		if y == p.Y+p.Height-1 && hasNeighbor(panes, p, false) {
+			return i, false, true
+		}
+	}
+	return -1, false, false
+}
+
+// hasNeighbor 检查面板右侧（vertical）或下方是否紧邻其他面板
+func hasNeighbor(panes []*Pane, p *Pane, vertical bool) bool {
+	for _, o := range panes {
+		if o == p {
+			continue
+		}
+		if vertical && o.X == p.X+p.Width && o.Y < p.Y+p.Height && p.Y < o.Y+o.Height {
+			return true
+		}
+		if !vertical && o.Y == p.Y+p.Height && o.X < p.X+p.Width && p.X < o.X+o.Width {
+			return true
+		}
+	}
+	return false
+}
+
+// WindowTab 状态栏中一个窗口标签所占的列范围 [Start, End)
+type WindowTab struct {
+	Index int
+	Label string
+	Start int
+	End   int
+}
+
+// StatusBarTabs 计算状态栏中各窗口标签的位置，prefix 为标签前的会话标识
+func StatusBarTabs(session *Session, prefix string) []WindowTab {
+	tabs := make([]WindowTab, 0, len(session.Windows))
+	col := len([]rune(prefix))
+	for i, w := range session.Windows {
+		label := fmt.Sprintf("%d:%s", w.Index, w.Name)
+		if i == session.ActiveWindow {
+			label += "*"
+		}
+		width := len([]rune(label))
+		tabs = append(tabs, WindowTab{Index: w.Index, Label: label, Start: col, End: col + width})
+		col += width + 1
+	}
+	return tabs
+}
@@ -0,0 +1,251 @@
+package terminal
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 测试SGR鼠标序列解析
+func TestParseSGRMouse(t *testing.T) {
+	tests := []struct {
+		name   string
+		seq    string
+		want   MouseEvent
+		wantOK bool
+	}{
+		{
+			name:   "左键按下",
+			seq:    "\x1b[<0;10;5M",
+			want:   MouseEvent{Button: MouseButtonLeft, Action: MousePress, X: 9, Y: 4},
+			wantOK: true,
+		},
+		{
+			name:   "左键释放",
+			seq:    "\x1b[<0;10;5m",
+			want:   MouseEvent{Button: MouseButtonLeft, Action: MouseRelease, X: 9, Y: 4},
+			wantOK: true,
+		},
+		{
+			name:   "拖动",
+			seq:    "\x1b[<32;20;3M",
+			want:   MouseEvent{Button: MouseButtonLeft, Action: MouseDrag, X: 19, Y: 2},
+			wantOK: true,
+		},
+		{
+			name:   "滚轮向上",
+			seq:    "\x1b[<64;1;1M",
+			want:   MouseEvent{Action: MouseWheelUp},
+			wantOK: true,
+		},
+		{
+			name:   "滚轮向下带Ctrl",
+			seq:    "\x1b[<81;1;1M",
+			want:   MouseEvent{Button: 1, Action: MouseWheelDown, Ctrl: true},
+			wantOK: true,
+		},
+		{
+			name: "非鼠标序列",
+			seq:  "\x1b[A",
+		},
+		{
+			name: "参数不完整",
+			seq:  "\x1b[<0;10M",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParseSGRMouse([]byte(tt.seq))
+			assert.Equal(t, tt.wantOK, ok)
+			if tt.wantOK {
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+// 测试编码与解析互逆
+func TestEncodeSGRMouse(t *testing.T) {
+	events := []MouseEvent{
+		{Button: MouseButtonLeft, Action: MousePress, X: 3, Y: 7},
+		{Button: MouseButtonRight, Action: MouseRelease, X: 0, Y: 0, Shift: true},
+		{Button: MouseButtonLeft, Action: MouseDrag, X: 40, Y: 12, Alt: true},
+		{Action: MouseWheelUp, X: 5, Y: 5},
+	}
+
+	for _, ev := range events {
+		got, ok := ParseSGRMouse([]byte(EncodeSGRMouse(ev)))
+		require.True(t, ok)
+		assert.Equal(t, ev, got)
+	}
+
+	assert.Equal(t, "\x1b[M !!", EncodeX10Mouse(MouseEvent{Action: MousePress}))
+	assert.Equal(t, "\x1b[M#!!", EncodeX10Mouse(MouseEvent{Action: MouseRelease}))
+}
+
+// 测试面板鼠标模式跟踪
+func TestPaneUpdateMouseMode(t *testing.T) {
+	pane := &Pane{X: 10, Y: 2}
+
+	assert.False(t, pane.WantsMouse(MousePress))
+
+	pane.UpdateMouseMode([]byte("hello\x1b[?1000h\x1b[?1006hworld"))
+	assert.Equal(t, MouseModeNormal, pane.MouseMode)
+	assert.True(t, pane.MouseSGR)
+	assert.True(t, pane.WantsMouse(MousePress))
+	assert.False(t, pane.WantsMouse(MouseDrag))
+
+	// 坐标转换为面板内坐标
+	encoded := pane.EncodeMouseForPane(MouseEvent{Action: MousePress, X: 12, Y: 5})
+	assert.Equal(t, "\x1b[<0;3;4M", encoded)
+
+	pane.UpdateMouseMode([]byte("\x1b[?1002;1006h"))
+	assert.Equal(t, MouseModeButton, pane.MouseMode)
+	assert.True(t, pane.WantsMouse(MouseDrag))
+
+	pane.UpdateMouseMode([]byte("\x1b[?1002l\x1b[?1006l"))
+	assert.Equal(t, MouseModeNone, pane.MouseMode)
+	assert.False(t, pane.MouseSGR)
+}
+
+// 测试跨两次输出截断的模式序列
+func TestPaneUpdateMouseModeSplit(t *testing.T) {
+	pane := &Pane{}
+
+	for _, split := range []int{1, 2, 3, 5, 7} {
+		seq := []byte("ok\x1b[?1002h")
+		pane.UpdateMouseMode(seq[:split+2])
+		pane.UpdateMouseMode(seq[split+2:])
+		assert.Equal(t, MouseModeButton, pane.MouseMode, "split at %d", split)
+
+		pane.UpdateMouseMode([]byte("\x1b[?10"))
+		pane.UpdateMouseMode([]byte("02l"))
+		assert.Equal(t, MouseModeNone, pane.MouseMode, "split at %d", split)
+	}
+
+	// 不完整的序列之后是普通输出时不影响后续解析
+	pane.UpdateMouseMode([]byte("\x1b["))
+	pane.UpdateMouseMode([]byte("0m\x1b[?1000h"))
+	assert.Equal(t, MouseModeNormal, pane.MouseMode)
+	assert.Empty(t, pane.modePending)
+}
+
+// 测试单独的 ESC 按键不会吞掉之后的输入，鼠标序列仍能完整读取
+func TestInputReaderEscape(t *testing.T) {
+	r, w := io.Pipe()
+	lines := make(chan string, 1)
+	mouse := make(chan MouseEvent, 1)
+	go newInputReader(r, &bytes.Buffer{}, lines, mouse).run()
+
+	_, err := w.Write([]byte{0x1b})
+	require.NoError(t, err)
+	time.Sleep(2 * escapeTime)
+	_, err = w.Write([]byte("ab\r"))
+	require.NoError(t, err)
+	select {
+	case line := <-lines:
+		assert.Equal(t, "ab", line)
+	case <-time.After(time.Second):
+		t.Fatal("line not received")
+	}
+
+	_, err = w.Write([]byte("\x1b[<0;"))
+	require.NoError(t, err)
+	_, err = w.Write([]byte("3;4M"))
+	require.NoError(t, err)
+	select {
+	case ev := <-mouse:
+		assert.Equal(t, MouseEvent{Action: MousePress, X: 2, Y: 3}, ev)
+	case <-time.After(time.Second):
+		t.Fatal("mouse event not received")
+	}
+
+	w.Close()
+	_, ok := <-lines
+	assert.False(t, ok)
+}
+
+// 测试面板与边框定位
+func TestPaneAndBorderAt(t *testing.T) {
+	sm := NewSessionManager(DefaultConfig)
+	panes := []*Pane{{}, {}, {}}
+	sm.layoutMainVertical(panes, DefaultTermWidth, DefaultTermHeight)
+
+	assert.Equal(t, 0, PaneAt(panes, 0, 0))
+	assert.Equal(t, 1, PaneAt(panes, 60, 0))
+	assert.Equal(t, 2, PaneAt(panes, 60, 20))
+	assert.Equal(t, -1, PaneAt(panes, 100, 0))
+
+	index, vertical, ok := BorderAt(panes, panes[0].Width-1, 5)
+	require.True(t, ok)
+	assert.Equal(t, 0, index)
+	assert.True(t, vertical)
+
+	index, vertical, ok = BorderAt(panes, 60, panes[1].Height-1)
+	require.True(t, ok)
+	assert.Equal(t, 1, index)
+	assert.False(t, vertical)
+
+	_, _, ok = BorderAt(panes, 10, 10)
+	assert.False(t, ok)
+}
+
+// 测试拖动边框调整面板大小
+func TestResizePane(t *testing.T) {
+	sm := NewSessionManager(DefaultConfig)
+	session, err := sm.CreateSession("mouse")
+	require.NoError(t, err)
+	_, err = sm.SplitPane(session.ID, 0, "vertical")
+	require.NoError(t, err)
+	_, err = sm.SplitPane(session.ID, 0, "vertical")
+	require.NoError(t, err)
+
+	panes := session.Windows[0].Panes
+	mainWidth := panes[0].Width
+
+	require.NoError(t, sm.ResizePane(session.ID, 0, 0, true, 5))
+	assert.Equal(t, mainWidth+5, panes[0].Width)
+	for _, p := range panes[1:] {
+		assert.Equal(t, mainWidth+5, p.X)
+		assert.Equal(t, DefaultTermWidth-mainWidth-5, p.Width)
+	}
+
+	// 移动距离被限制，面板至少保留一列
+	require.NoError(t, sm.ResizePane(session.ID, 0, 0, true, 1000))
+	assert.Equal(t, 1, panes[1].Width)
+
+	// 最右侧面板没有可调整的相邻面板
+	assert.Error(t, sm.ResizePane(session.ID, 0, 1, true, 1))
+}
+
+// 测试滚轮滚动回滚缓冲区
+func TestScrollPane(t *testing.T) {
+	sm := NewSessionManager(DefaultConfig)
+	session, err := sm.CreateSession("scroll")
+	require.NoError(t, err)
+
+	pane := session.Windows[0].Panes[0]
+	pane.Height = 10
+	for i := 0; i < 15; i++ {
+		pane.Buffer.Lines = append(pane.Buffer.Lines, []rune("line"))
+	}
+
+	p, err := sm.ScrollPane(session.ID, 0, 0, 3)
+	require.NoError(t, err)
+	assert.True(t, p.CopyMode)
+	assert.Equal(t, 3, p.ScrollOffset)
+
+	p, err = sm.ScrollPane(session.ID, 0, 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 5, p.ScrollOffset)
+
+	p, err = sm.ScrollPane(session.ID, 0, 0, -10)
+	require.NoError(t, err)
+	assert.False(t, p.CopyMode)
+	assert.Equal(t, 0, p.ScrollOffset)
+}
@@ -0,0 +1,156 @@
+package terminal
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/Lzww0608/ClixGo/pkg/logger"
+	"github.com/creack/pty"
+	"go.uber.org/zap"
+)
+
+// paneWaitDelay 进程退出后等待读完伪终端输出的最长时间，
+// 避免后台子进程持有伪终端导致面板状态无法更新
+const paneWaitDelay = time.Second
+
+// RunPaneCommand 在面板中启动其命令并阻塞直到进程退出，返回进程退出码。
+// 命令运行在大小与面板一致的伪终端中，全屏程序可以正常检测终端并请求鼠标模式；
+// 伪终端的输出写入面板缓冲区，面板 Input 连接到伪终端的输入
+func (sm *SessionManager) RunPaneCommand(ctx context.Context, pane *Pane) (int, error) {
+	pane.mutex.Lock()
+	cmd := exec.CommandContext(ctx, "/bin/sh", "-c", pane.Command)
+	cmd.Cancel = func() error {
+		return killProcessGroup(cmd.Process)
+	}
+	cmd.Dir = pane.WorkingDir
+	if os.Getenv("TERM") == "" {
+		cmd.Env = append(os.Environ(), "TERM=xterm-256color")
+	}
+
+	cols, rows := pane.Width, pane.Height
+	if cols <= 0 || rows <= 0 {
+		cols, rows = DefaultTermWidth, DefaultTermHeight
+	}
+	ptmx, err := pty.StartWithSize(cmd, &pty.Winsize{Cols: uint16(cols), Rows: uint16(rows)})
+	if err != nil {
+		pane.Exited = true
+		pane.ExitCode = -1
+		pane.mutex.Unlock()
+		return -1, err
+	}
+
+	pane.Process = cmd.Process
+	pane.ProcessID = cmd.Process.Pid
+	pane.Input = ptmx
+	pane.pty = ptmx
+	pane.Exited = false
+	pane.mutex.Unlock()
+
+	// 所有打开伪终端从端的进程退出后读取返回 EIO，视为输出结束
+	copied := make(chan struct{})
+	go func() {
+		io.Copy(pane, ptmx)
+		close(copied)
+	}()
+
+	err = cmd.Wait()
+	select {
+	case <-copied:
+	case <-time.After(paneWaitDelay):
+	}
+
+	code := -1
+	if cmd.ProcessState != nil {
+		code = cmd.ProcessState.ExitCode()
+	}
+
+	pane.mutex.Lock()
+	pane.Exited = true
+	pane.ExitCode = code
+	pane.Input = nil
+	pane.Process = nil
+	pane.pty = nil
+	pane.mutex.Unlock()
+	ptmx.Close()
+
+	return code, err
+}
+
+// Kill 终止面板程序及其启动的所有子进程，面板没有运行中的进程时不做任何操作
+func (p *Pane) Kill() error {
+	p.mutex.RLock()
+	process := p.Process
+	p.mutex.RUnlock()
+
+	if process == nil {
+		return nil
+	}
+	err := killProcessGroup(process)
+	if errors.Is(err, os.ErrProcessDone) {
+		return nil
+	}
+	return err
+}
+
+// resizePTY 将伪终端大小同步为面板大小，面板程序会收到 SIGWINCH
+func (p *Pane) resizePTY() {
+	p.mutex.RLock()
+	f, cols, rows := p.pty, p.Width, p.Height
+	p.mutex.RUnlock()
+
+	if f == nil || cols <= 0 || rows <= 0 {
+		return
+	}
+	if err := pty.Setsize(f, &pty.Winsize{Cols: uint16(cols), Rows: uint16(rows)}); err != nil {
+		logger.Warn("Failed to resize pane pty", zap.String("pane_id", p.ID), zap.Error(err))
+	}
+}
+
+// Write 实现 io.Writer，将进程输出写入面板缓冲区并跟踪鼠标模式请求
+func (p *Pane) Write(data []byte) (int, error) {
+	p.UpdateMouseMode(data)
+
+	p.mutex.Lock()
+	p.LastOutput = time.Now()
+	buffer := p.Buffer
+	p.mutex.Unlock()
+
+	if buffer != nil {
+		buffer.Write(data)
+	}
+	return len(data), nil
+}
+
+// Write 实现 io.Writer，按行追加输出并保留最多 MaxLines 行
+func (b *Buffer) Write(data []byte) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if len(b.Lines) == 0 {
+		b.Lines = append(b.Lines, []rune{})
+	}
+
+	for _, r := range string(data) {
+		switch r {
+		case '\n':
+			b.Lines = append(b.Lines, []rune{})
+		case '\r':
+			// 忽略回车，行首覆盖写入暂不支持
+		default:
+			last := len(b.Lines) - 1
+			b.Lines[last] = append(b.Lines[last], r)
+		}
+	}
+
+	if b.MaxLines > 0 && len(b.Lines) > b.MaxLines {
+		b.Lines = b.Lines[len(b.Lines)-b.MaxLines:]
+	}
+
+	b.CursorY = len(b.Lines) - 1
+	b.CursorX = len(b.Lines[b.CursorY])
+	return len(data), nil
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd
+
+package terminal
+
+import "os"
+
+// killProcessGroup 当前平台没有进程组，只终止进程本身
+func killProcessGroup(process *os.Process) error {
+	return process.Kill()
+}
@@ -0,0 +1,262 @@
+package terminal
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Lzww0608/ClixGo/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 测试在面板中运行命令
+func TestRunPaneCommand(t *testing.T) {
+	sm := NewSessionManager(DefaultConfig)
+	session, err := sm.CreateSession("run")
+	require.NoError(t, err)
+
+	window, err := sm.CreateWindowWithCommand(session.ID, "job", "echo hello; echo world >&2; exit 3")
+	require.NoError(t, err)
+	pane := window.Panes[0]
+
+	code, err := sm.RunPaneCommand(context.Background(), pane)
+	assert.Error(t, err)
+	assert.Equal(t, 3, code)
+	assert.True(t, pane.Exited)
+	assert.Equal(t, 3, pane.ExitCode)
+	assert.Nil(t, pane.Input)
+
+	var lines []string
+	for _, line := range pane.Buffer.Lines {
+		lines = append(lines, string(line))
+	}
+	output := strings.Join(lines, "\n")
+	assert.Contains(t, output, "hello")
+	assert.Contains(t, output, "world")
+}
+
+// 测试按ID查找面板
+func TestPaneByID(t *testing.T) {
+	sm := NewSessionManager(DefaultConfig)
+	session, err := sm.CreateSession("find")
+	require.NoError(t, err)
+	window, err := sm.CreateWindowWithCommand(session.ID, "job", "true")
+	require.NoError(t, err)
+	pane := window.Panes[0]
+
+	assert.Same(t, pane, sm.paneByID(pane.ID))
+	assert.Nil(t, sm.paneByID("missing"))
+}
+
+// 测试按索引定位面板，索引越界时返回错误
+func TestResolvePane(t *testing.T) {
+	ts := NewTerminalServerWithSocket(nil, filepath.Join(t.TempDir(), "server.sock"))
+	defer ts.cancel()
+	session, err := ts.sessionManager.CreateSession("resolve")
+	require.NoError(t, err)
+	client := &ClientConnection{SessionID: session.ID}
+
+	pane, err := ts.resolvePane(client, map[string]interface{}{})
+	require.NoError(t, err)
+	assert.Same(t, session.Windows[0].Panes[0], pane)
+
+	_, err = ts.resolvePane(client, map[string]interface{}{"pane_index": float64(5)})
+	assert.Error(t, err)
+	_, err = ts.resolvePane(client, map[string]interface{}{"window_index": float64(-1)})
+	assert.Error(t, err)
+}
+
+// 测试没有会话时为任务创建的 tasks 会话的默认面板会被启动
+func TestTaskSessionStartsPane(t *testing.T) {
+	// 面板进程退出时记录日志
+	logger.SetLogPath(filepath.Join(t.TempDir(), "test.log"))
+	require.NoError(t, logger.InitLogger())
+
+	ts := NewTerminalServerWithSocket(nil, filepath.Join(t.TempDir(), "server.sock"))
+	defer ts.cancel()
+
+	session, err := ts.taskSession(&ClientConnection{}, "")
+	require.NoError(t, err)
+	assert.Equal(t, "tasks", session.Name)
+	defer ts.sessionManager.KillSession(session.ID)
+
+	pane := session.Windows[0].Panes[0]
+	require.Eventually(t, func() bool {
+		pane.mutex.RLock()
+		defer pane.mutex.RUnlock()
+		return pane.Process != nil
+	}, 5*time.Second, 10*time.Millisecond, "默认面板应该在运行")
+
+	// 再次确定会话时使用已有的会话
+	again, err := ts.taskSession(&ClientConnection{}, "")
+	require.NoError(t, err)
+	assert.Equal(t, session.ID, again.ID)
+}
+
+// 测试面板程序运行在伪终端中：能检测到终端、大小与面板一致，鼠标模式请求被记录，输入可以送达
+func TestRunPaneCommandPTY(t *testing.T) {
+	sm := NewSessionManager(DefaultConfig)
+	session, err := sm.CreateSession("pty")
+	require.NoError(t, err)
+	require.NoError(t, sm.SetClientSize(session.ID, 100, 31))
+
+	window, err := sm.CreateWindowWithCommand(session.ID, "tui",
+		`test -t 0 && test -t 1 && printf '\033[?1002h\033[?1006h'; stty size; read code; exit $code`)
+	require.NoError(t, err)
+	pane := window.Panes[0]
+	assert.Equal(t, 100, pane.Width)
+	assert.Equal(t, 30, pane.Height)
+
+	done := make(chan int)
+	go func() {
+		code, _ := sm.RunPaneCommand(context.Background(), pane)
+		done <- code
+	}()
+
+	require.Eventually(t, func() bool {
+		pane.mutex.RLock()
+		defer pane.mutex.RUnlock()
+		return pane.MouseMode == MouseModeButton
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.True(t, pane.MouseSGR)
+
+	pane.mutex.RLock()
+	input := pane.Input
+	pane.mutex.RUnlock()
+	_, err = io.WriteString(input, "7\n")
+	require.NoError(t, err)
+
+	select {
+	case code := <-done:
+		assert.Equal(t, 7, code)
+	case <-time.After(5 * time.Second):
+		t.Fatal("面板程序没有退出")
+	}
+
+	var lines []string
+	for _, line := range pane.Buffer.Lines {
+		lines = append(lines, string(line))
+	}
+	assert.Contains(t, strings.Join(lines, "\n"), "30 100")
+}
+
+// 测试终止面板时连同 sh 启动的子进程一起终止，并且 KillSession 不会死锁
+func TestPaneKillProcessGroup(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("需要 /proc 检查进程状态")
+	}
+
+	sm := NewSessionManager(DefaultConfig)
+	session, err := sm.CreateSession("kill")
+	require.NoError(t, err)
+	window, err := sm.CreateWindowWithCommand(session.ID, "job", "trap '' HUP; sleep 100 & echo child=$!; sleep 100; wait")
+	require.NoError(t, err)
+	pane := window.Panes[0]
+
+	done := make(chan struct{})
+	go func() {
+		sm.RunPaneCommand(context.Background(), pane)
+		close(done)
+	}()
+
+	childPattern := regexp.MustCompile(`child=(\d+)`)
+	var child string
+	require.Eventually(t, func() bool {
+		pane.Buffer.mutex.RLock()
+		defer pane.Buffer.mutex.RUnlock()
+		for _, line := range pane.Buffer.Lines {
+			if m := childPattern.FindStringSubmatch(string(line)); m != nil {
+				child = m[1]
+				return true
+			}
+		}
+		return false
+	}, 5*time.Second, 10*time.Millisecond)
+
+	killed := make(chan error)
+	go func() { killed <- sm.KillSession(session.ID) }()
+	select {
+	case err := <-killed:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("KillSession 没有返回")
+	}
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("面板程序没有退出")
+	}
+
+	// 子进程已退出：/proc 中不存在或只剩等待回收的僵尸进程
+	assert.Eventually(t, func() bool {
+		stat, err := os.ReadFile(filepath.Join("/proc", child, "stat"))
+		if err != nil {
+			return true
+		}
+		fields := strings.Fields(string(stat[bytes.LastIndexByte(stat, ')')+1:]))
+		return len(fields) > 0 && fields[0] == "Z"
+	}, 5*time.Second, 10*time.Millisecond, "后台子进程 %s 仍在运行", child)
+}
+
+// 测试客户端报告的终端大小用于计算布局
+func TestSetClientSize(t *testing.T) {
+	sm := NewSessionManager(DefaultConfig)
+	session, err := sm.CreateSession("size")
+	require.NoError(t, err)
+	pane := session.Windows[0].Panes[0]
+	assert.Equal(t, DefaultTermWidth, pane.Width)
+	assert.Equal(t, DefaultTermHeight, pane.Height)
+
+	require.NoError(t, sm.SetClientSize(session.ID, 120, 40))
+	assert.Equal(t, 120, pane.Width)
+	assert.Equal(t, 39, pane.Height)
+
+	// 新窗口使用会话的大小
+	window, err := sm.CreateWindow(session.ID, "second")
+	require.NoError(t, err)
+	assert.Equal(t, 120, window.Panes[0].Width)
+
+	assert.Error(t, sm.SetClientSize(session.ID, 0, 0))
+}
+
+// 测试缓冲区按行写入和行数上限
+func TestBufferWrite(t *testing.T) {
+	buffer := &Buffer{MaxLines: 3}
+
+	buffer.Write([]byte("a\r\nb\nc"))
+	buffer.Write([]byte("d\ne\n"))
+
+	require.Len(t, buffer.Lines, 3)
+	assert.Equal(t, "cd", string(buffer.Lines[0]))
+	assert.Equal(t, "e", string(buffer.Lines[1]))
+	assert.Equal(t, "", string(buffer.Lines[2]))
+	assert.Equal(t, 2, buffer.CursorY)
+}
+
+// 测试按面板ID查找和切换
+func TestSelectPane(t *testing.T) {
+	sm := NewSessionManager(DefaultConfig)
+	session, err := sm.CreateSession("select")
+	require.NoError(t, err)
+
+	window, err := sm.CreateWindow(session.ID, "second")
+	require.NoError(t, err)
+	pane, err := sm.SplitPane(session.ID, window.Index, "vertical")
+	require.NoError(t, err)
+	require.NoError(t, sm.SwitchWindow(session.ID, 0))
+
+	require.NoError(t, sm.SelectPane(session.ID, pane.ID))
+	assert.Equal(t, window.Index, session.ActiveWindow)
+	assert.Equal(t, pane.Index, window.ActivePane)
+
+	assert.Error(t, sm.SelectPane(session.ID, "missing"))
+}
@@ -0,0 +1,20 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+
+package terminal
+
+import (
+	"errors"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// killProcessGroup 终止进程所在的整个进程组。面板程序在伪终端中以新会话启动，
+// 进程组号与进程号相同，向 -pid 发送信号才能连同 sh 启动的子进程一起终止
+func killProcessGroup(process *os.Process) error {
+	err := unix.Kill(-process.Pid, unix.SIGKILL)
+	if errors.Is(err, unix.ESRCH) {
+		return os.ErrProcessDone
+	}
+	return err
+}
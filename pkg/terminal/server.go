@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net"
 	"os"
 	"path/filepath"
@@ -11,6 +12,7 @@ import (
 	"time"
 
 	"github.com/Lzww0608/ClixGo/pkg/logger"
+	"github.com/Lzww0608/ClixGo/pkg/task"
 	"go.uber.org/zap"
 )
 
@@ -20,6 +22,7 @@ type TerminalServer struct {
 	sessionManager *SessionManager
 	listener       net.Listener
 	clients        map[string]*ClientConnection
+	taskManager    *task.TaskManager
 	socketPath     string
 	running        bool
 	mutex          sync.RWMutex
@@ -35,23 +38,21 @@ type ClientConnection struct {
 	LastActive time.Time
 }
 
-// NewTerminalServer 创建终端服务器
+// NewTerminalServer 创建使用默认socket的终端服务器
 func NewTerminalServer(config *TerminalConfig) *TerminalServer {
+	socketPath, _ := SocketPathForName(DefaultServerName)
+	return NewTerminalServerWithSocket(config, socketPath)
+}
+
+// NewTerminalServerWithSocket 创建监听指定socket路径的终端服务器，
+// 不同路径上的服务器相互独立
+func NewTerminalServerWithSocket(config *TerminalConfig, socketPath string) *TerminalServer {
 	if config == nil {
 		config = DefaultConfig
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 
-	// 创建socket路径
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		homeDir = "/tmp"
-	}
-	socketDir := filepath.Join(homeDir, ".clixgo", "terminal")
-	os.MkdirAll(socketDir, 0755)
-	socketPath := filepath.Join(socketDir, "clixgo-terminal.sock")
-
 	server := &TerminalServer{
 		config:         config,
 		sessionManager: NewSessionManager(config),
@@ -74,15 +75,10 @@ func (ts *TerminalServer) Start() error {
 		return fmt.Errorf("server is already running")
 	}
 
-	// 删除已存在的socket文件
-	if err := os.Remove(ts.socketPath); err != nil && !os.IsNotExist(err) {
-		return fmt.Errorf("failed to remove existing socket: %v", err)
-	}
-
-	// 创建Unix domain socket监听器
-	listener, err := net.Listen("unix", ts.socketPath)
+	// 创建仅当前用户可访问的Unix domain socket监听器
+	listener, err := listenSocket(ts.socketPath)
 	if err != nil {
-		return fmt.Errorf("failed to create listener: %v", err)
+		return err
 	}
 
 	ts.listener = listener
@@ -228,6 +224,20 @@ func (ts *TerminalServer) handleCommand(client *ClientConnection, cmd *Command)
 		return ts.handleRename(client, cmd.Payload)
 	case CmdKillSession:
 		return ts.handleKillSession(client, cmd.Payload)
+	case CmdResizePane:
+		return ts.handleResizePane(client, cmd.Payload)
+	case CmdCopyMode:
+		return ts.handleCopyMode(client, cmd.Payload)
+	case CmdGetSession:
+		return ts.handleGetSession(client, cmd.Payload)
+	case CmdSelectPane:
+		return ts.handleSelectPane(client, cmd.Payload)
+	case CmdRunTask:
+		return ts.handleRunTask(client, cmd.Payload)
+	case CmdCancelTask:
+		return ts.handleCancelTask(client, cmd.Payload)
+	case CmdResizeClient:
+		return ts.handleResizeClient(client, cmd.Payload)
 	default:
 		return map[string]interface{}{
 			"error": fmt.Sprintf("unknown command type: %s", cmd.Type),
@@ -249,6 +259,7 @@ func (ts *TerminalServer) handleCreateSession(client *ClientConnection, payload
 	}
 
 	client.SessionID = session.ID
+	ts.startPane(session.Windows[0].Panes[0])
 	logger.Info("Session created", zap.String("session_id", session.ID), zap.String("name", session.Name))
 
 	return map[string]interface{}{
@@ -341,6 +352,7 @@ func (ts *TerminalServer) handleCreateWindow(client *ClientConnection, payload i
 	if err != nil {
 		return map[string]interface{}{"error": err.Error()}
 	}
+	ts.startPane(window.Panes[0])
 
 	return map[string]interface{}{
 		"success": true,
@@ -399,6 +411,7 @@ func (ts *TerminalServer) handleSplitPane(client *ClientConnection, payload inte
 	if err != nil {
 		return map[string]interface{}{"error": err.Error()}
 	}
+	ts.startPane(pane)
 
 	return map[string]interface{}{
 		"success": true,
@@ -510,14 +523,183 @@ func (ts *TerminalServer) handleSendKeys(client *ClientConnection, payload inter
 		return map[string]interface{}{"error": "keys required"}
 	}
 
-	// 这里应该将按键发送到活动面板
-	// TODO: 实现按键发送逻辑
+	pane, err := ts.resolvePane(client, data)
+	if err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+
+	pane.mutex.RLock()
+	input := pane.Input
+	pane.mutex.RUnlock()
+	if input == nil {
+		return map[string]interface{}{"error": "pane has no running process"}
+	}
+
+	if _, err := io.WriteString(input, keys); err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+
+	return map[string]interface{}{
+		"success": true,
+	}
+}
+
+// resolvePane 根据payload中的window_index/pane_index定位面板，缺省时使用活动窗口和活动面板
+func (ts *TerminalServer) resolvePane(client *ClientConnection, data map[string]interface{}) (*Pane, error) {
+	_, _, pane, err := ts.resolvePaneIndex(client, data)
+	return pane, err
+}
+
+// resolvePaneIndex 解析并校验窗口和面板索引，在同一次加锁中取出对应的面板，
+// 避免校验后其他客户端关闭窗口或面板导致索引越界
+func (ts *TerminalServer) resolvePaneIndex(client *ClientConnection, data map[string]interface{}) (int, int, *Pane, error) {
+	session, err := ts.sessionManager.GetSession(client.SessionID)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+
+	session.mutex.RLock()
+	defer session.mutex.RUnlock()
+
+	windowIndex := session.ActiveWindow
+	if v, ok := data["window_index"].(float64); ok {
+		windowIndex = int(v)
+	}
+	if windowIndex < 0 || windowIndex >= len(session.Windows) {
+		return 0, 0, nil, fmt.Errorf("window index out of range: %d", windowIndex)
+	}
+
+	window := session.Windows[windowIndex]
+	window.mutex.RLock()
+	defer window.mutex.RUnlock()
+
+	paneIndex := window.ActivePane
+	if v, ok := data["pane_index"].(float64); ok {
+		paneIndex = int(v)
+	}
+	if paneIndex < 0 || paneIndex >= len(window.Panes) {
+		return 0, 0, nil, fmt.Errorf("pane index out of range: %d", paneIndex)
+	}
+
+	return windowIndex, paneIndex, window.Panes[paneIndex], nil
+}
+
+// handleResizePane 处理调整面板大小命令
+func (ts *TerminalServer) handleResizePane(client *ClientConnection, payload interface{}) interface{} {
+	if client.SessionID == "" {
+		return map[string]interface{}{"error": "no active session"}
+	}
+
+	data, ok := payload.(map[string]interface{})
+	if !ok {
+		return map[string]interface{}{"error": "invalid payload"}
+	}
+
+	delta, ok := data["delta"].(float64)
+	if !ok {
+		return map[string]interface{}{"error": "delta required"}
+	}
+	vertical, _ := data["vertical"].(bool)
+
+	windowIndex, paneIndex, _, err := ts.resolvePaneIndex(client, data)
+	if err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+
+	if err := ts.sessionManager.ResizePane(client.SessionID, windowIndex, paneIndex, vertical, int(delta)); err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
 
 	return map[string]interface{}{
 		"success": true,
 	}
 }
 
+// handleResizeClient 处理客户端报告的终端大小，按新的大小重新计算布局
+func (ts *TerminalServer) handleResizeClient(client *ClientConnection, payload interface{}) interface{} {
+	if client.SessionID == "" {
+		return map[string]interface{}{"error": "no active session"}
+	}
+
+	data, ok := payload.(map[string]interface{})
+	if !ok {
+		return map[string]interface{}{"error": "invalid payload"}
+	}
+
+	cols, _ := data["cols"].(float64)
+	rows, _ := data["rows"].(float64)
+	if err := ts.sessionManager.SetClientSize(client.SessionID, int(cols), int(rows)); err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+
+	return map[string]interface{}{
+		"success": true,
+	}
+}
+
+// handleCopyMode 处理复制模式命令，action 可为 enter、exit 或 scroll
+func (ts *TerminalServer) handleCopyMode(client *ClientConnection, payload interface{}) interface{} {
+	if client.SessionID == "" {
+		return map[string]interface{}{"error": "no active session"}
+	}
+
+	data, ok := payload.(map[string]interface{})
+	if !ok {
+		return map[string]interface{}{"error": "invalid payload"}
+	}
+
+	windowIndex, paneIndex, _, err := ts.resolvePaneIndex(client, data)
+	if err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+
+	action, _ := data["action"].(string)
+	switch action {
+	case "", "enter":
+		err = ts.sessionManager.SetCopyMode(client.SessionID, windowIndex, paneIndex, true)
+	case "exit":
+		err = ts.sessionManager.SetCopyMode(client.SessionID, windowIndex, paneIndex, false)
+	case "scroll":
+		lines, _ := data["lines"].(float64)
+		var pane *Pane
+		pane, err = ts.sessionManager.ScrollPane(client.SessionID, windowIndex, paneIndex, int(lines))
+		if err == nil {
+			return map[string]interface{}{
+				"success":       true,
+				"copy_mode":     pane.CopyMode,
+				"scroll_offset": pane.ScrollOffset,
+			}
+		}
+	default:
+		return map[string]interface{}{"error": fmt.Sprintf("invalid copy mode action: %s", action)}
+	}
+
+	if err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+
+	return map[string]interface{}{
+		"success": true,
+	}
+}
+
+// handleGetSession 处理获取当前会话快照命令
+func (ts *TerminalServer) handleGetSession(client *ClientConnection, payload interface{}) interface{} {
+	if client.SessionID == "" {
+		return map[string]interface{}{"error": "no active session"}
+	}
+
+	session, err := ts.sessionManager.GetSession(client.SessionID)
+	if err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+
+	return map[string]interface{}{
+		"success": true,
+		"session": session,
+	}
+}
+
 // handleRename 处理重命名命令
 func (ts *TerminalServer) handleRename(client *ClientConnection, payload interface{}) interface{} {
 	if client.SessionID == "" {
@@ -588,6 +770,215 @@ func (ts *TerminalServer) handleKillSession(client *ClientConnection, payload in
 	}
 }
 
+// handleSelectPane 处理按面板ID切换焦点命令，同时切换到面板所在窗口
+func (ts *TerminalServer) handleSelectPane(client *ClientConnection, payload interface{}) interface{} {
+	if client.SessionID == "" {
+		return map[string]interface{}{"error": "no active session"}
+	}
+
+	data, ok := payload.(map[string]interface{})
+	if !ok {
+		return map[string]interface{}{"error": "invalid payload"}
+	}
+
+	paneID, _ := data["pane_id"].(string)
+	if paneID == "" {
+		return map[string]interface{}{"error": "pane_id required"}
+	}
+
+	if err := ts.sessionManager.SelectPane(client.SessionID, paneID); err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+
+	return map[string]interface{}{
+		"success": true,
+	}
+}
+
+// handleRunTask 处理运行任务命令：在新窗口（或分割出的面板）中运行命令，
+// 并在任务管理器中创建与面板进程状态同步的任务
+func (ts *TerminalServer) handleRunTask(client *ClientConnection, payload interface{}) interface{} {
+	if ts.taskManager == nil || !ts.config.TaskIntegration {
+		return map[string]interface{}{"error": "task integration is disabled"}
+	}
+
+	data, ok := payload.(map[string]interface{})
+	if !ok {
+		return map[string]interface{}{"error": "invalid payload"}
+	}
+
+	name, _ := data["name"].(string)
+	command, _ := data["command"].(string)
+	if name == "" || command == "" {
+		return map[string]interface{}{"error": "name and command required"}
+	}
+	target, _ := data["session"].(string)
+	split, _ := data["split"].(bool)
+
+	session, err := ts.taskSession(client, target)
+	if err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+
+	var pane *Pane
+	var window *Window
+	if split {
+		session.mutex.RLock()
+		windowIndex := session.ActiveWindow
+		if windowIndex >= 0 && windowIndex < len(session.Windows) {
+			window = session.Windows[windowIndex]
+		}
+		session.mutex.RUnlock()
+
+		pane, err = ts.sessionManager.SplitPaneWithCommand(session.ID, windowIndex, "vertical", command)
+	} else {
+		window, err = ts.sessionManager.CreateWindowWithCommand(session.ID, name, command)
+		if err == nil {
+			pane = window.Panes[0]
+		}
+	}
+	if err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+
+	t, err := ts.taskManager.CreateTask(name, command, nil)
+	if err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+	pane.mutex.Lock()
+	pane.TaskID = t.ID
+	pane.mutex.Unlock()
+
+	// 关闭其他窗口或面板时序号会在会话锁和窗口锁下重新编号
+	session.mutex.RLock()
+	window.mutex.RLock()
+	windowIndex, windowName, paneIndex := window.Index, window.Name, pane.Index
+	window.mutex.RUnlock()
+	session.mutex.RUnlock()
+
+	host := &task.TerminalHost{
+		SessionID:   session.ID,
+		SessionName: session.Name,
+		WindowIndex: windowIndex,
+		WindowName:  windowName,
+		PaneID:      pane.ID,
+		PaneIndex:   paneIndex,
+		Command:     command,
+		SocketPath:  ts.socketPath,
+	}
+	if err := ts.taskManager.SetTerminalHost(t.ID, host); err != nil {
+		logger.Error("Failed to record task host", zap.Error(err), zap.String("task_id", t.ID))
+	}
+
+	err = ts.taskManager.StartTask(ts.ctx, t.ID, func(ctx context.Context, _ *task.Task) error {
+		code, err := ts.sessionManager.RunPaneCommand(ctx, pane)
+		if err := ts.taskManager.SetExitCode(t.ID, code); err != nil {
+			logger.Error("Failed to record task exit code", zap.Error(err), zap.String("task_id", t.ID))
+		}
+		logger.Info("Task process exited", zap.String("task_id", t.ID), zap.Int("exit_code", code))
+		return err
+	})
+	if err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+
+	logger.Info("Task started in pane",
+		zap.String("task_id", t.ID),
+		zap.String("session_id", session.ID),
+		zap.String("pane_id", pane.ID))
+
+	return map[string]interface{}{
+		"success":      true,
+		"task_id":      t.ID,
+		"session_id":   session.ID,
+		"session_name": session.Name,
+		"window_index": windowIndex,
+		"pane_index":   paneIndex,
+		"pane_id":      pane.ID,
+	}
+}
+
+// startPane 在后台运行交互面板的程序，进程退出后面板保留退出状态
+func (ts *TerminalServer) startPane(pane *Pane) {
+	go func() {
+		code, err := ts.sessionManager.RunPaneCommand(ts.ctx, pane)
+		logger.Info("Pane process exited",
+			zap.String("pane_id", pane.ID),
+			zap.Int("exit_code", code),
+			zap.Error(err))
+	}()
+}
+
+// taskSession 确定运行任务的会话：指定的会话、客户端当前会话、最近活动的会话，
+// 都不存在时创建名为 tasks 的新会话，并与 new-session 一样启动其默认面板
+func (ts *TerminalServer) taskSession(client *ClientConnection, target string) (*Session, error) {
+	if target != "" {
+		if session, err := ts.sessionManager.GetSession(target); err == nil {
+			return session, nil
+		}
+		return ts.sessionManager.GetSessionByName(target)
+	}
+
+	if client.SessionID != "" {
+		return ts.sessionManager.GetSession(client.SessionID)
+	}
+
+	var latest *Session
+	for _, session := range ts.sessionManager.ListSessions() {
+		if latest == nil || session.LastActive.After(latest.LastActive) {
+			latest = session
+		}
+	}
+	if latest != nil {
+		return latest, nil
+	}
+
+	if session, err := ts.sessionManager.GetSessionByName("tasks"); err == nil {
+		return session, nil
+	}
+	session, err := ts.sessionManager.CreateSession("tasks")
+	if err != nil {
+		return nil, err
+	}
+	ts.startPane(session.Windows[0].Panes[0])
+	return session, nil
+}
+
+// handleCancelTask 处理取消任务命令，终止承载任务的面板进程
+func (ts *TerminalServer) handleCancelTask(client *ClientConnection, payload interface{}) interface{} {
+	if ts.taskManager == nil {
+		return map[string]interface{}{"error": "task integration is disabled"}
+	}
+
+	data, ok := payload.(map[string]interface{})
+	if !ok {
+		return map[string]interface{}{"error": "invalid payload"}
+	}
+
+	taskID, _ := data["task_id"].(string)
+	t, err := ts.taskManager.GetTask(taskID)
+	if err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+
+	// 先标记取消，进程退出后任务状态不会再被改为失败
+	if err := ts.taskManager.CancelTask(taskID); err != nil {
+		return map[string]interface{}{"error": err.Error()}
+	}
+
+	if t.Terminal != nil {
+		if pane := ts.sessionManager.paneByID(t.Terminal.PaneID); pane != nil {
+			if err := pane.Kill(); err != nil {
+				logger.Error("Failed to kill task process", zap.Error(err), zap.String("task_id", taskID))
+			}
+		}
+	}
+
+	return map[string]interface{}{
+		"success": true,
+	}
+}
+
 // autoSave 自动保存会话状态
 func (ts *TerminalServer) autoSave() {
 	ticker := time.NewTicker(ts.config.SaveInterval)
@@ -649,6 +1040,13 @@ func (ts *TerminalServer) GetClientCount() int {
 	return len(ts.clients)
 }
 
+// SetTaskManager 设置任务管理器，用于在面板中运行并跟踪 ClixGo 任务
+func (ts *TerminalServer) SetTaskManager(tm *task.TaskManager) {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+	ts.taskManager = tm
+}
+
 // GetSessionManager 获取会话管理器
 func (ts *TerminalServer) GetSessionManager() *SessionManager {
 	return ts.sessionManager
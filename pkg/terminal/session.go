@@ -8,6 +8,12 @@ import (
 	"github.com/google/uuid"
 )
 
+// 客户端报告终端大小之前使用的默认面板区域大小，状态栏位于面板区域下方一行
+const (
+	DefaultTermWidth  = 80
+	DefaultTermHeight = 24
+)
+
 // SessionManager 会话管理器
 type SessionManager struct {
 	sessions map[string]*Session
@@ -46,7 +52,7 @@ func (sm *SessionManager) CreateSession(name string) (*Session, error) {
 	}
 
 	// 创建默认窗口
-	window, err := sm.createWindow(session, "")
+	window, err := sm.createWindow(session, "", "")
 	if err != nil {
 		return nil, fmt.Errorf("failed to create default window: %v", err)
 	}
@@ -115,17 +121,16 @@ func (sm *SessionManager) KillSession(sessionID string) error {
 	}
 
 	session.mutex.Lock()
-	defer session.mutex.Unlock()
+	windows := session.Windows
+	session.Windows = nil
+	session.Status = SessionDestroyed
+	session.mutex.Unlock()
 
-	// 关闭所有窗口
-	for _, window := range session.Windows {
-		if err := sm.closeWindow(session, window.Index); err != nil {
-			// 记录错误但继续
-			fmt.Printf("Warning: failed to close window %d: %v\n", window.Index, err)
-		}
+	// 终止所有窗口中的面板进程
+	for _, window := range windows {
+		sm.killPanes(window)
 	}
 
-	session.Status = SessionDestroyed
 	delete(sm.sessions, sessionID)
 
 	return nil
@@ -133,12 +138,17 @@ func (sm *SessionManager) KillSession(sessionID string) error {
 
 // CreateWindow 创建新窗口
 func (sm *SessionManager) CreateWindow(sessionID, name string) (*Window, error) {
+	return sm.CreateWindowWithCommand(sessionID, name, "")
+}
+
+// CreateWindowWithCommand 创建新窗口，默认面板运行指定命令（为空时使用默认shell）
+func (sm *SessionManager) CreateWindowWithCommand(sessionID, name, command string) (*Window, error) {
 	session, err := sm.GetSession(sessionID)
 	if err != nil {
 		return nil, err
 	}
 
-	window, err := sm.createWindow(session, name)
+	window, err := sm.createWindow(session, name, command)
 	if err != nil {
 		return nil, err
 	}
@@ -154,7 +164,7 @@ func (sm *SessionManager) CreateWindow(sessionID, name string) (*Window, error)
 }
 
 // createWindow 内部创建窗口方法
-func (sm *SessionManager) createWindow(session *Session, name string) (*Window, error) {
+func (sm *SessionManager) createWindow(session *Session, name, command string) (*Window, error) {
 	if name == "" {
 		name = fmt.Sprintf("window-%d", len(session.Windows))
 	}
@@ -167,18 +177,45 @@ func (sm *SessionManager) createWindow(session *Session, name string) (*Window,
 		ActivePane: 0,
 		Layout:     LayoutMainVertical,
 		CreatedAt:  time.Now(),
+		Width:      session.Width,
+		Height:     session.Height,
 	}
 
 	// 创建默认面板
-	pane, err := sm.createPane(window, "")
+	pane, err := sm.createPane(window, command)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create default pane: %v", err)
 	}
 
 	window.Panes = append(window.Panes, pane)
+	sm.recalculateLayout(window)
 	return window, nil
 }
 
+// SetClientSize 按客户端报告的终端大小（列数和行数）重新计算会话中所有窗口的布局，
+// 最后一行留给状态栏，面板程序的伪终端大小随之更新
+func (sm *SessionManager) SetClientSize(sessionID string, cols, rows int) error {
+	session, err := sm.GetSession(sessionID)
+	if err != nil {
+		return err
+	}
+	if cols < 1 || rows < 2 {
+		return fmt.Errorf("invalid terminal size: %dx%d", cols, rows)
+	}
+
+	session.mutex.Lock()
+	defer session.mutex.Unlock()
+
+	session.Width, session.Height = cols, rows-1
+	for _, window := range session.Windows {
+		window.mutex.Lock()
+		window.Width, window.Height = session.Width, session.Height
+		sm.recalculateLayout(window)
+		window.mutex.Unlock()
+	}
+	return nil
+}
+
 // CloseWindow 关闭窗口
 func (sm *SessionManager) CloseWindow(sessionID string, windowIndex int) error {
 	session, err := sm.GetSession(sessionID)
@@ -200,12 +237,8 @@ func (sm *SessionManager) closeWindow(session *Session, windowIndex int) error {
 
 	window := session.Windows[windowIndex]
 
-	// 关闭所有面板
-	for _, pane := range window.Panes {
-		if err := sm.closePane(window, pane.Index); err != nil {
-			fmt.Printf("Warning: failed to close pane %d: %v\n", pane.Index, err)
-		}
-	}
+	// 终止所有面板进程
+	sm.killPanes(window)
 
 	// 从会话中移除窗口
 	session.Windows = append(session.Windows[:windowIndex], session.Windows[windowIndex+1:]...)
@@ -249,6 +282,11 @@ func (sm *SessionManager) SwitchWindow(sessionID string, windowIndex int) error
 
 // SplitPane 分割面板
 func (sm *SessionManager) SplitPane(sessionID string, windowIndex int, direction string) (*Pane, error) {
+	return sm.SplitPaneWithCommand(sessionID, windowIndex, direction, "")
+}
+
+// SplitPaneWithCommand 分割面板，新面板运行指定命令（为空时使用默认shell）
+func (sm *SessionManager) SplitPaneWithCommand(sessionID string, windowIndex int, direction, command string) (*Pane, error) {
 	session, err := sm.GetSession(sessionID)
 	if err != nil {
 		return nil, err
@@ -261,7 +299,7 @@ func (sm *SessionManager) SplitPane(sessionID string, windowIndex int, direction
 	window := session.Windows[windowIndex]
 
 	// 创建新面板
-	pane, err := sm.createPane(window, "")
+	pane, err := sm.createPane(window, command)
 	if err != nil {
 		return nil, err
 	}
@@ -330,6 +368,19 @@ func (sm *SessionManager) ClosePane(sessionID string, windowIndex, paneIndex int
 	return sm.closePane(window, paneIndex)
 }
 
+// killPanes 终止窗口中所有面板的进程
+func (sm *SessionManager) killPanes(window *Window) {
+	window.mutex.RLock()
+	panes := append([]*Pane(nil), window.Panes...)
+	window.mutex.RUnlock()
+
+	for _, pane := range panes {
+		if err := pane.Kill(); err != nil {
+			fmt.Printf("Warning: failed to kill process in pane %d: %v\n", pane.Index, err)
+		}
+	}
+}
+
 // closePane 内部关闭面板方法
 func (sm *SessionManager) closePane(window *Window, paneIndex int) error {
 	window.mutex.Lock()
@@ -341,11 +392,9 @@ func (sm *SessionManager) closePane(window *Window, paneIndex int) error {
 
 	pane := window.Panes[paneIndex]
 
-	// 终止进程
-	if pane.Process != nil {
-		if err := pane.Process.Kill(); err != nil {
-			fmt.Printf("Warning: failed to kill process: %v\n", err)
-		}
+	// 终止进程及其子进程
+	if err := pane.Kill(); err != nil {
+		fmt.Printf("Warning: failed to kill process: %v\n", err)
 	}
 
 	// 从窗口中移除面板
@@ -403,14 +452,198 @@ func (sm *SessionManager) SwitchPane(sessionID string, windowIndex, paneIndex in
 	return nil
 }
 
+// ResizePane 拖动面板右侧（vertical）或下方的边框，delta 为移动的列数或行数。
+// 与该边框对齐的所有面板同时调整，保证布局不出现重叠或空隙
+func (sm *SessionManager) ResizePane(sessionID string, windowIndex, paneIndex int, vertical bool, delta int) error {
+	session, err := sm.GetSession(sessionID)
+	if err != nil {
+		return err
+	}
+
+	if windowIndex < 0 || windowIndex >= len(session.Windows) {
+		return fmt.Errorf("window index out of range: %d", windowIndex)
+	}
+
+	window := session.Windows[windowIndex]
+
+	window.mutex.Lock()
+	defer window.mutex.Unlock()
+
+	if paneIndex < 0 || paneIndex >= len(window.Panes) {
+		return fmt.Errorf("pane index out of range: %d", paneIndex)
+	}
+	if delta == 0 {
+		return nil
+	}
+
+	pane := window.Panes[paneIndex]
+	edge := pane.Y + pane.Height
+	if vertical {
+		edge = pane.X + pane.Width
+	}
+
+	// 收集边框两侧的面板
+	var before, after []*Pane
+	for _, p := range window.Panes {
+		if vertical {
+			if p.X+p.Width == edge {
+				before = append(before, p)
+			} else if p.X == edge {
+				after = append(after, p)
+			}
+		} else {
+			if p.Y+p.Height == edge {
+				before = append(before, p)
+			} else if p.Y == edge {
+				after = append(after, p)
+			}
+		}
+	}
+	if len(after) == 0 {
+		return fmt.Errorf("pane %d has no adjacent pane to resize against", paneIndex)
+	}
+
+	// 限制移动距离，保证每个面板至少保留一行/一列
+	for _, p := range before {
+		size := p.Height
+		if vertical {
+			size = p.Width
+		}
+		if size+delta < 1 {
+			delta = 1 - size
+		}
+	}
+	for _, p := range after {
+		size := p.Height
+		if vertical {
+			size = p.Width
+		}
+		if size-delta < 1 {
+			delta = size - 1
+		}
+	}
+
+	for _, p := range before {
+		if vertical {
+			p.Width += delta
+		} else {
+			p.Height += delta
+		}
+	}
+	for _, p := range after {
+		if vertical {
+			p.X += delta
+			p.Width -= delta
+		} else {
+			p.Y += delta
+			p.Height -= delta
+		}
+	}
+	for _, p := range append(before, after...) {
+		p.resizePTY()
+	}
+
+	session.LastActive = time.Now()
+	return nil
+}
+
+// ScrollPane 在面板回滚缓冲区中滚动，lines 为正表示向上滚动。
+// 向上滚动时自动进入复制模式，回到底部时退出复制模式
+func (sm *SessionManager) ScrollPane(sessionID string, windowIndex, paneIndex, lines int) (*Pane, error) {
+	session, err := sm.GetSession(sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if windowIndex < 0 || windowIndex >= len(session.Windows) {
+		return nil, fmt.Errorf("window index out of range: %d", windowIndex)
+	}
+
+	window := session.Windows[windowIndex]
+
+	window.mutex.RLock()
+	defer window.mutex.RUnlock()
+
+	if paneIndex < 0 || paneIndex >= len(window.Panes) {
+		return nil, fmt.Errorf("pane index out of range: %d", paneIndex)
+	}
+
+	pane := window.Panes[paneIndex]
+	pane.mutex.Lock()
+	defer pane.mutex.Unlock()
+
+	maxOffset := 0
+	if pane.Buffer != nil {
+		pane.Buffer.mutex.RLock()
+		maxOffset = len(pane.Buffer.Lines) - pane.Height
+		pane.Buffer.mutex.RUnlock()
+	}
+	if maxOffset < 0 {
+		maxOffset = 0
+	}
+
+	offset := pane.ScrollOffset + lines
+	if offset > maxOffset {
+		offset = maxOffset
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	pane.ScrollOffset = offset
+	if lines > 0 {
+		pane.CopyMode = true
+	} else if offset == 0 {
+		pane.CopyMode = false
+	}
+
+	session.LastActive = time.Now()
+	return pane, nil
+}
+
+// SetCopyMode 进入或退出面板的复制模式，退出时回到缓冲区底部
+func (sm *SessionManager) SetCopyMode(sessionID string, windowIndex, paneIndex int, enabled bool) error {
+	session, err := sm.GetSession(sessionID)
+	if err != nil {
+		return err
+	}
+
+	if windowIndex < 0 || windowIndex >= len(session.Windows) {
+		return fmt.Errorf("window index out of range: %d", windowIndex)
+	}
+
+	window := session.Windows[windowIndex]
+
+	window.mutex.RLock()
+	defer window.mutex.RUnlock()
+
+	if paneIndex < 0 || paneIndex >= len(window.Panes) {
+		return fmt.Errorf("pane index out of range: %d", paneIndex)
+	}
+
+	pane := window.Panes[paneIndex]
+	pane.mutex.Lock()
+	defer pane.mutex.Unlock()
+
+	pane.CopyMode = enabled
+	if !enabled {
+		pane.ScrollOffset = 0
+	}
+
+	session.LastActive = time.Now()
+	return nil
+}
+
 // recalculateLayout 重新计算布局
 func (sm *SessionManager) recalculateLayout(window *Window) {
 	if len(window.Panes) == 0 {
 		return
 	}
 
-	// 假设终端大小为 80x24（这应该从实际终端获取）
-	termWidth, termHeight := 80, 24
+	termWidth, termHeight := window.Width, window.Height
+	if termWidth <= 0 || termHeight <= 0 {
+		termWidth, termHeight = DefaultTermWidth, DefaultTermHeight
+	}
 
 	switch window.Layout {
 	case LayoutEven:
@@ -424,6 +657,10 @@ func (sm *SessionManager) recalculateLayout(window *Window) {
 	default:
 		sm.layoutEven(window.Panes, termWidth, termHeight)
 	}
+
+	for _, pane := range window.Panes {
+		pane.resizePTY()
+	}
 }
 
 // layoutEven 均匀布局
@@ -581,6 +818,65 @@ func (sm *SessionManager) RenameWindow(sessionID string, windowIndex int, newNam
 	return nil
 }
 
+// FindPane 根据面板ID查找所在的会话、窗口和面板索引
+func (sm *SessionManager) FindPane(paneID string) (*Session, int, int, error) {
+	for _, session := range sm.sessions {
+		session.mutex.RLock()
+		for wi, window := range session.Windows {
+			window.mutex.RLock()
+			for pi, pane := range window.Panes {
+				if pane.ID == paneID {
+					window.mutex.RUnlock()
+					session.mutex.RUnlock()
+					return session, wi, pi, nil
+				}
+			}
+			window.mutex.RUnlock()
+		}
+		session.mutex.RUnlock()
+	}
+	return nil, 0, 0, fmt.Errorf("pane not found: %s", paneID)
+}
+
+// paneByID 查找指定ID的面板，不存在时返回 nil。FindPane 释放锁后面板可能被关闭或重新编号，
+// 因此在会话锁和窗口锁下重新检查索引和ID
+func (sm *SessionManager) paneByID(paneID string) *Pane {
+	session, windowIndex, paneIndex, err := sm.FindPane(paneID)
+	if err != nil {
+		return nil
+	}
+
+	session.mutex.RLock()
+	defer session.mutex.RUnlock()
+	if windowIndex >= len(session.Windows) {
+		return nil
+	}
+	window := session.Windows[windowIndex]
+
+	window.mutex.RLock()
+	defer window.mutex.RUnlock()
+	if paneIndex >= len(window.Panes) || window.Panes[paneIndex].ID != paneID {
+		return nil
+	}
+	return window.Panes[paneIndex]
+}
+
+// SelectPane 切换到指定ID的面板所在窗口，并将其设为活动面板
+func (sm *SessionManager) SelectPane(sessionID, paneID string) error {
+	session, windowIndex, paneIndex, err := sm.FindPane(paneID)
+	if err != nil {
+		return err
+	}
+	if session.ID != sessionID {
+		return fmt.Errorf("pane %s does not belong to session %s", paneID, sessionID)
+	}
+
+	if err := sm.SwitchWindow(sessionID, windowIndex); err != nil {
+		return err
+	}
+	return sm.SwitchPane(sessionID, windowIndex, paneIndex)
+}
+
 // GetSessionByName 根据名称获取会话
 func (sm *SessionManager) GetSessionByName(name string) (*Session, error) {
 	for _, session := range sm.sessions {
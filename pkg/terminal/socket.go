@@ -0,0 +1,143 @@
+package terminal
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultServerName 默认服务器名称
+const DefaultServerName = "default"
+
+// socketSuffix 服务器socket文件扩展名
+const socketSuffix = ".sock"
+
+// ServerInfo 描述一个已发现的服务器socket
+type ServerInfo struct {
+	Name       string `json:"name"`
+	SocketPath string `json:"socket_path"`
+	Running    bool   `json:"running"`
+}
+
+// SocketDir 返回存放命名服务器socket的目录 ~/.clixgo/terminal
+func SocketDir() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		homeDir = "/tmp"
+	}
+	return filepath.Join(homeDir, ".clixgo", "terminal")
+}
+
+// SocketPathForName 返回命名服务器的socket路径，对应 tmux 的 -L 选项
+func SocketPathForName(name string) (string, error) {
+	if name == "" {
+		name = DefaultServerName
+	}
+	if strings.ContainsAny(name, "/\\") || name == "." || name == ".." {
+		return "", fmt.Errorf("invalid server name: %s", name)
+	}
+	return filepath.Join(SocketDir(), name+socketSuffix), nil
+}
+
+// ResolveSocketPath 根据服务器名称或显式路径（对应 -S 选项，优先）确定socket路径
+func ResolveSocketPath(name, path string) (string, error) {
+	if path != "" {
+		if name != "" {
+			return "", fmt.Errorf("server name and socket path are mutually exclusive")
+		}
+		return filepath.Abs(path)
+	}
+	return SocketPathForName(name)
+}
+
+// ensureSocketDir 创建socket所在目录。默认目录强制为0700，
+// 使其他本地用户无法访问其中的socket；自定义目录只在不存在时以0700创建
+func ensureSocketDir(socketPath string) error {
+	dir := filepath.Dir(socketPath)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create socket directory: %v", err)
+	}
+	if dir == SocketDir() {
+		if err := os.Chmod(dir, 0700); err != nil {
+			return fmt.Errorf("failed to secure socket directory: %v", err)
+		}
+	}
+	return nil
+}
+
+// listenSocket 在指定路径创建权限为0600的Unix socket，socket 文件创建时即为0600。
+// 已有服务器在监听时返回错误，残留的socket文件会被清理
+func listenSocket(socketPath string) (net.Listener, error) {
+	if err := ensureSocketDir(socketPath); err != nil {
+		return nil, err
+	}
+
+	if _, err := os.Lstat(socketPath); err == nil {
+		if socketAlive(socketPath) {
+			return nil, fmt.Errorf("a server is already listening on %s", socketPath)
+		}
+		if err := os.Remove(socketPath); err != nil {
+			return nil, fmt.Errorf("failed to remove existing socket: %v", err)
+		}
+	}
+
+	listener, err := listenUnix(socketPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create listener: %v", err)
+	}
+
+	// 不支持 umask 的平台上由此收紧权限
+	if err := os.Chmod(socketPath, 0600); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to secure socket: %v", err)
+	}
+
+	return listener, nil
+}
+
+// socketAlive 检查socket上是否有服务器在监听
+func socketAlive(socketPath string) bool {
+	conn, err := net.DialTimeout("unix", socketPath, 500*time.Millisecond)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+// ListServers 列出默认目录下的所有命名服务器及其运行状态
+func ListServers() ([]ServerInfo, error) {
+	entries, err := os.ReadDir(SocketDir())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var servers []ServerInfo
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), socketSuffix) {
+			continue
+		}
+		if entry.Type()&os.ModeSocket == 0 {
+			continue
+		}
+
+		socketPath := filepath.Join(SocketDir(), entry.Name())
+		servers = append(servers, ServerInfo{
+			Name:       strings.TrimSuffix(entry.Name(), socketSuffix),
+			SocketPath: socketPath,
+			Running:    socketAlive(socketPath),
+		})
+	}
+
+	sort.Slice(servers, func(i, j int) bool {
+		return servers[i].Name < servers[j].Name
+	})
+	return servers, nil
+}
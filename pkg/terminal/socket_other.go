@@ -0,0 +1,10 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd
+
+package terminal
+
+import "net"
+
+// listenUnix 当前平台没有 umask，直接创建 Unix socket
+func listenUnix(socketPath string) (net.Listener, error) {
+	return net.Listen("unix", socketPath)
+}
@@ -0,0 +1,86 @@
+package terminal
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Lzww0608/ClixGo/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 测试socket路径解析
+func TestResolveSocketPath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	path, err := ResolveSocketPath("", "")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(home, ".clixgo", "terminal", "default.sock"), path)
+
+	path, err = ResolveSocketPath("work", "")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(home, ".clixgo", "terminal", "work.sock"), path)
+
+	path, err = ResolveSocketPath("", "/tmp/custom.sock")
+	require.NoError(t, err)
+	assert.Equal(t, "/tmp/custom.sock", path)
+
+	_, err = ResolveSocketPath("work", "/tmp/custom.sock")
+	assert.Error(t, err, "名称和路径不能同时指定")
+
+	_, err = ResolveSocketPath("../evil", "")
+	assert.Error(t, err, "名称不能包含路径分隔符")
+}
+
+// 测试socket和目录权限以及多个独立服务器
+func TestNamedServers(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	logger.SetLogPath(filepath.Join(home, "test.log"))
+	require.NoError(t, logger.InitLogger())
+
+	// 已存在且权限过宽的目录会被收紧
+	require.NoError(t, os.MkdirAll(SocketDir(), 0755))
+
+	workPath, err := SocketPathForName("work")
+	require.NoError(t, err)
+	work := NewTerminalServerWithSocket(DefaultConfig, workPath)
+	require.NoError(t, work.Start())
+	defer work.Stop()
+
+	dirInfo, err := os.Stat(SocketDir())
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0700), dirInfo.Mode().Perm())
+
+	sockInfo, err := os.Stat(workPath)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), sockInfo.Mode().Perm())
+
+	// 同一socket上不能启动第二个服务器
+	duplicate := NewTerminalServerWithSocket(DefaultConfig, workPath)
+	assert.Error(t, duplicate.Start())
+
+	// 模拟服务器异常退出后残留的socket文件
+	stalePath, err := SocketPathForName("stale")
+	require.NoError(t, err)
+	listener, err := net.Listen("unix", stalePath)
+	require.NoError(t, err)
+	listener.(*net.UnixListener).SetUnlinkOnClose(false)
+	listener.Close()
+
+	servers, err := ListServers()
+	require.NoError(t, err)
+	require.Len(t, servers, 2)
+	assert.Equal(t, "stale", servers[0].Name)
+	assert.False(t, servers[0].Running)
+	assert.Equal(t, "work", servers[1].Name)
+	assert.True(t, servers[1].Running)
+
+	// 残留的socket文件会被清理
+	restarted := NewTerminalServerWithSocket(DefaultConfig, stalePath)
+	require.NoError(t, restarted.Start())
+	defer restarted.Stop()
+}
@@ -0,0 +1,24 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+
+package terminal
+
+import (
+	"net"
+	"sync"
+
+	"golang.org/x/sys/unix"
+)
+
+// umaskMutex 串行化临时修改 umask 的监听操作
+var umaskMutex sync.Mutex
+
+// listenUnix 以 0177 的 umask 创建 Unix socket，使 socket 文件从创建起就只有所有者可以连接，
+// 不存在按进程 umask 创建后再收紧权限的时间窗口。umask 是进程级设置，监听完成后立即恢复
+func listenUnix(socketPath string) (net.Listener, error) {
+	umaskMutex.Lock()
+	defer umaskMutex.Unlock()
+
+	old := unix.Umask(0177)
+	defer unix.Umask(old)
+	return net.Listen("unix", socketPath)
+}
@@ -0,0 +1,29 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+
+package terminal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+// 测试socket创建时即为0600，且进程umask被恢复
+func TestListenUnixUmask(t *testing.T) {
+	old := unix.Umask(0)
+	defer unix.Umask(old)
+
+	socketPath := filepath.Join(t.TempDir(), "test.sock")
+	listener, err := listenUnix(socketPath)
+	require.NoError(t, err)
+	defer listener.Close()
+
+	info, err := os.Stat(socketPath)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	assert.Equal(t, 0, unix.Umask(0))
+}
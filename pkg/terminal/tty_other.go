@@ -0,0 +1,24 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd
+
+package terminal
+
+import (
+	"fmt"
+	"os"
+)
+
+// resizeSignals 当前平台没有终端大小变化的信号
+var resizeSignals []os.Signal
+
+// ttyState 在不支持的平台上为空
+type ttyState struct{}
+
+// enableCbreak 当前平台不支持逐字节输入，鼠标模式将被禁用
+func enableCbreak(fd int) (*ttyState, error) {
+	return nil, fmt.Errorf("mouse mode is not supported on this platform")
+}
+
+// restoreTTY 当前平台无需恢复
+func restoreTTY(fd int, state *ttyState) error {
+	return nil
+}
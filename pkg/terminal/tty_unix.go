@@ -0,0 +1,43 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+
+package terminal
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// resizeSignals 终端大小变化时收到的信号
+var resizeSignals = []os.Signal{unix.SIGWINCH}
+
+// ttyState 保存终端原始属性，用于退出时恢复
+type ttyState struct {
+	termios unix.Termios
+}
+
+// enableCbreak 关闭规范模式和回显，使鼠标序列和按键逐字节到达。
+// 保留 ISIG 和输出处理，Ctrl+C 与换行行为不受影响
+func enableCbreak(fd int) (*ttyState, error) {
+	termios, err := unix.IoctlGetTermios(fd, ioctlReadTermios)
+	if err != nil {
+		return nil, err
+	}
+
+	state := &ttyState{termios: *termios}
+
+	termios.Lflag &^= unix.ICANON | unix.ECHO
+	termios.Iflag &^= unix.ICRNL
+	termios.Cc[unix.VMIN] = 1
+	termios.Cc[unix.VTIME] = 0
+	if err := unix.IoctlSetTermios(fd, ioctlWriteTermios, termios); err != nil {
+		return nil, err
+	}
+
+	return state, nil
+}
+
+// restoreTTY 恢复终端属性
+func restoreTTY(fd int, state *ttyState) error {
+	return unix.IoctlSetTermios(fd, ioctlWriteTermios, &state.termios)
+}
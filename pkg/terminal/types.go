@@ -26,7 +26,10 @@ type Session struct {
 	LastActive   time.Time     `json:"last_active"`
 	Windows      []*Window     `json:"windows"`
 	ActiveWindow int           `json:"active_window"`
-	mutex        sync.RWMutex
+	// 面板区域的大小：客户端报告的终端大小减去最后一行状态栏
+	Width  int `json:"width"`
+	Height int `json:"height"`
+	mutex  sync.RWMutex
 }
 
 // Window 窗口结构
@@ -38,7 +41,10 @@ type Window struct {
 	ActivePane int       `json:"active_pane"`
 	Layout     Layout    `json:"layout"`
 	CreatedAt  time.Time `json:"created_at"`
-	mutex      sync.RWMutex
+	// 面板区域的大小
+	Width  int `json:"width"`
+	Height int `json:"height"`
+	mutex  sync.RWMutex
 }
 
 // Pane 面板结构
@@ -59,7 +65,21 @@ type Pane struct {
 	Active     bool        `json:"active"`
 	CreatedAt  time.Time   `json:"created_at"`
 	LastOutput time.Time   `json:"last_output"`
-	mutex      sync.RWMutex
+	// 面板程序自身请求的鼠标跟踪模式（DECSET 1000/1002/1003/1006）
+	MouseMode MouseMode `json:"mouse_mode"`
+	MouseSGR  bool      `json:"mouse_sgr"`
+	// 复制模式与回滚偏移（距底部的行数）
+	CopyMode     bool `json:"copy_mode"`
+	ScrollOffset int  `json:"scroll_offset"`
+	// 面板进程退出状态及关联的 ClixGo 任务
+	Exited   bool   `json:"exited"`
+	ExitCode int    `json:"exit_code"`
+	TaskID   string `json:"task_id,omitempty"`
+	// 面板程序所在伪终端的主端
+	pty *os.File
+	// 上次输出末尾未完整的 DECSET/DECRST 序列，与下次输出拼接后再解析
+	modePending []byte
+	mutex       sync.RWMutex
 }
 
 // Layout 布局类型
@@ -162,6 +182,11 @@ const (
 	CmdSetLayout     = "set_layout"
 	CmdRename        = "rename"
 	CmdKillSession   = "kill_session"
+	CmdGetSession    = "get_session"
+	CmdSelectPane    = "select_pane"
+	CmdRunTask       = "run_task"
+	CmdCancelTask    = "cancel_task"
+	CmdResizeClient  = "resize_client"
 )
 
 // 默认配置
@@ -0,0 +1,146 @@
+package textproc
+
+import (
+	"errors"
+	"os/exec"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const conformanceInput = "alpha 1\r\nBeta 22\nfoo.bar\n\ngamma 333\nbeta again\nlast line"
+
+// runSystem 执行系统命令，退出码为1时返回空输出而不是错误
+func runSystem(t *testing.T, name string, args []string, input string) string {
+	t.Helper()
+	cmd := exec.Command(name, args...)
+	cmd.Stdin = strings.NewReader(input)
+	out, err := cmd.Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return string(out)
+	}
+	require.NoError(t, err, "%s %v", name, args)
+	return string(out)
+}
+
+// 测试内置 grep 与系统中的 grep -E 输出一致
+func TestGrepConformance(t *testing.T) {
+	if _, err := exec.LookPath("grep"); err != nil {
+		t.Skip("系统中没有 grep")
+	}
+
+	tests := []struct {
+		pattern string
+		opts    GrepOptions
+	}{
+		{"beta", GrepOptions{}},
+		{"beta", GrepOptions{IgnoreCase: true, LineNumber: true}},
+		{"^[a-z]+ [0-9]+$", GrepOptions{}},
+		{"1\r$", GrepOptions{}},
+		{"[0-9]{2,}", GrepOptions{OnlyMatching: true}},
+		{"a", GrepOptions{Invert: true, LineNumber: true}},
+		{"^$", GrepOptions{Count: true}},
+		{"o.b", GrepOptions{Fixed: true}},
+		{"foo.bar", GrepOptions{Fixed: true}},
+		{"gamma", GrepOptions{Before: 2, After: 1, LineNumber: true}},
+		{"a", GrepOptions{After: 1}},
+		{"line", GrepOptions{}},
+		{"omega", GrepOptions{Count: true}},
+		{"(al|ga)", GrepOptions{OnlyMatching: true, IgnoreCase: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			args := []string{"-E"}
+			if tt.opts.Fixed {
+				args = []string{"-F"}
+			}
+			for flag, set := range map[string]bool{
+				"-i": tt.opts.IgnoreCase, "-v": tt.opts.Invert, "-n": tt.opts.LineNumber,
+				"-c": tt.opts.Count, "-o": tt.opts.OnlyMatching,
+			} {
+				if set {
+					args = append(args, flag)
+				}
+			}
+			// 显式的 -A 0 也会让 GNU grep 输出 -- 分隔符，与 commands 包一样只在大于0时传递
+			if tt.opts.Before > 0 {
+				args = append(args, "-B", strconv.Itoa(tt.opts.Before))
+			}
+			if tt.opts.After > 0 {
+				args = append(args, "-A", strconv.Itoa(tt.opts.After))
+			}
+			args = append(args, "-e", tt.pattern)
+			want := runSystem(t, "grep", args, conformanceInput)
+
+			got, err := GrepString(conformanceInput, tt.pattern, tt.opts)
+			if err != nil {
+				require.ErrorIs(t, err, ErrNoMatch)
+			}
+			assert.Equal(t, want, got)
+		})
+	}
+}
+
+// 测试内置 sed 与系统中的 sed 输出一致
+func TestSedConformance(t *testing.T) {
+	if _, err := exec.LookPath("sed"); err != nil {
+		t.Skip("系统中没有 sed")
+	}
+
+	tests := []struct {
+		script string
+		opts   SedOptions
+	}{
+		{"s/a/A/g", SedOptions{}},
+		{"s/a/A/2", SedOptions{}},
+		{"s/a/A/2g", SedOptions{}},
+		{`s/\([a-z]*\) \([0-9]*\)/\2:\1/`, SedOptions{}},
+		{`s/([a-z]+) ([0-9]+)/\2=\1 [&]/`, SedOptions{Extended: true}},
+		{`s/\./\n/`, SedOptions{}},
+		{"s|/|_|g;s/BETA/b/I", SedOptions{}},
+		{`s/[0-9]\{2\}/##/`, SedOptions{}},
+		{"2d;$d", SedOptions{}},
+		{"/^$/,$d", SedOptions{}},
+		{"/Beta/,/gamma/!d", SedOptions{}},
+		{"2,4p", SedOptions{Quiet: true}},
+		{"s/beta/X/p", SedOptions{Quiet: true}},
+		{"4q", SedOptions{}},
+		{"/gamma/=", SedOptions{}},
+		{"$=", SedOptions{Quiet: true}},
+		{"1~3d", SedOptions{}},
+		{"2~0p", SedOptions{Quiet: true}},
+		{"0~2s/$/ <even>/", SedOptions{}},
+		{"/a/,+1s/^/> /", SedOptions{}},
+		{"/Beta/,~4d", SedOptions{}},
+		{"4,2p", SedOptions{Quiet: true}},
+		{"0,/a/d", SedOptions{}},
+		{"1,/a/d", SedOptions{}},
+		{"//d", SedOptions{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.script, func(t *testing.T) {
+			var args []string
+			if tt.opts.Quiet {
+				args = append(args, "-n")
+			}
+			if tt.opts.Extended {
+				args = append(args, "-E")
+			}
+			if tt.script == "//d" {
+				// 没有可复用的正则时两者都报错
+				_, err := SedString(conformanceInput, tt.script, tt.opts)
+				assert.Error(t, err)
+				return
+			}
+			want := runSystem(t, "sed", append(args, "-e", tt.script), conformanceInput)
+
+			got, err := SedString(conformanceInput, tt.script, tt.opts)
+			require.NoError(t, err)
+			assert.Equal(t, want, got)
+		})
+	}
+}
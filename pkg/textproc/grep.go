@@ -0,0 +1,167 @@
+package textproc
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// 单行的最大长度
+const maxLineSize = 64 * 1024 * 1024
+
+// ErrNoMatch 没有匹配的行，对应 grep 的退出码1
+var ErrNoMatch = errors.New("没有匹配的行")
+
+// ErrUnsupported 模式或脚本使用了内置实现不支持的功能（如 RE2 无法表示的反向引用、
+// sed 的 y、a、i 等命令），调用方可以改用系统中的 grep 或 sed 执行
+var ErrUnsupported = errors.New("内置实现不支持")
+
+// unsupported 返回包装了 ErrUnsupported 的错误
+func unsupported(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrUnsupported, fmt.Sprintf(format, args...))
+}
+
+// GrepOptions grep 选项，含义与 GNU grep 的同名参数一致
+type GrepOptions struct {
+	IgnoreCase   bool // -i 忽略大小写
+	Invert       bool // -v 输出不匹配的行
+	LineNumber   bool // -n 输出行号
+	Count        bool // -c 只输出匹配的行数
+	OnlyMatching bool // -o 只输出每行中匹配的部分
+	Fixed        bool // -F 将模式作为普通字符串而不是正则
+	Before       int  // -B 输出匹配行之前的行数
+	After        int  // -A 输出匹配行之后的行数
+}
+
+// CompileGrepPattern 按选项编译模式，正则使用 RE2 语法，RE2 无法编译的模式返回 ErrUnsupported
+func CompileGrepPattern(pattern string, opts GrepOptions) (*regexp.Regexp, error) {
+	if opts.Fixed {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+	if opts.IgnoreCase {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, unsupported("正则表达式: %v", err)
+	}
+	return re, nil
+}
+
+// scanLines 按换行符切分输入，与 grep 一致保留行尾的 \r
+func scanLines(data []byte, atEOF bool) (int, []byte, error) {
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+// contextLine 等待作为上文输出的行
+type contextLine struct {
+	number int
+	text   string
+}
+
+// Grep 逐行匹配输入并按 GNU grep 的格式输出：匹配行的行号后跟冒号，上下文行跟短横线，
+// 不连续的输出块之间用 -- 分隔。返回匹配的行数，没有匹配时同时返回 ErrNoMatch
+func Grep(input io.Reader, output io.Writer, pattern string, opts GrepOptions) (int, error) {
+	re, err := CompileGrepPattern(pattern, opts)
+	if err != nil {
+		return 0, err
+	}
+
+	w := bufio.NewWriter(output)
+	scanner := bufio.NewScanner(input)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	scanner.Split(scanLines)
+
+	// 只输出计数或匹配部分时不输出上下文
+	withContext := !opts.Count && !opts.OnlyMatching && (opts.Before > 0 || opts.After > 0)
+	var before []contextLine
+	afterLeft := 0
+	lastPrinted := 0
+
+	printLine := func(number int, text string, sep byte) {
+		if withContext && lastPrinted > 0 && number > lastPrinted+1 {
+			w.WriteString("--\n")
+		}
+		if opts.LineNumber {
+			fmt.Fprintf(w, "%d%c", number, sep)
+		}
+		w.WriteString(text)
+		w.WriteByte('\n')
+		lastPrinted = number
+	}
+
+	matched := 0
+	number := 0
+	for scanner.Scan() {
+		number++
+		line := scanner.Text()
+		if re.MatchString(line) == opts.Invert {
+			if !withContext {
+				continue
+			}
+			if afterLeft > 0 {
+				printLine(number, line, '-')
+				afterLeft--
+			} else if opts.Before > 0 {
+				before = append(before, contextLine{number, line})
+				if len(before) > opts.Before {
+					before = before[1:]
+				}
+			}
+			continue
+		}
+
+		matched++
+		switch {
+		case opts.Count:
+		case opts.OnlyMatching:
+			if opts.Invert {
+				// 与 GNU grep 一致：-v 与 -o 同时使用时没有可输出的匹配部分
+				continue
+			}
+			for _, m := range re.FindAllString(line, -1) {
+				if m != "" {
+					printLine(number, m, ':')
+				}
+			}
+		default:
+			for _, c := range before {
+				printLine(c.number, c.text, '-')
+			}
+			before = before[:0]
+			printLine(number, line, ':')
+			afterLeft = opts.After
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return matched, fmt.Errorf("读取输入失败: %v", err)
+	}
+
+	if opts.Count {
+		fmt.Fprintf(w, "%d\n", matched)
+	}
+	if err := w.Flush(); err != nil {
+		return matched, err
+	}
+	if matched == 0 {
+		return 0, ErrNoMatch
+	}
+	return matched, nil
+}
+
+// GrepString 对字符串执行 Grep 并返回输出
+func GrepString(input, pattern string, opts GrepOptions) (string, error) {
+	var out strings.Builder
+	_, err := Grep(strings.NewReader(input), &out, pattern, opts)
+	return out.String(), err
+}
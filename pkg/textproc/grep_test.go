@@ -0,0 +1,87 @@
+package textproc
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const grepInput = "alpha\nbeta\nGamma\ndelta\nepsilon\nzeta\ngamma ray\n"
+
+// 测试 grep 的各个选项
+func TestGrep(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		opts    GrepOptions
+		want    string
+	}{
+		{"普通匹配", "gamma", GrepOptions{}, "gamma ray\n"},
+		{"忽略大小写", "gamma", GrepOptions{IgnoreCase: true}, "Gamma\ngamma ray\n"},
+		{"反向匹配", "a$", GrepOptions{Invert: true}, "epsilon\ngamma ray\n"},
+		{"行号", "^[bd]", GrepOptions{LineNumber: true}, "2:beta\n4:delta\n"},
+		{"计数", "ta", GrepOptions{Count: true}, "3\n"},
+		{"只输出匹配部分", "[a-z]*ta", GrepOptions{OnlyMatching: true}, "beta\ndelta\nzeta\n"},
+		{"固定字符串", "a.", GrepOptions{Fixed: true}, ""},
+		{"正则", "^e.*n$", GrepOptions{}, "epsilon\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GrepString(grepInput, tt.pattern, tt.opts)
+			if tt.want == "" {
+				assert.ErrorIs(t, err, ErrNoMatch)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// 测试上下文输出与分隔符
+func TestGrepContext(t *testing.T) {
+	input := strings.Join([]string{"1", "2", "x", "4", "5", "6", "7", "x", "9"}, "\n")
+
+	got, err := GrepString(input, "x", GrepOptions{Before: 1, After: 1, LineNumber: true})
+	require.NoError(t, err)
+	assert.Equal(t, "2-2\n3:x\n4-4\n--\n7-7\n8:x\n9-9\n", got)
+
+	// 相邻的上下文合并为一块
+	got, err = GrepString(input, "x", GrepOptions{After: 4})
+	require.NoError(t, err)
+	assert.Equal(t, "x\n4\n5\n6\n7\nx\n9\n", got)
+}
+
+// 测试计数在没有匹配时仍然输出
+func TestGrepCountNoMatch(t *testing.T) {
+	var out strings.Builder
+	n, err := Grep(strings.NewReader(grepInput), &out, "omega", GrepOptions{Count: true})
+	assert.ErrorIs(t, err, ErrNoMatch)
+	assert.Zero(t, n)
+	assert.Equal(t, "0\n", out.String())
+
+	_, err = GrepString(grepInput, "(", GrepOptions{})
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrNoMatch)
+
+	// RE2 不支持反向引用
+	_, err = GrepString(grepInput, `(a)\1`, GrepOptions{})
+	assert.ErrorIs(t, err, ErrUnsupported)
+}
+
+// 测试保留行尾的 \r，没有换行结尾的最后一行也会被匹配
+func TestGrepCRLF(t *testing.T) {
+	got, err := GrepString("a\r\nb\r\nab", "b", GrepOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "b\r\nab\n", got)
+
+	got, err = GrepString("a\r\nb\r\n", "a$", GrepOptions{})
+	assert.ErrorIs(t, err, ErrNoMatch)
+	assert.Empty(t, got)
+
+	got, err = GrepString("a\r\nb\r\n", "a\r$", GrepOptions{Count: true})
+	require.NoError(t, err)
+	assert.Equal(t, "1\n", got)
+}
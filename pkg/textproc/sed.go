@@ -0,0 +1,638 @@
+package textproc
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// SedOptions sed 选项
+type SedOptions struct {
+	Quiet    bool // -n 不自动输出模式空间
+	Extended bool // -E 使用扩展正则，默认按基本正则(BRE)解析
+}
+
+// addrKind 地址类型
+type addrKind int
+
+const (
+	addrLine     addrKind = iota // 行号
+	addrLast                     // $ 最后一行
+	addrRegex                    // /regex/
+	addrStep                     // first~step，从 first 行开始每隔 step 行
+	addrRelative                 // 范围结束地址 +N，范围开始后的 N 行
+	addrMultiple                 // 范围结束地址 ~N，到行号为 N 的倍数的行为止
+)
+
+// sedAddr 命令地址
+type sedAddr struct {
+	kind addrKind
+	line int // 行号；first~step 的 first；+N 和 ~N 的 N
+	step int
+	re   *regexp.Regexp
+}
+
+// sedCommand 一条 sed 命令
+type sedCommand struct {
+	addr1, addr2 *sedAddr
+	negate       bool
+	name         byte // s、d、p、q、=
+
+	// s 命令
+	re          *regexp.Regexp
+	replacement []replacePart
+	global      bool
+	occurrence  int
+	print       bool
+
+	exitCode int // q 命令
+
+	inRange bool
+	rangeTo int // addr2 为 +N 时范围结束的行
+}
+
+// replacePart 替换文本的组成部分，group 为 -1 表示字面文本
+type replacePart struct {
+	literal string
+	group   int
+}
+
+// SedProgram 编译后的 sed 脚本，支持 s、d、p、q、= 命令，行号、$、/regex/、first~step 地址，
+// addr1,addr2、addr1,+N、addr1,~N、0,/regex/ 范围以及 ! 取反。
+// 其他命令和标志编译时返回 ErrUnsupported
+type SedProgram struct {
+	commands []*sedCommand
+	quiet    bool
+}
+
+// sedParser 解析 sed 脚本
+type sedParser struct {
+	src       string
+	pos       int
+	extended  bool
+	lastRegex *regexp.Regexp
+}
+
+// CompileSed 编译 sed 脚本，命令之间用分号或换行分隔
+func CompileSed(script string, opts SedOptions) (*SedProgram, error) {
+	p := &sedParser{src: script, extended: opts.Extended}
+	prog := &SedProgram{quiet: opts.Quiet}
+	for {
+		p.skip(" \t\n;")
+		if p.pos >= len(p.src) {
+			return prog, nil
+		}
+		cmd, err := p.command()
+		if err != nil {
+			return nil, fmt.Errorf("sed 脚本错误(第%d个字符): %w", p.pos+1, err)
+		}
+		prog.commands = append(prog.commands, cmd)
+	}
+}
+
+func (p *sedParser) skip(chars string) {
+	for p.pos < len(p.src) && strings.IndexByte(chars, p.src[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+func (p *sedParser) peek() byte {
+	if p.pos < len(p.src) {
+		return p.src[p.pos]
+	}
+	return 0
+}
+
+func (p *sedParser) command() (*sedCommand, error) {
+	cmd := &sedCommand{}
+	addr, err := p.address()
+	if err != nil {
+		return nil, err
+	}
+	cmd.addr1 = addr
+	if addr != nil && (addr.kind == addrRelative || addr.kind == addrMultiple) {
+		return nil, fmt.Errorf("+N 和 ~N 只能作为范围的结束地址")
+	}
+	if addr != nil && p.peek() == ',' {
+		p.pos++
+		p.skip(" \t")
+		if cmd.addr2, err = p.address(); err != nil {
+			return nil, err
+		}
+		if cmd.addr2 == nil {
+			return nil, fmt.Errorf("范围缺少结束地址")
+		}
+		if cmd.addr2.kind == addrStep {
+			return nil, unsupported("结束地址 first~step")
+		}
+	}
+	if addr != nil && addr.kind == addrLine && addr.line == 0 && (cmd.addr2 == nil || cmd.addr2.kind != addrRegex) {
+		return nil, fmt.Errorf("无效的行号 0，只能用于 0,/regex/")
+	}
+	p.skip(" \t")
+	if p.peek() == '!' {
+		cmd.negate = true
+		p.pos++
+		p.skip(" \t")
+	}
+
+	if p.pos >= len(p.src) {
+		return nil, fmt.Errorf("缺少命令")
+	}
+	cmd.name = p.src[p.pos]
+	p.pos++
+	switch cmd.name {
+	case 's':
+		if err := p.substitute(cmd); err != nil {
+			return nil, err
+		}
+	case 'd', 'p', '=':
+	case 'q':
+		p.skip(" \t")
+		start := p.pos
+		for p.pos < len(p.src) && p.src[p.pos] >= '0' && p.src[p.pos] <= '9' {
+			p.pos++
+		}
+		if p.pos > start {
+			cmd.exitCode, _ = strconv.Atoi(p.src[start:p.pos])
+		}
+	default:
+		return nil, unsupported("命令 %q", cmd.name)
+	}
+
+	// 命令之后只能是分隔符
+	p.skip(" \t")
+	if c := p.peek(); c != 0 && c != ';' && c != '\n' {
+		if cmd.name == 's' && strings.IndexByte("wWeEmM", c) >= 0 {
+			return nil, unsupported("s 命令的标志 %c", c)
+		}
+		if c == '}' {
+			return nil, unsupported("命令组 {}")
+		}
+		return nil, fmt.Errorf("命令 %c 后有多余的字符 %q", cmd.name, c)
+	}
+	return cmd, nil
+}
+
+// number 读取一个非负整数，没有数字时 ok 为假
+func (p *sedParser) number() (n int, ok bool) {
+	start := p.pos
+	for p.pos < len(p.src) && p.src[p.pos] >= '0' && p.src[p.pos] <= '9' {
+		p.pos++
+	}
+	n, err := strconv.Atoi(p.src[start:p.pos])
+	return n, err == nil
+}
+
+func (p *sedParser) address() (*sedAddr, error) {
+	c := p.peek()
+	switch {
+	case c >= '0' && c <= '9':
+		n, _ := p.number()
+		if p.peek() == '~' {
+			p.pos++
+			step, ok := p.number()
+			if !ok {
+				return nil, fmt.Errorf("~ 后缺少步长")
+			}
+			return &sedAddr{kind: addrStep, line: n, step: step}, nil
+		}
+		return &sedAddr{kind: addrLine, line: n}, nil
+	case c == '+' || c == '~':
+		// 只能作为范围的结束地址，调用方在开始地址之后才会遇到
+		p.pos++
+		n, ok := p.number()
+		if !ok {
+			return nil, fmt.Errorf("%c 后缺少行数", c)
+		}
+		if c == '+' {
+			return &sedAddr{kind: addrRelative, line: n}, nil
+		}
+		return &sedAddr{kind: addrMultiple, line: n}, nil
+	case c == '$':
+		p.pos++
+		return &sedAddr{kind: addrLast}, nil
+	case c == '/' || c == '\\':
+		if c == '\\' {
+			p.pos++
+		}
+		delim := p.peek()
+		if delim == 0 || delim == '\n' || delim == '\\' {
+			return nil, fmt.Errorf("无效的正则分隔符")
+		}
+		p.pos++
+		src, err := p.delimited(delim)
+		if err != nil {
+			return nil, err
+		}
+		flags := ""
+		if p.peek() == 'I' {
+			p.pos++
+			flags = "(?i)"
+		}
+		if p.peek() == 'M' {
+			return nil, unsupported("地址标志 M")
+		}
+		re, err := p.regex(src, flags)
+		if err != nil {
+			return nil, err
+		}
+		return &sedAddr{kind: addrRegex, re: re}, nil
+	}
+	return nil, nil
+}
+
+// delimited 读取到未转义的分隔符为止，\分隔符 转换为分隔符本身
+func (p *sedParser) delimited(delim byte) (string, error) {
+	var sb strings.Builder
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		if c == '\\' && p.pos+1 < len(p.src) {
+			if p.src[p.pos+1] == delim {
+				sb.WriteByte(delim)
+			} else {
+				sb.WriteString(p.src[p.pos : p.pos+2])
+			}
+			p.pos += 2
+			continue
+		}
+		if c == delim {
+			p.pos++
+			return sb.String(), nil
+		}
+		if c == '\n' {
+			break
+		}
+		sb.WriteByte(c)
+		p.pos++
+	}
+	return "", fmt.Errorf("缺少结束的分隔符 %c", delim)
+}
+
+// regex 编译正则，空正则表示复用上一个正则
+func (p *sedParser) regex(src, flags string) (*regexp.Regexp, error) {
+	if src == "" {
+		if p.lastRegex == nil {
+			return nil, fmt.Errorf("没有可复用的正则表达式")
+		}
+		return p.lastRegex, nil
+	}
+	if p.extended {
+		src = convertERE(src)
+	} else {
+		src = convertBRE(src)
+	}
+	// RE2 不支持的正则（如反向引用）交给系统中的 sed 处理
+	re, err := regexp.Compile(flags + src)
+	if err != nil {
+		return nil, unsupported("正则表达式: %v", err)
+	}
+	p.lastRegex = re
+	return re, nil
+}
+
+func (p *sedParser) substitute(cmd *sedCommand) error {
+	delim := p.peek()
+	if delim == 0 || delim == '\n' || delim == '\\' {
+		return fmt.Errorf("未终止的 s 命令")
+	}
+	p.pos++
+	pattern, err := p.delimited(delim)
+	if err != nil {
+		return fmt.Errorf("未终止的 s 命令")
+	}
+	replacement, err := p.delimited(delim)
+	if err != nil {
+		return fmt.Errorf("未终止的 s 命令")
+	}
+
+	flags := ""
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch {
+		case c == 'g':
+			cmd.global = true
+		case c == 'p':
+			cmd.print = true
+		case c == 'i' || c == 'I':
+			flags = "(?i)"
+		case c >= '1' && c <= '9':
+			start := p.pos
+			for p.pos+1 < len(p.src) && p.src[p.pos+1] >= '0' && p.src[p.pos+1] <= '9' {
+				p.pos++
+			}
+			cmd.occurrence, _ = strconv.Atoi(p.src[start : p.pos+1])
+		default:
+			goto done
+		}
+		p.pos++
+	}
+done:
+	if cmd.re, err = p.regex(pattern, flags); err != nil {
+		return err
+	}
+	cmd.replacement, err = parseReplacement(replacement, cmd.re.NumSubexp())
+	return err
+}
+
+// parseReplacement 解析替换文本：& 表示整个匹配，\1-\9 表示分组，\n 表示换行
+func parseReplacement(s string, groups int) ([]replacePart, error) {
+	var parts []replacePart
+	var lit strings.Builder
+	flush := func() {
+		if lit.Len() > 0 {
+			parts = append(parts, replacePart{literal: lit.String(), group: -1})
+			lit.Reset()
+		}
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '&':
+			flush()
+			parts = append(parts, replacePart{group: 0})
+		case c == '\\' && i+1 < len(s):
+			i++
+			switch n := s[i]; {
+			case n >= '1' && n <= '9':
+				group := int(n - '0')
+				if group > groups {
+					return nil, fmt.Errorf("替换文本引用了不存在的分组 \\%c", n)
+				}
+				flush()
+				parts = append(parts, replacePart{group: group})
+			case n == 'n':
+				lit.WriteByte('\n')
+			case n == 't':
+				lit.WriteByte('\t')
+			default:
+				lit.WriteByte(n)
+			}
+		default:
+			lit.WriteByte(c)
+		}
+	}
+	flush()
+	return parts, nil
+}
+
+// convertBRE 将 POSIX 基本正则转换为 RE2 语法：\( \) \{ \} \| \+ \? 是元字符，
+// 不带反斜杠的这些字符是普通字符；开头的 * 是普通字符
+func convertBRE(src string) string {
+	var sb strings.Builder
+	atStart := true
+	for i := 0; i < len(src); i++ {
+		c := src[i]
+		switch {
+		case c == '[':
+			end := bracketEnd(src, i)
+			sb.WriteString(src[i:end])
+			i = end - 1
+			atStart = false
+			continue
+		case c == '\\' && i+1 < len(src):
+			i++
+			switch n := src[i]; n {
+			case '(', ')', '{', '}', '|', '+', '?':
+				sb.WriteByte(n)
+				atStart = n == '(' || n == '|'
+				continue
+			case '<', '>':
+				sb.WriteString(`\b`)
+			default:
+				sb.WriteByte('\\')
+				sb.WriteByte(n)
+			}
+		case strings.IndexByte("(){}|+?", c) >= 0:
+			sb.WriteByte('\\')
+			sb.WriteByte(c)
+		case c == '*' && atStart:
+			sb.WriteString(`\*`)
+		default:
+			sb.WriteByte(c)
+		}
+		atStart = c == '^' && sb.Len() == 1
+	}
+	return sb.String()
+}
+
+// convertERE 将 POSIX 扩展正则转换为 RE2 语法，仅需处理单词边界
+func convertERE(src string) string {
+	return strings.NewReplacer(`\<`, `\b`, `\>`, `\b`).Replace(src)
+}
+
+// bracketEnd 返回从 start 开始的方括号表达式之后的位置，开头的 ] 是普通字符
+func bracketEnd(src string, start int) int {
+	i := start + 1
+	if i < len(src) && src[i] == '^' {
+		i++
+	}
+	if i < len(src) && src[i] == ']' {
+		i++
+	}
+	for i < len(src) {
+		if src[i] == '[' && i+1 < len(src) && (src[i+1] == ':' || src[i+1] == '.' || src[i+1] == '=') {
+			// [:alpha:] 等字符类
+			if end := strings.Index(src[i+2:], string(src[i+1])+"]"); end >= 0 {
+				i += end + 4
+				continue
+			}
+		}
+		if src[i] == ']' {
+			return i + 1
+		}
+		i++
+	}
+	return len(src)
+}
+
+// Sed 逐行执行 sed 脚本，将结果写入 output
+func Sed(input io.Reader, output io.Writer, script string, opts SedOptions) error {
+	prog, err := CompileSed(script, opts)
+	if err != nil {
+		return err
+	}
+	_, err = prog.Run(input, output)
+	return err
+}
+
+// SedString 对字符串执行 sed 脚本并返回输出
+func SedString(input, script string, opts SedOptions) (string, error) {
+	var out strings.Builder
+	err := Sed(strings.NewReader(input), &out, script, opts)
+	return out.String(), err
+}
+
+// Run 执行编译后的脚本，返回 q 命令指定的退出码
+func (prog *SedProgram) Run(input io.Reader, output io.Writer) (int, error) {
+	for _, cmd := range prog.commands {
+		// 0,/regex/ 的范围从第一行之前开始，第一行就可以结束范围
+		cmd.inRange = cmd.addr1 != nil && cmd.addr1.kind == addrLine && cmd.addr1.line == 0
+	}
+
+	r := bufio.NewReader(input)
+	w := bufio.NewWriter(output)
+	defer w.Flush()
+
+	// 预读一行以判断当前行是否为最后一行
+	readLine := func() (string, bool, bool, error) {
+		line, err := r.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return "", false, false, err
+		}
+		if line == "" && err == io.EOF {
+			return "", false, false, nil
+		}
+		newline := strings.HasSuffix(line, "\n")
+		return strings.TrimSuffix(line, "\n"), newline, true, nil
+	}
+
+	line, newline, ok, err := readLine()
+	if err != nil {
+		return 0, fmt.Errorf("读取输入失败: %v", err)
+	}
+	number := 0
+	for ok {
+		number++
+		next, nextNewline, nextOK, err := readLine()
+		if err != nil {
+			return 0, fmt.Errorf("读取输入失败: %v", err)
+		}
+
+		// 与 GNU sed 一致：输入最后一行没有换行时输出也不加换行
+		writeLine := func(s string, eol bool) {
+			w.WriteString(s)
+			if eol {
+				w.WriteByte('\n')
+			}
+		}
+
+		space := line
+		deleted, quit, exitCode := false, false, 0
+		for _, cmd := range prog.commands {
+			if !cmd.selects(space, number, !nextOK) {
+				continue
+			}
+			switch cmd.name {
+			case 's':
+				var replaced bool
+				space, replaced = cmd.substitute(space)
+				if replaced && cmd.print {
+					writeLine(space, true)
+				}
+			case 'd':
+				deleted = true
+			case 'p':
+				writeLine(space, true)
+			case '=':
+				fmt.Fprintf(w, "%d\n", number)
+			case 'q':
+				quit, exitCode = true, cmd.exitCode
+			}
+			if deleted || quit {
+				break
+			}
+		}
+
+		if !deleted && !prog.quiet {
+			writeLine(space, newline)
+		}
+		if quit {
+			return exitCode, w.Flush()
+		}
+		line, newline, ok = next, nextNewline, nextOK
+	}
+	return 0, w.Flush()
+}
+
+// selects 判断命令是否作用于当前行
+func (cmd *sedCommand) selects(space string, number int, last bool) bool {
+	var selected bool
+	switch {
+	case cmd.addr1 == nil:
+		selected = true
+	case cmd.addr2 == nil:
+		selected = cmd.addr1.matches(space, number, last)
+	case cmd.inRange:
+		selected = true
+		switch cmd.addr2.kind {
+		case addrLine:
+			cmd.inRange = number < cmd.addr2.line
+		case addrRelative:
+			cmd.inRange = number < cmd.rangeTo
+		case addrMultiple:
+			cmd.inRange = number%cmd.addr2.line != 0
+		default:
+			cmd.inRange = !cmd.addr2.matches(space, number, last)
+		}
+	case cmd.addr1.matches(space, number, last):
+		selected = true
+		// 结束地址为行号且不大于当前行时范围只包含一行；正则结束地址从下一行开始检查
+		switch cmd.addr2.kind {
+		case addrLine:
+			cmd.inRange = cmd.addr2.line > number
+		case addrRelative:
+			cmd.rangeTo = number + cmd.addr2.line
+			cmd.inRange = cmd.addr2.line > 0
+		case addrMultiple:
+			cmd.inRange = cmd.addr2.line > 0 && number%cmd.addr2.line != 0
+		case addrLast:
+			cmd.inRange = !last
+		default:
+			cmd.inRange = true
+		}
+	}
+	return selected != cmd.negate
+}
+
+func (a *sedAddr) matches(space string, number int, last bool) bool {
+	switch a.kind {
+	case addrLine:
+		return number == a.line
+	case addrLast:
+		return last
+	case addrStep:
+		if a.step <= 0 {
+			return number == a.line
+		}
+		return number >= a.line && (number-a.line)%a.step == 0
+	}
+	return a.re.MatchString(space)
+}
+
+// substitute 执行 s 命令，返回替换后的文本和是否发生了替换
+func (cmd *sedCommand) substitute(space string) (string, bool) {
+	matches := cmd.re.FindAllStringSubmatchIndex(space, -1)
+	occurrence := cmd.occurrence
+	if occurrence == 0 {
+		occurrence = 1
+	}
+
+	var sb strings.Builder
+	last := 0
+	replaced := false
+	for i, m := range matches {
+		n := i + 1
+		if n < occurrence || (n > occurrence && !cmd.global) {
+			continue
+		}
+		sb.WriteString(space[last:m[0]])
+		for _, part := range cmd.replacement {
+			if part.group < 0 {
+				sb.WriteString(part.literal)
+			} else if m[2*part.group] >= 0 {
+				sb.WriteString(space[m[2*part.group]:m[2*part.group+1]])
+			}
+		}
+		last = m[1]
+		replaced = true
+	}
+	if !replaced {
+		return space, false
+	}
+	sb.WriteString(space[last:])
+	return sb.String(), true
+}
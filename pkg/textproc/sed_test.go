@@ -0,0 +1,89 @@
+package textproc
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sedInput = "one fish\ntwo fish\nred fish\nblue fish\n"
+
+// 测试 sed 命令与地址
+func TestSed(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		opts   SedOptions
+		want   string
+	}{
+		{"全局替换", "s/fish/cat/g", SedOptions{}, "one cat\ntwo cat\nred cat\nblue cat\n"},
+		{"第N次匹配", "s/[a-z]/X/2", SedOptions{}, "oXe fish\ntXo fish\nrXd fish\nbXue fish\n"},
+		{"分组与&", `s/\(o\)\(ne\)/[\2\1]&/`, SedOptions{}, "[neo]one fish\ntwo fish\nred fish\nblue fish\n"},
+		{"扩展正则", "s/(t|r)(w|e)/<\\2>/", SedOptions{Extended: true}, "one fish\n<w>o fish\n<e>d fish\nblue fish\n"},
+		{"自定义分隔符", "s#fish#/#", SedOptions{}, "one /\ntwo /\nred /\nblue /\n"},
+		{"忽略大小写", "s/ONE/1/I", SedOptions{}, "1 fish\ntwo fish\nred fish\nblue fish\n"},
+		{"删除行号", "2d", SedOptions{}, "one fish\nred fish\nblue fish\n"},
+		{"删除最后一行", "$d", SedOptions{}, "one fish\ntwo fish\nred fish\n"},
+		{"正则范围", "/two/,/red/d", SedOptions{}, "one fish\nblue fish\n"},
+		{"取反", "/red/!d", SedOptions{}, "red fish\n"},
+		{"静默打印", "2,3p", SedOptions{Quiet: true}, "two fish\nred fish\n"},
+		{"替换后打印", "s/red/RED/p", SedOptions{Quiet: true}, "RED fish\n"},
+		{"退出", "2q", SedOptions{}, "one fish\ntwo fish\n"},
+		{"行号命令", "3=;3!d", SedOptions{}, "3\nred fish\n"},
+		{"多条命令", "s/one/1/;s/two/2/;3,$d", SedOptions{}, "1 fish\n2 fish\n"},
+		{"BRE 重复", `s/e\{2,\}/E/;s/b.*/+/`, SedOptions{}, "one fish\ntwo fish\nred fish\n+\n"},
+		{"步长地址", "1~2d", SedOptions{}, "two fish\nblue fish\n"},
+		{"从0开始的步长", "0~3p", SedOptions{Quiet: true}, "red fish\n"},
+		{"相对范围", "/two/,+1d", SedOptions{}, "one fish\nblue fish\n"},
+		{"倍数范围", "1,~2s/^/>/", SedOptions{}, ">one fish\n>two fish\nred fish\nblue fish\n"},
+		{"0,/regex/", "0,/fish/s/fish/cat/", SedOptions{}, "one cat\ntwo fish\nred fish\nblue fish\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SedString(sedInput, tt.script, tt.opts)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// 测试保留输入末尾缺少的换行
+func TestSedNoTrailingNewline(t *testing.T) {
+	got, err := SedString("a\nb", "s/b/c/", SedOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "a\nc", got)
+}
+
+// 测试 q 命令的退出码
+func TestSedQuitExitCode(t *testing.T) {
+	prog, err := CompileSed("/two/q5", SedOptions{})
+	require.NoError(t, err)
+
+	var out strings.Builder
+	code, err := prog.Run(strings.NewReader(sedInput), &out)
+	require.NoError(t, err)
+	assert.Equal(t, 5, code)
+	assert.Equal(t, "one fish\ntwo fish\n", out.String())
+}
+
+// 测试脚本语法错误
+func TestSedErrors(t *testing.T) {
+	for _, script := range []string{"s/abc", "0p", "0,5p", "+1p", "1~p", "s/a/\\1/", "1,d", "/a"} {
+		_, err := CompileSed(script, SedOptions{})
+		assert.Error(t, err, script)
+		assert.NotErrorIs(t, err, ErrUnsupported, script)
+	}
+}
+
+// 测试不支持的命令、标志和正则返回 ErrUnsupported，调用方据此改用系统中的 sed
+func TestSedUnsupported(t *testing.T) {
+	for _, script := range []string{
+		"s/a/b/;x", "y/abc/xyz/", "1i\\\nhead", "$a tail", "N;P;D", "/a/{p;d}",
+		"s/a/b/w out.txt", "s/a/b/e", `s/\(a\)\1/b/`, "/a/Mp", "1,3~2p",
+	} {
+		_, err := CompileSed(script, SedOptions{})
+		assert.ErrorIs(t, err, ErrUnsupported, script)
+	}
+}
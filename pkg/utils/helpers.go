@@ -3,15 +3,14 @@ package utils
 import (
 	"fmt"
 	"strings"
+
+	"github.com/Lzww0608/ClixGo/pkg/shell"
 )
 
-// SplitCommands 将命令字符串分割成命令数组
+// SplitCommands 将命令字符串按顶层分号分割成命令数组，
+// 引号、转义和命令替换中的分号不会被分割
 func SplitCommands(commandString string) []string {
-	commands := strings.Split(commandString, ";")
-	for i := range commands {
-		commands[i] = strings.TrimSpace(commands[i])
-	}
-	return commands
+	return shell.SplitStatements(commandString)
 }
 
 // ValidateCommands 验证命令数组是否有效
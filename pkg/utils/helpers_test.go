@@ -34,7 +34,7 @@ func TestSplitCommands(t *testing.T) {
 		{
 			name:     "命令中间有多个空格",
 			input:    "ls   -la;cd   /home",
-			expected: []string{"ls   -la", "cd   /home"},
+			expected: []string{"ls -la", "cd /home"},
 		},
 		{
 			name:     "包含空命令",
@@ -44,7 +44,12 @@ func TestSplitCommands(t *testing.T) {
 		{
 			name:     "以分号结尾",
 			input:    "ls;cd;",
-			expected: []string{"ls", "cd", ""},
+			expected: []string{"ls", "cd"},
+		},
+		{
+			name:     "复合命令",
+			input:    "for i in 1 2; do echo $i; done; pwd",
+			expected: []string{"for i in 1 2; do echo $i; done", "pwd"},
 		},
 		{
 			name:     "以分号开头",
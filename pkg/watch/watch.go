@@ -0,0 +1,395 @@
+package watch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Lzww0608/ClixGo/pkg/commands"
+	"github.com/Lzww0608/ClixGo/pkg/logger"
+	"github.com/fatih/color"
+	"github.com/fsnotify/fsnotify"
+	"go.uber.org/zap"
+)
+
+// DefaultDebounce 默认的事件合并窗口
+const DefaultDebounce = 300 * time.Millisecond
+
+// 清屏并将光标移到左上角
+const clearScreen = "\033[H\033[2J"
+
+// DefaultIgnore 默认忽略的目录和文件
+var DefaultIgnore = []string{".git", ".hg", ".svn", "node_modules", ".idea", ".vscode", "*.swp", "*~", ".#*"}
+
+// Options 监视选项
+type Options struct {
+	Paths       []string      // 监视的文件或目录，目录会递归监视，为空时监视当前目录
+	Patterns    []string      // 触发执行的文件模式，为空时任意文件变更都会触发
+	Ignore      []string      // 忽略的文件或目录模式，追加在 DefaultIgnore 之后
+	Debounce    time.Duration // 最后一次变更后等待的时间，期间的变更合并为一次执行，0 使用 DefaultDebounce
+	Spec        commands.Spec // 每次执行的命令
+	ClearScreen bool          // 每次执行前清屏
+	SkipInitial bool          // 启动时不立即执行一次
+	Output      io.Writer     // 变更提示和执行结果的输出，为空时丢弃
+	OnResult    func(Run)     // 每次执行结束后调用
+}
+
+// Run 一次执行的结果
+type Run struct {
+	Number   int              // 执行序号，从1开始
+	Trigger  []string         // 触发本次执行的文件，启动时的执行为空
+	Result   *commands.Result // 命令启动失败时为空
+	Err      error
+	Canceled bool // 因新的变更被终止
+}
+
+// Summary 监视期间的执行统计
+type Summary struct {
+	Runs     int
+	Passed   int
+	Failed   int
+	Canceled int
+	Last     *Run
+}
+
+// String 返回统计的文字描述
+func (s Summary) String() string {
+	return fmt.Sprintf("共执行 %d 次：成功 %d，失败 %d，中断 %d", s.Runs, s.Passed, s.Failed, s.Canceled)
+}
+
+// Watch 监视文件变更并重新执行命令，直到 ctx 结束。
+// 变更在 Debounce 时间内合并为一次执行；上一次执行尚未结束时先终止它再重新执行。
+// 开发服务器等长期运行的命令很常见，因此默认不使用 commands 的默认超时；
+// Linux 上命令总是在独立的进程组中运行，终止时同时结束它启动的所有子进程
+func Watch(ctx context.Context, opts Options) (*Summary, error) {
+	if opts.Spec.Command == "" && len(opts.Spec.Args) == 0 {
+		return nil, fmt.Errorf("未指定要执行的命令")
+	}
+	if opts.Spec.Timeout == 0 {
+		opts.Spec.Timeout = -1
+	}
+	if runtime.GOOS == "linux" {
+		opts.Spec.Sandbox.ProcessGroup = true
+	}
+	if len(opts.Paths) == 0 {
+		opts.Paths = []string{"."}
+	}
+	if opts.Debounce <= 0 {
+		opts.Debounce = DefaultDebounce
+	}
+	if opts.Output == nil {
+		opts.Output = io.Discard
+	}
+	opts.Ignore = append(append([]string{}, DefaultIgnore...), opts.Ignore...)
+	for _, pattern := range append(append([]string{}, opts.Patterns...), opts.Ignore...) {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("无效的文件模式 %q: %v", pattern, err)
+		}
+	}
+
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("创建文件监视器失败: %v", err)
+	}
+	defer fsw.Close()
+
+	w := &watcher{opts: opts, fsw: fsw, summary: &Summary{}}
+	for _, path := range opts.Paths {
+		if err := w.add(path); err != nil {
+			return nil, err
+		}
+	}
+	logger.Info("开始监视文件变更",
+		zap.Strings("paths", opts.Paths),
+		zap.Strings("patterns", opts.Patterns))
+
+	return w.loop(ctx)
+}
+
+// watcher 保存一次监视的状态
+type watcher struct {
+	opts    Options
+	fsw     *fsnotify.Watcher
+	roots   []string
+	summary *Summary
+
+	mu      sync.Mutex
+	cancel  context.CancelFunc // 终止正在进行的执行
+	done    chan struct{}      // 正在进行的执行结束时关闭
+	running bool
+}
+
+// add 添加监视路径，目录递归添加其中未被忽略的子目录
+func (w *watcher) add(path string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		return fmt.Errorf("无法监视 %s: %v", path, err)
+	}
+	w.roots = append(w.roots, abs)
+	if !info.IsDir() {
+		// 监视文件所在目录，编辑器保存时常通过重命名替换文件
+		return w.fsw.Add(filepath.Dir(abs))
+	}
+	return w.addDir(abs)
+}
+
+func (w *watcher) addDir(dir string) error {
+	return filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			// 子目录在遍历过程中被删除或无权访问时跳过
+			if path != dir {
+				return nil
+			}
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != dir && w.ignored(path) {
+			return filepath.SkipDir
+		}
+		if err := w.fsw.Add(path); err != nil {
+			return fmt.Errorf("无法监视 %s: %v", path, err)
+		}
+		return nil
+	})
+}
+
+// relative 返回相对于所属监视根路径的路径
+func (w *watcher) relative(path string) string {
+	for _, root := range w.roots {
+		if rel, err := filepath.Rel(root, path); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			if rel == "." {
+				return filepath.Base(path)
+			}
+			return rel
+		}
+	}
+	return path
+}
+
+// matchAny 模式不含路径分隔符时匹配文件名及路径中的每一级目录名，否则匹配相对路径
+func matchAny(patterns []string, rel string, dirs bool) bool {
+	rel = filepath.ToSlash(rel)
+	parts := strings.Split(rel, "/")
+	if !dirs {
+		parts = parts[len(parts)-1:]
+	}
+	for _, pattern := range patterns {
+		if strings.Contains(pattern, "/") {
+			if ok, _ := filepath.Match(pattern, rel); ok {
+				return true
+			}
+			continue
+		}
+		for _, part := range parts {
+			if ok, _ := filepath.Match(pattern, part); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (w *watcher) ignored(path string) bool {
+	return matchAny(w.opts.Ignore, w.relative(path), true)
+}
+
+// relevant 判断事件是否应触发执行
+func (w *watcher) relevant(event fsnotify.Event) bool {
+	if event.Op == fsnotify.Chmod || w.ignored(event.Name) {
+		return false
+	}
+	// 只监视单个文件时忽略同目录下的其他文件
+	rel := w.relative(event.Name)
+	if filepath.IsAbs(rel) {
+		return false
+	}
+	return len(w.opts.Patterns) == 0 || matchAny(w.opts.Patterns, rel, false)
+}
+
+func (w *watcher) loop(ctx context.Context) (*Summary, error) {
+	var pending []string
+	timer := time.NewTimer(w.opts.Debounce)
+	if w.opts.SkipInitial {
+		stopTimer(timer)
+	}
+	number := 0
+
+	for {
+		select {
+		case <-ctx.Done():
+			w.stop()
+			return w.summary, nil
+
+		case event, ok := <-w.fsw.Events:
+			if !ok {
+				w.stop()
+				return w.summary, nil
+			}
+			if event.Has(fsnotify.Create) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() && !w.ignored(event.Name) {
+					if err := w.addDir(event.Name); err != nil {
+						logger.Error("添加监视目录失败", zap.Error(err))
+					}
+				}
+			}
+			if !w.relevant(event) {
+				continue
+			}
+			rel := w.relative(event.Name)
+			if !contains(pending, rel) {
+				pending = append(pending, rel)
+			}
+			resetTimer(timer, w.opts.Debounce)
+
+		case err, ok := <-w.fsw.Errors:
+			if !ok {
+				w.stop()
+				return w.summary, nil
+			}
+			logger.Error("文件监视出错", zap.Error(err))
+
+		case <-timer.C:
+			if w.stop() {
+				fmt.Fprintln(w.opts.Output, color.YellowString("检测到新的变更，已终止上一次执行"))
+			}
+			number++
+			w.start(ctx, number, pending)
+			pending = nil
+		}
+	}
+}
+
+// stopTimer 停止计时器并取出已经触发但还没有读取的值。go.mod 声明的 Go 版本低于 1.23，
+// 计时器沿用旧的语义，Stop 不会清空通道
+func stopTimer(timer *time.Timer) {
+	if !timer.Stop() {
+		select {
+		case <-timer.C:
+		default:
+		}
+	}
+}
+
+// resetTimer 重新开始计时，避免残留的触发值使下一次 <-timer.C 立即返回而不再合并变更
+func resetTimer(timer *time.Timer, d time.Duration) {
+	stopTimer(timer)
+	timer.Reset(d)
+}
+
+// start 在后台开始一次执行，结束时记录结果
+func (w *watcher) start(ctx context.Context, number int, trigger []string) {
+	if w.opts.ClearScreen {
+		fmt.Fprint(w.opts.Output, clearScreen)
+	}
+	if len(trigger) > 0 {
+		fmt.Fprintf(w.opts.Output, "%s %s\n", color.CyanString("检测到变更:"), strings.Join(trigger, ", "))
+	}
+	command := w.opts.Spec.Command
+	if len(w.opts.Spec.Args) > 0 {
+		command = strings.Join(w.opts.Spec.Args, " ")
+	}
+	fmt.Fprintf(w.opts.Output, "%s %s\n", color.CyanString(fmt.Sprintf("[#%d] 执行:", number)), command)
+
+	runCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	w.mu.Lock()
+	w.cancel, w.done, w.running = cancel, done, true
+	w.mu.Unlock()
+
+	go func() {
+		defer close(done)
+		defer cancel()
+		result, err := commands.Run(runCtx, w.opts.Spec)
+
+		w.mu.Lock()
+		if w.done == done {
+			w.running = false
+		}
+		w.mu.Unlock()
+		// 退出监视时被终止的执行不计入统计
+		if ctx.Err() != nil {
+			return
+		}
+		w.record(Run{
+			Number:   number,
+			Trigger:  trigger,
+			Result:   result,
+			Err:      err,
+			Canceled: err != nil && errors.Is(runCtx.Err(), context.Canceled),
+		})
+	}()
+}
+
+// stop 终止正在进行的执行并等待其结束，返回是否有执行被终止
+func (w *watcher) stop() bool {
+	w.mu.Lock()
+	cancel, done, running := w.cancel, w.done, w.running
+	w.cancel, w.done, w.running = nil, nil, false
+	w.mu.Unlock()
+	if cancel == nil {
+		return false
+	}
+	cancel()
+	<-done
+	return running
+}
+
+// record 统计并输出一次执行的结果
+func (w *watcher) record(run Run) {
+	w.mu.Lock()
+	s := w.summary
+	s.Runs++
+	switch {
+	case run.Canceled:
+		s.Canceled++
+	case run.Err == nil:
+		s.Passed++
+	default:
+		s.Failed++
+	}
+	s.Last = &run
+	summary := *s
+	w.mu.Unlock()
+
+	var status string
+	switch {
+	case run.Canceled:
+		status = color.YellowString("■ 已中断")
+	case run.Err == nil:
+		status = color.GreenString("✓ 成功")
+	case run.Result != nil:
+		status = color.RedString("✗ 失败(退出码 %d)", run.Result.ExitCode)
+	default:
+		status = color.RedString("✗ 失败: %v", run.Err)
+	}
+	if run.Result != nil {
+		status += fmt.Sprintf(" 耗时 %s", run.Result.Duration.Round(time.Millisecond))
+	}
+	fmt.Fprintf(w.opts.Output, "[#%d] %s  |  %s\n", run.Number, status, summary)
+
+	if w.opts.OnResult != nil {
+		w.opts.OnResult(run)
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,218 @@
+package watch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Lzww0608/ClixGo/pkg/commands"
+	"github.com/Lzww0608/ClixGo/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	// 在进程组中执行脚本时重新执行测试程序作为引导进程
+	commands.SandboxInit()
+	os.Exit(m.Run())
+}
+
+func setupTest(t *testing.T) string {
+	dir := t.TempDir()
+	logger.SetLogPath(filepath.Join(t.TempDir(), "test.log"))
+	logger.InitLogger()
+	return dir
+}
+
+// 测试文件模式匹配
+func TestMatchAny(t *testing.T) {
+	assert.True(t, matchAny([]string{"*.go"}, "pkg/a.go", false))
+	assert.False(t, matchAny([]string{"*.go"}, "pkg/a.txt", false))
+	assert.True(t, matchAny([]string{"pkg/*.go"}, "pkg/a.go", false))
+	assert.False(t, matchAny([]string{"pkg/*.go"}, "cmd/a.go", false))
+	// 忽略模式匹配路径中的任意一级目录
+	assert.True(t, matchAny([]string{"node_modules"}, "web/node_modules/x.js", true))
+	assert.False(t, matchAny([]string{"node_modules"}, "web/node_modules/x.js", false))
+}
+
+// 测试重置已经触发但没有读取的计时器时丢弃残留的触发值（go.mod 声明的版本低于 1.23 时
+// 工具链沿用旧的计时器语义）
+func TestResetTimer(t *testing.T) {
+	timer := time.NewTimer(time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+
+	resetTimer(timer, time.Hour)
+	select {
+	case <-timer.C:
+		t.Fatal("重置后计时器不应立即触发")
+	case <-time.After(50 * time.Millisecond):
+	}
+	stopTimer(timer)
+}
+
+// collector 收集执行结果
+type collector struct {
+	mu   sync.Mutex
+	runs []Run
+}
+
+func (c *collector) add(run Run) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.runs = append(c.runs, run)
+}
+
+func (c *collector) get() []Run {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Run(nil), c.runs...)
+}
+
+// start 在后台开始监视，返回停止函数
+func start(t *testing.T, opts Options) (*collector, func() *Summary) {
+	c := &collector{}
+	opts.OnResult = c.add
+	opts.Debounce = 50 * time.Millisecond
+	opts.Spec.NoHistory = true
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan *Summary)
+	go func() {
+		summary, err := Watch(ctx, opts)
+		assert.NoError(t, err)
+		done <- summary
+	}()
+	return c, func() *Summary {
+		cancel()
+		return <-done
+	}
+}
+
+// 测试启动时执行以及匹配的文件变更触发执行
+func TestWatchRunsOnChange(t *testing.T) {
+	dir := setupTest(t)
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "sub"), 0755))
+
+	c, stop := start(t, Options{
+		Paths:    []string{dir},
+		Patterns: []string{"*.go"},
+		Spec:     commands.Spec{Args: []string{"true"}},
+	})
+	require.Eventually(t, func() bool { return len(c.get()) == 1 }, 5*time.Second, 10*time.Millisecond)
+
+	// 不匹配的文件不触发执行
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("x"), 0644))
+	time.Sleep(200 * time.Millisecond)
+	assert.Len(t, c.get(), 1)
+
+	// 连续的变更合并为一次执行
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.go"), []byte("package a"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "sub", "b.go"), []byte("package b"), 0644))
+	require.Eventually(t, func() bool { return len(c.get()) == 2 }, 5*time.Second, 10*time.Millisecond)
+	runs := c.get()
+	assert.ElementsMatch(t, []string{"a.go", filepath.Join("sub", "b.go")}, runs[1].Trigger)
+	assert.NoError(t, runs[1].Err)
+
+	summary := stop()
+	assert.Equal(t, 2, summary.Runs)
+	assert.Equal(t, 2, summary.Passed)
+}
+
+// 测试新的变更终止仍在进行的执行
+func TestWatchCancelsPreviousRun(t *testing.T) {
+	dir := setupTest(t)
+
+	c, stop := start(t, Options{
+		Paths: []string{dir},
+		Spec:  commands.Spec{Args: []string{"sleep", "10"}},
+	})
+
+	// 启动时的执行仍在进行时修改文件
+	time.Sleep(300 * time.Millisecond)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a"), []byte("1"), 0644))
+	require.Eventually(t, func() bool { return len(c.get()) == 1 }, 5*time.Second, 10*time.Millisecond)
+
+	runs := c.get()
+	assert.True(t, runs[0].Canceled)
+	assert.Equal(t, 1, runs[0].Number)
+
+	// 退出时被终止的执行不计入统计
+	summary := stop()
+	assert.Equal(t, 1, summary.Runs)
+	assert.Equal(t, 1, summary.Canceled)
+}
+
+// 测试默认不使用 commands 的默认超时
+func TestWatchNoDefaultTimeout(t *testing.T) {
+	dir := setupTest(t)
+	defer commands.SetDefaultTimeout(commands.DefaultTimeout())
+	commands.SetDefaultTimeout(100 * time.Millisecond)
+
+	c, stop := start(t, Options{
+		Paths: []string{dir},
+		Spec:  commands.Spec{Args: []string{"sleep", "0.5"}},
+	})
+	require.Eventually(t, func() bool { return len(c.get()) == 1 }, 5*time.Second, 10*time.Millisecond)
+	run := c.get()[0]
+	assert.NoError(t, run.Err)
+	assert.False(t, run.Result.TimedOut)
+	stop()
+}
+
+// 测试终止执行时命令在后台启动的子进程随进程组一起结束
+func TestWatchKillsProcessGroup(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("进程组仅在 Linux 上使用")
+	}
+	dir := setupTest(t)
+
+	pidFile := filepath.Join(t.TempDir(), "pid")
+	_, stop := start(t, Options{
+		Paths: []string{dir},
+		Spec:  commands.Spec{Command: "sh -c 'sleep 100 & echo $! > " + pidFile + "; wait'", Shell: true},
+	})
+	var pid string
+	require.Eventually(t, func() bool {
+		data, err := os.ReadFile(pidFile)
+		pid = strings.TrimSpace(string(data))
+		return err == nil && pid != ""
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// 后台子进程持有输出管道，未被终止时 stop 会一直等到它退出
+	begin := time.Now()
+	stop()
+	assert.Less(t, time.Since(begin), 10*time.Second)
+
+	// 子进程已退出：/proc 中不存在或只剩等待回收的僵尸进程
+	assert.Eventually(t, func() bool {
+		stat, err := os.ReadFile(filepath.Join("/proc", pid, "stat"))
+		return err != nil || strings.Contains(string(stat), ") Z ")
+	}, 5*time.Second, 10*time.Millisecond, "后台子进程 %s 仍在运行", pid)
+}
+
+// 测试失败的执行计入统计
+func TestWatchFailure(t *testing.T) {
+	dir := setupTest(t)
+
+	c, stop := start(t, Options{
+		Paths: []string{dir},
+		Spec:  commands.Spec{Command: "exit 3", Shell: true},
+	})
+	require.Eventually(t, func() bool { return len(c.get()) == 1 }, 5*time.Second, 10*time.Millisecond)
+
+	run := c.get()[0]
+	assert.Error(t, run.Err)
+	require.NotNil(t, run.Result)
+	assert.Equal(t, 3, run.Result.ExitCode)
+	assert.Equal(t, 1, stop().Failed)
+
+	_, err := Watch(context.Background(), Options{Paths: []string{dir}})
+	assert.Error(t, err)
+	_, err = Watch(context.Background(), Options{Paths: []string{filepath.Join(dir, "missing")}, Spec: commands.Spec{Command: "true"}})
+	assert.Error(t, err)
+}
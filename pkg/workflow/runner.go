@@ -0,0 +1,367 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Lzww0608/ClixGo/pkg/alias"
+	"github.com/Lzww0608/ClixGo/pkg/commands"
+	"github.com/Lzww0608/ClixGo/pkg/engine"
+	"github.com/Lzww0608/ClixGo/pkg/history"
+	"github.com/Lzww0608/ClixGo/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// 步骤状态
+const (
+	StatePending engine.State = "pending"
+	StateRunning engine.State = "running"
+	StateSuccess engine.State = "success"
+	StateFailed  engine.State = "failed"
+	StateSkipped engine.State = "skipped"
+)
+
+// 驱动步骤状态转换的事件
+const (
+	eventStart   engine.Event = "start"
+	eventRetry   engine.Event = "retry"
+	eventSucceed engine.Event = "succeed"
+	eventFail    engine.Event = "fail"
+	eventSkip    engine.Event = "skip"
+)
+
+// Options 工作流执行选项
+type Options struct {
+	From        string    // 从该步骤开始执行，只运行它及其下游步骤
+	Only        []string  // 只执行这些步骤
+	MaxParallel int       // 最大并发步骤数，非0时覆盖工作流中的设置
+	Output      io.Writer // 步骤输出按行加上步骤名前缀实时写入，为空时丢弃
+	Color       bool      // 前缀使用颜色区分
+}
+
+// StepResult 单个步骤的执行结果
+type StepResult struct {
+	Name     string         `json:"name"`
+	Status   engine.State   `json:"status"`
+	Reason   string         `json:"reason,omitempty"`
+	Attempts int            `json:"attempts"`
+	ExitCode int            `json:"exit_code"`
+	Duration time.Duration  `json:"duration"`
+	States   []engine.State `json:"states"` // 状态机经历的状态
+}
+
+// Report 工作流执行报告，步骤按定义顺序排列
+type Report struct {
+	Workflow string        `json:"workflow"`
+	Steps    []StepResult  `json:"steps"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Failed 返回失败的步骤名称
+func (r *Report) Failed() []string {
+	var failed []string
+	for _, step := range r.Steps {
+		if step.Status == StateFailed {
+			failed = append(failed, step.Name)
+		}
+	}
+	return failed
+}
+
+// stepRun 步骤的运行时状态
+type stepRun struct {
+	step    *Step
+	index   int
+	machine *engine.StateMachine
+	result  StepResult
+	blocked bool // 失败或因上游失败被跳过，下游步骤不能执行
+	done    chan struct{}
+}
+
+// runner 一次工作流执行
+type runner struct {
+	wf    *Workflow
+	opts  Options
+	steps map[string]*stepRun
+	sem   chan struct{}
+	outMu sync.Mutex
+}
+
+// Run 按依赖关系以最大并行度执行工作流：每个步骤在其依赖全部完成后立即启动，
+// 依赖失败的步骤及其下游被跳过，不相关的分支继续执行。每个步骤的状态由
+// engine.StateMachine 驱动，执行结果记录到命令历史。存在失败步骤时返回错误
+func Run(ctx context.Context, wf *Workflow, opts Options) (*Report, error) {
+	if err := wf.Validate(); err != nil {
+		return nil, err
+	}
+	selected, err := wf.Select(opts.From, opts.Only)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &runner{wf: wf, opts: opts, steps: make(map[string]*stepRun, len(wf.Steps))}
+	maxParallel := wf.MaxParallel
+	if opts.MaxParallel > 0 {
+		maxParallel = opts.MaxParallel
+	}
+	if maxParallel > 0 {
+		r.sem = make(chan struct{}, maxParallel)
+	}
+
+	for i, step := range wf.Steps {
+		r.steps[step.Name] = &stepRun{
+			step:    step,
+			index:   i,
+			machine: newStepMachine(),
+			result:  StepResult{Name: step.Name},
+			done:    make(chan struct{}),
+		}
+	}
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for _, step := range wf.Steps {
+		run := r.steps[step.Name]
+		if !selected[step.Name] {
+			r.skip(ctx, run, "未选中", false)
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.execute(ctx, run)
+		}()
+	}
+	wg.Wait()
+
+	report := &Report{Workflow: wf.Name, Duration: time.Since(start)}
+	for _, step := range wf.Steps {
+		run := r.steps[step.Name]
+		run.result.Status = run.machine.GetCurrentState()
+		run.result.States = run.machine.GetHistory()
+		report.Steps = append(report.Steps, run.result)
+	}
+
+	if failed := report.Failed(); len(failed) > 0 {
+		return report, fmt.Errorf("工作流步骤执行失败: %s", strings.Join(failed, ", "))
+	}
+	if err := ctx.Err(); err != nil {
+		return report, err
+	}
+	return report, nil
+}
+
+// StepPlan 步骤的执行计划
+type StepPlan struct {
+	Name     string           `json:"name"`
+	Needs    []string         `json:"needs,omitempty"`
+	If       string           `json:"if,omitempty"`
+	Retries  int              `json:"retries,omitempty"`
+	Commands []*commands.Plan `json:"commands"`
+}
+
+// Explain 按执行顺序返回选中步骤的执行计划，同一层的步骤可以并行执行。
+// 计划与 Run 使用相同的别名展开、环境变量、工作目录和超时时间，但不执行任何命令，
+// if 条件也不会求值
+func Explain(wf *Workflow, opts Options) ([][]*StepPlan, error) {
+	if err := wf.Validate(); err != nil {
+		return nil, err
+	}
+	selected, err := wf.Select(opts.From, opts.Only)
+	if err != nil {
+		return nil, err
+	}
+
+	var levels [][]*StepPlan
+	for _, names := range wf.Order() {
+		var level []*StepPlan
+		for _, name := range names {
+			if !selected[name] {
+				continue
+			}
+			step := wf.Step(name)
+			timeout, _ := parseDuration(step.Timeout)
+			plan := &StepPlan{Name: step.Name, Needs: step.Needs, If: step.If, Retries: step.Retries}
+			for _, command := range step.commands() {
+				plan.Commands = append(plan.Commands, commands.ExplainCommand(command, commands.Spec{
+					Env:     wf.environ(step),
+					Dir:     step.Dir,
+					Timeout: timeout,
+				}))
+			}
+			level = append(level, plan)
+		}
+		if len(level) > 0 {
+			levels = append(levels, level)
+		}
+	}
+	return levels, nil
+}
+
+// execute 等待依赖完成后执行步骤
+func (r *runner) execute(ctx context.Context, run *stepRun) {
+	for _, need := range run.step.Needs {
+		dep := r.steps[need]
+		select {
+		case <-dep.done:
+		case <-ctx.Done():
+		}
+	}
+	if ctx.Err() != nil {
+		r.skip(ctx, run, "已取消", true)
+		return
+	}
+	for _, need := range run.step.Needs {
+		if r.steps[need].blocked {
+			r.skip(ctx, run, fmt.Sprintf("依赖步骤 %s 未成功", need), true)
+			return
+		}
+	}
+
+	if r.sem != nil {
+		select {
+		case r.sem <- struct{}{}:
+			defer func() { <-r.sem }()
+		case <-ctx.Done():
+			r.skip(ctx, run, "已取消", true)
+			return
+		}
+	}
+
+	if run.step.If != "" && !r.conditionMet(ctx, run.step) {
+		r.skip(ctx, run, "条件不满足", false)
+		return
+	}
+
+	defer close(run.done)
+	run.machine.ProcessEvent(ctx, eventStart, nil)
+
+	retryDelay, _ := parseDuration(run.step.RetryDelay)
+	startTime := time.Now()
+	var output strings.Builder
+	var err error
+	for attempt := 0; attempt <= run.step.Retries; attempt++ {
+		if attempt > 0 {
+			logger.Info("重试工作流步骤", zap.String("step", run.step.Name), zap.Int("attempt", attempt+1))
+			select {
+			case <-time.After(retryDelay):
+			case <-ctx.Done():
+			}
+			if ctx.Err() != nil {
+				break
+			}
+			run.machine.ProcessEvent(ctx, eventRetry, nil)
+		}
+		run.result.Attempts = attempt + 1
+		run.result.ExitCode, err = r.runCommands(ctx, run, &output)
+		if err == nil {
+			break
+		}
+	}
+	run.result.Duration = time.Since(startTime)
+
+	if err != nil {
+		run.result.Reason = err.Error()
+		run.blocked = true
+		run.machine.ProcessEvent(ctx, eventFail, nil)
+	} else {
+		run.machine.ProcessEvent(ctx, eventSucceed, nil)
+	}
+	r.record(run, startTime, output.String(), err)
+}
+
+// runCommands 依次执行步骤中的命令，遇到失败立即停止
+func (r *runner) runCommands(ctx context.Context, run *stepRun, output *strings.Builder) (int, error) {
+	timeout, _ := parseDuration(run.step.Timeout)
+	tag := commands.ColorTag("["+run.step.Name+"]", run.index, r.opts.Color) + " "
+	out := commands.NewPrefixWriter(r.opts.Output, &r.outMu, tag)
+	defer out.Flush()
+
+	for _, command := range run.step.commands() {
+		expanded, err := alias.Expand(command)
+		if err != nil {
+			return -1, err
+		}
+		result, err := commands.Run(ctx, commands.Spec{
+			Command:   expanded,
+			Env:       r.wf.environ(run.step),
+			Dir:       run.step.Dir,
+			Timeout:   timeout,
+			Stdout:    out,
+			Stderr:    out,
+			NoHistory: true,
+		})
+		if result != nil {
+			output.WriteString(result.Output)
+		}
+		if err != nil {
+			exitCode := -1
+			if result != nil {
+				exitCode = result.ExitCode
+			}
+			return exitCode, fmt.Errorf("命令 %q 执行失败: %v", command, err)
+		}
+	}
+	return 0, nil
+}
+
+// conditionMet 使用进程内解释器求值步骤的 if 条件
+func (r *runner) conditionMet(ctx context.Context, step *Step) bool {
+	_, err := commands.Run(ctx, commands.Spec{
+		Command:   step.If,
+		Shell:     true,
+		Env:       r.wf.environ(step),
+		Dir:       step.Dir,
+		NoHistory: true,
+	})
+	return err == nil
+}
+
+// skip 将步骤标记为跳过。blocked 为真表示因失败或取消跳过，下游步骤同样不会执行
+func (r *runner) skip(ctx context.Context, run *stepRun, reason string, blocked bool) {
+	run.result.Reason = reason
+	run.blocked = blocked
+	run.machine.ProcessEvent(ctx, eventSkip, nil)
+	close(run.done)
+}
+
+// record 把步骤执行结果写入命令历史
+func (r *runner) record(run *stepRun, startTime time.Time, output string, err error) {
+	name := run.step.Name
+	if r.wf.Name != "" {
+		name = r.wf.Name + "/" + name
+	}
+
+	cmdHistory := &history.CommandHistory{
+		Command:   fmt.Sprintf("[workflow %s] %s", name, strings.Join(run.step.commands(), "; ")),
+		Status:    "success",
+		Output:    output,
+		StartTime: startTime,
+		EndTime:   startTime.Add(run.result.Duration),
+		Duration:  run.result.Duration.String(),
+		ExitCode:  run.result.ExitCode,
+		Dir:       run.step.Dir,
+	}
+	if err != nil {
+		cmdHistory.Status = "failed"
+	}
+	if err := history.SaveHistory(cmdHistory); err != nil {
+		logger.Error("保存命令历史失败", zap.Error(err))
+	}
+}
+
+// newStepMachine 创建描述步骤生命周期的状态机
+func newStepMachine() *engine.StateMachine {
+	sm := engine.NewStateMachine(StatePending, 0)
+	noop := func(context.Context, interface{}) error { return nil }
+	sm.AddTransition(StatePending, eventStart, StateRunning, noop)
+	sm.AddTransition(StatePending, eventSkip, StateSkipped, noop)
+	sm.AddTransition(StateRunning, eventRetry, StateRunning, noop)
+	sm.AddTransition(StateRunning, eventSucceed, StateSuccess, noop)
+	sm.AddTransition(StateRunning, eventFail, StateFailed, noop)
+	return sm
+}
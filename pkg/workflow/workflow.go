@@ -0,0 +1,276 @@
+package workflow
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Workflow 工作流定义，由若干通过 needs 声明依赖关系的步骤组成
+type Workflow struct {
+	Name        string            `yaml:"name"`
+	Env         map[string]string `yaml:"env"`          // 所有步骤共享的环境变量
+	MaxParallel int               `yaml:"max_parallel"` // 最大并发步骤数，0 表示不限制
+	Steps       []*Step           `yaml:"steps"`
+}
+
+// Step 工作流中的单个步骤
+type Step struct {
+	Name       string            `yaml:"name"`
+	Needs      []string          `yaml:"needs"`       // 依赖的步骤，全部成功后才会执行
+	Run        string            `yaml:"run"`         // 要执行的命令或多行脚本
+	Commands   []string          `yaml:"commands"`    // 依次执行的多个命令，在 run 之后执行
+	Env        map[string]string `yaml:"env"`         // 步骤环境变量，覆盖工作流级别的同名变量
+	Dir        string            `yaml:"dir"`         // 工作目录
+	If         string            `yaml:"if"`          // 执行条件，shell 命令退出码为0时才执行
+	Retries    int               `yaml:"retries"`     // 失败后的重试次数
+	RetryDelay string            `yaml:"retry_delay"` // 重试间隔，如 "2s"
+	Timeout    string            `yaml:"timeout"`     // 单个命令的超时时间，如 "5m"
+}
+
+// Load 从 YAML 文件加载工作流并校验
+func Load(path string) (*Workflow, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取工作流文件失败: %v", err)
+	}
+	return Parse(data)
+}
+
+// Parse 解析 YAML 格式的工作流定义并校验
+func Parse(data []byte) (*Workflow, error) {
+	var wf Workflow
+	if err := yaml.Unmarshal(data, &wf); err != nil {
+		return nil, fmt.Errorf("解析工作流失败: %v", err)
+	}
+	if err := wf.Validate(); err != nil {
+		return nil, err
+	}
+	return &wf, nil
+}
+
+// Validate 检查步骤名称唯一、依赖存在、参数合法且依赖关系中不存在环
+func (wf *Workflow) Validate() error {
+	if len(wf.Steps) == 0 {
+		return fmt.Errorf("工作流没有定义任何步骤")
+	}
+
+	names := make(map[string]bool, len(wf.Steps))
+	for i, step := range wf.Steps {
+		if step == nil || strings.TrimSpace(step.Name) == "" {
+			return fmt.Errorf("第 %d 个步骤缺少名称", i+1)
+		}
+		if names[step.Name] {
+			return fmt.Errorf("步骤名称重复: %s", step.Name)
+		}
+		names[step.Name] = true
+
+		if strings.TrimSpace(step.Run) == "" && len(step.Commands) == 0 {
+			return fmt.Errorf("步骤 %s 没有要执行的命令", step.Name)
+		}
+		if step.Retries < 0 {
+			return fmt.Errorf("步骤 %s 的重试次数不能为负数", step.Name)
+		}
+		if _, err := parseDuration(step.RetryDelay); err != nil {
+			return fmt.Errorf("步骤 %s 的 retry_delay 无效: %v", step.Name, err)
+		}
+		if _, err := parseDuration(step.Timeout); err != nil {
+			return fmt.Errorf("步骤 %s 的 timeout 无效: %v", step.Name, err)
+		}
+	}
+
+	for _, step := range wf.Steps {
+		for _, need := range step.Needs {
+			if !names[need] {
+				return fmt.Errorf("步骤 %s 依赖的步骤 %s 不存在", step.Name, need)
+			}
+		}
+	}
+
+	if cycle := wf.findCycle(); cycle != nil {
+		return fmt.Errorf("步骤之间存在循环依赖: %s", strings.Join(cycle, " -> "))
+	}
+	return nil
+}
+
+// Step 按名称查找步骤
+func (wf *Workflow) Step(name string) *Step {
+	for _, step := range wf.Steps {
+		if step.Name == name {
+			return step
+		}
+	}
+	return nil
+}
+
+// findCycle 深度优先搜索依赖关系，返回发现的第一个环的路径
+func (wf *Workflow) findCycle() []string {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[string]int, len(wf.Steps))
+	var path []string
+
+	var visit func(name string) []string
+	visit = func(name string) []string {
+		state[name] = visiting
+		path = append(path, name)
+		for _, need := range wf.Step(name).Needs {
+			switch state[need] {
+			case visiting:
+				for i, n := range path {
+					if n == need {
+						return append(append([]string{}, path[i:]...), need)
+					}
+				}
+			case unvisited:
+				if cycle := visit(need); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = done
+		return nil
+	}
+
+	for _, step := range wf.Steps {
+		if state[step.Name] == unvisited {
+			if cycle := visit(step.Name); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
+
+// Select 根据 --from 和 --only 计算需要执行的步骤集合。
+// from 非空时执行该步骤及其所有下游步骤；only 非空时只执行列出的步骤。
+// 未被选中的步骤不会执行，其下游步骤视其依赖已满足
+func (wf *Workflow) Select(from string, only []string) (map[string]bool, error) {
+	selected := make(map[string]bool, len(wf.Steps))
+
+	if len(only) > 0 {
+		for _, name := range only {
+			if wf.Step(name) == nil {
+				return nil, fmt.Errorf("步骤不存在: %s", name)
+			}
+			selected[name] = true
+		}
+	} else {
+		for _, step := range wf.Steps {
+			selected[step.Name] = true
+		}
+	}
+
+	if from != "" {
+		if wf.Step(from) == nil {
+			return nil, fmt.Errorf("步骤不存在: %s", from)
+		}
+		downstream := wf.downstream(from)
+		for name := range selected {
+			if !downstream[name] {
+				delete(selected, name)
+			}
+		}
+	}
+
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("没有选中任何步骤")
+	}
+	return selected, nil
+}
+
+// downstream 返回指定步骤及所有直接或间接依赖它的步骤
+func (wf *Workflow) downstream(name string) map[string]bool {
+	result := map[string]bool{name: true}
+	for changed := true; changed; {
+		changed = false
+		for _, step := range wf.Steps {
+			if result[step.Name] {
+				continue
+			}
+			for _, need := range step.Needs {
+				if result[need] {
+					result[step.Name] = true
+					changed = true
+					break
+				}
+			}
+		}
+	}
+	return result
+}
+
+// Order 返回一个满足依赖关系的步骤执行顺序，同一层的步骤按定义顺序排列
+func (wf *Workflow) Order() [][]string {
+	level := make(map[string]int, len(wf.Steps))
+	var depth func(name string) int
+	depth = func(name string) int {
+		if d, ok := level[name]; ok {
+			return d
+		}
+		d := 0
+		for _, need := range wf.Step(name).Needs {
+			if nd := depth(need) + 1; nd > d {
+				d = nd
+			}
+		}
+		level[name] = d
+		return d
+	}
+
+	maxLevel := 0
+	for _, step := range wf.Steps {
+		if d := depth(step.Name); d > maxLevel {
+			maxLevel = d
+		}
+	}
+
+	order := make([][]string, maxLevel+1)
+	for _, step := range wf.Steps {
+		order[level[step.Name]] = append(order[level[step.Name]], step.Name)
+	}
+	return order
+}
+
+// environ 合并工作流和步骤的环境变量，按名称排序保证结果稳定
+func (wf *Workflow) environ(step *Step) []string {
+	merged := make(map[string]string, len(wf.Env)+len(step.Env))
+	for k, v := range wf.Env {
+		merged[k] = v
+	}
+	for k, v := range step.Env {
+		merged[k] = v
+	}
+
+	env := make([]string, 0, len(merged))
+	for k, v := range merged {
+		env = append(env, k+"="+v)
+	}
+	sort.Strings(env)
+	return env
+}
+
+// commands 返回步骤要依次执行的命令
+func (s *Step) commands() []string {
+	var cmds []string
+	if strings.TrimSpace(s.Run) != "" {
+		cmds = append(cmds, s.Run)
+	}
+	return append(cmds, s.Commands...)
+}
+
+// parseDuration 解析可为空的时长字符串
+func parseDuration(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(value)
+}
@@ -0,0 +1,232 @@
+package workflow
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Lzww0608/ClixGo/pkg/history"
+	"github.com/Lzww0608/ClixGo/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupTest(t *testing.T) string {
+	dir := t.TempDir()
+	logger.SetLogPath(filepath.Join(dir, "test.log"))
+	logger.InitLogger()
+	history.SetHistoryFilePath(filepath.Join(dir, "history.json"))
+	return dir
+}
+
+// 测试解析与校验
+func TestParseAndValidate(t *testing.T) {
+	wf, err := Parse([]byte(`
+name: build
+env:
+  MODE: test
+steps:
+  - name: lint
+    run: echo lint
+  - name: test
+    needs: [lint]
+    commands: ["echo a", "echo b"]
+    retries: 2
+    retry_delay: 10ms
+`))
+	require.NoError(t, err)
+	assert.Equal(t, "build", wf.Name)
+	assert.Equal(t, []string{"echo a", "echo b"}, wf.Step("test").commands())
+	assert.Equal(t, [][]string{{"lint"}, {"test"}}, wf.Order())
+
+	tests := map[string]string{
+		"重复名称":  "steps:\n  - {name: a, run: x}\n  - {name: a, run: y}",
+		"依赖不存在": "steps:\n  - {name: a, run: x, needs: [b]}",
+		"缺少命令":  "steps:\n  - {name: a}",
+		"时长无效":  "steps:\n  - {name: a, run: x, timeout: soon}",
+		"循环依赖":  "steps:\n  - {name: a, run: x, needs: [c]}\n  - {name: b, run: x, needs: [a]}\n  - {name: c, run: x, needs: [b]}",
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Parse([]byte(data))
+			assert.Error(t, err)
+		})
+	}
+
+	_, err = Parse([]byte("steps:\n  - {name: a, run: x, needs: [b]}\n  - {name: b, run: x, needs: [a]}"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "a -> b -> a")
+}
+
+// 测试步骤选择
+func TestSelect(t *testing.T) {
+	wf := &Workflow{Steps: []*Step{
+		{Name: "a", Run: "x"},
+		{Name: "b", Run: "x", Needs: []string{"a"}},
+		{Name: "c", Run: "x", Needs: []string{"b"}},
+		{Name: "d", Run: "x"},
+	}}
+
+	selected, err := wf.Select("b", nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{"b": true, "c": true}, selected)
+
+	selected, err = wf.Select("", []string{"a", "d"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{"a": true, "d": true}, selected)
+
+	_, err = wf.Select("missing", nil)
+	assert.Error(t, err)
+}
+
+// 测试按依赖并行执行、失败后跳过下游、条件与环境变量
+func TestRun(t *testing.T) {
+	dir := setupTest(t)
+	marker := filepath.Join(dir, "marker")
+
+	wf, err := Parse([]byte(`
+name: demo
+env:
+  GREETING: hello
+steps:
+  - name: slow1
+    run: sleep 0.2
+  - name: slow2
+    run: sleep 0.2
+  - name: greet
+    needs: [slow1, slow2]
+    run: echo "$GREETING $TARGET"
+    env:
+      TARGET: world
+  - name: broken
+    run: sh -c 'exit 7'
+  - name: after-broken
+    needs: [broken]
+    run: touch ` + marker + `
+  - name: conditional
+    if: test -f /nonexistent-clixgo-file
+    run: touch ` + marker + `
+  - name: after-conditional
+    needs: [conditional]
+    run: echo done
+`))
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	start := time.Now()
+	report, err := Run(context.Background(), wf, Options{Output: &out})
+	require.Error(t, err)
+	assert.Less(t, time.Since(start), 400*time.Millisecond, "无依赖的步骤应并行执行")
+	assert.Equal(t, []string{"broken"}, report.Failed())
+
+	results := make(map[string]StepResult)
+	for _, step := range report.Steps {
+		results[step.Name] = step
+	}
+	assert.Equal(t, StateSuccess, results["greet"].Status)
+	assert.Equal(t, 7, results["broken"].ExitCode)
+	assert.Equal(t, StateSkipped, results["after-broken"].Status)
+	assert.Equal(t, StateSkipped, results["conditional"].Status)
+	assert.Equal(t, StateSuccess, results["after-conditional"].Status)
+	assert.Equal(t, []string{"pending", "running", "success"}, statesOf(results["greet"]))
+	assert.Contains(t, out.String(), "[greet] hello world")
+
+	_, statErr := os.Stat(marker)
+	assert.True(t, os.IsNotExist(statErr))
+
+	entries, err := history.GetHistory()
+	require.NoError(t, err)
+	var recorded []string
+	for _, h := range entries {
+		recorded = append(recorded, h.Command)
+	}
+	assert.Contains(t, strings.Join(recorded, "\n"), "[workflow demo/greet]")
+}
+
+// 测试失败重试与最大并发
+func TestRunRetriesAndMaxParallel(t *testing.T) {
+	dir := setupTest(t)
+	counter := filepath.Join(dir, "count")
+
+	wf := &Workflow{Steps: []*Step{
+		{
+			Name:       "flaky",
+			Run:        `echo x >> ` + counter + ` && test $(wc -l < ` + counter + `) -ge 3`,
+			Retries:    3,
+			RetryDelay: "10ms",
+		},
+	}}
+	report, err := Run(context.Background(), wf, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, 3, report.Steps[0].Attempts)
+	assert.Equal(t, []string{"pending", "running", "running", "running", "success"}, statesOf(report.Steps[0]))
+
+	wf = &Workflow{MaxParallel: 1, Steps: []*Step{
+		{Name: "a", Run: "sleep 0.1"},
+		{Name: "b", Run: "sleep 0.1"},
+	}}
+	start := time.Now()
+	_, err = Run(context.Background(), wf, Options{})
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond)
+}
+
+func statesOf(result StepResult) []string {
+	states := make([]string, len(result.States))
+	for i, s := range result.States {
+		states[i] = string(s)
+	}
+	return states
+}
+
+// 测试执行计划按层排列且不执行任何步骤
+func TestExplain(t *testing.T) {
+	dir := setupTest(t)
+	marker := filepath.Join(dir, "marker")
+
+	wf, err := Parse([]byte(`
+name: plan
+env:
+  STAGE: ci
+steps:
+  - name: build
+    needs: [lint]
+    commands: ["touch ` + marker + `", "echo built"]
+    dir: ` + dir + `
+    timeout: 2m
+  - name: lint
+    run: go vet ./...
+    env:
+      STAGE: lint
+  - name: docs
+    run: echo docs
+`))
+	require.NoError(t, err)
+
+	levels, err := Explain(wf, Options{})
+	require.NoError(t, err)
+	require.Len(t, levels, 2)
+	require.Len(t, levels[0], 2)
+	assert.Equal(t, "lint", levels[0][0].Name)
+	assert.Equal(t, "docs", levels[0][1].Name)
+	assert.Equal(t, []string{"STAGE=lint"}, levels[0][0].Commands[0].Env)
+
+	build := levels[1][0]
+	assert.Equal(t, []string{"lint"}, build.Needs)
+	require.Len(t, build.Commands, 2)
+	assert.Equal(t, dir, build.Commands[0].Dir)
+	assert.Equal(t, 2*time.Minute, build.Commands[0].Timeout)
+	assert.Equal(t, []string{"STAGE=ci"}, build.Commands[0].Env)
+
+	_, statErr := os.Stat(marker)
+	assert.True(t, os.IsNotExist(statErr))
+
+	levels, err = Explain(wf, Options{Only: []string{"build"}})
+	require.NoError(t, err)
+	require.Len(t, levels, 1)
+	assert.Equal(t, "build", levels[0][0].Name)
+}
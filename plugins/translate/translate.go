@@ -15,6 +15,7 @@ import (
 	"sync"
 	"time"
 
+	clixconfig "github.com/Lzww0608/ClixGo/pkg/config"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -73,6 +74,11 @@ type TranslationService struct {
 	httpClient *http.Client
 	ctx        context.Context
 	cancel     context.CancelFunc
+
+	// API 密钥在第一次请求时解析一次
+	keyOnce sync.Once
+	key     string
+	keyErr  error
 }
 
 var (
@@ -162,6 +168,12 @@ func (s *TranslationService) translateWithRetry(ctx context.Context, text, sourc
 	s.recordActiveRequest(1)
 	defer s.recordActiveRequest(-1)
 
+	// 密钥错误重试也不会成功
+	if _, err := s.apiKey(); err != nil {
+		s.recordMetrics(start, "text", sourceLang, targetLang, err, 0)
+		return nil, err
+	}
+
 	var lastErr error
 	for i := 0; i < s.config.MaxRetries; i++ {
 		select {
@@ -191,12 +203,35 @@ func (s *TranslationService) translateWithRetry(ctx context.Context, text, sourc
 	return nil, errors.Wrap(ErrTranslation, lastErr.Error())
 }
 
+// apiKey 返回 API 密钥。没有在插件配置或环境变量 TRANSLATE_API_KEY 中设置时，
+// 读取 ClixGo 配置中的 translate.api_key，可以用 config set --secret 加密保存。
+// 密钥只解析一次，读取或解密失败时返回错误而不是使用空密钥
+func (s *TranslationService) apiKey() (string, error) {
+	s.keyOnce.Do(func() {
+		if s.config.APIKey != "" {
+			s.key = s.config.APIKey
+			return
+		}
+		key, err := clixconfig.LookupString("translate.api_key")
+		if err != nil {
+			s.keyErr = errors.Wrap(err, "读取配置项 translate.api_key 失败")
+			return
+		}
+		s.key = key
+	})
+	return s.key, s.keyErr
+}
+
 // translate 执行翻译
 func (s *TranslationService) translate(ctx context.Context, text, sourceLang, targetLang string) (*TranslationResult, error) {
+	key, err := s.apiKey()
+	if err != nil {
+		return nil, err
+	}
 	baseURL := "https://translation.googleapis.com/language/translate/v2"
 
 	params := url.Values{}
-	params.Add("key", s.config.APIKey)
+	params.Add("key", key)
 	params.Add("q", text)
 	params.Add("source", sourceLang)
 	params.Add("target", targetLang)
@@ -377,10 +412,14 @@ func translateText(text, sourceLang, targetLang string) (*TranslationResult, err
 		return result, nil
 	}
 
+	key, err := service.apiKey()
+	if err != nil {
+		return nil, err
+	}
 	baseURL := "https://translation.googleapis.com/language/translate/v2"
 
 	params := url.Values{}
-	params.Add("key", service.config.APIKey)
+	params.Add("key", key)
 	params.Add("q", text)
 	params.Add("source", sourceLang)
 	params.Add("target", targetLang)
@@ -434,10 +473,14 @@ func translateText(text, sourceLang, targetLang string) (*TranslationResult, err
 
 // detectLanguage 检测文本语言
 func (s *TranslationService) detectLanguage(text string) (*LanguageDetection, error) {
+	key, err := s.apiKey()
+	if err != nil {
+		return nil, err
+	}
 	baseURL := "https://translation.googleapis.com/language/translate/v2/detect"
 
 	params := url.Values{}
-	params.Add("key", s.config.APIKey)
+	params.Add("key", key)
 	params.Add("q", text)
 
 	reqURL := fmt.Sprintf("%s?%s", baseURL, params.Encode())
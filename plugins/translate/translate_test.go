@@ -6,10 +6,12 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
+	clixconfig "github.com/Lzww0608/ClixGo/pkg/config"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"golang.org/x/time/rate"
@@ -70,6 +72,36 @@ func setupTestService() (*TranslationService, func()) {
 	return service, cleanup
 }
 
+// 测试配置中的密钥无法解密时返回错误，而不是使用空密钥发送请求
+func TestAPIKeyError(t *testing.T) {
+	t.Setenv(clixconfig.KeyEnv, "")
+	t.Setenv(clixconfig.PassphraseEnv, "")
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	key, err := clixconfig.GenerateKey()
+	require.NoError(t, err)
+	keyring, err := clixconfig.NewKeyring(key, "测试")
+	require.NoError(t, err)
+	encrypted, err := keyring.Encrypt("translate.api_key", "secret")
+	require.NoError(t, err)
+	path := filepath.Join(home, ".clixgo", "config.yaml")
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0700))
+	require.NoError(t, os.WriteFile(path, []byte("translate:\n  api_key: "+encrypted+"\n"), 0600))
+
+	service, cleanup := setupTestService()
+	defer cleanup()
+	service.config.APIKey = ""
+
+	start := time.Now()
+	_, err = service.translateWithRetry(context.Background(), "Hello", "en", "zh")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "translate.api_key")
+	assert.Less(t, time.Since(start), time.Second, "密钥错误不应重试")
+
+	_, err = service.apiKey()
+	assert.Error(t, err, "密钥只解析一次，错误保持不变")
+}
+
 // TestTranslateText 测试文本翻译功能
 func TestTranslateText(t *testing.T) {
 	service, cleanup := setupTestService()
@@ -6,8 +6,10 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"sync"
 	"time"
 
+	"github.com/Lzww0608/ClixGo/pkg/config"
 	"github.com/spf13/cobra"
 )
 
@@ -49,20 +51,38 @@ type WeatherData struct {
 	} `json:"forecast"`
 }
 
+// API 密钥在第一次请求时解析一次
 var (
-	apiKey string
+	apiKeyOnce sync.Once
+	apiKey     string
+	apiKeyErr  error
 )
 
-func init() {
-	apiKey = os.Getenv("WEATHER_API_KEY")
-	if apiKey == "" {
-		fmt.Println("警告: 未设置 WEATHER_API_KEY 环境变量")
-	}
+// getAPIKey 返回 API 密钥。没有设置环境变量 WEATHER_API_KEY 时读取配置中的 weather.api_key，
+// 可以用 config set --secret 加密保存；读取或解密失败时返回错误而不是使用空密钥
+func getAPIKey() (string, error) {
+	apiKeyOnce.Do(func() {
+		apiKey = os.Getenv("WEATHER_API_KEY")
+		if apiKey == "" {
+			if apiKey, apiKeyErr = config.LookupString("weather.api_key"); apiKeyErr != nil {
+				apiKeyErr = fmt.Errorf("读取配置项 weather.api_key 失败: %v", apiKeyErr)
+				return
+			}
+		}
+		if apiKey == "" {
+			fmt.Println("警告: 未设置 WEATHER_API_KEY 环境变量或配置项 weather.api_key")
+		}
+	})
+	return apiKey, apiKeyErr
 }
 
 // getWeather 获取天气数据
 func getWeather(city string) (*WeatherData, error) {
-	url := fmt.Sprintf("http://api.weatherapi.com/v1/forecast.json?key=%s&q=%s&days=3", apiKey, city)
+	key, err := getAPIKey()
+	if err != nil {
+		return nil, err
+	}
+	url := fmt.Sprintf("http://api.weatherapi.com/v1/forecast.json?key=%s&q=%s&days=3", key, city)
 	resp, err := http.Get(url)
 	if err != nil {
 		return nil, fmt.Errorf("获取天气数据失败: %v", err)